@@ -0,0 +1,246 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// 批量操作中单个条目的处理结果
+const (
+	BatchItemSucceeded = "succeeded"
+	BatchItemSkipped   = "skipped"
+	BatchItemFailed    = "failed"
+	BatchItemNotRun    = "not_run" // 因前面的条目失败而中止，未处理
+)
+
+const (
+	// DefaultListPageSize 分页查询笔记列表时的每页数量
+	DefaultListPageSize = 50
+	// MaxListPages 分页查询笔记列表的最大页数，避免接口异常时无限翻页
+	MaxListPages = 100
+)
+
+// BatchItemResult 批量操作中单个条目的处理结果
+type BatchItemResult struct {
+	ID     string // 条目标识，例如笔记ID
+	Status string // 处理结果：succeeded、skipped、failed、not_run
+	Reason string // 跳过或失败的原因
+}
+
+// RenderBatchReport 将批量操作结果渲染为包含计数和失败明细的文本
+func RenderBatchReport(action string, results []BatchItemResult) string {
+	var succeeded, skipped, notRun int
+	var failures []BatchItemResult
+	for _, result := range results {
+		switch result.Status {
+		case BatchItemSucceeded:
+			succeeded++
+		case BatchItemSkipped:
+			skipped++
+		case BatchItemFailed:
+			failures = append(failures, result)
+		case BatchItemNotRun:
+			notRun++
+		}
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s：共 %d 项，成功 %d 项，跳过 %d 项，失败 %d 项",
+		action, len(results), succeeded, skipped, len(failures))
+	if notRun > 0 {
+		fmt.Fprintf(&sb, "，因失败中止未处理 %d 项", notRun)
+	}
+	for _, failure := range failures {
+		fmt.Fprintf(&sb, "\n- %s：%s", failure.ID, failure.Reason)
+	}
+	return sb.String()
+}
+
+// runBatch 依次处理ids中的每个条目。
+// stopOnError为true时在首个失败后停止，其余条目记为未处理；否则处理全部条目并收集结果。
+func runBatch(ids []string, stopOnError bool, process func(id string) BatchItemResult) []BatchItemResult {
+	results := make([]BatchItemResult, 0, len(ids))
+	for i, id := range ids {
+		result := process(id)
+		result.ID = id
+		results = append(results, result)
+		if result.Status == BatchItemFailed && stopOnError {
+			for _, rest := range ids[i+1:] {
+				results = append(results, BatchItemResult{ID: rest, Status: BatchItemNotRun})
+			}
+			break
+		}
+	}
+	return results
+}
+
+// searchAllNotes 逐页查询并返回符合条件的全部笔记。
+// 响应中带有游标时改用游标翻页，直到游标为空；否则按页码翻页，直到某页不满。
+// 翻到MaxListPages页后仍有结果时返回错误，避免批量操作只处理部分笔记。
+func (s *MowenMCPServer) searchAllNotes(ctx context.Context, req NoteListRequest) ([]NoteSummary, error) {
+	req.PageSize = DefaultListPageSize
+
+	var notes []NoteSummary
+	for page := 1; ; page++ {
+		if page > MaxListPages {
+			return nil, fmt.Errorf("note search stopped after %d pages (%d notes) with more results remaining; narrow the query or tag", MaxListPages, len(notes))
+		}
+		if req.Cursor == "" {
+			req.Page = page
+		}
+		result, err := s.mowenClient.ListNotes(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		notes = append(notes, result.Notes...)
+
+		if result.NextCursor != "" {
+			req.Cursor = result.NextCursor
+			req.Page = 0
+			continue
+		}
+		if req.Cursor != "" || len(result.Notes) < req.PageSize || (result.Total > 0 && len(notes) >= result.Total) {
+			return notes, nil
+		}
+	}
+}
+
+// handleBulkTagNotes 处理按搜索结果批量添加标签的MCP工具请求。
+// 默认单篇笔记设置失败不会中断整个批次，StopOnError为true时在首个失败后停止，最终返回各项的处理结果。
+func (s *MowenMCPServer) handleBulkTagNotes(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args BulkTagNotesArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	tag := strings.TrimSpace(args.Tag)
+	if tag == "" {
+		return nil, fmt.Errorf("tag must not be empty")
+	}
+
+	notes, err := s.searchAllNotes(ctx, NoteListRequest{Query: args.Query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search notes: %w", err)
+	}
+	if len(notes) == 0 {
+		return textResult(fmt.Sprintf("没有找到匹配 %q 的笔记", args.Query)), nil
+	}
+
+	ids := make([]string, 0, len(notes))
+	tagsByID := make(map[string][]string, len(notes))
+	for _, note := range notes {
+		ids = append(ids, note.NoteID)
+		tagsByID[note.NoteID] = note.Tags
+	}
+
+	results := runBatch(ids, args.StopOnError, s.addTagProcessor(ctx, tag, func(_ context.Context, id string) ([]string, error) {
+		return tagsByID[id], nil
+	}))
+	s.tagCache.invalidate()
+
+	batch, err := newBatchResult(BatchOperationBulkTag, fmt.Sprintf("为匹配 %q 的笔记添加标签 %q", args.Query, tag), bulkTagParams{Tag: tag}, results)
+	if err != nil {
+		return nil, err
+	}
+	return textResult(batch.Report()), nil
+}
+
+// addTagProcessor 返回为单篇笔记追加标签的处理函数，tagsOf返回笔记当前的标签，已有该标签时跳过
+func (s *MowenMCPServer) addTagProcessor(ctx context.Context, tag string, tagsOf func(ctx context.Context, id string) ([]string, error)) func(id string) BatchItemResult {
+	return func(id string) BatchItemResult {
+		current, err := tagsOf(ctx, id)
+		if err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		if containsTag(current, tag) {
+			return BatchItemResult{Status: BatchItemSkipped, Reason: "已有该标签"}
+		}
+
+		tags := append(append([]string{}, current...), tag)
+		if _, err := s.mowenClient.SetNoteTags(ctx, id, tags); err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		return BatchItemResult{Status: BatchItemSucceeded}
+	}
+}
+
+// handleBulkSetPrivacy 处理为搜索或标签匹配的笔记批量设置规则公开的MCP工具请求。
+// 公开截止时间在搜索前校验，必须晚于当前时间，未指定时与set_note_privacy一样使用默认公开时长；
+// 按标签筛选时会再次核对每篇笔记的标签。
+func (s *MowenMCPServer) handleBulkSetPrivacy(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args BulkSetPrivacyArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	tag := strings.TrimSpace(args.Tag)
+	if strings.TrimSpace(args.Query) == "" && tag == "" {
+		return nil, fmt.Errorf("query or tag is required to select notes")
+	}
+	// 与set_note_privacy相同，未指定截止时间时使用MOWEN_DEFAULT_EXPIRE_IN
+	now := time.Now()
+	expireAtTS, err := s.resolveExpireAt(args.ExpireAt, "", now)
+	if err != nil {
+		return nil, err
+	}
+	var expireAtValue int64
+	if expireAtTS != nil {
+		expireAtValue = *expireAtTS
+	}
+	expireAt, err := FormatExpireAt(expireAtValue, s.expireAtUnit, now)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := s.searchAllNotes(ctx, NoteListRequest{Query: args.Query, Tag: tag})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search notes: %w", err)
+	}
+	var ids []string
+	for _, note := range notes {
+		if tag == "" || containsTag(note.Tags, tag) {
+			ids = append(ids, note.NoteID)
+		}
+	}
+
+	selector := fmt.Sprintf("匹配 %q", args.Query)
+	switch {
+	case args.Query == "":
+		selector = fmt.Sprintf("带标签 %q", tag)
+	case tag != "":
+		selector = fmt.Sprintf("匹配 %q 且带标签 %q", args.Query, tag)
+	}
+	if len(ids) == 0 {
+		return textResult(fmt.Sprintf("没有找到%s 的笔记", selector)), nil
+	}
+
+	privacy := &NotePrivacySet{
+		Type: "rule",
+		Rule: &NotePrivacySetRule{NoShare: args.NoShare, ExpireAt: expireAt},
+	}
+	results := runBatch(ids, args.StopOnError, s.setPrivacyProcessor(ctx, privacy))
+
+	params := bulkPrivacyParams{NoShare: args.NoShare, ExpireAt: expireAt}
+	batch, err := newBatchResult(BatchOperationBulkPrivacy, fmt.Sprintf("为%s 的笔记设置规则公开", selector), params, results)
+	if err != nil {
+		return nil, err
+	}
+	return textResult(batch.Report()), nil
+}
+
+// setPrivacyProcessor 返回为单篇笔记应用隐私设置的处理函数
+func (s *MowenMCPServer) setPrivacyProcessor(ctx context.Context, privacy *NotePrivacySet) func(id string) BatchItemResult {
+	return func(id string) BatchItemResult {
+		_, err := s.mowenClient.SetNotePrivacy(ctx, NoteSetRequest{
+			NoteID:   id,
+			Section:  SectionPrivacy,
+			Settings: &NoteSettings{Privacy: privacy},
+		})
+		if err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		return BatchItemResult{Status: BatchItemSucceeded}
+	}
+}
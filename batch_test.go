@@ -0,0 +1,279 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRenderBatchReport 测试批量操作结果渲染
+func TestRenderBatchReport(t *testing.T) {
+	report := RenderBatchReport("添加标签", []BatchItemResult{
+		{ID: "note-1", Status: BatchItemSucceeded},
+		{ID: "note-2", Status: BatchItemSkipped, Reason: "已有该标签"},
+		{ID: "note-3", Status: BatchItemFailed, Reason: "status 500"},
+	})
+
+	assert.Equal(t, "添加标签：共 3 项，成功 1 项，跳过 1 项，失败 1 项\n- note-3：status 500", report)
+}
+
+// TestRunBatch 测试批量处理在失败时继续或中止
+func TestRunBatch(t *testing.T) {
+	ids := []string{"a", "b", "c", "d"}
+	var processed []string
+	process := func(id string) BatchItemResult {
+		processed = append(processed, id)
+		if id == "b" {
+			return BatchItemResult{Status: BatchItemFailed, Reason: "boom"}
+		}
+		return BatchItemResult{Status: BatchItemSucceeded}
+	}
+
+	// 默认继续处理全部条目
+	results := runBatch(ids, false, process)
+	assert.Equal(t, ids, processed)
+	require.Len(t, results, 4)
+	assert.Equal(t, BatchItemFailed, results[1].Status)
+	assert.Equal(t, BatchItemSucceeded, results[3].Status)
+
+	// 首个失败后中止
+	processed = nil
+	results = runBatch(ids, true, process)
+	assert.Equal(t, []string{"a", "b"}, processed)
+	require.Len(t, results, 4)
+	assert.Equal(t, BatchItemResult{ID: "c", Status: BatchItemNotRun}, results[2])
+	assert.Equal(t, BatchItemResult{ID: "d", Status: BatchItemNotRun}, results[3])
+	assert.Equal(t, "处理：共 4 项，成功 1 项，跳过 0 项，失败 1 项，因失败中止未处理 2 项\n- b：boom", RenderBatchReport("处理", results))
+}
+
+// TestHandleBulkTagNotes 测试按搜索结果批量添加标签，部分笔记设置失败时继续处理其余笔记
+func (suite *ServerTestSuite) TestHandleBulkTagNotes() {
+	var listReq NoteListRequest
+	suite.routes[NoteListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&listReq))
+		mockSuccess(NoteListResult{
+			Notes: []NoteSummary{
+				{NoteID: "note-aaaa-1", Tags: []string{"周报"}},
+				{NoteID: "note-bbbb-2"},
+				{NoteID: "note-cccc-3", Tags: []string{"项目"}},
+				{NoteID: "note-dddd-4"},
+			},
+			Total: 4,
+		})(w, r)
+	}
+
+	var mu sync.Mutex
+	tagged := make(map[string][]string)
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var setReq NoteSetRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		assert.Equal(suite.T(), 2, setReq.Section)
+		if setReq.NoteID == "note-dddd-4" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"code":500,"message":"internal error"}`))
+			return
+		}
+		mu.Lock()
+		tagged[setReq.NoteID] = setReq.Settings.Tags
+		mu.Unlock()
+		mockSuccess(nil)(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleBulkTagNotes, BulkTagNotesArgs{Query: "项目", Tag: "项目"})
+	require.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "项目", listReq.Query)
+	assert.Contains(suite.T(), text, "共 4 项，成功 2 项，跳过 1 项，失败 1 项")
+	assert.Contains(suite.T(), text, "- note-dddd-4：")
+	assert.Contains(suite.T(), text, "status 500")
+
+	// 保留原有标签并追加新标签
+	assert.Equal(suite.T(), []string{"周报", "项目"}, tagged["note-aaaa-1"])
+	assert.Equal(suite.T(), []string{"项目"}, tagged["note-bbbb-2"])
+	assert.NotContains(suite.T(), tagged, "note-cccc-3")
+}
+
+// TestHandleBulkTagNotesPaging 测试搜索结果跨多页时全部处理
+func (suite *ServerTestSuite) TestHandleBulkTagNotesPaging() {
+	var pages []int
+	suite.routes[NoteListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var listReq NoteListRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&listReq))
+		pages = append(pages, listReq.Page)
+
+		var notes []NoteSummary
+		count := listReq.PageSize
+		if listReq.Page == 2 {
+			count = 3
+		}
+		for i := 0; i < count; i++ {
+			notes = append(notes, NoteSummary{NoteID: "note-page-item"})
+		}
+		mockSuccess(NoteListResult{Notes: notes})(w, r)
+	}
+	suite.routes[NoteSetEndpoint] = mockSuccess(nil)
+
+	text, err := suite.callTool(suite.mcpServer.handleBulkTagNotes, BulkTagNotesArgs{Query: "会议", Tag: "会议"})
+	require.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), []int{1, 2}, pages)
+	assert.Contains(suite.T(), text, "成功 53 项")
+}
+
+// TestHandleBulkTagNotesCursor 测试接口返回游标时按游标翻页
+func (suite *ServerTestSuite) TestHandleBulkTagNotesCursor() {
+	var requests []NoteListRequest
+	suite.routes[NoteListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var listReq NoteListRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&listReq))
+		requests = append(requests, listReq)
+
+		result := NoteListResult{Notes: []NoteSummary{{NoteID: "note-cursor-" + listReq.Cursor}}}
+		if listReq.Cursor == "" {
+			result.NextCursor = "next-page"
+		}
+		mockSuccess(result)(w, r)
+	}
+	suite.routes[NoteSetEndpoint] = mockSuccess(nil)
+
+	text, err := suite.callTool(suite.mcpServer.handleBulkTagNotes, BulkTagNotesArgs{Query: "q", Tag: "t"})
+	require.NoError(suite.T(), err)
+
+	require.Len(suite.T(), requests, 2)
+	assert.Equal(suite.T(), 1, requests[0].Page)
+	assert.Equal(suite.T(), "next-page", requests[1].Cursor)
+	assert.Zero(suite.T(), requests[1].Page)
+	assert.Contains(suite.T(), text, "成功 2 项")
+}
+
+// TestHandleBulkTagNotesPageLimit 测试翻到最大页数后仍有结果时返回错误，不处理部分笔记
+func (suite *ServerTestSuite) TestHandleBulkTagNotesPageLimit() {
+	requests := 0
+	suite.routes[NoteListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		mockSuccess(NoteListResult{
+			Notes:      []NoteSummary{{NoteID: "note-loop-" + strconv.Itoa(requests)}},
+			NextCursor: "cursor-" + strconv.Itoa(requests),
+		})(w, r)
+	}
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("unexpected set request")
+	}
+
+	_, err := suite.callTool(suite.mcpServer.handleBulkTagNotes, BulkTagNotesArgs{Query: "q", Tag: "t"})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "note search stopped after 100 pages (100 notes)")
+	assert.Equal(suite.T(), MaxListPages, requests)
+}
+
+// TestHandleBulkTagNotesNoMatch 测试没有匹配笔记时不发送标签设置请求
+func (suite *ServerTestSuite) TestHandleBulkTagNotesNoMatch() {
+	suite.routes[NoteListEndpoint] = mockSuccess(NoteListResult{})
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("unexpected set request")
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleBulkTagNotes, BulkTagNotesArgs{Query: "不存在", Tag: "标签"})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "没有找到匹配")
+}
+
+// TestHandleBulkTagNotesStopOnError 测试设置stop_on_error后在首个失败时停止
+func (suite *ServerTestSuite) TestHandleBulkTagNotesStopOnError() {
+	suite.routes[NoteListEndpoint] = mockSuccess(NoteListResult{
+		Notes: []NoteSummary{{NoteID: "note-aaaa-1"}, {NoteID: "note-bbbb-2"}, {NoteID: "note-cccc-3"}},
+	})
+
+	var attempted []string
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var setReq NoteSetRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		attempted = append(attempted, setReq.NoteID)
+		if setReq.NoteID == "note-bbbb-2" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		mockSuccess(nil)(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleBulkTagNotes, BulkTagNotesArgs{Query: "q", Tag: "t", StopOnError: true})
+	require.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), []string{"note-aaaa-1", "note-bbbb-2"}, attempted)
+	assert.Contains(suite.T(), text, "成功 1 项，跳过 0 项，失败 1 项，因失败中止未处理 1 项")
+}
+
+// TestHandleBulkSetPrivacy 测试为带标签的多篇笔记设置规则公开，标签不匹配的笔记不处理
+func (suite *ServerTestSuite) TestHandleBulkSetPrivacy() {
+	var listReq NoteListRequest
+	suite.routes[NoteListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&listReq))
+		mockSuccess(NoteListResult{Notes: []NoteSummary{
+			{NoteID: "note-aaaa-1", Tags: []string{"公开"}},
+			{NoteID: "note-bbbb-2", Tags: []string{"公开", "周报"}},
+			{NoteID: "note-cccc-3", Tags: []string{"公开周报"}},
+		}})(w, r)
+	}
+
+	privacy := make(map[string]*NotePrivacySet)
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var setReq NoteSetRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		assert.Equal(suite.T(), 1, setReq.Section)
+		privacy[setReq.NoteID] = setReq.Settings.Privacy
+		mockSuccess(nil)(w, r)
+	}
+
+	expireAt := time.Now().Add(24 * time.Hour).Unix()
+	text, err := suite.callTool(suite.mcpServer.handleBulkSetPrivacy, BulkSetPrivacyArgs{Tag: "公开", NoShare: true, ExpireAt: &expireAt})
+	require.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), "公开", listReq.Tag)
+	assert.Contains(suite.T(), text, "为带标签 \"公开\" 的笔记设置规则公开：共 2 项，成功 2 项")
+	want := &NotePrivacySet{Type: "rule", Rule: &NotePrivacySetRule{NoShare: true, ExpireAt: strconv.FormatInt(expireAt, 10)}}
+	assert.Equal(suite.T(), map[string]*NotePrivacySet{"note-aaaa-1": want, "note-bbbb-2": want}, privacy)
+
+	// 未指定截止时间时与set_note_privacy一样使用MOWEN_DEFAULT_EXPIRE_IN
+	suite.mcpServer.defaultExpireIn = 48 * time.Hour
+	before := time.Now()
+	_, err = suite.callTool(suite.mcpServer.handleBulkSetPrivacy, BulkSetPrivacyArgs{Tag: "公开"})
+	require.NoError(suite.T(), err)
+	applied, err := strconv.ParseInt(privacy["note-aaaa-1"].Rule.ExpireAt, 10, 64)
+	require.NoError(suite.T(), err)
+	assert.InDelta(suite.T(), before.Add(48*time.Hour).Unix(), applied, 2)
+
+	// 显式指定0时仍为永不过期
+	never := int64(0)
+	_, err = suite.callTool(suite.mcpServer.handleBulkSetPrivacy, BulkSetPrivacyArgs{Tag: "公开", ExpireAt: &never})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "0", privacy["note-aaaa-1"].Rule.ExpireAt)
+}
+
+// TestHandleBulkSetPrivacyRejectsPastExpiry 测试截止时间已过或未指定筛选条件时拒绝执行，且不搜索和修改笔记
+func (suite *ServerTestSuite) TestHandleBulkSetPrivacyRejectsPastExpiry() {
+	calls := 0
+	suite.routes[NoteListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		mockSuccess(NoteListResult{Notes: []NoteSummary{{NoteID: "note-aaaa-1"}}})(w, r)
+	}
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		mockSuccess(nil)(w, r)
+	}
+
+	past := time.Now().Add(-time.Hour).Unix()
+	_, err := suite.callTool(suite.mcpServer.handleBulkSetPrivacy, BulkSetPrivacyArgs{Query: "周报", ExpireAt: &past})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "is not in the future")
+
+	future := time.Now().Add(time.Hour).Unix()
+	_, err = suite.callTool(suite.mcpServer.handleBulkSetPrivacy, BulkSetPrivacyArgs{ExpireAt: &future})
+	require.Error(suite.T(), err)
+	assert.Equal(suite.T(), 0, calls)
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// BatchResultVersion 批量结果格式的版本号，格式不兼容地变化时递增
+const BatchResultVersion = 1
+
+// 可由retry_batch重新执行的批量操作，取值为产生结果的工具名
+const (
+	BatchOperationBulkTag     = "bulk_tag_notes"
+	BatchOperationBulkPrivacy = "bulk_set_privacy"
+	BatchOperationCleanup     = "cleanup_notes"
+)
+
+// BatchResult 批量操作的结构化结果，可原样传给retry_batch重新执行失败的条目。
+// Items保持原批次的顺序，Index为条目在原批次中的下标，重试后保持不变。
+type BatchResult struct {
+	Version   int               `json:"version"`
+	Operation string            `json:"operation"`        // 产生结果的工具名
+	Action    string            `json:"action"`           // 批量操作的描述，用于报告标题
+	Params    json.RawMessage   `json:"params,omitempty"` // 重新执行条目所需的参数，格式由Operation决定
+	Items     []BatchResultItem `json:"items"`
+}
+
+// BatchResultItem 批量结果中的单个条目
+type BatchResultItem struct {
+	Index  int    `json:"index"`
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// bulkTagParams bulk_tag_notes重新执行所需的参数
+type bulkTagParams struct {
+	Tag string `json:"tag"`
+}
+
+// bulkPrivacyParams bulk_set_privacy重新执行所需的参数，公开截止时间已按接口格式转换
+type bulkPrivacyParams struct {
+	NoShare  bool   `json:"no_share,omitempty"`
+	ExpireAt string `json:"expire_at,omitempty"`
+}
+
+// cleanupParams cleanup_notes重新执行所需的参数
+type cleanupParams struct {
+	Tag string `json:"tag"`
+}
+
+// needsRetry 判断条目是否需要重试：处理失败，或因前面的条目失败而未处理
+func (item BatchResultItem) needsRetry() bool {
+	return item.Status == BatchItemFailed || item.Status == BatchItemNotRun
+}
+
+// newBatchResult 根据runBatch的结果创建批量结果，条目下标即其在results中的位置
+func newBatchResult(operation, action string, params interface{}, results []BatchItemResult) (*BatchResult, error) {
+	rawParams, err := json.Marshal(params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal batch params: %w", err)
+	}
+	items := make([]BatchResultItem, len(results))
+	for i, result := range results {
+		items[i] = BatchResultItem{Index: i, ID: result.ID, Status: result.Status, Reason: result.Reason}
+	}
+	return &BatchResult{
+		Version:   BatchResultVersion,
+		Operation: operation,
+		Action:    action,
+		Params:    rawParams,
+		Items:     items,
+	}, nil
+}
+
+// ParseBatchResult 解析批量结果。
+// text可以是批量工具的完整输出：失败原因中也可能含有JSON，因此依次尝试每个“{”，
+// 使用第一个能解析出version的JSON对象。
+func ParseBatchResult(text string) (*BatchResult, error) {
+	var result BatchResult
+	var decodeErr error
+	for offset := 0; ; {
+		start := strings.Index(text[offset:], "{")
+		if start < 0 {
+			break
+		}
+		offset += start
+		result = BatchResult{}
+		decodeErr = json.NewDecoder(strings.NewReader(text[offset:])).Decode(&result)
+		if decodeErr == nil && result.Version != 0 {
+			break
+		}
+		offset++
+	}
+	if result.Version == 0 {
+		if decodeErr != nil {
+			return nil, fmt.Errorf("invalid batch result: %w", decodeErr)
+		}
+		return nil, fmt.Errorf("no batch result JSON found")
+	}
+	if result.Version != BatchResultVersion {
+		return nil, fmt.Errorf("unsupported batch result version %d, expected %d", result.Version, BatchResultVersion)
+	}
+
+	seen := make(map[int]bool, len(result.Items))
+	for i, item := range result.Items {
+		if item.ID == "" {
+			return nil, fmt.Errorf("batch result item %d has no id", i)
+		}
+		switch item.Status {
+		case BatchItemSucceeded, BatchItemSkipped, BatchItemFailed, BatchItemNotRun:
+		default:
+			return nil, fmt.Errorf("batch result item %d has unknown status %q", i, item.Status)
+		}
+		if item.Index < 0 || seen[item.Index] || (i > 0 && item.Index < result.Items[i-1].Index) {
+			return nil, fmt.Errorf("batch result item %d has invalid index %d: indices must be unique and ascending", i, item.Index)
+		}
+		seen[item.Index] = true
+	}
+	return &result, nil
+}
+
+// retryBatchItems 按原顺序重新处理需要重试的条目，其余条目保持原结果。
+// stopOnError为true时在首个失败后停止，之后待重试的条目记为未处理。
+func retryBatchItems(items []BatchResultItem, stopOnError bool, process func(id string) BatchItemResult) []BatchResultItem {
+	retried := make([]BatchResultItem, len(items))
+	stopped := false
+	for i, item := range items {
+		if !item.needsRetry() {
+			retried[i] = item
+			continue
+		}
+		if stopped {
+			retried[i] = BatchResultItem{Index: item.Index, ID: item.ID, Status: BatchItemNotRun}
+			continue
+		}
+		result := process(item.ID)
+		retried[i] = BatchResultItem{Index: item.Index, ID: item.ID, Status: result.Status, Reason: result.Reason}
+		if result.Status == BatchItemFailed && stopOnError {
+			stopped = true
+		}
+	}
+	return retried
+}
+
+// Report 将批量结果渲染为报告文本。
+// 有失败或未处理的条目时附上结构化结果，便于交给retry_batch重试。
+func (r *BatchResult) Report() string {
+	results := make([]BatchItemResult, len(r.Items))
+	retry := false
+	for i, item := range r.Items {
+		results[i] = BatchItemResult{ID: item.ID, Status: item.Status, Reason: item.Reason}
+		retry = retry || item.needsRetry()
+	}
+
+	report := RenderBatchReport(r.Action, results)
+	if !retry {
+		return report
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		return report
+	}
+	return report + "\n\n可将以下批量结果原样传给 retry_batch，只重试失败和未处理的条目：\n" + string(data)
+}
+
+// batchProcessor 根据批量结果的操作和参数返回处理单个条目的函数。
+// 批量结果由调用方提供，因此重新执行前按原工具的要求再次校验：产生结果的工具必须已注册，
+// 删除需要confirm与清理标签一致，公开截止时间必须仍晚于当前时间。
+func (s *MowenMCPServer) batchProcessor(ctx context.Context, result *BatchResult, confirm string) (func(id string) BatchItemResult, error) {
+	switch result.Operation {
+	case BatchOperationBulkTag, BatchOperationBulkPrivacy, BatchOperationCleanup:
+		if !s.toolRegistered(result.Operation) {
+			return nil, fmt.Errorf("cannot retry %s: the tool is disabled by MOWEN_ENABLED_TOOLS or MOWEN_DISABLED_TOOLS", result.Operation)
+		}
+	}
+
+	switch result.Operation {
+	case BatchOperationBulkTag:
+		var params bulkTagParams
+		if err := json.Unmarshal(result.Params, &params); err != nil || strings.TrimSpace(params.Tag) == "" {
+			return nil, fmt.Errorf("invalid params for %s: tag is required", result.Operation)
+		}
+		return s.addTagProcessor(ctx, params.Tag, s.currentNoteTags), nil
+	case BatchOperationBulkPrivacy:
+		var params bulkPrivacyParams
+		if err := json.Unmarshal(result.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params for %s: %w", result.Operation, err)
+		}
+		if err := checkStoredExpireAt(params.ExpireAt, s.expireAtUnit, time.Now()); err != nil {
+			return nil, err
+		}
+		return s.setPrivacyProcessor(ctx, &NotePrivacySet{
+			Type: "rule",
+			Rule: &NotePrivacySetRule{NoShare: params.NoShare, ExpireAt: params.ExpireAt},
+		}), nil
+	case BatchOperationCleanup:
+		var params cleanupParams
+		if err := json.Unmarshal(result.Params, &params); err != nil || strings.TrimSpace(params.Tag) == "" {
+			return nil, fmt.Errorf("invalid params for %s: tag is required", result.Operation)
+		}
+		if confirm != params.Tag {
+			return nil, fmt.Errorf("confirm must be exactly %q to retry deleting notes tagged with it", params.Tag)
+		}
+		return s.retryDeleteProcessor(ctx, params.Tag), nil
+	}
+	return nil, fmt.Errorf("batch operation %q cannot be retried", result.Operation)
+}
+
+// checkStoredExpireAt 再次校验批量结果中已按接口单位转换的公开截止时间，空值和0表示永不过期
+func checkStoredExpireAt(expireAt, unit string, now time.Time) error {
+	if expireAt == "" || expireAt == "0" {
+		return nil
+	}
+	value, err := strconv.ParseInt(expireAt, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid expire_at %q in batch result: %v", expireAt, err)
+	}
+	if unit == ExpireAtUnitMilliseconds {
+		value /= 1000
+	}
+	_, err = FormatExpireAt(value, unit, now)
+	return err
+}
+
+// toolRegistered 判断工具是否按MOWEN_ENABLED_TOOLS和MOWEN_DISABLED_TOOLS注册
+func (s *MowenMCPServer) toolRegistered(name string) bool {
+	for _, registered := range s.registeredTools {
+		if registered == name {
+			return true
+		}
+	}
+	return false
+}
+
+// currentNoteTags 读取笔记当前的标签
+func (s *MowenMCPServer) currentNoteTags(ctx context.Context, id string) ([]string, error) {
+	result, err := s.mowenClient.GetNote(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, err
+	}
+	return detail.Tags, nil
+}
+
+// retryDeleteProcessor 返回重试删除笔记的处理函数。
+// 批量结果由调用方提供，删除前会再次确认笔记仍带有清理标签，避免误删其他笔记。
+func (s *MowenMCPServer) retryDeleteProcessor(ctx context.Context, tag string) func(id string) BatchItemResult {
+	return func(id string) BatchItemResult {
+		tags, err := s.currentNoteTags(ctx, id)
+		if err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		if !containsTag(tags, tag) {
+			return BatchItemResult{Status: BatchItemSkipped, Reason: fmt.Sprintf("笔记不再带有标签 %q", tag)}
+		}
+		if _, err := s.mowenClient.DeleteNote(ctx, id); err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		return BatchItemResult{Status: BatchItemSucceeded}
+	}
+}
+
+// handleRetryBatch 处理重试批量操作失败条目的MCP工具请求。
+// 只重新处理失败和因失败中止未处理的条目，成功和跳过的条目保持原结果，返回的结果可再次重试。
+func (s *MowenMCPServer) handleRetryBatch(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args RetryBatchArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	result, err := ParseBatchResult(args.Result)
+	if err != nil {
+		return nil, err
+	}
+	pending := 0
+	for _, item := range result.Items {
+		if item.needsRetry() {
+			pending++
+		}
+	}
+	if pending == 0 {
+		return textResult("批量结果中没有失败或未处理的条目，无需重试"), nil
+	}
+
+	process, err := s.batchProcessor(ctx, result, args.Confirm)
+	if err != nil {
+		return nil, err
+	}
+	result.Items = retryBatchItems(result.Items, args.StopOnError, process)
+	if result.Operation != BatchOperationBulkPrivacy {
+		s.tagCache.invalidate()
+	}
+
+	return textResult(fmt.Sprintf("已重试 %d 个失败或未处理的条目\n", pending) + result.Report()), nil
+}
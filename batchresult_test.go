@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRetryBatchItems 测试只重试失败和未处理的条目，并保持原顺序和下标
+func TestRetryBatchItems(t *testing.T) {
+	items := []BatchResultItem{
+		{Index: 0, ID: "a", Status: BatchItemSucceeded},
+		{Index: 1, ID: "b", Status: BatchItemFailed, Reason: "boom"},
+		{Index: 2, ID: "c", Status: BatchItemSkipped, Reason: "已有该标签"},
+		{Index: 3, ID: "d", Status: BatchItemNotRun},
+		{Index: 4, ID: "e", Status: BatchItemFailed, Reason: "boom"},
+	}
+	var processed []string
+	process := func(id string) BatchItemResult {
+		processed = append(processed, id)
+		if id == "d" {
+			return BatchItemResult{Status: BatchItemFailed, Reason: "still broken"}
+		}
+		return BatchItemResult{Status: BatchItemSucceeded}
+	}
+
+	retried := retryBatchItems(items, false, process)
+	assert.Equal(t, []string{"b", "d", "e"}, processed)
+	assert.Equal(t, []BatchResultItem{
+		{Index: 0, ID: "a", Status: BatchItemSucceeded},
+		{Index: 1, ID: "b", Status: BatchItemSucceeded},
+		{Index: 2, ID: "c", Status: BatchItemSkipped, Reason: "已有该标签"},
+		{Index: 3, ID: "d", Status: BatchItemFailed, Reason: "still broken"},
+		{Index: 4, ID: "e", Status: BatchItemSucceeded},
+	}, retried)
+
+	processed = nil
+	retried = retryBatchItems(items, true, process)
+	assert.Equal(t, []string{"b", "d"}, processed)
+	assert.Equal(t, BatchResultItem{Index: 4, ID: "e", Status: BatchItemNotRun}, retried[4])
+}
+
+// TestParseBatchResult 测试解析完整工具输出中的批量结果，并拒绝无效结果
+func TestParseBatchResult(t *testing.T) {
+	batch, err := newBatchResult(BatchOperationBulkTag, "添加标签", bulkTagParams{Tag: "项目"}, []BatchItemResult{
+		{ID: "a", Status: BatchItemSucceeded},
+		{ID: "b", Status: BatchItemFailed, Reason: "boom"},
+	})
+	require.NoError(t, err)
+	report := batch.Report()
+	assert.True(t, strings.HasPrefix(report, "添加标签：共 2 项，成功 1 项，跳过 0 项，失败 1 项\n- b：boom\n\n"))
+
+	parsed, err := ParseBatchResult(report)
+	require.NoError(t, err)
+	assert.Equal(t, batch, parsed)
+
+	// 全部成功时不附带批量结果
+	batch.Items[1].Status = BatchItemSucceeded
+	assert.NotContains(t, batch.Report(), "retry_batch")
+
+	for _, text := range []string{
+		"没有结果",
+		`{"version": 2, "operation": "bulk_tag_notes", "items": []}`,
+		`{"version": 1, "items": [{"index": 0, "status": "failed"}]}`,
+		`{"version": 1, "items": [{"index": 0, "id": "a", "status": "broken"}]}`,
+		`{"version": 1, "items": [{"index": 1, "id": "a", "status": "failed"}, {"index": 0, "id": "b", "status": "failed"}]}`,
+	} {
+		_, err := ParseBatchResult(text)
+		assert.Error(t, err, text)
+	}
+}
+
+// TestHandleRetryBatch 测试批量添加标签部分失败后，只重试失败和未处理的笔记
+func (suite *ServerTestSuite) TestHandleRetryBatch() {
+	suite.routes[NoteListEndpoint] = mockSuccess(NoteListResult{
+		Notes: []NoteSummary{
+			{NoteID: "note-aaaa-1"},
+			{NoteID: "note-bbbb-2"},
+			{NoteID: "note-cccc-3", Tags: []string{"项目"}},
+			{NoteID: "note-dddd-4"},
+		},
+		Total: 4,
+	})
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var detailReq NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&detailReq))
+		tags := []string{"周报"}
+		if detailReq.NoteID == "note-cccc-3" {
+			tags = []string{"项目"}
+		}
+		mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID, "tags": tags})(w, r)
+	}
+
+	failing := true
+	var setIDs []string
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var setReq NoteSetRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		if failing && setReq.NoteID == "note-bbbb-2" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"code":500,"message":"internal error"}`))
+			return
+		}
+		setIDs = append(setIDs, setReq.NoteID)
+		if !failing {
+			assert.Equal(suite.T(), []string{"周报", "项目"}, setReq.Settings.Tags)
+		}
+		mockSuccess(nil)(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleBulkTagNotes, BulkTagNotesArgs{Query: "项目", Tag: "项目", StopOnError: true})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "成功 1 项，跳过 0 项，失败 1 项，因失败中止未处理 2 项")
+	assert.Equal(suite.T(), []string{"note-aaaa-1"}, setIDs)
+
+	failing = false
+	setIDs = nil
+	text, err = suite.callTool(suite.mcpServer.handleRetryBatch, RetryBatchArgs{Result: text})
+	require.NoError(suite.T(), err)
+	assert.True(suite.T(), strings.HasPrefix(text, "已重试 3 个失败或未处理的条目\n"+
+		`为匹配 "项目" 的笔记添加标签 "项目"：共 4 项，成功 3 项，跳过 1 项，失败 0 项`), text)
+	assert.NotContains(suite.T(), text, "retry_batch")
+	// 重试时读取笔记当前的标签，已有该标签的note-cccc-3被跳过
+	assert.Equal(suite.T(), []string{"note-bbbb-2", "note-dddd-4"}, setIDs)
+
+	_, err = suite.callTool(suite.mcpServer.handleRetryBatch, RetryBatchArgs{
+		Result: `{"version": 1, "operation": "export_notes_markdown", "items": [{"index": 0, "id": "a", "status": "failed"}]}`,
+	})
+	assert.Error(suite.T(), err)
+
+	text, err = suite.callTool(suite.mcpServer.handleRetryBatch, RetryBatchArgs{
+		Result: `{"version": 1, "operation": "bulk_tag_notes", "params": {"tag": "项目"}, "items": [{"index": 0, "id": "a", "status": "succeeded"}]}`,
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "批量结果中没有失败或未处理的条目，无需重试", text)
+}
+
+// TestHandleRetryBatchGuards 测试重试前按原工具的要求校验调用方提供的批量结果：
+// 删除需要confirm，工具被关闭时拒绝，过期的公开截止时间被拒绝
+func (suite *ServerTestSuite) TestHandleRetryBatchGuards() {
+	deleted := 0
+	suite.routes[NoteDeleteEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		deleted++
+		mockSuccess(nil)(w, r)
+	}
+	suite.routes[NoteDetailEndpoint] = mockSuccess(map[string]interface{}{"noteId": "note-aaaa-1", "tags": []string{"test"}})
+	cleanup := `{"version": 1, "operation": "cleanup_notes", "params": {"tag": "test"}, "items": [{"index": 0, "id": "note-aaaa-1", "status": "failed"}]}`
+
+	_, err := suite.callTool(suite.mcpServer.handleRetryBatch, RetryBatchArgs{Result: cleanup})
+	assert.ErrorContains(suite.T(), err, `confirm must be exactly "test"`)
+	_, err = suite.callTool(suite.mcpServer.handleRetryBatch, RetryBatchArgs{Result: cleanup, Confirm: "other"})
+	assert.Error(suite.T(), err)
+	assert.Zero(suite.T(), deleted)
+
+	text, err := suite.callTool(suite.mcpServer.handleRetryBatch, RetryBatchArgs{Result: cleanup, Confirm: "test"})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "成功 1 项")
+	assert.Equal(suite.T(), 1, deleted)
+
+	// 产生结果的工具被关闭时拒绝重试
+	registered := suite.mcpServer.registeredTools
+	defer func() { suite.mcpServer.registeredTools = registered }()
+	suite.mcpServer.registeredTools = []string{"retry_batch", "bulk_set_privacy"}
+	_, err = suite.callTool(suite.mcpServer.handleRetryBatch, RetryBatchArgs{Result: cleanup, Confirm: "test"})
+	assert.ErrorContains(suite.T(), err, "cannot retry cleanup_notes: the tool is disabled")
+	assert.Equal(suite.T(), 1, deleted)
+
+	// 批量结果中的公开截止时间已经过去
+	privacySet := 0
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		privacySet++
+		mockSuccess(nil)(w, r)
+	}
+	expired := fmt.Sprintf(`{"version": 1, "operation": "bulk_set_privacy", "params": {"expire_at": "%d"}, "items": [{"index": 0, "id": "note-aaaa-1", "status": "failed"}]}`, time.Now().Add(-time.Hour).Unix())
+	_, err = suite.callTool(suite.mcpServer.handleRetryBatch, RetryBatchArgs{Result: expired})
+	assert.ErrorContains(suite.T(), err, "is not in the future")
+	assert.Zero(suite.T(), privacySet)
+
+	future := fmt.Sprintf(`{"version": 1, "operation": "bulk_set_privacy", "params": {"expire_at": "%d"}, "items": [{"index": 0, "id": "note-aaaa-1", "status": "failed"}]}`, time.Now().Add(time.Hour).Unix())
+	_, err = suite.callTool(suite.mcpServer.handleRetryBatch, RetryBatchArgs{Result: future})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, privacySet)
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// cleanupTag 返回要清理的标签，参数为空时使用MOWEN_CLEANUP_TAG
+func cleanupTag(tag string) string {
+	if tag = strings.TrimSpace(tag); tag != "" {
+		return tag
+	}
+	return strings.TrimSpace(os.Getenv("MOWEN_CLEANUP_TAG"))
+}
+
+// handleCleanupNotes 处理按标签批量删除笔记的MCP工具请求，用于清理测试环境产生的笔记。
+// 只删除标签中确实包含该标签的笔记；非试运行时confirm必须与标签一致。
+// 删除请求经过客户端限流（MOWEN_RATE_LIMIT），单篇失败不会中断整个批次。
+func (s *MowenMCPServer) handleCleanupNotes(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args CleanupNotesArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	tag := cleanupTag(args.Tag)
+	if tag == "" {
+		return nil, fmt.Errorf("tag is required when MOWEN_CLEANUP_TAG is not set")
+	}
+	if !args.DryRun && args.Confirm != tag {
+		return nil, fmt.Errorf("confirm must be exactly %q to delete notes tagged with it; use dry_run to preview", tag)
+	}
+
+	notes, err := s.searchAllNotes(ctx, NoteListRequest{Tag: tag})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	// 列表接口的标签筛选可能不精确，删除前再次核对标签
+	var ids []string
+	titles := make(map[string]string, len(notes))
+	for _, note := range notes {
+		if containsTag(note.Tags, tag) {
+			ids = append(ids, note.NoteID)
+			titles[note.NoteID] = note.Title
+		}
+	}
+	if len(ids) == 0 {
+		return textResult(fmt.Sprintf("没有带标签 %q 的笔记", tag)), nil
+	}
+
+	if args.DryRun {
+		var sb strings.Builder
+		fmt.Fprintf(&sb, "试运行：将删除 %d 篇带标签 %q 的笔记：", len(ids), tag)
+		for _, id := range ids {
+			fmt.Fprintf(&sb, "\n- %s", id)
+			if titles[id] != "" {
+				fmt.Fprintf(&sb, "（%s）", titles[id])
+			}
+		}
+		return textResult(sb.String()), nil
+	}
+
+	unsupported := false
+	results := runBatch(ids, false, func(id string) BatchItemResult {
+		if unsupported {
+			return BatchItemResult{Status: BatchItemNotRun}
+		}
+		if _, err := s.mowenClient.DeleteNote(ctx, id); err != nil {
+			if errors.Is(err, ErrNotSupported) {
+				unsupported = true
+			}
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		return BatchItemResult{Status: BatchItemSucceeded}
+	})
+	// 第一篇就返回404说明接口不存在
+	if unsupported && results[0].Status == BatchItemFailed {
+		return textResult("当前墨问API不支持删除笔记"), nil
+	}
+	s.tagCache.invalidate()
+
+	batch, err := newBatchResult(BatchOperationCleanup, fmt.Sprintf("删除带标签 %q 的笔记", tag), cleanupParams{Tag: tag}, results)
+	if err != nil {
+		return nil, err
+	}
+	return textResult(batch.Report()), nil
+}
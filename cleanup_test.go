@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routeCleanupNotes 模拟按标签筛选的笔记列表，其中一篇笔记的标签并不匹配，返回记录删除请求的切片指针
+func (suite *ServerTestSuite) routeCleanupNotes() *[]string {
+	suite.routes[NoteListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var listReq NoteListRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&listReq))
+		assert.Equal(suite.T(), "test", listReq.Tag)
+		mockSuccess(NoteListResult{Notes: []NoteSummary{
+			{NoteID: "note-test-1", Title: "CI用例", Tags: []string{"test"}},
+			{NoteID: "note-test-2", Tags: []string{"test", "项目"}},
+			{NoteID: "note-keep-3", Tags: []string{"testing"}},
+		}})(w, r)
+	}
+
+	deleted := &[]string{}
+	suite.routes[NoteDeleteEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var deleteReq NoteDeleteRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&deleteReq))
+		*deleted = append(*deleted, deleteReq.NoteID)
+		mockSuccess(nil)(w, r)
+	}
+	return deleted
+}
+
+// TestHandleCleanupNotesDryRun 测试试运行只列出将被删除的笔记
+func (suite *ServerTestSuite) TestHandleCleanupNotesDryRun() {
+	deleted := suite.routeCleanupNotes()
+
+	text, err := suite.callTool(suite.mcpServer.handleCleanupNotes, CleanupNotesArgs{Tag: "test", DryRun: true})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "试运行：将删除 2 篇带标签 \"test\" 的笔记：\n- note-test-1（CI用例）\n- note-test-2", text)
+	assert.Empty(suite.T(), *deleted)
+}
+
+// TestHandleCleanupNotesConfirmed 测试确认后删除带标签的笔记，未确认时拒绝删除
+func (suite *ServerTestSuite) TestHandleCleanupNotesConfirmed() {
+	deleted := suite.routeCleanupNotes()
+
+	_, err := suite.callTool(suite.mcpServer.handleCleanupNotes, CleanupNotesArgs{Tag: "test"})
+	require.Error(suite.T(), err)
+	_, err = suite.callTool(suite.mcpServer.handleCleanupNotes, CleanupNotesArgs{Tag: "test", Confirm: "yes"})
+	require.Error(suite.T(), err)
+	assert.Empty(suite.T(), *deleted)
+
+	suite.T().Setenv("MOWEN_CLEANUP_TAG", "test")
+	text, err := suite.callTool(suite.mcpServer.handleCleanupNotes, CleanupNotesArgs{Confirm: "test"})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 2 项，成功 2 项")
+	assert.Equal(suite.T(), []string{"note-test-1", "note-test-2"}, *deleted)
+}
+
+// TestHandleCleanupNotesNotSupported 测试删除接口不存在时返回提示并停止删除
+func (suite *ServerTestSuite) TestHandleCleanupNotesNotSupported() {
+	suite.routeCleanupNotes()
+	calls := 0
+	suite.routes[NoteDeleteEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleCleanupNotes, CleanupNotesArgs{Tag: "test", Confirm: "test"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "当前墨问API不支持删除笔记", text)
+	assert.Equal(suite.T(), 1, calls)
+}
@@ -2,62 +2,412 @@ package main
 
 import (
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 const (
 	// MowenAPIBaseURL 墨问API基础URL
 	MowenAPIBaseURL = "https://open.mowen.cn"
 	// API端点
-	NoteCreateEndpoint    = "/api/open/api/v1/note/create"
-	NoteEditEndpoint      = "/api/open/api/v1/note/edit"
-	NoteSetEndpoint       = "/api/open/api/v1/note/set"
-	KeyResetEndpoint      = "/api/open/api/v1/auth/key/reset"
-	UploadPrepareEndpoint = "/api/open/api/v1/upload/prepare"
-	UploadURLEndpoint     = "/api/open/api/v1/upload/url"
+	NoteCreateEndpoint      = "/api/open/api/v1/note/create"
+	NoteEditEndpoint        = "/api/open/api/v1/note/edit"
+	NoteSetEndpoint         = "/api/open/api/v1/note/set"
+	NoteDetailEndpoint      = "/api/open/api/v1/note/detail"
+	NoteShareEndpoint       = "/api/open/api/v1/note/share"
+	NoteListEndpoint        = "/api/open/api/v1/note/list"
+	NoteDeleteEndpoint      = "/api/open/api/v1/note/delete"
+	KeyResetEndpoint        = "/api/open/api/v1/auth/key/reset"
+	AccountSettingsEndpoint = "/api/open/api/v1/account/settings"
+	TagListEndpoint         = "/api/open/api/v1/tag/list"
+	UploadPrepareEndpoint   = "/api/open/api/v1/upload/prepare"
+	UploadURLEndpoint       = "/api/open/api/v1/upload/url"
+	UploadStatusEndpoint    = "/api/open/api/v1/upload/status"
+	FileDownloadEndpoint    = "/api/open/api/v1/upload/download"
+
+	// DefaultHTTPTimeout 单次HTTP请求的默认超时时间，可通过WithTimeout调整
+	DefaultHTTPTimeout = 30 * time.Second
+	// DefaultSignatureHeader 请求签名默认使用的请求头，可通过MOWEN_SIGNATURE_HEADER调整
+	DefaultSignatureHeader = "X-Mowen-Signature"
+	// DefaultMaxRedirects 默认最多跟随的重定向次数，与net/http默认值一致
+	DefaultMaxRedirects = 10
+	// DefaultMaxIdleConns 连接池中每个主机保留的默认空闲连接数。
+	// net/http默认只保留2个，多个会话并发调用时会频繁新建连接。
+	DefaultMaxIdleConns = 16
+	// MaxNoteChunks 获取分块返回的笔记详情时最多请求的分块数，避免游标异常时无限请求
+	MaxNoteChunks = 100
+	// DefaultUploadReadyTimeout 等待上传的文件处理完成的默认时长，可通过MOWEN_UPLOAD_READY_TIMEOUT调整
+	DefaultUploadReadyTimeout = 60 * time.Second
+	// DefaultUploadPollInterval 首次查询文件处理状态前的等待时间，之后每次翻倍
+	DefaultUploadPollInterval = 500 * time.Millisecond
+	// MaxUploadPollInterval 查询文件处理状态的最长间隔
+	MaxUploadPollInterval = 5 * time.Second
+	// DefaultMaxConcurrentUploads 所有上传操作共享的默认并发上限，可通过MOWEN_MAX_CONCURRENT_UPLOADS调整
+	DefaultMaxConcurrentUploads = 2
 )
 
-// MowenClient 墨问API客户端
+// ErrNotSupported 表示当前墨问API不支持该操作（接口不存在）
+var ErrNotSupported = errors.New("operation not supported by the Mowen API")
+
+// ErrTimestampsNotSupported 墨问API拒绝了自定义的创建或更新时间
+var ErrTimestampsNotSupported = errors.New("the Mowen API rejected custom created_at/updated_at timestamps; setting note dates may not be supported")
+
+// ErrNoteNotFound 墨问API报告要操作的笔记不存在
+var ErrNoteNotFound = errors.New("note does not exist")
+
+// ErrUploadNotReady 等待超时后上传的文件仍未处理完成
+var ErrUploadNotReady = errors.New("uploaded file is not ready")
+
+// ErrLoginRedirect 请求被重定向到登录页面，通常是网关会话过期或API密钥无效
+var ErrLoginRedirect = errors.New("authentication required: the request was redirected to a login page; check MOWEN_API_KEY or re-authenticate with the gateway")
+
+// HTTPStatusError 墨问API返回非200状态码时的错误
+type HTTPStatusError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// apiResponse 墨问API的响应信封，code为0表示成功
+type apiResponse struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// APIError 墨问API在响应体中返回的业务错误（code不为0），HTTP状态码可能仍为200
+type APIError struct {
+	Code    int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error %d: %s", e.Code, e.Message)
+}
+
+// decodeResponse 解析响应信封，code不为0时返回*APIError。
+// 响应体不是JSON对象或没有code字段时视为成功，由调用方按各接口的格式解析。
+func decodeResponse(body []byte) error {
+	var envelope apiResponse
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil
+	}
+	if envelope.Code != 0 {
+		return &APIError{Code: envelope.Code, Message: envelope.Message}
+	}
+	return nil
+}
+
+// isNotFound 判断错误是否为接口返回404
+func isNotFound(err error) bool {
+	var statusErr *HTTPStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// isNoteNotFoundResponse 判断响应是否表示笔记不存在：HTTP状态码为404，
+// 或者业务错误码为404（部分接口以200或400状态码返回业务错误码）
+func isNoteNotFoundResponse(err error, result map[string]interface{}) bool {
+	if isNotFound(err) {
+		return true
+	}
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusNotFound
+	}
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		result = nil
+		_ = json.Unmarshal([]byte(statusErr.Body), &result)
+	}
+	code, ok := result["code"].(float64)
+	return ok && int(code) == http.StatusNotFound
+}
+
+// isNoteMissingStatus 判断HTTP 404是否为墨问API答复笔记不存在：响应体是带有业务错误码的API信封，
+// 且错误码为404或message说明笔记不存在。接口本身不存在时返回的404不带业务错误码。
+func isNoteMissingStatus(err error) bool {
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusNotFound {
+		return false
+	}
+	var envelope apiResponse
+	if json.Unmarshal([]byte(statusErr.Body), &envelope) != nil || envelope.Code == 0 {
+		return false
+	}
+	message := strings.ToLower(envelope.Message)
+	return envelope.Code == http.StatusNotFound ||
+		strings.Contains(message, "不存在") ||
+		strings.Contains(message, "not found") ||
+		strings.Contains(message, "not exist")
+}
+
+// isBadRequest 判断错误是否为接口返回400
+func isBadRequest(err error) bool {
+	var statusErr *HTTPStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusBadRequest
+}
+
+// MowenClient 墨问API客户端。
+// 客户端可以被多个goroutine并发使用：服务只创建一个客户端，所有MCP会话和工具处理器共享它的
+// 连接池、请求频率限制和限流信息。创建后配置不再修改，可变状态（限流器、限流信息）各自加锁保护。
 type MowenClient struct {
 	apiKey     string
 	httpClient *http.Client
 	baseURL    string
+	throttle   *requestThrottle // 请求频率限制，为nil时不限制
+	logger     *componentLogger // 客户端日志，为nil时不输出
+	// debugBodies 是否在调试日志中记录请求体和响应体
+	debugBodies bool
+	// maxRetries 可安全重试的操作遇到临时性错误时的最大重试次数
+	maxRetries int
+	// retryBackoff 首次重试前的等待时间，之后每次翻倍
+	retryBackoff time.Duration
+	// retryStatuses 视为临时性错误、允许重试的HTTP状态码
+	retryStatuses map[int]bool
+	// prepareTimeout 上传准备请求的超时时间，与存储上传使用的默认超时分开
+	prepareTimeout time.Duration
+	// prepareRetries 上传准备请求的最大重试次数，不受maxRetries影响
+	prepareRetries int
+	// uploadReadyTimeout 等待文件处理完成的最长时间，0表示只受调用方ctx限制
+	uploadReadyTimeout time.Duration
+	// uploadPollInterval 首次查询文件处理状态前的等待时间
+	uploadPollInterval time.Duration
+	// uploadSlots 所有上传操作共享的并发限制，与工具调用的并发限制分开，为nil时不限制
+	uploadSlots uploadSemaphore
+	// maxRedirects 最多跟随的重定向次数
+	maxRedirects int
+	// detectLoginRedirect 是否将重定向到登录页面的响应报告为ErrLoginRedirect
+	detectLoginRedirect bool
+	// schemaVersion 创建和编辑笔记时发送的笔记内容结构版本，为空时不发送
+	schemaVersion string
+	// rateLimits 最近一次响应的限流信息，为nil时不记录
+	rateLimits *rateLimitTracker
+	// signingSecret 请求签名密钥，为空时不签名
+	signingSecret []byte
+	// signatureHeader 携带请求签名的请求头
+	signatureHeader string
+}
+
+// ClientOption 创建客户端时的可选配置，在环境变量之后应用，会覆盖对应的环境变量
+type ClientOption func(c *MowenClient)
+
+// WithTimeout 设置单次HTTP请求的超时时间，默认为DefaultHTTPTimeout（30秒），0表示不限制
+func WithTimeout(timeout time.Duration) ClientOption {
+	return func(c *MowenClient) {
+		c.httpClient.Timeout = timeout
+	}
 }
 
-// NewMowenClient 创建新的墨问API客户端
-func NewMowenClient() (*MowenClient, error) {
+// WithRetry 设置可安全重试的请求遇到临时性错误时的重试策略，覆盖MOWEN_MAX_RETRIES。
+// maxAttempts为包含首次请求在内的最多尝试次数，1表示不重试；backoff为首次重试前的等待时间，之后每次翻倍。
+// 只有网络超时、连接错误和MOWEN_RETRY_STATUSES中的状态码（默认为429和全部5xx）会重试，其他4xx错误从不重试。
+// 未设置时默认超时为30秒且不重试（DefaultMaxRetries为0），与早期版本保持一致；开启重试后首次等待DefaultRetryBackoff。
+func WithRetry(maxAttempts int, backoff time.Duration) ClientOption {
+	return func(c *MowenClient) {
+		if maxAttempts < 1 {
+			maxAttempts = 1
+		}
+		c.maxRetries = maxAttempts - 1
+		c.retryBackoff = backoff
+	}
+}
+
+// NewMowenClient 创建新的墨问API客户端，配置读取自环境变量，opts可覆盖其中的超时和重试策略
+func NewMowenClient(opts ...ClientOption) (*MowenClient, error) {
 	apiKey := os.Getenv("MOWEN_API_KEY")
 	if apiKey == "" {
 		return nil, fmt.Errorf("MOWEN_API_KEY environment variable is required")
 	}
 
-	return &MowenClient{
-		apiKey:  apiKey,
-		baseURL: MowenAPIBaseURL,
+	// 重定向次数上限，可通过环境变量MOWEN_MAX_REDIRECTS调整，0表示不跟随重定向
+	maxRedirects, err := envInt("MOWEN_MAX_REDIRECTS", DefaultMaxRedirects)
+	if err != nil {
+		return nil, err
+	}
+
+	// 连接池中每个主机保留的空闲连接数，可通过环境变量MOWEN_MAX_IDLE_CONNS调整，0表示不保留空闲连接
+	maxIdleConns, err := envInt("MOWEN_MAX_IDLE_CONNS", DefaultMaxIdleConns)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每秒最多发送的请求数，可通过环境变量MOWEN_RATE_LIMIT调整，0表示不限制
+	rateLimit, err := envInt("MOWEN_RATE_LIMIT", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	logger, err := newComponentLogger(LogComponentClient)
+	if err != nil {
+		return nil, err
+	}
+
+	// 临时性错误的最大重试次数，可通过环境变量MOWEN_MAX_RETRIES调整，0表示不重试
+	maxRetries, err := envInt("MOWEN_MAX_RETRIES", DefaultMaxRetries)
+	if err != nil {
+		return nil, err
+	}
+	retryStatuses, err := loadRetryStatuses()
+	if err != nil {
+		return nil, err
+	}
+
+	// 上传准备请求的超时秒数和重试次数，可通过MOWEN_PREPARE_TIMEOUT和MOWEN_PREPARE_RETRIES调整
+	prepareTimeout, err := envInt("MOWEN_PREPARE_TIMEOUT", int(DefaultPrepareTimeout/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	prepareRetries, err := envInt("MOWEN_PREPARE_RETRIES", DefaultPrepareRetries)
+	if err != nil {
+		return nil, err
+	}
+	// 等待文件处理完成的秒数，可通过MOWEN_UPLOAD_READY_TIMEOUT调整
+	uploadReadyTimeout, err := envInt("MOWEN_UPLOAD_READY_TIMEOUT", int(DefaultUploadReadyTimeout/time.Second))
+	if err != nil {
+		return nil, err
+	}
+
+	// 同时进行的上传数，可通过MOWEN_MAX_CONCURRENT_UPLOADS调整，0表示不限制
+	maxConcurrentUploads, err := envInt("MOWEN_MAX_CONCURRENT_UPLOADS", DefaultMaxConcurrentUploads)
+	if err != nil {
+		return nil, err
+	}
+
+	// MOWEN_DEBUG 记录脱敏并缩进后的请求体和响应体，同时将客户端日志级别设为debug
+	debugBodies, err := envBool("MOWEN_DEBUG", false)
+	if err != nil {
+		return nil, err
+	}
+	if debugBodies {
+		logger.level = LogLevelDebug
+	}
+
+	// MOWEN_DETECT_LOGIN_REDIRECT 为false时不检查重定向后的响应，按原样解析
+	detectLoginRedirect, err := envBool("MOWEN_DETECT_LOGIN_REDIRECT", true)
+	if err != nil {
+		return nil, err
+	}
+
+	// MOWEN_SIGNING_SECRET 设置后对每个API请求的请求体计算HMAC-SHA256签名，放在MOWEN_SIGNATURE_HEADER请求头中
+	signatureHeader := strings.TrimSpace(os.Getenv("MOWEN_SIGNATURE_HEADER"))
+	if signatureHeader == "" {
+		signatureHeader = DefaultSignatureHeader
+	}
+
+	client := &MowenClient{
+		apiKey:              apiKey,
+		signingSecret:       []byte(os.Getenv("MOWEN_SIGNING_SECRET")),
+		signatureHeader:     signatureHeader,
+		schemaVersion:       strings.TrimSpace(os.Getenv("MOWEN_SCHEMA_VERSION")),
+		baseURL:             MowenAPIBaseURL,
+		throttle:            newRequestThrottle(rateLimit),
+		logger:              logger,
+		debugBodies:         debugBodies,
+		maxRetries:          maxRetries,
+		retryBackoff:        DefaultRetryBackoff,
+		retryStatuses:       retryStatuses,
+		maxRedirects:        maxRedirects,
+		detectLoginRedirect: detectLoginRedirect,
+		prepareTimeout:      time.Duration(prepareTimeout) * time.Second,
+		prepareRetries:      prepareRetries,
+		uploadReadyTimeout:  time.Duration(uploadReadyTimeout) * time.Second,
+		uploadPollInterval:  DefaultUploadPollInterval,
+		uploadSlots:         newUploadSemaphore(maxConcurrentUploads),
+		rateLimits:          newRateLimitTracker(),
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Transport:     newHTTPTransport(maxIdleConns),
+			Timeout:       DefaultHTTPTimeout,
+			CheckRedirect: newRedirectPolicy(maxRedirects),
 		},
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
+}
+
+// newHTTPTransport 基于net/http的默认传输层创建客户端专用的连接池，
+// maxIdleConns为每个主机保留的空闲连接数，为0时每次请求后关闭连接
+func newHTTPTransport(maxIdleConns int) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if maxIdleConns == 0 {
+		transport.DisableKeepAlives = true
+		return transport
+	}
+	transport.MaxIdleConnsPerHost = maxIdleConns
+	if transport.MaxIdleConns < maxIdleConns {
+		transport.MaxIdleConns = maxIdleConns
+	}
+	return transport
+}
+
+// newRedirectPolicy 创建重定向策略。
+// 跨主机重定向时移除Authorization请求头，避免API密钥泄露给第三方；超过maxRedirects次后停止跟随。
+// maxRedirects为0时不跟随重定向，直接返回3xx响应。
+func newRedirectPolicy(maxRedirects int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if maxRedirects == 0 {
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		if req.URL.Host != via[0].URL.Host {
+			req.Header.Del("Authorization")
+		}
+		return nil
+	}
 }
 
 // makeRequest 发送HTTP请求到墨问API
-func (c *MowenClient) makeRequest(method, endpoint string, body interface{}) ([]byte, error) {
+func (c *MowenClient) makeRequest(ctx context.Context, method, endpoint string, body interface{}) ([]byte, error) {
+	return c.makeRequestWithHeaders(ctx, method, endpoint, body, nil, 0)
+}
+
+// makeRequestWithHeaders 发送一次HTTP请求并附加额外的请求头，不做重试。
+// ctx取消时中止请求；timeout大于0时为本次请求单独设置超时，与客户端的默认超时同时生效。
+func (c *MowenClient) makeRequestWithHeaders(ctx context.Context, method, endpoint string, body interface{}, headers map[string]string, timeout time.Duration) ([]byte, error) {
 	var reqBody io.Reader
+	var jsonData []byte
 	if body != nil {
-		jsonData, err := json.Marshal(body)
+		var err error
+		jsonData, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
 		reqBody = bytes.NewBuffer(jsonData)
+		if c.debugBodies {
+			c.logger.Debugf("%s %s 请求体:\n%s", method, endpoint, c.maskAPIKey(formatDebugBody(jsonData)))
+		}
 	}
 
-	req, err := http.NewRequest(method, c.baseURL+endpoint, reqBody)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+endpoint, reqBody)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -65,29 +415,161 @@ func (c *MowenClient) makeRequest(method, endpoint string, body interface{}) ([]
 	// 设置请求头
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	req.Header.Set("Accept-Encoding", "gzip")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	if len(c.signingSecret) > 0 {
+		req.Header.Set(c.signatureHeader, signRequestBody(c.signingSecret, jsonData))
+	}
 
+	if err := c.throttle.wait(ctx); err != nil {
+		return nil, fmt.Errorf("request canceled while rate limited: %w", err)
+	}
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
+		c.logger.Warnf("%s %s 请求失败: %s", method, endpoint, c.maskAPIKey(err.Error()))
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 	defer resp.Body.Close()
+	c.logger.Debugf("%s %s -> %d (%s)", method, endpoint, resp.StatusCode, time.Since(start).Round(time.Millisecond))
+	c.rateLimits.record(method, endpoint, resp)
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readResponseBody(resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	if c.debugBodies {
+		debugBody := respBody
+		if endpoint == KeyResetEndpoint {
+			debugBody = redactResetAPIKeyBody(respBody)
+		}
+		c.logger.Debugf("%s %s 响应体:\n%s", method, endpoint, c.maskAPIKey(formatDebugBody(debugBody)))
+	}
 
+	if c.detectLoginRedirect {
+		if err := checkLoginRedirect(req, resp, respBody); err != nil {
+			c.logger.Warnf("%s %s 被重定向到登录页面: %s", method, endpoint, c.maskAPIKey(err.Error()))
+			return nil, err
+		}
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Body: c.maskAPIKey(string(respBody))}
+	}
+	// HTTP 200也可能携带业务错误码
+	if err := decodeResponse(respBody); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) {
+			apiErr.Message = c.maskAPIKey(apiErr.Message)
+		}
+		c.logger.Warnf("%s %s 返回业务错误: %v", method, endpoint, err)
+		return nil, err
 	}
 
 	return respBody, nil
 }
 
-// CreateNote 创建笔记
-func (c *MowenClient) CreateNote(req NoteCreateRequest) (map[string]interface{}, error) {
-	respBody, err := c.makeRequest("POST", NoteCreateEndpoint, req)
+// maskAPIKey 把文本中作为独立取值出现的API密钥替换为只显示末4位的形式，用于错误信息和日志。
+// 只替换前后不与ASCII字母、数字、连字符或下划线相连的出现，即JSON字段值、Bearer请求头值或
+// 消息中回显的密钥，较短的密钥不会误改恰好包含它的其他单词。
+func (c *MowenClient) maskAPIKey(text string) string {
+	if c.apiKey == "" {
+		return text
+	}
+	var sb strings.Builder
+	rest := text
+	for {
+		i := strings.Index(rest, c.apiKey)
+		if i < 0 {
+			sb.WriteString(rest)
+			return sb.String()
+		}
+		end := i + len(c.apiKey)
+		sb.WriteString(rest[:i])
+		if isKeyBoundary(rest[:i], true) && isKeyBoundary(rest[end:], false) {
+			sb.WriteString(maskKey(c.apiKey))
+		} else {
+			sb.WriteString(c.apiKey)
+		}
+		rest = rest[end:]
+	}
+}
+
+// isKeyBoundary 判断密钥前（before为true时取text末尾）或后（取text开头）的字符是否为取值边界
+func isKeyBoundary(text string, before bool) bool {
+	if text == "" {
+		return true
+	}
+	var r rune
+	if before {
+		r, _ = utf8.DecodeLastRuneInString(text)
+	} else {
+		r, _ = utf8.DecodeRuneInString(text)
+	}
+	// 密钥由ASCII字符组成，与中文等非ASCII字符相连时仍视为独立取值
+	return !(r < utf8.RuneSelf && (r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)))
+}
+
+// signRequestBody 计算请求体的HMAC-SHA256签名，返回小写十六进制字符串；没有请求体时对空内容签名
+func signRequestBody(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// checkLoginRedirect 检查请求是否被重定向到了登录页面。
+// 网关会话过期时常以302跳转到SSO登录页，跟随后得到的HTML无法按API响应解析。
+// 发生过重定向且响应为HTML页面，或落到其他主机且响应不是JSON时，返回包装了ErrLoginRedirect的错误。
+func checkLoginRedirect(req *http.Request, resp *http.Response, body []byte) error {
+	final := resp.Request
+	if final == nil || final.URL.String() == req.URL.String() {
+		return nil
+	}
+	if !isHTMLResponse(resp) && (final.URL.Host == req.URL.Host || json.Valid(body)) {
+		return nil
+	}
+	return fmt.Errorf("%w (redirected to %s://%s%s)", ErrLoginRedirect, final.URL.Scheme, final.URL.Host, final.URL.Path)
+}
+
+// isHTMLResponse 判断响应的Content-Type是否为HTML页面
+func isHTMLResponse(resp *http.Response) bool {
+	mediaType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	return err == nil && (mediaType == "text/html" || mediaType == "application/xhtml+xml")
+}
+
+// readResponseBody 读取响应体，响应为gzip压缩时自动解压。
+// 手动设置Accept-Encoding后net/http不再自动解压，部分服务端也会在未请求时返回压缩内容。
+func readResponseBody(resp *http.Response) ([]byte, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return io.ReadAll(resp.Body)
+	}
+
+	reader, err := gzip.NewReader(resp.Body)
 	if err != nil {
+		return nil, fmt.Errorf("failed to decompress gzip response: %w", err)
+	}
+	defer reader.Close()
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress gzip response: %w", err)
+	}
+	return body, nil
+}
+
+// CreateNote 创建笔记。
+// 指定了创建或更新时间而接口返回400时，返回包装了ErrTimestampsNotSupported的错误。
+// 创建不是幂等操作，只有请求携带幂等键时才会在临时性错误后自动重试。
+func (c *MowenClient) CreateNote(ctx context.Context, req NoteCreateRequest) (map[string]interface{}, error) {
+	if req.SchemaVersion == "" {
+		req.SchemaVersion = c.schemaVersion
+	}
+	respBody, err := c.doOperation(ctx, nonIdempotentOperation.withIdempotencyKey(req.IdempotencyKey), NoteCreateEndpoint, req)
+	if err != nil {
+		if (req.Settings.CreatedAt != 0 || req.Settings.UpdatedAt != 0) && isBadRequest(err) {
+			return nil, fmt.Errorf("failed to create note: %w (%v)", ErrTimestampsNotSupported, err)
+		}
 		return nil, fmt.Errorf("failed to create note: %w", err)
 	}
 
@@ -99,18 +581,124 @@ func (c *MowenClient) CreateNote(req NoteCreateRequest) (map[string]interface{},
 	return result, nil
 }
 
+// GetNote 获取笔记详情。
+// 内容较长的笔记可能分块返回，此时会按响应中的游标依次获取后续分块，并把各分块的段落合并到第一个分块的body中。
+func (c *MowenClient) GetNote(ctx context.Context, noteID string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	req := NoteDetailRequest{NoteID: noteID}
+	seen := make(map[string]bool)
+
+	for chunk := 0; ; chunk++ {
+		if chunk >= MaxNoteChunks {
+			return nil, fmt.Errorf("failed to get note: body exceeds %d chunks", MaxNoteChunks)
+		}
+
+		respBody, err := c.doOperation(ctx, idempotentOperation, NoteDetailEndpoint, req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get note: %w", err)
+		}
+
+		var chunkResult map[string]interface{}
+		if err := json.Unmarshal(respBody, &chunkResult); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+		}
+
+		data, _ := chunkResult["data"].(map[string]interface{})
+		cursor := popNoteCursor(data)
+		if result == nil {
+			result = chunkResult
+		} else {
+			appendNoteBody(result, data)
+		}
+
+		if cursor == "" {
+			return result, nil
+		}
+		if seen[cursor] {
+			return nil, fmt.Errorf("failed to get note: chunk cursor %q repeated", cursor)
+		}
+		seen[cursor] = true
+		req.Cursor = cursor
+	}
+}
+
+// popNoteCursor 读取并移除详情响应data中的分块游标，没有后续分块时返回空字符串
+func popNoteCursor(data map[string]interface{}) string {
+	var cursor string
+	for _, key := range []string{"next_cursor", "nextCursor", "nextToken"} {
+		if value, ok := data[key].(string); ok && cursor == "" {
+			cursor = value
+		}
+		delete(data, key)
+	}
+	return cursor
+}
+
+// appendNoteBody 将分块data中body的段落追加到完整结果的body中
+func appendNoteBody(result map[string]interface{}, chunk map[string]interface{}) {
+	data, _ := result["data"].(map[string]interface{})
+	body, _ := data["body"].(map[string]interface{})
+	chunkBody, _ := chunk["body"].(map[string]interface{})
+	if body == nil || chunkBody == nil {
+		return
+	}
+
+	content, _ := body["content"].([]interface{})
+	chunkContent, _ := chunkBody["content"].([]interface{})
+	body["content"] = append(content, chunkContent...)
+}
+
+// ListNotes 按条件分页查询笔记列表。
+// 接口使用游标分页时，响应中的next_cursor或nextToken会统一放入NextCursor，调用方将其作为下一次请求的Cursor。
+func (c *MowenClient) ListNotes(ctx context.Context, req NoteListRequest) (*NoteListResult, error) {
+	respBody, err := c.doOperation(ctx, idempotentOperation, NoteListEndpoint, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+
+	var result struct {
+		Data struct {
+			NoteListResult
+			NextToken string `json:"nextToken,omitempty"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	list := result.Data.NoteListResult
+	if list.NextCursor == "" {
+		list.NextCursor = result.Data.NextToken
+	}
+	return &list, nil
+}
+
+// validateFileType 检查上传接口的文件类型编号，只允许1（图片）、2（音频）、3（PDF）
+func validateFileType(fileType int) error {
+	switch fileType {
+	case 1, 2, 3:
+		return nil
+	}
+	return fmt.Errorf("invalid file type %d: must be 1 (image), 2 (audio) or 3 (PDF)", fileType)
+}
+
 // UploadFileViaURL 通过URL上传文件到墨问
-func (c *MowenClient) UploadFileViaURL(fileURL string, fileType int, fileName string) (map[string]interface{}, error) {
-	req := map[string]interface{}{
-		"url":       fileURL,
-		"file_type": fileType,
+func (c *MowenClient) UploadFileViaURL(ctx context.Context, fileURL string, fileType int, fileName string) (map[string]interface{}, error) {
+	if err := validateFileType(fileType); err != nil {
+		return nil, err
+	}
+	if err := c.uploadSlots.acquire(ctx); err != nil {
+		return nil, err
 	}
+	defer c.uploadSlots.release()
 
-	if fileName != "" {
-		req["file_name"] = fileName
+	req := UploadURLRequest{
+		URL:      fileURL,
+		FileType: fileType,
+		FileName: fileName,
 	}
 
-	respBody, err := c.makeRequest("POST", UploadURLEndpoint, req)
+	respBody, err := c.doOperation(ctx, nonIdempotentOperation, UploadURLEndpoint, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload file via URL: %w", err)
 	}
@@ -123,10 +711,202 @@ func (c *MowenClient) UploadFileViaURL(fileURL string, fileType int, fileName st
 	return result, nil
 }
 
-// EditNote 编辑笔记
-func (c *MowenClient) EditNote(req NoteEditRequest) (map[string]interface{}, error) {
-	respBody, err := c.makeRequest("POST", NoteEditEndpoint, req)
+// GetNoteShare 获取笔记的分享短链接和二维码。
+// 当墨问API不提供分享接口时返回ErrNotSupported。
+func (c *MowenClient) GetNoteShare(ctx context.Context, noteID string) (*NoteShareInfo, error) {
+	req := NoteDetailRequest{NoteID: noteID}
+	respBody, err := c.doOperation(ctx, idempotentOperation, NoteShareEndpoint, req)
 	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("failed to get note share info: %w", err)
+	}
+
+	var result struct {
+		Data NoteShareInfo `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result.Data, nil
+}
+
+// GetAccountDefaults 获取当前账号的默认笔记设置。
+// 当墨问API不提供账号设置接口时返回ErrNotSupported。
+func (c *MowenClient) GetAccountDefaults(ctx context.Context) (*AccountDefaults, error) {
+	respBody, err := c.doOperation(ctx, idempotentOperation, AccountSettingsEndpoint, struct{}{})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("failed to get account defaults: %w", err)
+	}
+
+	var result struct {
+		Data AccountDefaults `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return &result.Data, nil
+}
+
+// ListTags 获取账号下已使用的全部标签。
+// 当墨问API不提供标签列表接口时返回ErrNotSupported。
+func (c *MowenClient) ListTags(ctx context.Context) ([]TagCount, error) {
+	respBody, err := c.doOperation(ctx, idempotentOperation, TagListEndpoint, struct{}{})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	var result struct {
+		Data struct {
+			Tags []TagCount `json:"tags"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return result.Data.Tags, nil
+}
+
+// GetUploadStatus 查询已上传文件的处理状态。
+// 当墨问API不提供状态查询接口时返回ErrNotSupported。
+func (c *MowenClient) GetUploadStatus(ctx context.Context, fileUUID string) (*UploadStatus, error) {
+	req := UploadStatusRequest{UUID: fileUUID}
+	respBody, err := c.doOperation(ctx, idempotentOperation, UploadStatusEndpoint, req)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("failed to get upload status: %w", err)
+	}
+
+	var result struct {
+		Data UploadStatus `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if result.Data.UUID == "" {
+		result.Data.UUID = fileUUID
+	}
+
+	return &result.Data, nil
+}
+
+// GetFileDownload 获取已上传文件的下载地址。
+// 当墨问API不提供下载地址接口时返回ErrNotSupported。
+func (c *MowenClient) GetFileDownload(ctx context.Context, fileUUID string) (*FileDownloadInfo, error) {
+	req := FileDownloadRequest{UUID: fileUUID}
+	respBody, err := c.doOperation(ctx, idempotentOperation, FileDownloadEndpoint, req)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("failed to get file download url: %w", err)
+	}
+
+	var result struct {
+		Data FileDownloadInfo `json:"data"`
+	}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	if result.Data.URL == "" {
+		return nil, fmt.Errorf("download response for file %s has no url", fileUUID)
+	}
+	return &result.Data, nil
+}
+
+// DownloadFile 下载文件内容并写入w，返回写入的字节数。
+// 下载地址通常是存储服务的地址，请求不携带API密钥。
+func (c *MowenClient) DownloadFile(ctx context.Context, fileURL string, w io.Writer) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create download request: %w", err)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return 0, fmt.Errorf("download request failed with status %d", resp.StatusCode)
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return n, fmt.Errorf("failed to read downloaded file: %w", err)
+	}
+	return n, nil
+}
+
+// waitUploadReady 轮询文件处理状态直到文件就绪，查询间隔从uploadPollInterval开始逐次翻倍，最长为MaxUploadPollInterval。
+// 超过uploadReadyTimeout仍未就绪时返回包装了ErrUploadNotReady的错误，处理失败时返回失败原因，
+// 墨问API不支持状态查询时返回ErrNotSupported。
+func (c *MowenClient) waitUploadReady(ctx context.Context, fileUUID string) error {
+	if c.uploadReadyTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.uploadReadyTimeout)
+		defer cancel()
+	}
+	stopped := func() error {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return fmt.Errorf("%w: file %s is still processing after %s", ErrUploadNotReady, fileUUID, c.uploadReadyTimeout)
+		}
+		return fmt.Errorf("waiting for file %s canceled: %w", fileUUID, ctx.Err())
+	}
+
+	interval := c.uploadPollInterval
+	for {
+		status, err := c.GetUploadStatus(ctx, fileUUID)
+		if ctx.Err() != nil {
+			return stopped()
+		}
+		if err != nil {
+			return err
+		}
+		switch status.Status {
+		case UploadStatusReady:
+			return nil
+		case UploadStatusFailed:
+			if status.Message != "" {
+				return fmt.Errorf("file %s processing failed: %s", fileUUID, status.Message)
+			}
+			return fmt.Errorf("file %s processing failed", fileUUID)
+		}
+
+		c.logger.Debugf("文件 %s 处理状态为 %q，%s 后再次查询", fileUUID, status.Status, interval)
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return stopped()
+		case <-timer.C:
+		}
+		if interval *= 2; interval > MaxUploadPollInterval {
+			interval = MaxUploadPollInterval
+		}
+	}
+}
+
+// EditNote 编辑笔记。笔记不存在时返回包装了ErrNoteNotFound的错误。
+func (c *MowenClient) EditNote(ctx context.Context, req NoteEditRequest) (map[string]interface{}, error) {
+	if req.SchemaVersion == "" {
+		req.SchemaVersion = c.schemaVersion
+	}
+	respBody, err := c.doOperation(ctx, idempotentOperation, NoteEditEndpoint, req)
+	if err != nil {
+		if isNoteNotFoundResponse(err, nil) {
+			return nil, fmt.Errorf("failed to edit note %s: %w (%v)", req.NoteID, ErrNoteNotFound, err)
+		}
 		return nil, fmt.Errorf("failed to edit note: %w", err)
 	}
 
@@ -139,8 +919,8 @@ func (c *MowenClient) EditNote(req NoteEditRequest) (map[string]interface{}, err
 }
 
 // SetNotePrivacy 设置笔记隐私
-func (c *MowenClient) SetNotePrivacy(req NoteSetRequest) (map[string]interface{}, error) {
-	respBody, err := c.makeRequest("POST", NoteSetEndpoint, req)
+func (c *MowenClient) SetNotePrivacy(ctx context.Context, req NoteSetRequest) (map[string]interface{}, error) {
+	respBody, err := c.doOperation(ctx, idempotentOperation, NoteSetEndpoint, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set note privacy: %w", err)
 	}
@@ -153,10 +933,81 @@ func (c *MowenClient) SetNotePrivacy(req NoteSetRequest) (map[string]interface{}
 	return result, nil
 }
 
-// ResetAPIKey 重置API密钥
-func (c *MowenClient) ResetAPIKey() (map[string]interface{}, error) {
+// SetNoteTags 设置笔记标签，tags将完全替换原有标签
+func (c *MowenClient) SetNoteTags(ctx context.Context, noteID string, tags []string) (map[string]interface{}, error) {
+	req := NoteSetRequest{
+		NoteID:  noteID,
+		Section: SectionTags,
+		Settings: &NoteSettings{
+			Tags: tags,
+		},
+	}
+	respBody, err := c.doOperation(ctx, idempotentOperation, NoteSetEndpoint, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set note tags: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return result, nil
+}
+
+// DeleteNote 删除笔记。
+// 笔记不存在时返回包装了ErrNoteNotFound的错误；当墨问API不提供删除接口时返回ErrNotSupported。
+func (c *MowenClient) DeleteNote(ctx context.Context, noteID string) (map[string]interface{}, error) {
+	req := NoteDeleteRequest{NoteID: noteID}
+	respBody, err := c.doOperation(ctx, idempotentOperation, NoteDeleteEndpoint, req)
+	if err != nil {
+		if isNoteMissingStatus(err) {
+			return nil, fmt.Errorf("failed to delete note %s: %w", noteID, ErrNoteNotFound)
+		}
+		if isNotFound(err) {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("failed to delete note: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return result, nil
+}
+
+// SetNotePinned 设置或取消笔记置顶。
+// 当墨问API不提供置顶设置（接口对该设置类别返回400或404）时返回ErrNotSupported。
+func (c *MowenClient) SetNotePinned(ctx context.Context, noteID string, pinned bool) (map[string]interface{}, error) {
+	req := NoteSetRequest{
+		NoteID:  noteID,
+		Section: SectionPinned,
+		Settings: &NoteSettings{
+			Pinned: &pinned,
+		},
+	}
+	respBody, err := c.doOperation(ctx, idempotentOperation, NoteSetEndpoint, req)
+	if err != nil {
+		if isNotFound(err) || isBadRequest(err) {
+			return nil, ErrNotSupported
+		}
+		return nil, fmt.Errorf("failed to set note pinned: %w", err)
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	return result, nil
+}
+
+// ResetAPIKey 重置API密钥，重复执行会使新密钥失效，因此不会自动重试
+func (c *MowenClient) ResetAPIKey(ctx context.Context) (map[string]interface{}, error) {
 	req := KeyResetRequest{}
-	respBody, err := c.makeRequest("POST", KeyResetEndpoint, req)
+	respBody, err := c.doOperation(ctx, nonIdempotentOperation, KeyResetEndpoint, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reset API key: %w", err)
 	}
@@ -169,16 +1020,50 @@ func (c *MowenClient) ResetAPIKey() (map[string]interface{}, error) {
 	return result, nil
 }
 
-// UploadFile 上传文件
-// UploadFile 通过准备接口上传本地文件到墨问
-func (c *MowenClient) UploadFile(filePath string, fileType int, fileName string) (map[string]interface{}, error) {
-	// 第一步：获取上传准备信息
-	prepareReq := map[string]interface{}{
-		"file_type": fileType,
-		"file_name": fileName,
+// resetAPIKeyFields 重置接口响应中新密钥可能使用的字段名
+var resetAPIKeyFields = []string{"api_key", "apiKey", "key"}
+
+// redactResetAPIKeyBody 将重置接口响应（及其data信封）中所有可能的新密钥字段替换为占位符，用于调试日志。
+// 通用的敏感字段名不包含key，以免隐藏上传表单等处无害的key字段，因此单独处理该接口。
+func redactResetAPIKeyBody(body []byte) []byte {
+	var result map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&result); err != nil {
+		return body
+	}
+	for _, fields := range []interface{}{result, result["data"]} {
+		if fields, ok := fields.(map[string]interface{}); ok {
+			for _, name := range resetAPIKeyFields {
+				if _, ok := fields[name]; ok {
+					fields[name] = redactedValue
+				}
+			}
+		}
+	}
+	redacted, err := json.Marshal(result)
+	if err != nil {
+		return body
 	}
+	return redacted
+}
+
+// UploadPlan 准备接口返回后计划执行的存储上传，用于预览上传请求
+type UploadPlan struct {
+	Method      string            // 上传方法：POST（multipart表单）或PUT（预签名地址）
+	URL         string            // 存储上传地址
+	FileField   string            // multipart表单中的文件字段名，PUT上传时为空
+	FormFields  map[string]string // 随文件一起提交的表单字段，非字符串的值已转换为字符串
+	ContentType string            // PUT上传时的Content-Type
+}
 
-	prepareResp, err := c.makeRequest("POST", UploadPrepareEndpoint, prepareReq)
+// uploadFileField multipart上传时文件内容所在的表单字段名
+const uploadFileField = "file"
+
+// PrepareUpload 调用上传准备接口，返回响应中的data
+func (c *MowenClient) PrepareUpload(ctx context.Context, req UploadPrepareRequest) (map[string]interface{}, error) {
+	// 准备请求只申请上传地址，文件内容尚未写入存储，超时后重试是安全的
+	prepareResp, err := c.doOperation(ctx, nonIdempotentOperation.withPolicy(c.prepareTimeout, c.prepareRetries), UploadPrepareEndpoint, req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to prepare upload: %w", err)
 	}
@@ -193,7 +1078,12 @@ func (c *MowenClient) UploadFile(filePath string, fileType int, fileName string)
 	if !ok {
 		return nil, fmt.Errorf("invalid prepare response format")
 	}
+	return data, nil
+}
 
+// planUpload 根据准备接口返回的data确定存储上传方式。
+// 有form_data时以multipart表单POST，否则视为预签名PUT地址。
+func planUpload(data map[string]interface{}, fileName string) (*UploadPlan, error) {
 	uploadURL, ok := data["upload_url"].(string)
 	if !ok {
 		return nil, fmt.Errorf("missing upload_url in prepare response")
@@ -201,7 +1091,97 @@ func (c *MowenClient) UploadFile(filePath string, fileType int, fileName string)
 
 	formData, ok := data["form_data"].(map[string]interface{})
 	if !ok {
-		return nil, fmt.Errorf("missing form_data in prepare response")
+		contentType, _ := data["content_type"].(string)
+		if contentType == "" {
+			contentType = mime.TypeByExtension(filepath.Ext(fileName))
+		}
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		return &UploadPlan{Method: "PUT", URL: uploadURL, ContentType: contentType}, nil
+	}
+
+	plan := &UploadPlan{
+		Method:     "POST",
+		URL:        uploadURL,
+		FileField:  uploadFileField,
+		FormFields: make(map[string]string, len(formData)),
+	}
+	for key, value := range formData {
+		plan.FormFields[key] = formFieldValue(value)
+	}
+	return plan, nil
+}
+
+// formFieldValue 将form_data中的值转换为表单字段值：数字不使用科学计数法，
+// 布尔值为true/false，null为空字符串，对象和数组编码为JSON
+func formFieldValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(data)
+	}
+}
+
+// PreviewUpload 预览本地文件上传：检查文件后调用准备接口，返回准备请求和计划的存储上传，不上传文件内容。
+// 准备接口本身仍会被调用，预览得到的上传地址不会被使用。
+func (c *MowenClient) PreviewUpload(ctx context.Context, filePath string, fileType int, fileName string) (UploadPrepareRequest, *UploadPlan, error) {
+	prepareReq := UploadPrepareRequest{
+		FileType: fileType,
+		FileName: fileName,
+	}
+	if err := validateFileType(fileType); err != nil {
+		return prepareReq, nil, err
+	}
+	if _, err := os.Stat(filePath); err != nil {
+		return prepareReq, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	data, err := c.PrepareUpload(ctx, prepareReq)
+	if err != nil {
+		return prepareReq, nil, err
+	}
+	plan, err := planUpload(data, fileName)
+	if err != nil {
+		return prepareReq, nil, err
+	}
+	return prepareReq, plan, nil
+}
+
+// UploadFile 通过准备接口上传本地文件到墨问。
+// 存储上传失败时，错误中附带脱敏后的准备接口响应，便于排查。
+func (c *MowenClient) UploadFile(ctx context.Context, filePath string, fileType int, fileName string) (map[string]interface{}, error) {
+	if err := validateFileType(fileType); err != nil {
+		return nil, err
+	}
+	if err := c.uploadSlots.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer c.uploadSlots.release()
+
+	// 第一步：获取上传准备信息
+	data, err := c.PrepareUpload(ctx, UploadPrepareRequest{
+		FileType: fileType,
+		FileName: fileName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	plan, err := planUpload(data, fileName)
+	if err != nil {
+		return nil, err
 	}
 
 	// 第二步：上传文件到指定的URL
@@ -211,18 +1191,71 @@ func (c *MowenClient) UploadFile(filePath string, fileType int, fileName string)
 	}
 	defer file.Close()
 
+	// 没有form_data时准备接口返回的是预签名PUT地址，直接上传文件内容
+	var result map[string]interface{}
+	if plan.Method == "PUT" {
+		result, err = c.uploadPresignedPut(ctx, plan, file, data)
+	} else {
+		result, err = c.uploadMultipartForm(ctx, plan, file, fileName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w (prepare response: %s)", err, redactPrepareData(data))
+	}
+	return result, nil
+}
+
+// redactPrepareData 将准备接口的data编码为JSON用于错误信息：
+// policy、signature等敏感字段替换为占位符，upload_url去掉可能含有签名的查询参数
+func redactPrepareData(data map[string]interface{}) string {
+	value, err := redactedPrepareCopy(data)
+	if err != nil {
+		return "(无法编码)"
+	}
+	redacted, err := json.Marshal(value)
+	if err != nil {
+		return "(无法编码)"
+	}
+	return string(redacted)
+}
+
+// redactedPrepareCopy 返回准备接口data脱敏后的副本，不修改原始数据，脱敏规则同redactPrepareData
+func redactedPrepareCopy(data map[string]interface{}) (map[string]interface{}, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return nil, err
+	}
+	redactValue(value)
+	if uploadURL, ok := value["upload_url"].(string); ok {
+		value["upload_url"] = redactURLQuery(uploadURL)
+	}
+	return value, nil
+}
+
+// redactURLQuery 将地址中可能含有签名的查询参数替换为占位符
+func redactURLQuery(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.RawQuery != "" {
+		u.RawQuery = redactedValue
+		return u.String()
+	}
+	return rawURL
+}
+
+// uploadMultipartForm 以multipart表单方式将文件POST到上传地址
+func (c *MowenClient) uploadMultipartForm(ctx context.Context, plan *UploadPlan, file io.Reader, fileName string) (map[string]interface{}, error) {
 	body := &bytes.Buffer{}
 	writer := multipart.NewWriter(body)
 
 	// 添加表单数据字段
-	for key, value := range formData {
-		if strValue, ok := value.(string); ok {
-			_ = writer.WriteField(key, strValue)
-		}
+	for key, value := range plan.FormFields {
+		_ = writer.WriteField(key, value)
 	}
 
 	// 添加文件字段
-	part, err := writer.CreateFormFile("file", fileName)
+	part, err := writer.CreateFormFile(plan.FileField, fileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create form file: %w", err)
 	}
@@ -233,7 +1266,7 @@ func (c *MowenClient) UploadFile(filePath string, fileType int, fileName string)
 	writer.Close()
 
 	// 发送上传请求
-	req, err := http.NewRequest("POST", uploadURL, body)
+	req, err := http.NewRequestWithContext(ctx, plan.Method, plan.URL, body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create upload request: %w", err)
 	}
@@ -246,13 +1279,13 @@ func (c *MowenClient) UploadFile(filePath string, fileType int, fileName string)
 	}
 	defer resp.Body.Close()
 
-	respBody, err := io.ReadAll(resp.Body)
+	respBody, err := readResponseBody(resp)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read upload response body: %w", err)
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("upload request failed with status %d: %s", resp.StatusCode, string(respBody))
+		return nil, fmt.Errorf("upload request failed with status %d: %s", resp.StatusCode, c.maskAPIKey(string(respBody)))
 	}
 
 	var result map[string]interface{}
@@ -262,3 +1295,46 @@ func (c *MowenClient) UploadFile(filePath string, fileType int, fileName string)
 
 	return result, nil
 }
+
+// uploadPresignedPut 将文件原始内容PUT到预签名上传地址。
+// 存储服务通常不返回JSON，此时以准备接口的data作为上传结果。
+func (c *MowenClient) uploadPresignedPut(ctx context.Context, plan *UploadPlan, file io.Reader, prepareData map[string]interface{}) (map[string]interface{}, error) {
+	content, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file content: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, plan.Method, plan.URL, bytes.NewReader(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create upload request: %w", err)
+	}
+	req.Header.Set("Content-Type", plan.ContentType)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send upload request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := readResponseBody(resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload response body: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("upload request failed with status %d: %s", resp.StatusCode, c.maskAPIKey(string(respBody)))
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(respBody, &result); err == nil && result != nil {
+		return result, nil
+	}
+
+	data := make(map[string]interface{}, len(prepareData))
+	for key, value := range prepareData {
+		if key != "upload_url" {
+			data[key] = value
+		}
+	}
+	return map[string]interface{}{"data": data}, nil
+}
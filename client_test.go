@@ -1,10 +1,17 @@
 package main
 
 import (
+	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -72,12 +79,18 @@ func (suite *ClientTestSuite) mockHandler(w http.ResponseWriter, r *http.Request
 		suite.handleMockNoteEdit(w, r)
 	case NoteSetEndpoint:
 		suite.handleMockNoteSet(w, r)
+	case NoteDeleteEndpoint:
+		suite.handleMockNoteDelete(w, r)
 	case KeyResetEndpoint:
 		suite.handleMockKeyReset(w, r)
 	case UploadPrepareEndpoint:
 		suite.handleMockUploadPrepare(w, r)
 	case UploadURLEndpoint:
 		suite.handleMockUploadURL(w, r)
+	case NoteDetailEndpoint:
+		suite.handleMockNoteDetail(w, r)
+	case UploadStatusEndpoint:
+		suite.handleMockUploadStatus(w, r)
 	default:
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "endpoint not found"})
@@ -136,6 +149,23 @@ func (suite *ClientTestSuite) handleMockNoteSet(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleMockNoteDelete 模拟笔记删除响应
+func (suite *ClientTestSuite) handleMockNoteDelete(w http.ResponseWriter, r *http.Request) {
+	var req NoteDeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.NoteID == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"code": 0,
+		"data": map[string]interface{}{
+			"note_id": req.NoteID,
+		},
+		"message": "success",
+	})
+}
+
 // handleMockKeyReset 模拟密钥重置响应
 func (suite *ClientTestSuite) handleMockKeyReset(w http.ResponseWriter, r *http.Request) {
 	if r.Method != "POST" {
@@ -193,6 +223,56 @@ func (suite *ClientTestSuite) handleMockUploadURL(w http.ResponseWriter, r *http
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleMockNoteDetail 模拟笔记详情响应
+func (suite *ClientTestSuite) handleMockNoteDetail(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"code": 0,
+		"data": map[string]interface{}{
+			"noteId": "test-note-id-123",
+			"body": map[string]interface{}{
+				"type": "doc",
+				"content": []interface{}{
+					map[string]interface{}{
+						"type":    "paragraph",
+						"content": []interface{}{map[string]interface{}{"type": "text", "text": "第一段"}},
+					},
+					map[string]interface{}{
+						"type":    "paragraph",
+						"content": []interface{}{map[string]interface{}{"type": "text", "text": "第二段"}},
+					},
+				},
+			},
+			"tags": []string{"测试"},
+		},
+		"message": "success",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleMockUploadStatus 模拟文件处理状态响应，状态由请求中的UUID决定
+func (suite *ClientTestSuite) handleMockUploadStatus(w http.ResponseWriter, r *http.Request) {
+	var req UploadStatusRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	data := map[string]interface{}{"uuid": req.UUID}
+	switch req.UUID {
+	case "file-processing":
+		data["status"] = UploadStatusProcessing
+	case "file-failed":
+		data["status"] = UploadStatusFailed
+		data["message"] = "unsupported codec"
+	default:
+		data["status"] = UploadStatusReady
+	}
+
+	response := map[string]interface{}{
+		"code":    0,
+		"data":    data,
+		"message": "success",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
 // TestNewMowenClient 测试客户端创建
 func (suite *ClientTestSuite) TestNewMowenClient() {
 	// 测试正常创建
@@ -237,7 +317,7 @@ func (suite *ClientTestSuite) TestCreateNote() {
 		},
 	}
 	
-	result, err := suite.client.CreateNote(req)
+	result, err := suite.client.CreateNote(context.Background(), req)
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), result)
 	
@@ -267,7 +347,7 @@ func (suite *ClientTestSuite) TestEditNote() {
 		},
 	}
 	
-	result, err := suite.client.EditNote(req)
+	result, err := suite.client.EditNote(context.Background(), req)
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), result)
 	
@@ -289,7 +369,7 @@ func (suite *ClientTestSuite) TestSetNotePrivacy() {
 		},
 	}
 	
-	result, err := suite.client.SetNotePrivacy(req)
+	result, err := suite.client.SetNotePrivacy(context.Background(), req)
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), result)
 	
@@ -299,9 +379,39 @@ func (suite *ClientTestSuite) TestSetNotePrivacy() {
 	assert.Equal(suite.T(), "test-note-id-123", data["note_id"])
 }
 
+// TestDeleteNote 测试删除笔记
+func (suite *ClientTestSuite) TestDeleteNote() {
+	result, err := suite.client.DeleteNote(context.Background(), "test-note-id-123")
+	require.NoError(suite.T(), err)
+
+	data, ok := result["data"].(map[string]interface{})
+	require.True(suite.T(), ok)
+	assert.Equal(suite.T(), "test-note-id-123", data["note_id"])
+}
+
+// TestDeleteNoteNotFound 测试删除接口以HTTP 404答复笔记不存在时返回ErrNoteNotFound，接口本身不存在时返回ErrNotSupported
+func (suite *ClientTestSuite) TestDeleteNoteNotFound() {
+	body := `{"code":404,"message":"note not found"}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	suite.client.baseURL = server.URL
+
+	_, err := suite.client.DeleteNote(context.Background(), "note-missing")
+	assert.ErrorIs(suite.T(), err, ErrNoteNotFound)
+	assert.NotErrorIs(suite.T(), err, ErrNotSupported)
+
+	for _, body = range []string{"404 page not found", `{"error":"endpoint not found"}`} {
+		_, err = suite.client.DeleteNote(context.Background(), "note-1")
+		assert.ErrorIs(suite.T(), err, ErrNotSupported, body)
+	}
+}
+
 // TestResetAPIKey 测试API密钥重置
 func (suite *ClientTestSuite) TestResetAPIKey() {
-	result, err := suite.client.ResetAPIKey()
+	result, err := suite.client.ResetAPIKey(context.Background())
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), result)
 	
@@ -313,7 +423,7 @@ func (suite *ClientTestSuite) TestResetAPIKey() {
 
 // TestUploadFileViaURL 测试URL文件上传
 func (suite *ClientTestSuite) TestUploadFileViaURL() {
-	result, err := suite.client.UploadFileViaURL("https://example.com/test.jpg", 1, "test.jpg")
+	result, err := suite.client.UploadFileViaURL(context.Background(), "https://example.com/test.jpg", 1, "test.jpg")
 	assert.NoError(suite.T(), err)
 	assert.NotNil(suite.T(), result)
 	
@@ -323,6 +433,704 @@ func (suite *ClientTestSuite) TestUploadFileViaURL() {
 	assert.Equal(suite.T(), "test-url-file-uuid-999", data["uuid"])
 }
 
+// TestUploadInvalidFileType 测试文件类型不是1、2、3时在发送请求前拒绝上传
+func (suite *ClientTestSuite) TestUploadInvalidFileType() {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	suite.client.baseURL = server.URL
+
+	for _, fileType := range []int{0, 4, 5, -1} {
+		_, err := suite.client.UploadFileViaURL(context.Background(), "https://example.com/a.png", fileType, "a.png")
+		require.Error(suite.T(), err)
+		assert.Contains(suite.T(), err.Error(), fmt.Sprintf("invalid file type %d", fileType))
+
+		_, err = suite.client.UploadFile(context.Background(), suite.writeUploadFixture("photo.png"), fileType, "photo.png")
+		require.Error(suite.T(), err)
+		assert.Contains(suite.T(), err.Error(), "must be 1 (image), 2 (audio) or 3 (PDF)")
+
+		_, _, err = suite.client.PreviewUpload(context.Background(), suite.writeUploadFixture("photo.png"), fileType, "photo.png")
+		assert.Error(suite.T(), err)
+	}
+	assert.Zero(suite.T(), requests)
+	assert.NoError(suite.T(), validateFileType(3))
+}
+
+// TestGetNote 测试获取笔记详情
+func (suite *ClientTestSuite) TestGetNote() {
+	result, err := suite.client.GetNote(context.Background(), "test-note-id-123")
+	assert.NoError(suite.T(), err)
+	assert.NotNil(suite.T(), result)
+
+	detail, err := ParseNoteDetail(result)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "test-note-id-123", detail.NoteID)
+	assert.Equal(suite.T(), "doc", detail.Body.Type)
+	assert.Len(suite.T(), detail.Body.Content, 2)
+	assert.Equal(suite.T(), []string{"测试"}, detail.Tags)
+}
+
+// TestRedirectStripsAuthorization 测试跨主机重定向时移除Authorization请求头
+func (suite *ClientTestSuite) TestRedirectStripsAuthorization() {
+	var receivedAuth string
+	otherHost := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedAuth = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+	}))
+	defer otherHost.Close()
+
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(suite.T(), "Bearer test-api-key", r.Header.Get("Authorization"))
+		http.Redirect(w, r, otherHost.URL+"/target", http.StatusTemporaryRedirect)
+	}))
+	defer redirector.Close()
+
+	suite.client.baseURL = redirector.URL
+	_, err := suite.client.makeRequest(context.Background(), "POST", "/redirect", map[string]string{"test": "data"})
+	assert.NoError(suite.T(), err)
+	assert.Empty(suite.T(), receivedAuth)
+}
+
+// TestRequestCanceledByContext 测试context到期时中止进行中的请求
+func (suite *ClientTestSuite) TestRequestCanceledByContext() {
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	suite.client.baseURL = slow.URL
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := suite.client.CreateNote(ctx, NoteCreateRequest{})
+	require.Error(suite.T(), err)
+	assert.True(suite.T(), errors.Is(err, context.DeadlineExceeded), err.Error())
+	assert.Less(suite.T(), time.Since(start), 2*time.Second)
+}
+
+// TestRequestSigning 测试配置签名密钥后按请求体计算HMAC-SHA256签名，默认不签名
+func (suite *ClientTestSuite) TestRequestSigning() {
+	var body, signature, customSignature string
+	signed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		body = string(data)
+		signature = r.Header.Get(DefaultSignatureHeader)
+		customSignature = r.Header.Get("X-Gateway-Signature")
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"noteId": "note-1"}})
+	}))
+	defer signed.Close()
+	suite.client.baseURL = signed.URL
+
+	_, err := suite.client.GetNote(context.Background(), "note-1")
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), signature)
+
+	os.Setenv("MOWEN_SIGNING_SECRET", "test-secret")
+	defer os.Unsetenv("MOWEN_SIGNING_SECRET")
+	client, err := NewMowenClient()
+	require.NoError(suite.T(), err)
+	client.baseURL = signed.URL
+	_, err = client.GetNote(context.Background(), "note-1")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), `{"noteId":"note-1"}`, body)
+	assert.Equal(suite.T(), "8387f45253103faf31ac9c4fa3cd744981543add7e51819d9ea0f912e3f04604", signature)
+
+	// 自定义签名请求头
+	os.Setenv("MOWEN_SIGNATURE_HEADER", "X-Gateway-Signature")
+	defer os.Unsetenv("MOWEN_SIGNATURE_HEADER")
+	client, err = NewMowenClient()
+	require.NoError(suite.T(), err)
+	client.baseURL = signed.URL
+	_, err = client.GetNote(context.Background(), "note-1")
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), signature)
+	assert.Equal(suite.T(), "8387f45253103faf31ac9c4fa3cd744981543add7e51819d9ea0f912e3f04604", customSignature)
+}
+
+// TestRedirectToLoginPage 测试重定向到HTML登录页面时返回认证错误
+func (suite *ClientTestSuite) TestRedirectToLoginPage() {
+	loginPage := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write([]byte("<html><body><form action=\"/login\">请登录</form></body></html>"))
+	}))
+	defer loginPage.Close()
+
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/sso/login" {
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html>请登录</html>"))
+			return
+		}
+		if r.URL.Path == NoteDetailEndpoint {
+			http.Redirect(w, r, "/sso/login", http.StatusFound)
+			return
+		}
+		http.Redirect(w, r, loginPage.URL+"/sso?return=api", http.StatusFound)
+	}))
+	defer gateway.Close()
+
+	suite.client.baseURL = gateway.URL
+	_, err := suite.client.CreateNote(context.Background(), NoteCreateRequest{})
+	require.Error(suite.T(), err)
+	assert.True(suite.T(), errors.Is(err, ErrLoginRedirect), err.Error())
+	assert.Contains(suite.T(), err.Error(), loginPage.URL+"/sso")
+	assert.NotContains(suite.T(), err.Error(), "unmarshal")
+
+	// 同一主机上的登录页面
+	_, err = suite.client.GetNote(context.Background(), "note-1")
+	assert.True(suite.T(), errors.Is(err, ErrLoginRedirect))
+
+	// 关闭检查后按原样解析
+	suite.client.detectLoginRedirect = false
+	_, err = suite.client.CreateNote(context.Background(), NoteCreateRequest{})
+	require.Error(suite.T(), err)
+	assert.False(suite.T(), errors.Is(err, ErrLoginRedirect))
+}
+
+// TestRedirectLimit 测试重定向次数上限
+func (suite *ClientTestSuite) TestRedirectLimit() {
+	os.Setenv("MOWEN_MAX_REDIRECTS", "0")
+	defer os.Unsetenv("MOWEN_MAX_REDIRECTS")
+
+	client, err := NewMowenClient()
+	require.NoError(suite.T(), err)
+
+	redirector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusTemporaryRedirect)
+	}))
+	defer redirector.Close()
+
+	client.baseURL = redirector.URL
+	_, err = client.makeRequest(context.Background(), "POST", "/loop", map[string]string{"test": "data"})
+	// 不跟随重定向时按原样返回3xx响应
+	var statusErr *HTTPStatusError
+	require.ErrorAs(suite.T(), err, &statusErr)
+	assert.Equal(suite.T(), http.StatusTemporaryRedirect, statusErr.StatusCode)
+	assert.NotContains(suite.T(), err.Error(), "stopped after")
+
+	os.Setenv("MOWEN_MAX_REDIRECTS", "2")
+	client, err = NewMowenClient()
+	require.NoError(suite.T(), err)
+	client.baseURL = redirector.URL
+	_, err = client.makeRequest(context.Background(), "POST", "/loop", map[string]string{"test": "data"})
+	assert.ErrorContains(suite.T(), err, "stopped after 2 redirects")
+
+	// 非法配置
+	os.Setenv("MOWEN_MAX_REDIRECTS", "-1")
+	_, err = NewMowenClient()
+	assert.Error(suite.T(), err)
+}
+
+// TestGetNoteChunked 测试分块返回的笔记内容被完整合并
+func (suite *ClientTestSuite) TestGetNoteChunked() {
+	client, err := NewMowenClient()
+	require.NoError(suite.T(), err)
+
+	chunks := map[string]map[string]interface{}{
+		"": {
+			"noteId":      "long-note",
+			"title":       "长笔记",
+			"body":        mustConvert(suite.T(), []Paragraph{{Texts: []TextNode{{Text: "第一段"}}}}),
+			"next_cursor": "chunk-2",
+		},
+		"chunk-2": {
+			"body":      mustConvert(suite.T(), []Paragraph{{Texts: []TextNode{{Text: "第二段"}}}, {Texts: []TextNode{{Text: "第三段"}}}}),
+			"nextToken": "chunk-3",
+		},
+		"chunk-3": {
+			"body": mustConvert(suite.T(), []Paragraph{{Texts: []TextNode{{Text: "第四段"}}}}),
+		},
+	}
+	var cursors []string
+	chunkServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&req))
+		assert.Equal(suite.T(), "long-note", req.NoteID)
+		cursors = append(cursors, req.Cursor)
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": chunks[req.Cursor]})
+	}))
+	defer chunkServer.Close()
+	client.baseURL = chunkServer.URL
+
+	result, err := client.GetNote(context.Background(), "long-note")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"", "chunk-2", "chunk-3"}, cursors)
+
+	detail, err := ParseNoteDetail(result)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "长笔记", detail.Title)
+	require.Len(suite.T(), detail.Body.Content, 4)
+	assert.Equal(suite.T(), "第四段", detail.Body.Content[3].Content[0].Text)
+	assert.NotContains(suite.T(), result["data"], "next_cursor")
+}
+
+// TestGetNoteChunkLoop 测试分块游标重复时停止请求
+func (suite *ClientTestSuite) TestGetNoteChunkLoop() {
+	client, err := NewMowenClient()
+	require.NoError(suite.T(), err)
+
+	requests := 0
+	loopServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"next_cursor": "same"}})
+	}))
+	defer loopServer.Close()
+	client.baseURL = loopServer.URL
+
+	_, err = client.GetNote(context.Background(), "loop-note")
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), `chunk cursor "same" repeated`)
+	assert.Equal(suite.T(), 2, requests)
+}
+
+// TestListNotesCursor 测试读取游标分页字段并在下一次请求中携带游标
+func (suite *ClientTestSuite) TestListNotesCursor() {
+	client, err := NewMowenClient()
+	require.NoError(suite.T(), err)
+
+	var cursors []string
+	listServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req NoteListRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&req))
+		cursors = append(cursors, req.Cursor)
+
+		data := map[string]interface{}{"notes": []map[string]interface{}{{"noteId": "note-" + req.Cursor}}}
+		switch req.Cursor {
+		case "":
+			data["next_cursor"] = "c1"
+		case "c1":
+			data["nextToken"] = "c2"
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": data})
+	}))
+	defer listServer.Close()
+	client.baseURL = listServer.URL
+
+	result, err := client.ListNotes(context.Background(), NoteListRequest{Query: "q"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "c1", result.NextCursor)
+
+	result, err = client.ListNotes(context.Background(), NoteListRequest{Query: "q", Cursor: result.NextCursor})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "c2", result.NextCursor)
+
+	result, err = client.ListNotes(context.Background(), NoteListRequest{Query: "q", Cursor: result.NextCursor})
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), result.NextCursor)
+	assert.Equal(suite.T(), []string{"", "c1", "c2"}, cursors)
+}
+
+// TestGzipResponse 测试自动解压gzip编码的响应
+func (suite *ClientTestSuite) TestGzipResponse() {
+	client, err := NewMowenClient()
+	require.NoError(suite.T(), err)
+
+	gzipServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(suite.T(), "gzip", r.Header.Get("Accept-Encoding"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		json.NewEncoder(gz).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"note_id": "gzip-note-id"},
+		})
+	}))
+	defer gzipServer.Close()
+
+	client.baseURL = gzipServer.URL
+	result, err := client.CreateNote(context.Background(), NoteCreateRequest{})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "gzip-note-id", extractDataString(result, "note_id"))
+
+	// 声明gzip但内容未压缩时返回明确的错误
+	brokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte(`{"code":0}`))
+	}))
+	defer brokenServer.Close()
+
+	client.baseURL = brokenServer.URL
+	_, err = client.CreateNote(context.Background(), NoteCreateRequest{})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "failed to decompress gzip response")
+}
+
+// newUploadServer 创建模拟准备接口和存储服务的测试服务器，prepareData为准备接口返回的data（upload_url会被自动填充）
+func (suite *ClientTestSuite) newUploadServer(prepareData map[string]interface{}, storage http.HandlerFunc) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == UploadPrepareEndpoint {
+			prepareData["upload_url"] = server.URL + "/storage"
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": prepareData})
+			return
+		}
+		storage(w, r)
+	}))
+	return server
+}
+
+// writeUploadFixture 写入用于上传测试的临时文件
+func (suite *ClientTestSuite) writeUploadFixture(name string) string {
+	path := filepath.Join(suite.T().TempDir(), name)
+	require.NoError(suite.T(), os.WriteFile(path, []byte("fake image bytes"), 0o644))
+	return path
+}
+
+// TestUploadFileMultipartForm 测试准备响应包含form_data时使用multipart表单上传
+func (suite *ClientTestSuite) TestUploadFileMultipartForm() {
+	var fields map[string]string
+	var fileContent string
+	server := suite.newUploadServer(map[string]interface{}{
+		"uuid":      "form-file-uuid",
+		"form_data": map[string]interface{}{"key": "test-file-key", "policy": "test-policy"},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(suite.T(), "POST", r.Method)
+		require.NoError(suite.T(), r.ParseMultipartForm(1<<20))
+		fields = map[string]string{"key": r.FormValue("key"), "policy": r.FormValue("policy")}
+		file, _, err := r.FormFile("file")
+		require.NoError(suite.T(), err)
+		content, _ := io.ReadAll(file)
+		fileContent = string(content)
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"uuid": "form-file-uuid"}})
+	})
+	defer server.Close()
+	suite.client.baseURL = server.URL
+
+	result, err := suite.client.UploadFile(context.Background(), suite.writeUploadFixture("photo.png"), 1, "photo.png")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "form-file-uuid", extractDataString(result, "uuid"))
+	assert.Equal(suite.T(), map[string]string{"key": "test-file-key", "policy": "test-policy"}, fields)
+	assert.Equal(suite.T(), "fake image bytes", fileContent)
+}
+
+// TestUploadFileNonStringFormFields 测试form_data中的数字、布尔等非字符串值被转换后提交而不是丢弃
+func (suite *ClientTestSuite) TestUploadFileNonStringFormFields() {
+	var form map[string][]string
+	server := suite.newUploadServer(map[string]interface{}{
+		"form_data": map[string]interface{}{
+			"key":     "test-file-key",
+			"expires": 1735689600,
+			"size":    1.5,
+			"public":  true,
+			"meta":    map[string]interface{}{"a": 1},
+		},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), r.ParseMultipartForm(1<<20))
+		form = r.MultipartForm.Value
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{"uuid": "form-file-uuid"}})
+	})
+	defer server.Close()
+	suite.client.baseURL = server.URL
+
+	_, err := suite.client.UploadFile(context.Background(), suite.writeUploadFixture("photo.png"), 1, "photo.png")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), map[string][]string{
+		"key":     {"test-file-key"},
+		"expires": {"1735689600"},
+		"size":    {"1.5"},
+		"public":  {"true"},
+		"meta":    {`{"a":1}`},
+	}, form)
+}
+
+// TestUploadFileStorageFailureIncludesPrepareResponse 测试存储上传失败时错误中包含脱敏后的准备响应
+func (suite *ClientTestSuite) TestUploadFileStorageFailureIncludesPrepareResponse() {
+	server := suite.newUploadServer(map[string]interface{}{
+		"uuid":      "prepare-uuid-42",
+		"form_data": map[string]interface{}{"key": "test-file-key", "policy": "secret-policy", "signature": "secret-signature"},
+	}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("AccessDenied"))
+	})
+	defer server.Close()
+	suite.client.baseURL = server.URL
+
+	_, err := suite.client.UploadFile(context.Background(), suite.writeUploadFixture("photo.png"), 1, "photo.png")
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "upload request failed with status 403")
+	assert.Contains(suite.T(), err.Error(), "prepare-uuid-42")
+	assert.Contains(suite.T(), err.Error(), "test-file-key")
+	assert.NotContains(suite.T(), err.Error(), "secret-policy")
+	assert.NotContains(suite.T(), err.Error(), "secret-signature")
+
+	// 预签名地址的查询参数中可能带有签名
+	assert.Equal(suite.T(),
+		`{"upload_url":"https://storage.example.com/file?[REDACTED]","uuid":"prepare-uuid-42"}`,
+		redactPrepareData(map[string]interface{}{
+			"uuid":       "prepare-uuid-42",
+			"upload_url": "https://storage.example.com/file?X-Amz-Signature=abc",
+		}))
+}
+
+// TestPreviewUpload 测试上传预览返回准备请求和计划的存储上传，不会请求存储服务
+func (suite *ClientTestSuite) TestPreviewUpload() {
+	var prepareBody UploadPrepareRequest
+	prepareData := map[string]interface{}{
+		"uuid":      "form-file-uuid",
+		"form_data": map[string]interface{}{"key": "test-file-key", "policy": "test-policy", "expires": 3600},
+	}
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(suite.T(), UploadPrepareEndpoint, r.URL.Path, "preview must not upload to storage")
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&prepareBody))
+		prepareData["upload_url"] = server.URL + "/storage"
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": prepareData})
+	}))
+	defer server.Close()
+	suite.client.baseURL = server.URL
+
+	prepareReq, plan, err := suite.client.PreviewUpload(context.Background(), suite.writeUploadFixture("photo.png"), 1, "photo.png")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), UploadPrepareRequest{FileType: 1, FileName: "photo.png"}, prepareReq)
+	assert.Equal(suite.T(), prepareReq, prepareBody)
+	assert.Equal(suite.T(), &UploadPlan{
+		Method:        "POST",
+		URL:           server.URL + "/storage",
+		FileField:     "file",
+		FormFields:    map[string]string{"key": "test-file-key", "policy": "test-policy", "expires": "3600"},
+	}, plan)
+
+	// 没有form_data时计划PUT上传
+	delete(prepareData, "form_data")
+	_, plan, err = suite.client.PreviewUpload(context.Background(), suite.writeUploadFixture("photo.png"), 1, "photo.png")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "PUT", plan.Method)
+	assert.Equal(suite.T(), "image/png", plan.ContentType)
+
+	// 文件不存在时不调用准备接口
+	prepareBody = UploadPrepareRequest{}
+	_, _, err = suite.client.PreviewUpload(context.Background(), filepath.Join(suite.T().TempDir(), "missing.png"), 1, "missing.png")
+	require.Error(suite.T(), err)
+	assert.Empty(suite.T(), prepareBody.FileName)
+}
+
+// TestUploadFilePresignedPut 测试准备响应没有form_data时直接PUT文件内容到预签名地址
+func (suite *ClientTestSuite) TestUploadFilePresignedPut() {
+	cases := []struct {
+		name                string
+		prepareContentType  string
+		expectedContentType string
+	}{
+		{name: "photo.png", expectedContentType: "image/png"},
+		{name: "doc.pdf", prepareContentType: "application/x-custom", expectedContentType: "application/x-custom"},
+		{name: "blob", expectedContentType: "application/octet-stream"},
+	}
+	for _, tc := range cases {
+		var method, contentType, body string
+		prepareData := map[string]interface{}{"uuid": "put-file-uuid"}
+		if tc.prepareContentType != "" {
+			prepareData["content_type"] = tc.prepareContentType
+		}
+		server := suite.newUploadServer(prepareData, func(w http.ResponseWriter, r *http.Request) {
+			method = r.Method
+			contentType = r.Header.Get("Content-Type")
+			content, _ := io.ReadAll(r.Body)
+			body = string(content)
+			// 预签名存储服务通常返回空响应体
+			w.WriteHeader(http.StatusOK)
+		})
+		suite.client.baseURL = server.URL
+
+		result, err := suite.client.UploadFile(context.Background(), suite.writeUploadFixture(tc.name), 1, tc.name)
+		server.Close()
+		require.NoError(suite.T(), err, tc.name)
+		assert.Equal(suite.T(), "PUT", method, tc.name)
+		assert.Equal(suite.T(), tc.expectedContentType, contentType, tc.name)
+		assert.Equal(suite.T(), "fake image bytes", body, tc.name)
+		assert.Equal(suite.T(), "put-file-uuid", extractDataString(result, "uuid"), tc.name)
+		assert.Empty(suite.T(), extractDataString(result, "upload_url"), tc.name)
+	}
+}
+
+// TestUploadFilePresignedPutFailure 测试预签名上传失败时返回状态码
+func (suite *ClientTestSuite) TestUploadFilePresignedPutFailure() {
+	server := suite.newUploadServer(map[string]interface{}{"uuid": "put-file-uuid"}, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte("SignatureDoesNotMatch"))
+	})
+	defer server.Close()
+	suite.client.baseURL = server.URL
+
+	_, err := suite.client.UploadFile(context.Background(), suite.writeUploadFixture("photo.png"), 1, "photo.png")
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "status 403")
+}
+
+// TestGetUploadStatus 测试查询文件处理状态
+func (suite *ClientTestSuite) TestGetUploadStatus() {
+	cases := map[string]string{
+		"file-ready":      UploadStatusReady,
+		"file-processing": UploadStatusProcessing,
+		"file-failed":     UploadStatusFailed,
+	}
+	for uuid, expected := range cases {
+		status, err := suite.client.GetUploadStatus(context.Background(), uuid)
+		assert.NoError(suite.T(), err)
+		assert.Equal(suite.T(), uuid, status.UUID)
+		assert.Equal(suite.T(), expected, status.Status)
+	}
+}
+
+// TestGetUploadStatusNotSupported 测试状态查询接口不存在时返回ErrNotSupported
+func (suite *ClientTestSuite) TestGetUploadStatusNotSupported() {
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+
+	suite.client.baseURL = notFound.URL
+	_, err := suite.client.GetUploadStatus(context.Background(), "file-ready")
+	assert.ErrorIs(suite.T(), err, ErrNotSupported)
+}
+
+// TestWaitUploadReady 测试轮询文件处理状态直到就绪，以及超时和处理失败时的错误
+func (suite *ClientTestSuite) TestWaitUploadReady() {
+	statuses := []string{UploadStatusProcessing, UploadStatusProcessing, UploadStatusReady}
+	var polls int
+	statusServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[len(statuses)-1]
+		if polls < len(statuses) {
+			status = statuses[polls]
+		}
+		polls++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{"status": status, "message": "格式不支持"},
+		})
+	}))
+	defer statusServer.Close()
+	suite.client.baseURL = statusServer.URL
+	suite.client.uploadPollInterval = time.Millisecond
+
+	require.NoError(suite.T(), suite.client.waitUploadReady(context.Background(), "file-1"))
+	assert.Equal(suite.T(), 3, polls)
+
+	// 一直处理中时超时
+	polls = 0
+	statuses = []string{UploadStatusProcessing}
+	suite.client.uploadReadyTimeout = 30 * time.Millisecond
+	err := suite.client.waitUploadReady(context.Background(), "file-1")
+	require.ErrorIs(suite.T(), err, ErrUploadNotReady)
+	assert.Contains(suite.T(), err.Error(), "file-1")
+	assert.Greater(suite.T(), polls, 1)
+
+	polls = 0
+	statuses = []string{UploadStatusProcessing, UploadStatusFailed}
+	err = suite.client.waitUploadReady(context.Background(), "file-1")
+	require.Error(suite.T(), err)
+	assert.NotErrorIs(suite.T(), err, ErrUploadNotReady)
+	assert.Contains(suite.T(), err.Error(), "processing failed: 格式不支持")
+
+	notFound := httptest.NewServer(http.NotFoundHandler())
+	defer notFound.Close()
+	suite.client.baseURL = notFound.URL
+	assert.ErrorIs(suite.T(), suite.client.waitUploadReady(context.Background(), "file-1"), ErrNotSupported)
+}
+
+// TestGetAccountDefaults 测试获取账号默认设置，以及接口不存在与请求失败的区分
+func (suite *ClientTestSuite) TestGetAccountDefaults() {
+	status := http.StatusOK
+	accountServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(suite.T(), AccountSettingsEndpoint, r.URL.Path)
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code": 0,
+			"data": map[string]interface{}{
+				"defaultPrivacy": map[string]interface{}{"type": "private"},
+				"defaultTags":    []string{"收件箱"},
+			},
+		})
+	}))
+	defer accountServer.Close()
+	suite.client.baseURL = accountServer.URL
+
+	defaults, err := suite.client.GetAccountDefaults(context.Background())
+	require.NoError(suite.T(), err)
+	require.NotNil(suite.T(), defaults.Privacy)
+	assert.Equal(suite.T(), "private", defaults.Privacy.Type)
+	assert.Equal(suite.T(), []string{"收件箱"}, defaults.Tags)
+	assert.False(suite.T(), defaults.AutoPublish)
+
+	status = http.StatusNotFound
+	_, err = suite.client.GetAccountDefaults(context.Background())
+	assert.ErrorIs(suite.T(), err, ErrNotSupported)
+
+	status = http.StatusInternalServerError
+	_, err = suite.client.GetAccountDefaults(context.Background())
+	require.Error(suite.T(), err)
+	assert.NotErrorIs(suite.T(), err, ErrNotSupported)
+	assert.Contains(suite.T(), err.Error(), "failed to get account defaults")
+}
+
+// TestSetNotePinned 测试置顶与取消置顶的请求结构，以及不支持置顶时返回ErrNotSupported
+func (suite *ClientTestSuite) TestSetNotePinned() {
+	status := http.StatusOK
+	var bodies []string
+	pinServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(suite.T(), NoteSetEndpoint, r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+	}))
+	defer pinServer.Close()
+	suite.client.baseURL = pinServer.URL
+
+	_, err := suite.client.SetNotePinned(context.Background(), "note-1", true)
+	require.NoError(suite.T(), err)
+	_, err = suite.client.SetNotePinned(context.Background(), "note-1", false)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), bodies, 2)
+	assert.JSONEq(suite.T(), `{"noteId":"note-1","section":3,"settings":{"pinned":true}}`, bodies[0])
+	// 取消置顶必须显式提交false
+	assert.JSONEq(suite.T(), `{"noteId":"note-1","section":3,"settings":{"pinned":false}}`, bodies[1])
+
+	status = http.StatusBadRequest
+	_, err = suite.client.SetNotePinned(context.Background(), "note-1", true)
+	assert.ErrorIs(suite.T(), err, ErrNotSupported)
+
+	status = http.StatusInternalServerError
+	suite.client.maxRetries = 0
+	_, err = suite.client.SetNotePinned(context.Background(), "note-1", true)
+	require.Error(suite.T(), err)
+	assert.NotErrorIs(suite.T(), err, ErrNotSupported)
+}
+
+// TestSchemaVersion 测试配置的结构版本随创建和编辑请求发送，请求中已指定时不覆盖
+func (suite *ClientTestSuite) TestSchemaVersion() {
+	versions := make(map[string]interface{})
+	versionServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&body))
+		versions[r.URL.Path] = body["schemaVersion"]
+		json.NewEncoder(w).Encode(map[string]interface{}{"code": 0})
+	}))
+	defer versionServer.Close()
+	suite.client.baseURL = versionServer.URL
+
+	// 未配置时不发送
+	_, err := suite.client.EditNote(context.Background(), NoteEditRequest{NoteID: "note-1"})
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), versions[NoteEditEndpoint])
+
+	suite.client.schemaVersion = "2"
+	_, err = suite.client.CreateNote(context.Background(), NoteCreateRequest{})
+	require.NoError(suite.T(), err)
+	_, err = suite.client.EditNote(context.Background(), NoteEditRequest{NoteID: "note-1"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "2", versions[NoteCreateEndpoint])
+	assert.Equal(suite.T(), "2", versions[NoteEditEndpoint])
+
+	_, err = suite.client.EditNote(context.Background(), NoteEditRequest{NoteID: "note-1", SchemaVersion: "3"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "3", versions[NoteEditEndpoint])
+}
+
 // TestMakeRequestError 测试请求错误处理
 func (suite *ClientTestSuite) TestMakeRequestError() {
 	// 创建一个会返回错误的客户端
@@ -332,7 +1140,7 @@ func (suite *ClientTestSuite) TestMakeRequestError() {
 		httpClient: &http.Client{Timeout: 1 * time.Second},
 	}
 	
-	_, err := client.makeRequest("POST", "/test", map[string]string{"test": "data"})
+	_, err := client.makeRequest(context.Background(), "POST", "/test", map[string]string{"test": "data"})
 	assert.Error(suite.T(), err)
 }
 
@@ -350,4 +1158,99 @@ func TestConstants(t *testing.T) {
 	assert.Equal(t, "/api/open/api/v1/auth/key/reset", KeyResetEndpoint)
 	assert.Equal(t, "/api/open/api/v1/upload/prepare", UploadPrepareEndpoint)
 	assert.Equal(t, "/api/open/api/v1/upload/url", UploadURLEndpoint)
-}
\ No newline at end of file
+	assert.Equal(t, "/api/open/api/v1/note/detail", NoteDetailEndpoint)
+}
+// TestPrepareUploadOwnTimeout 测试准备请求使用独立的超时和重试次数，且不影响存储上传的超时
+func (suite *ClientTestSuite) TestPrepareUploadOwnTimeout() {
+	var prepareCalls atomic.Int32
+	var prepareDelay atomic.Int64
+	prepareDelay.Store(int64(200 * time.Millisecond))
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == UploadPrepareEndpoint {
+			prepareCalls.Add(1)
+			time.Sleep(time.Duration(prepareDelay.Load()))
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": map[string]interface{}{
+				"upload_url": server.URL + "/storage",
+				"form_data":  map[string]interface{}{"key": "test-file-key"},
+			}})
+			return
+		}
+		// 存储上传比准备请求的超时慢，但仍在客户端默认超时内
+		time.Sleep(200 * time.Millisecond)
+		json.NewEncoder(w).Encode(map[string]interface{}{"file_id": "slow-upload"})
+	}))
+	defer server.Close()
+	suite.client.baseURL = server.URL
+	suite.client.retryBackoff = time.Millisecond
+	suite.client.maxRetries = 0
+	suite.client.prepareTimeout = 50 * time.Millisecond
+	suite.client.prepareRetries = 1
+
+	_, err := suite.client.UploadFile(context.Background(), suite.writeUploadFixture("photo.png"), 1, "photo.png")
+	require.Error(suite.T(), err)
+	assert.ErrorIs(suite.T(), err, context.DeadlineExceeded)
+	assert.Contains(suite.T(), err.Error(), "failed to prepare upload")
+	assert.Equal(suite.T(), int32(2), prepareCalls.Load(), "prepare retries must not follow MOWEN_MAX_RETRIES")
+
+	prepareCalls.Store(0)
+	prepareDelay.Store(0)
+	result, err := suite.client.UploadFile(context.Background(), suite.writeUploadFixture("photo.png"), 1, "photo.png")
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "slow-upload", result["file_id"])
+	assert.Equal(suite.T(), int32(1), prepareCalls.Load())
+}
+
+// TestDecodeResponse 测试只有code不为0的JSON响应视为业务错误
+func TestDecodeResponse(t *testing.T) {
+	assert.NoError(t, decodeResponse([]byte(`{"code":0,"message":"success","data":{}}`)))
+	assert.NoError(t, decodeResponse([]byte(`{"file_id":"no-envelope"}`)))
+	assert.NoError(t, decodeResponse([]byte(`OK`)))
+
+	err := decodeResponse([]byte(`{"code":1001,"message":"tag limit exceeded"}`))
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, &APIError{Code: 1001, Message: "tag limit exceeded"}, apiErr)
+	assert.Equal(t, "API error 1001: tag limit exceeded", err.Error())
+}
+
+// TestClientMethodsReturnAPIError 测试HTTP 200但code不为0时各客户端方法返回业务错误
+func (suite *ClientTestSuite) TestClientMethodsReturnAPIError() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":1001,"message":"tag limit exceeded"}`))
+	}))
+	defer server.Close()
+	suite.client.baseURL = server.URL
+
+	calls := map[string]func() error{
+		"CreateNote": func() error {
+			_, err := suite.client.CreateNote(context.Background(), NoteCreateRequest{})
+			return err
+		},
+		"EditNote": func() error {
+			_, err := suite.client.EditNote(context.Background(), NoteEditRequest{NoteID: "note-1"})
+			return err
+		},
+		"SetNotePrivacy": func() error {
+			_, err := suite.client.SetNotePrivacy(context.Background(), NoteSetRequest{NoteID: "note-1", Section: 1})
+			return err
+		},
+		"ResetAPIKey": func() error {
+			_, err := suite.client.ResetAPIKey(context.Background())
+			return err
+		},
+		"UploadFileViaURL": func() error {
+			_, err := suite.client.UploadFileViaURL(context.Background(), "https://example.com/a.png", 1, "a.png")
+			return err
+		},
+	}
+	for name, call := range calls {
+		err := call()
+		require.Error(suite.T(), err, name)
+		var apiErr *APIError
+		require.True(suite.T(), errors.As(err, &apiErr), name)
+		assert.Equal(suite.T(), 1001, apiErr.Code, name)
+		assert.Contains(suite.T(), err.Error(), "tag limit exceeded", name)
+		assert.False(suite.T(), errors.Is(err, ErrNoteNotFound), name)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envInt 读取非负整数类型的环境变量，未设置时返回默认值
+func envInt(name string, defaultValue int) (int, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s value %q: must be a non-negative integer", name, value)
+	}
+	return n, nil
+}
+
+// envBool 读取布尔类型的环境变量，未设置时返回默认值
+func envBool(name string, defaultValue bool) (bool, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid %s value %q: must be true or false", name, value)
+	}
+	return b, nil
+}
+
+// loadConvertOptions 从环境变量读取段落转换选项
+func loadConvertOptions() (ConvertOptions, error) {
+	opts := DefaultConvertOptions()
+
+	// MOWEN_ID_PATTERN 自定义文件UUID和内链笔记ID的校验格式
+	if pattern := os.Getenv("MOWEN_ID_PATTERN"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return ConvertOptions{}, fmt.Errorf("invalid MOWEN_ID_PATTERN value %q: %w", pattern, err)
+		}
+		opts.IDPattern = re
+	}
+
+	// MOWEN_KEEP_EMPTY_TEXT 是否保留空字符串文本节点
+	keepEmpty, err := envBool("MOWEN_KEEP_EMPTY_TEXT", opts.KeepEmptyText)
+	if err != nil {
+		return ConvertOptions{}, err
+	}
+	opts.KeepEmptyText = keepEmpty
+
+	// MOWEN_AUTO_LINK_CARD 是否把只包含一个URL的段落自动转换为链接卡片
+	autoLinkCard, err := envBool("MOWEN_AUTO_LINK_CARD", opts.AutoLinkCard)
+	if err != nil {
+		return ConvertOptions{}, err
+	}
+	opts.AutoLinkCard = autoLinkCard
+
+	// MOWEN_MAX_PARAGRAPH_LENGTH 超过该字符数的段落会被拆分，0表示不拆分
+	maxParagraphLength, err := envInt("MOWEN_MAX_PARAGRAPH_LENGTH", opts.MaxParagraphLength)
+	if err != nil {
+		return ConvertOptions{}, err
+	}
+	opts.MaxParagraphLength = maxParagraphLength
+
+	// MOWEN_TRIM_TRAILING_EMPTY 是否移除末尾的空段落，默认开启
+	trimTrailingEmpty, err := envBool("MOWEN_TRIM_TRAILING_EMPTY", opts.TrimTrailingEmpty)
+	if err != nil {
+		return ConvertOptions{}, err
+	}
+	opts.TrimTrailingEmpty = trimTrailingEmpty
+
+	// MOWEN_TEXT_TRANSFORMS 逗号分隔的文本转换，按书写顺序应用，默认不启用
+	transforms, err := ParseTextTransforms(os.Getenv("MOWEN_TEXT_TRANSFORMS"))
+	if err != nil {
+		return ConvertOptions{}, fmt.Errorf("invalid MOWEN_TEXT_TRANSFORMS: %w", err)
+	}
+	opts.Transforms = transforms
+
+	return opts, nil
+}
+
+// loadMarkdownOptions 读取Markdown解析选项：MOWEN_MARKDOWN_TABLES为表格的转换方式，code或text，默认code
+func loadMarkdownOptions() (MarkdownOptions, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("MOWEN_MARKDOWN_TABLES")))
+	switch mode {
+	case "":
+		return MarkdownOptions{TableFallback: MarkdownTableCode}, nil
+	case MarkdownTableCode, MarkdownTableText:
+		return MarkdownOptions{TableFallback: mode}, nil
+	}
+	return MarkdownOptions{}, fmt.Errorf("invalid MOWEN_MARKDOWN_TABLES value %q: must be %s or %s", mode, MarkdownTableCode, MarkdownTableText)
+}
+
+// loadTagNormalization 读取normalize_note_tags使用的标签规范化规则：
+// MOWEN_TAG_LOWERCASE（默认true）、MOWEN_TAG_STRIP_PREFIXES（逗号分隔，默认 "#"）和MOWEN_TAG_ALIASES（“别名=标签”）
+func loadTagNormalization() (TagNormalization, error) {
+	lowercase, err := envBool("MOWEN_TAG_LOWERCASE", true)
+	if err != nil {
+		return TagNormalization{}, err
+	}
+
+	prefixes := []string{DefaultTagStripPrefix}
+	if value, ok := os.LookupEnv("MOWEN_TAG_STRIP_PREFIXES"); ok {
+		prefixes = nil
+		for _, prefix := range strings.Split(value, ",") {
+			if prefix = strings.TrimSpace(prefix); prefix != "" {
+				prefixes = append(prefixes, prefix)
+			}
+		}
+	}
+
+	aliases, err := parseTagAliases(os.Getenv("MOWEN_TAG_ALIASES"))
+	if err != nil {
+		return TagNormalization{}, fmt.Errorf("invalid MOWEN_TAG_ALIASES: %w", err)
+	}
+	// 别名按规范化后的标签匹配，键也需要做同样的大小写转换
+	if lowercase {
+		lowered := make(map[string]string, len(aliases))
+		for alias, tag := range aliases {
+			lowered[strings.ToLower(alias)] = tag
+		}
+		aliases = lowered
+	}
+	return TagNormalization{Lowercase: lowercase, StripPrefixes: prefixes, Aliases: aliases}, nil
+}
+
+// loadNoteLimits 读取check_note_limits和create_note使用的笔记限制，0表示不限制
+func loadNoteLimits() (NoteLimits, error) {
+	limits := DefaultNoteLimits()
+	for _, item := range []struct {
+		name  string
+		value *int
+	}{
+		{"MOWEN_MAX_BODY_BYTES", &limits.MaxBodyBytes},
+		{"MOWEN_MAX_PARAGRAPHS", &limits.MaxParagraphs},
+		{"MOWEN_MAX_TAGS", &limits.MaxTags},
+		{"MOWEN_MAX_TAG_LENGTH", &limits.MaxTagLength},
+	} {
+		n, err := envInt(item.name, *item.value)
+		if err != nil {
+			return NoteLimits{}, err
+		}
+		*item.value = n
+	}
+	return limits, nil
+}
+
+// loadExpireAtUnit 读取墨问API期望的公开截止时间单位，默认为秒
+func loadExpireAtUnit() (string, error) {
+	unit := strings.ToLower(strings.TrimSpace(os.Getenv("MOWEN_EXPIRE_AT_UNIT")))
+	switch unit {
+	case "":
+		return ExpireAtUnitSeconds, nil
+	case ExpireAtUnitSeconds, ExpireAtUnitMilliseconds:
+		return unit, nil
+	}
+	return "", fmt.Errorf("invalid MOWEN_EXPIRE_AT_UNIT value %q: must be %s or %s", unit, ExpireAtUnitSeconds, ExpireAtUnitMilliseconds)
+}
+
+// loadDefaultExpireIn 读取MOWEN_DEFAULT_EXPIRE_IN：规则公开未指定截止时间时默认的公开时长，未设置时返回0
+func loadDefaultExpireIn() (time.Duration, error) {
+	value := strings.TrimSpace(os.Getenv("MOWEN_DEFAULT_EXPIRE_IN"))
+	if value == "" {
+		return 0, nil
+	}
+	d, err := ParseExpireIn(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid MOWEN_DEFAULT_EXPIRE_IN: %w", err)
+	}
+	return d, nil
+}
+
+// loadRetryStatuses 读取MOWEN_RETRY_STATUSES中逗号分隔的HTTP状态码，未设置时使用DefaultRetryableStatuses
+func loadRetryStatuses() (map[int]bool, error) {
+	value := strings.TrimSpace(os.Getenv("MOWEN_RETRY_STATUSES"))
+	statuses := make(map[int]bool)
+	if value == "" {
+		for _, code := range DefaultRetryableStatuses {
+			statuses[code] = true
+		}
+		return statuses, nil
+	}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		code, err := strconv.Atoi(entry)
+		if err != nil || (code != http.StatusTooManyRequests && (code < 500 || code > 599)) {
+			return nil, fmt.Errorf("invalid MOWEN_RETRY_STATUSES entry %q: must be 429 or an HTTP status code between 500 and 599", entry)
+		}
+		statuses[code] = true
+	}
+	return statuses, nil
+}
+
+// loadNoteIDPaths 读取笔记ID的提取路径。
+// MOWEN_NOTE_ID_PATHS为逗号分隔的点路径（例如 "data.note.id,result.noteId"），优先于默认路径尝试。
+func loadNoteIDPaths() ([]string, error) {
+	var paths []string
+	for _, path := range strings.Split(os.Getenv("MOWEN_NOTE_ID_PATHS"), ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		for _, key := range strings.Split(path, ".") {
+			if key == "" {
+				return nil, fmt.Errorf("invalid MOWEN_NOTE_ID_PATHS entry %q: empty path segment", path)
+			}
+		}
+		paths = append(paths, path)
+	}
+	return dedupeTags(append(paths, DefaultNoteIDPaths...)), nil
+}
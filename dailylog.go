@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+const (
+	// DefaultDailyLogTag 每日日志笔记默认使用的标签，可通过MOWEN_DAILY_LOG_TAG调整
+	DefaultDailyLogTag = "每日日志"
+	// DailyLogDateFormat 每日日志笔记的标题和日期标签格式
+	DailyLogDateFormat = "2006-01-02"
+	// DailyLogTimeFormat 每条日志记录前的时间格式
+	DailyLogTimeFormat = "15:04"
+)
+
+// dailyLog 每日日志的配置和状态。
+// 追加记录时持有mu，避免并发调用都没有找到当天的笔记而各自创建一篇。
+type dailyLog struct {
+	mu  sync.Mutex
+	tag string
+	now func() time.Time
+	// notes 本次运行已找到或创建的每日笔记ID，按日期索引。刚创建的笔记可能还不会出现在搜索结果中
+	notes map[string]string
+}
+
+// newDailyLog 创建每日日志状态，tag为空时使用DefaultDailyLogTag
+func newDailyLog(tag string) *dailyLog {
+	if tag = strings.TrimSpace(tag); tag == "" {
+		tag = DefaultDailyLogTag
+	}
+	return &dailyLog{tag: tag, now: time.Now, notes: make(map[string]string)}
+}
+
+// dailyLogEntry 生成一条以加粗时间开头的日志段落
+func dailyLogEntry(at time.Time, text string) Paragraph {
+	return Paragraph{Texts: []TextNode{
+		{Text: at.Format(DailyLogTimeFormat), Bold: true},
+		{Text: " " + text},
+	}}
+}
+
+// findDailyNote 查找同时带有日期标签和每日日志标签的笔记，没有时返回空字符串
+func (s *MowenMCPServer) findDailyNote(ctx context.Context, date string) (string, error) {
+	notes, err := s.searchAllNotes(ctx, NoteListRequest{Tag: date})
+	if err != nil {
+		return "", fmt.Errorf("failed to search daily note: %w", err)
+	}
+	for _, note := range notes {
+		if containsTag(note.Tags, s.dailyLog.tag) {
+			return note.NoteID, nil
+		}
+	}
+	return "", nil
+}
+
+// appendDailyEntry 在笔记末尾追加日志段落，笔记不存在时返回包装了ErrNoteNotFound的错误
+func (s *MowenMCPServer) appendDailyEntry(ctx context.Context, noteID string, entry []NoteAtom) error {
+	result, err := s.mowenClient.GetNote(ctx, noteID)
+	if isNoteNotFoundResponse(err, result) {
+		return fmt.Errorf("failed to get daily note %s: %w", noteID, ErrNoteNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get daily note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return err
+	}
+
+	body := detail.Body
+	body.Content = append(append([]NoteAtom{}, body.Content...), entry...)
+	_, err = s.mowenClient.EditNote(ctx, NoteEditRequest{NoteID: noteID, Body: body})
+	return err
+}
+
+// maxDailyNoteCreateAttempts 创建每日笔记时最多尝试的次数，创建请求可能被去重到已删除的笔记
+const maxDailyNoteCreateAttempts = 3
+
+// dailyLogIdempotencyKey 生成创建每日笔记的幂等键。
+// 当天的第一篇笔记使用标签和日期；重新创建时加上被替换的（已删除的）笔记ID，
+// 这样各服务实例同时创建同一篇笔记时使用相同的键，而删除后重新创建的笔记不会被去重到已删除的笔记。
+func dailyLogIdempotencyKey(tag, date, replaces string) string {
+	key := fmt.Sprintf("daily-log:%s:%s", tag, date)
+	if replaces != "" {
+		key += ":after:" + replaces
+	}
+	return key
+}
+
+// createDailyNote 创建以日期为标题的每日笔记，第一条记录直接写入正文；replaces为被删除而需要替换的笔记ID。
+// 创建请求可能被墨问API按幂等键去重，因此创建后会重新读取笔记：
+// 笔记已被删除时换用新的幂等键重新创建；笔记是其他服务实例创建的时，把本条记录追加到该笔记末尾。
+// created表示笔记是否由本次调用创建。
+func (s *MowenMCPServer) createDailyNote(ctx context.Context, date string, entry Paragraph, entryAtoms []NoteAtom, replaces string) (noteID string, created bool, err error) {
+	paragraphs := []Paragraph{
+		{Type: "heading", Level: 1, Texts: []TextNode{{Text: date}}},
+		entry,
+	}
+	noteBody, err := ConvertParagraphsToNoteAtomWithOptions(paragraphs, s.convertOptions)
+	if err != nil {
+		return "", false, fmt.Errorf("invalid paragraphs: %w", err)
+	}
+
+	for attempt := 0; attempt < maxDailyNoteCreateAttempts; attempt++ {
+		createReq := NoteCreateRequest{
+			Body:           noteBody,
+			Settings:       NoteCreateRequestSettings{Tags: dedupeTags([]string{s.dailyLog.tag, date})},
+			IdempotencyKey: dailyLogIdempotencyKey(s.dailyLog.tag, date, replaces),
+		}
+		if err := s.noteQuota.reserve(); err != nil {
+			return "", false, err
+		}
+		result, err := s.mowenClient.CreateNote(ctx, createReq)
+		if err != nil {
+			s.noteQuota.release()
+			return "", false, fmt.Errorf("failed to create daily note: %w", err)
+		}
+		s.tagCache.invalidate()
+		noteID = s.extractNoteID(result)
+		if noteID == "" {
+			return "", false, fmt.Errorf("daily note was created but the response contains no note ID")
+		}
+
+		detailResult, err := s.mowenClient.GetNote(ctx, noteID)
+		if isNoteNotFoundResponse(err, detailResult) {
+			// 请求被去重到一篇已删除的笔记
+			s.noteQuota.release()
+			replaces = noteID
+			continue
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("failed to get daily note: %w", err)
+		}
+		detail, err := ParseNoteDetail(detailResult)
+		if err != nil {
+			return "", false, err
+		}
+		if len(detail.Body.Content) == len(noteBody.Content) && endsWithBlocks(detail.Body.Content, noteBody.Content) {
+			return noteID, true, nil
+		}
+
+		// 请求被去重到其他服务实例创建的笔记，本条记录还没有写入
+		s.noteQuota.release()
+		if err := s.appendDailyEntry(ctx, noteID, entryAtoms); err != nil {
+			return "", false, err
+		}
+		return noteID, false, nil
+	}
+	return "", false, fmt.Errorf("failed to create daily note: creation kept resolving to deleted notes, last %s", replaces)
+}
+
+// handleAppendDailyLog 处理向当天日志笔记追加一条带时间的记录的MCP工具请求。
+// 当天的笔记通过日期标签和每日日志标签查找，不存在时创建；已删除的笔记会被重新创建。
+func (s *MowenMCPServer) handleAppendDailyLog(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args AppendDailyLogArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	text := strings.TrimSpace(args.Text)
+	if text == "" {
+		return nil, fmt.Errorf("text is required")
+	}
+
+	dl := s.dailyLog
+	dl.mu.Lock()
+	defer dl.mu.Unlock()
+
+	now := dl.now()
+	date := now.Format(DailyLogDateFormat)
+	entry := dailyLogEntry(now, text)
+	entryAtom, err := ConvertParagraphsToNoteAtomWithOptions([]Paragraph{entry}, s.convertOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid text: %w", err)
+	}
+	logged := now.Format(DailyLogTimeFormat) + " " + text
+
+	replaces := ""
+	noteID, ok := dl.notes[date]
+	if !ok {
+		if noteID, err = s.findDailyNote(ctx, date); err != nil {
+			return nil, err
+		}
+	}
+	if noteID != "" {
+		err := s.appendDailyEntry(ctx, noteID, entryAtom.Content)
+		if err == nil {
+			dl.notes[date] = noteID
+			return textResult(fmt.Sprintf("已追加到 %s 的日志笔记 %s：%s", date, noteID, logged)), nil
+		}
+		if !errors.Is(err, ErrNoteNotFound) {
+			return nil, err
+		}
+		delete(dl.notes, date)
+		replaces = noteID
+	}
+
+	noteID, created, err := s.createDailyNote(ctx, date, entry, entryAtom.Content, replaces)
+	if err != nil {
+		return nil, err
+	}
+	dl.notes[date] = noteID
+	if !created {
+		return textResult(fmt.Sprintf("已追加到 %s 的日志笔记 %s：%s", date, noteID, logged)), nil
+	}
+	return textResult(fmt.Sprintf("已创建 %s 的日志笔记 %s：%s", date, noteID, logged)), nil
+}
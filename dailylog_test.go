@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixDailyLogTime 固定每日日志使用的当前时间
+func (suite *ServerTestSuite) fixDailyLogTime(at time.Time) {
+	suite.mcpServer.dailyLog.now = func() time.Time { return at }
+}
+
+// TestHandleAppendDailyLogExisting 测试追加到已有的当天日志笔记末尾，只处理同时带有日志标签的笔记
+func (suite *ServerTestSuite) TestHandleAppendDailyLogExisting() {
+	suite.fixDailyLogTime(time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local))
+
+	var listReq NoteListRequest
+	suite.routes[NoteListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&listReq))
+		mockSuccess(NoteListResult{Notes: []NoteSummary{
+			{NoteID: "other-note", Tags: []string{"2024-05-01"}},
+			{NoteID: "daily-note", Tags: []string{"每日日志", "2024-05-01"}},
+		}})(w, r)
+	}
+	suite.routes[NoteDetailEndpoint] = mockSuccess(map[string]interface{}{
+		"noteId": "daily-note",
+		"body": mustConvert(suite.T(), []Paragraph{
+			{Type: "heading", Level: 1, Texts: []TextNode{{Text: "2024-05-01"}}},
+			{Texts: []TextNode{{Text: "08:00", Bold: true}, {Text: " 起床"}}},
+		}),
+	})
+	var editReq NoteEditRequest
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&editReq))
+		mockSuccess(nil)(w, r)
+	}
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("已有当天的日志笔记时不应创建新笔记")
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleAppendDailyLog, AppendDailyLogArgs{Text: " 开会 "})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "已追加到 2024-05-01 的日志笔记 daily-note：09:30 开会", text)
+	assert.Equal(suite.T(), "2024-05-01", listReq.Tag)
+
+	assert.Equal(suite.T(), "daily-note", editReq.NoteID)
+	assert.Equal(suite.T(), []string{"# 2024-05-01", "**08:00** 起床", "**09:30** 开会"}, summarizeBlocks(editReq.Body.Content))
+}
+
+// fakeDailyNotes 模拟按幂等键去重的笔记创建，以及笔记的读取、编辑和删除
+type fakeDailyNotes struct {
+	bodies  map[string]NoteAtom // 未删除的笔记内容，按笔记ID索引
+	keys    map[string]string   // 幂等键对应的笔记ID，笔记删除后仍然保留
+	created []string            // 实际创建的笔记ID
+	reqKeys []string            // 每次创建请求携带的幂等键
+}
+
+// serveDailyNotes 把创建、详情和编辑接口交给fakeDailyNotes处理，搜索结果始终为空（刚创建的笔记还不会出现在搜索结果中）
+func (suite *ServerTestSuite) serveDailyNotes() *fakeDailyNotes {
+	fake := &fakeDailyNotes{bodies: make(map[string]NoteAtom), keys: make(map[string]string)}
+	suite.routes[NoteListEndpoint] = mockSuccess(NoteListResult{})
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var createReq NoteCreateRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&createReq))
+		key := r.Header.Get(IdempotencyKeyHeader)
+		fake.reqKeys = append(fake.reqKeys, key)
+		noteID, ok := fake.keys[key]
+		if !ok {
+			noteID = fmt.Sprintf("daily-%d", len(fake.created)+1)
+			fake.keys[key] = noteID
+			fake.bodies[noteID] = createReq.Body
+			fake.created = append(fake.created, noteID)
+		}
+		mockSuccess(map[string]interface{}{"noteId": noteID})(w, r)
+	}
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var detailReq NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&detailReq))
+		body, ok := fake.bodies[detailReq.NoteID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID, "body": body})(w, r)
+	}
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var editReq NoteEditRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&editReq))
+		fake.bodies[editReq.NoteID] = editReq.Body
+		mockSuccess(nil)(w, r)
+	}
+	return fake
+}
+
+// TestHandleAppendDailyLogCreate 测试当天没有日志笔记时创建，之后的记录追加到刚创建的笔记而不会重复创建，笔记被删除后重新创建
+func (suite *ServerTestSuite) TestHandleAppendDailyLogCreate() {
+	suite.fixDailyLogTime(time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local))
+	fake := suite.serveDailyNotes()
+
+	text, err := suite.callTool(suite.mcpServer.handleAppendDailyLog, AppendDailyLogArgs{Text: "起床"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "已创建 2024-05-01 的日志笔记 daily-1：09:30 起床", text)
+	assert.Equal(suite.T(), []string{"# 2024-05-01", "**09:30** 起床"}, summarizeBlocks(fake.bodies["daily-1"].Content))
+	assert.Equal(suite.T(), []string{"daily-log:每日日志:2024-05-01"}, fake.reqKeys)
+
+	suite.fixDailyLogTime(time.Date(2024, 5, 1, 10, 15, 0, 0, time.Local))
+	text, err = suite.callTool(suite.mcpServer.handleAppendDailyLog, AppendDailyLogArgs{Text: "写周报"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "已追加到 2024-05-01 的日志笔记 daily-1：10:15 写周报", text)
+	assert.Len(suite.T(), fake.reqKeys, 1)
+	assert.Equal(suite.T(), []string{"# 2024-05-01", "**09:30** 起床", "**10:15** 写周报"}, summarizeBlocks(fake.bodies["daily-1"].Content))
+
+	// 笔记被删除后重新创建，幂等键带上被删除的笔记ID，不会被去重到已删除的笔记
+	delete(fake.bodies, "daily-1")
+	text, err = suite.callTool(suite.mcpServer.handleAppendDailyLog, AppendDailyLogArgs{Text: "午饭"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "已创建 2024-05-01 的日志笔记 daily-2：10:15 午饭", text)
+	assert.Equal(suite.T(), []string{"daily-1", "daily-2"}, fake.created)
+	assert.Equal(suite.T(), "daily-log:每日日志:2024-05-01:after:daily-1", fake.reqKeys[1])
+	assert.Equal(suite.T(), []string{"# 2024-05-01", "**10:15** 午饭"}, summarizeBlocks(fake.bodies["daily-2"].Content))
+
+	_, err = suite.callTool(suite.mcpServer.handleAppendDailyLog, AppendDailyLogArgs{Text: "  "})
+	assert.Error(suite.T(), err)
+}
+
+// TestHandleAppendDailyLogDedupedToDeleted 测试创建请求被去重到本服务不知道的已删除笔记时，换用新的幂等键重新创建
+func (suite *ServerTestSuite) TestHandleAppendDailyLogDedupedToDeleted() {
+	suite.fixDailyLogTime(time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local))
+	fake := suite.serveDailyNotes()
+	// 之前的运行创建过当天的笔记，之后被删除
+	fake.keys["daily-log:每日日志:2024-05-01"] = "deleted-note"
+
+	text, err := suite.callTool(suite.mcpServer.handleAppendDailyLog, AppendDailyLogArgs{Text: "起床"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "已创建 2024-05-01 的日志笔记 daily-1：09:30 起床", text)
+	assert.Equal(suite.T(), []string{"daily-log:每日日志:2024-05-01", "daily-log:每日日志:2024-05-01:after:deleted-note"}, fake.reqKeys)
+	assert.Equal(suite.T(), []string{"# 2024-05-01", "**09:30** 起床"}, summarizeBlocks(fake.bodies["daily-1"].Content))
+}
+
+// TestHandleAppendDailyLogCreateRace 测试创建请求被去重到其他服务实例刚创建的笔记时，本条记录追加到该笔记而不会丢失
+func (suite *ServerTestSuite) TestHandleAppendDailyLogCreateRace() {
+	suite.fixDailyLogTime(time.Date(2024, 5, 1, 9, 30, 0, 0, time.Local))
+	fake := suite.serveDailyNotes()
+	fake.keys["daily-log:每日日志:2024-05-01"] = "other-instance-note"
+	fake.bodies["other-instance-note"] = mustConvert(suite.T(), []Paragraph{
+		{Type: "heading", Level: 1, Texts: []TextNode{{Text: "2024-05-01"}}},
+		{Texts: []TextNode{{Text: "09:29", Bold: true}, {Text: " 另一台机器的记录"}}},
+	})
+
+	text, err := suite.callTool(suite.mcpServer.handleAppendDailyLog, AppendDailyLogArgs{Text: "起床"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "已追加到 2024-05-01 的日志笔记 other-instance-note：09:30 起床", text)
+	assert.Empty(suite.T(), fake.created)
+	assert.Equal(suite.T(), []string{"# 2024-05-01", "**09:29** 另一台机器的记录", "**09:30** 起床"}, summarizeBlocks(fake.bodies["other-instance-note"].Content))
+
+	// 之后的记录直接追加到该笔记
+	text, err = suite.callTool(suite.mcpServer.handleAppendDailyLog, AppendDailyLogArgs{Text: "开会"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "已追加到 2024-05-01 的日志笔记 other-instance-note：09:30 开会", text)
+	assert.Len(suite.T(), fake.reqKeys, 1)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// diagnosticItem 诊断信息中的一项配置
+type diagnosticItem struct {
+	Key   string
+	Value string
+}
+
+// redactURL 隐藏URL中的用户名密码，无法解析时原样返回
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return u.Redacted()
+}
+
+// diagnostics 返回生效中的配置。API密钥只报告是否已配置，不包含任何密钥内容。
+func (s *MowenMCPServer) diagnostics() []diagnosticItem {
+	c := s.mowenClient
+	onOff := strconv.FormatBool
+	unlimited := func(n int) string {
+		if n == 0 {
+			return "不限制"
+		}
+		return strconv.Itoa(n)
+	}
+
+	apiKey := "未配置"
+	if c.apiKey != "" {
+		apiKey = "已配置（已隐藏）"
+	}
+	rateLimit := "不限制"
+	if c.throttle != nil {
+		rateLimit = fmt.Sprintf("%d 次/秒", int(time.Second/c.throttle.interval))
+	}
+	concurrency := "不限制"
+	if s.limiter != nil {
+		mode := ConcurrencyModeQueue
+		if s.limiter.reject {
+			mode = ConcurrencyModeReject
+		}
+		concurrency = fmt.Sprintf("%d（%s）", s.limiter.max, mode)
+	}
+	listenAddr := s.listenAddr
+	if s.transportMode == TransportStdio {
+		listenAddr = "不适用（stdio）"
+	}
+	maxConcurrentUploads := "不限制"
+	if c.uploadSlots != nil {
+		maxConcurrentUploads = strconv.Itoa(cap(c.uploadSlots))
+	}
+	noteQuota := "不限制"
+	if s.noteQuota != nil {
+		created, max := s.noteQuota.usage()
+		noteQuota = fmt.Sprintf("%d（已使用 %d）", max, created)
+	}
+	defaultExpireIn := "永不过期"
+	if s.defaultExpireIn > 0 {
+		defaultExpireIn = s.defaultExpireIn.String()
+	}
+	textTransforms := "未启用"
+	if len(s.convertOptions.Transforms) > 0 {
+		textTransforms = strings.Join(s.convertOptions.Transforms, " → ")
+	}
+	resourceThreshold := "未启用"
+	if s.resourceThreshold > 0 {
+		resourceThreshold = fmt.Sprintf("%d 字节", s.resourceThreshold)
+	}
+	idPattern := "不校验"
+	if s.convertOptions.IDPattern != nil {
+		idPattern = s.convertOptions.IDPattern.String()
+	}
+	retryStatuses := make([]string, 0, len(c.retryStatuses))
+	for _, code := range sortedStatuses(c.retryStatuses) {
+		retryStatuses = append(retryStatuses, strconv.Itoa(code))
+	}
+	maxIdleConns := "默认"
+	if transport, ok := c.httpClient.Transport.(*http.Transport); ok {
+		maxIdleConns = strconv.Itoa(transport.MaxIdleConnsPerHost)
+		if transport.DisableKeepAlives {
+			maxIdleConns = "不复用连接"
+		}
+	}
+	// 只显示是否启用和使用的请求头，不包含签名密钥
+	requestSigning := "未启用"
+	if len(c.signingSecret) > 0 {
+		requestSigning = "已启用（" + c.signatureHeader + "）"
+	}
+	clientLogLevel := "未启用"
+	if c.logger != nil {
+		clientLogLevel = c.logger.level.String()
+	}
+
+	return []diagnosticItem{
+		{"api_key", apiKey},
+		{"base_url", redactURL(c.baseURL)},
+		{"http_timeout", c.httpClient.Timeout.String()},
+		{"max_idle_conns", maxIdleConns},
+		{"max_redirects", strconv.Itoa(c.maxRedirects)},
+		{"detect_login_redirect", strconv.FormatBool(c.detectLoginRedirect)},
+		{"request_signing", requestSigning},
+		{"max_retries", strconv.Itoa(c.maxRetries)},
+		{"retry_backoff", c.retryBackoff.String()},
+		{"retry_statuses", strings.Join(retryStatuses, ", ")},
+		{"prepare_timeout", c.prepareTimeout.String()},
+		{"prepare_retries", strconv.Itoa(c.prepareRetries)},
+		{"upload_ready_timeout", c.uploadReadyTimeout.String()},
+		{"max_concurrent_uploads", maxConcurrentUploads},
+		{"rate_limit", rateLimit},
+		{"transport", transportName(s.transportMode)},
+		{"listen_addr", listenAddr},
+		{"max_concurrency", concurrency},
+		{"max_notes_per_session", noteQuota},
+		{"log_level_server", s.logger.level.String()},
+		{"log_level_client", clientLogLevel},
+		{"debug_bodies", onOff(c.debugBodies)},
+		{"show_warnings", onOff(s.showWarnings)},
+		{"expire_at_unit", s.expireAtUnit},
+		{"default_expire_in", defaultExpireIn},
+		{"id_pattern", idPattern},
+		{"keep_empty_text", onOff(s.convertOptions.KeepEmptyText)},
+		{"auto_link_card", onOff(s.convertOptions.AutoLinkCard)},
+		{"trim_trailing_empty", onOff(s.convertOptions.TrimTrailingEmpty)},
+		{"max_paragraph_length", unlimited(s.convertOptions.MaxParagraphLength)},
+		{"text_transforms", textTransforms},
+		{"markdown_tables", s.markdownOptions.TableFallback},
+		{"note_id_paths", strings.Join(s.noteIDPaths, ", ")},
+		{"resource_threshold", resourceThreshold},
+		{"tag_cache_ttl", s.tagCache.ttl.String()},
+		{"auto_tag_rules", strconv.Itoa(len(s.autoTagRules))},
+		{"registered_tools", strconv.Itoa(len(s.registeredTools))},
+		{"templates", strconv.Itoa(len(s.templates))},
+		{"scheduled_publishes", strconv.Itoa(s.scheduler.pending())},
+	}
+}
+
+// handleDiagnostics 处理获取服务配置诊断信息的MCP工具请求，不会调用墨问API
+func (s *MowenMCPServer) handleDiagnostics(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args DiagnosticsArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("服务配置诊断信息（密钥已隐藏）：")
+	for _, item := range s.diagnostics() {
+		fmt.Fprintf(&sb, "\n- %s: %s", item.Key, item.Value)
+	}
+	return textResult(sb.String()), nil
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// 段落差异类型
+const (
+	ParagraphAdded   = "added"
+	ParagraphRemoved = "removed"
+	ParagraphChanged = "changed"
+)
+
+// ParagraphChange 单个段落的差异
+type ParagraphChange struct {
+	Kind     string // 差异类型：added、removed、changed
+	OldIndex int    // 原内容中的段落序号（从1开始，新增时为0）
+	NewIndex int    // 新内容中的段落序号（从1开始，删除时为0）
+	Old      string // 原段落的文本表示
+	New      string // 新段落的文本表示
+}
+
+// DiffNoteBodies 对比两个笔记内容的顶层段落，返回新增、删除和修改的段落。
+// 段落先转换为文本表示，再按最长公共子序列对齐；相邻的删除和新增会合并为修改。
+func DiffNoteBodies(oldBody, newBody NoteAtom) []ParagraphChange {
+	oldLines := summarizeBlocks(oldBody.Content)
+	newLines := summarizeBlocks(newBody.Content)
+
+	// 计算最长公共子序列长度表
+	m, n := len(oldLines), len(newLines)
+	lcs := make([][]int, m+1)
+	for i := range lcs {
+		lcs[i] = make([]int, n+1)
+	}
+	for i := m - 1; i >= 0; i-- {
+		for j := n - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var changes []ParagraphChange
+	var removed, added []ParagraphChange
+
+	// flush 将一段连续的删除和新增合并输出，位置对应的部分视为修改
+	flush := func() {
+		paired := len(removed)
+		if len(added) < paired {
+			paired = len(added)
+		}
+		for k := 0; k < paired; k++ {
+			changes = append(changes, ParagraphChange{
+				Kind:     ParagraphChanged,
+				OldIndex: removed[k].OldIndex,
+				NewIndex: added[k].NewIndex,
+				Old:      removed[k].Old,
+				New:      added[k].New,
+			})
+		}
+		changes = append(changes, removed[paired:]...)
+		changes = append(changes, added[paired:]...)
+		removed, added = nil, nil
+	}
+
+	i, j := 0, 0
+	for i < m || j < n {
+		switch {
+		case i < m && j < n && oldLines[i] == newLines[j]:
+			flush()
+			i++
+			j++
+		case j < n && (i == m || lcs[i][j+1] >= lcs[i+1][j]):
+			added = append(added, ParagraphChange{Kind: ParagraphAdded, NewIndex: j + 1, New: newLines[j]})
+			j++
+		default:
+			removed = append(removed, ParagraphChange{Kind: ParagraphRemoved, OldIndex: i + 1, Old: oldLines[i]})
+			i++
+		}
+	}
+	flush()
+
+	return changes
+}
+
+// RenderParagraphDiff 将段落差异渲染为可读的文本
+func RenderParagraphDiff(changes []ParagraphChange) string {
+	if len(changes) == 0 {
+		return "内容无变化"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "共 %d 处差异：\n", len(changes))
+	for _, change := range changes {
+		switch change.Kind {
+		case ParagraphAdded:
+			fmt.Fprintf(&sb, "\n+ 第%d段（新增）\n  + %s\n", change.NewIndex, change.New)
+		case ParagraphRemoved:
+			fmt.Fprintf(&sb, "\n- 第%d段（删除）\n  - %s\n", change.OldIndex, change.Old)
+		case ParagraphChanged:
+			fmt.Fprintf(&sb, "\n~ 第%d段（修改）\n  - %s\n  + %s\n", change.OldIndex, change.Old, change.New)
+		}
+	}
+
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// summarizeBlocks 将顶层段落节点列表转换为文本表示
+func summarizeBlocks(blocks []NoteAtom) []string {
+	lines := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		lines = append(lines, summarizeBlock(block))
+	}
+	return lines
+}
+
+// summarizeBlock 生成单个顶层段落节点的文本表示，用于差异对比和展示
+func summarizeBlock(block NoteAtom) string {
+	switch block.Type {
+	case "paragraph":
+		text := summarizeInline(block.Content)
+		if block.Attrs["blockquote"] == "true" {
+			return "> " + text
+		}
+		return text
+	case "heading":
+		return strings.Repeat("#", atomHeadingLevel(block)) + " " + summarizeInline(block.Content)
+	case "bulletList", "orderedList":
+		// 每个列表项一行，有序列表从1开始编号
+		lines := make([]string, 0, len(block.Content))
+		for i, item := range block.Content {
+			marker := "-"
+			if block.Type == "orderedList" {
+				marker = strconv.Itoa(i+1) + "."
+			}
+			texts := make([]string, 0, len(item.Content))
+			for _, child := range item.Content {
+				texts = append(texts, summarizeInline(child.Content))
+			}
+			lines = append(lines, marker+" "+strings.Join(texts, " "))
+		}
+		return strings.Join(lines, "\n")
+	case "note":
+		return fmt.Sprintf("[内链笔记 %s]", block.Attrs["uuid"])
+	case "link_card":
+		return fmt.Sprintf("[链接卡片 %s]", block.Attrs["url"])
+	default:
+		if isFileAtomType(block.Type) {
+			return fmt.Sprintf("[%s %s]", block.Type, block.Attrs["uuid"])
+		}
+		if len(block.Content) > 0 {
+			return fmt.Sprintf("[%s] %s", block.Type, summarizeInline(block.Content))
+		}
+		return fmt.Sprintf("[%s]", block.Type)
+	}
+}
+
+// summarizeInline 将文本节点渲染为带标记提示的纯文本，例如 **加粗**、*斜体*、~~删除线~~、`代码`、==高亮==、[链接](地址)。
+// 行内代码总在最内层，避免其他标记符号被包进代码中。
+func summarizeInline(content []NoteAtom) string {
+	var sb strings.Builder
+	for _, atom := range content {
+		text := atom.Text
+		for _, mark := range atom.Marks {
+			if mark.Type == "code" {
+				text = "`" + text + "`"
+				break
+			}
+		}
+		for _, mark := range atom.Marks {
+			switch mark.Type {
+			case "bold":
+				text = "**" + text + "**"
+			case "italic":
+				text = "*" + text + "*"
+			case "strikethrough":
+				text = "~~" + text + "~~"
+			case "highlight":
+				text = "==" + text + "=="
+			case "link":
+				text = "[" + text + "](" + mark.Attrs["href"] + ")"
+			}
+		}
+		sb.WriteString(text)
+	}
+	return sb.String()
+}
+
+// RenderMarksPreview 列出每个文本片段转换后的标记，并附上带标记提示的纯文本预览
+func RenderMarksPreview(content []NoteAtom) string {
+	if len(content) == 0 {
+		return "没有可预览的文本"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "共 %d 个文本片段：\n", len(content))
+	for i, atom := range content {
+		marks := make([]string, 0, len(atom.Marks))
+		for _, mark := range atom.Marks {
+			if mark.Type == "link" {
+				marks = append(marks, fmt.Sprintf("link(%s)", mark.Attrs["href"]))
+				continue
+			}
+			if mark.Type == "textColor" {
+				marks = append(marks, fmt.Sprintf("textColor(%s)", mark.Attrs["color"]))
+				continue
+			}
+			marks = append(marks, mark.Type)
+		}
+		if len(marks) == 0 {
+			marks = append(marks, "无")
+		}
+		fmt.Fprintf(&sb, "%d. %q 标记：%s\n", i+1, atom.Text, strings.Join(marks, ", "))
+	}
+	fmt.Fprintf(&sb, "\n预览：%s", summarizeInline(content))
+
+	return sb.String()
+}
+
+// RenderDryRun 渲染试运行结果：转换后的段落预览和转换提示
+func RenderDryRun(doc NoteAtom, warnings []ConversionWarning) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "试运行：未调用墨问API，转换后共 %d 个段落：", len(doc.Content))
+	for i, line := range summarizeBlocks(doc.Content) {
+		fmt.Fprintf(&sb, "\n%d. %s", i+1, line)
+	}
+	if rendered := RenderConversionWarnings(warnings); rendered != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(rendered)
+	}
+	return sb.String()
+}
+
+// RenderUploadPlan 渲染本地文件上传预览：准备请求体和计划的存储上传请求。
+// 上传地址的查询参数和policy、signature等表单字段是存储服务的凭证，按redactPrepareData的规则脱敏后输出。
+func RenderUploadPlan(prepareReq UploadPrepareRequest, plan *UploadPlan) string {
+	var sb strings.Builder
+	sb.WriteString("上传预览：已调用准备接口，未上传文件内容\n\n准备请求：\n")
+	fmt.Fprintf(&sb, "POST %s\n", UploadPrepareEndpoint)
+	if body, err := json.MarshalIndent(prepareReq, "", "  "); err == nil {
+		sb.Write(body)
+	}
+
+	sb.WriteString("\n\n存储上传：\n")
+	fmt.Fprintf(&sb, "%s %s\n", plan.Method, redactURLQuery(plan.URL))
+	if plan.Method == "PUT" {
+		fmt.Fprintf(&sb, "Content-Type: %s\n请求体为文件原始内容", plan.ContentType)
+		return sb.String()
+	}
+
+	fmt.Fprintf(&sb, "multipart表单，文件字段名: %s", plan.FileField)
+	if len(plan.FormFields) > 0 {
+		fields := make(map[string]interface{}, len(plan.FormFields))
+		for key, value := range plan.FormFields {
+			fields[key] = value
+		}
+		redacted, err := redactedPrepareCopy(fields)
+		if err != nil {
+			return sb.String()
+		}
+		sb.WriteString("\n表单字段：")
+		for _, key := range sortedKeys(plan.FormFields) {
+			fmt.Fprintf(&sb, "\n- %s: %v", key, redacted[key])
+		}
+	}
+	return sb.String()
+}
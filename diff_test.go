@@ -0,0 +1,93 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDiffNoteBodies 测试已知旧内容与拟修改内容的段落差异
+func TestDiffNoteBodies(t *testing.T) {
+	oldBody := mustConvert(t, []Paragraph{
+		{Texts: []TextNode{{Text: "标题段落"}}},
+		{Texts: []TextNode{{Text: "将被修改的段落"}}},
+		{Texts: []TextNode{{Text: "将被删除的段落"}}},
+		{Type: "quote", Texts: []TextNode{{Text: "保留的引用"}}},
+	})
+	newBody := mustConvert(t, []Paragraph{
+		{Texts: []TextNode{{Text: "标题段落"}}},
+		{Texts: []TextNode{{Text: "已修改的段落", Bold: true}}},
+		{Type: "quote", Texts: []TextNode{{Text: "保留的引用"}}},
+		{Type: "note", NoteID: "linked-note-id"},
+	})
+
+	changes := DiffNoteBodies(oldBody, newBody)
+	require.Len(t, changes, 3)
+
+	assert.Equal(t, ParagraphChanged, changes[0].Kind)
+	assert.Equal(t, 2, changes[0].OldIndex)
+	assert.Equal(t, 2, changes[0].NewIndex)
+	assert.Equal(t, "将被修改的段落", changes[0].Old)
+	assert.Equal(t, "**已修改的段落**", changes[0].New)
+
+	assert.Equal(t, ParagraphRemoved, changes[1].Kind)
+	assert.Equal(t, 3, changes[1].OldIndex)
+	assert.Equal(t, "将被删除的段落", changes[1].Old)
+
+	assert.Equal(t, ParagraphAdded, changes[2].Kind)
+	assert.Equal(t, 4, changes[2].NewIndex)
+	assert.Equal(t, "[内链笔记 linked-note-id]", changes[2].New)
+}
+
+// TestDiffNoteBodiesNoChange 测试内容相同时无差异
+func TestDiffNoteBodiesNoChange(t *testing.T) {
+	body := mustConvert(t, []Paragraph{
+		{Texts: []TextNode{{Text: "相同内容", Highlight: true}}},
+	})
+
+	changes := DiffNoteBodies(body, body)
+	assert.Empty(t, changes)
+	assert.Equal(t, "内容无变化", RenderParagraphDiff(changes))
+}
+
+// TestRenderParagraphDiff 测试差异文本渲染
+func TestRenderParagraphDiff(t *testing.T) {
+	text := RenderParagraphDiff([]ParagraphChange{
+		{Kind: ParagraphAdded, NewIndex: 1, New: "新段落"},
+		{Kind: ParagraphRemoved, OldIndex: 2, Old: "旧段落"},
+	})
+
+	assert.Contains(t, text, "共 2 处差异")
+	assert.Contains(t, text, "+ 第1段（新增）\n  + 新段落")
+	assert.Contains(t, text, "- 第2段（删除）\n  - 旧段落")
+}
+
+// TestRenderMarksPreview 测试文本标记预览
+func TestRenderMarksPreview(t *testing.T) {
+	content := convertTextsToContent([]TextNode{
+		{Text: "普通"},
+		{Text: "加粗", Bold: true},
+		{Text: "加粗高亮", Bold: true, Highlight: true},
+		{Text: "全部", Bold: true, Highlight: true, Link: "https://example.com"},
+	}, false)
+
+	preview := RenderMarksPreview(content)
+	assert.Contains(t, preview, "共 4 个文本片段")
+	assert.Contains(t, preview, `1. "普通" 标记：无`)
+	assert.Contains(t, preview, `2. "加粗" 标记：bold`)
+	assert.Contains(t, preview, `3. "加粗高亮" 标记：bold, highlight`)
+	assert.Contains(t, preview, `4. "全部" 标记：bold, highlight, link(https://example.com)`)
+	assert.Contains(t, preview, "预览：普通**加粗**==**加粗高亮**==[==**全部**==](https://example.com)")
+
+	assert.Equal(t, "没有可预览的文本", RenderMarksPreview(nil))
+
+	// 行内代码总在最内层
+	preview = RenderMarksPreview(convertTextsToContent([]TextNode{
+		{Text: "斜体", Italic: true},
+		{Text: "删除", Strikethrough: true},
+		{Text: "代码", Bold: true, InlineCode: true},
+	}, false))
+	assert.Contains(t, preview, `3. "代码" 标记：bold, code`)
+	assert.Contains(t, preview, "预览：*斜体*~~删除~~**`代码`**")
+}
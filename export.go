@@ -0,0 +1,534 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// 笔记导出包格式标识与版本
+const (
+	NoteBundleFormat  = "mowen-note-bundle"
+	NoteBundleVersion = 1
+
+	// MowenNoteURLFormat 墨问笔记详情页地址格式
+	MowenNoteURLFormat = "https://note.mowen.cn/detail/%s"
+
+	// DefaultResourceThreshold 导出内容超过该字节数时默认作为嵌入资源返回
+	DefaultResourceThreshold = 64 << 10
+)
+
+// NoteBundle 笔记导出包，包含笔记内容、元数据和引用的文件，可用于备份和迁移
+type NoteBundle struct {
+	Format     string          `json:"format"`          // 格式标识，固定为mowen-note-bundle
+	Version    int             `json:"version"`         // 格式版本
+	ExportedAt string          `json:"exported_at"`     // 导出时间（RFC3339）
+	Note       NoteBundleNote  `json:"note"`            // 笔记内容与元数据
+	Files      []FileReference `json:"files"`           // 笔记中引用的文件
+	Links      []NoteLink      `json:"links,omitempty"` // 内链笔记的标题和地址，仅在导出时要求解析内链时提供
+}
+
+// NoteBundleNote 导出包中的笔记部分
+type NoteBundleNote struct {
+	NoteID    string          `json:"note_id"`              // 原笔记ID
+	Title     string          `json:"title,omitempty"`      // 笔记标题
+	Body      NoteAtom        `json:"body"`                 // 笔记内容
+	Tags      []string        `json:"tags"`                 // 标签列表
+	Privacy   *NotePrivacySet `json:"privacy,omitempty"`    // 隐私设置
+	CreatedAt int64           `json:"created_at,omitempty"` // 原笔记创建时间（Unix秒级时间戳）
+	UpdatedAt int64           `json:"updated_at,omitempty"` // 原笔记更新时间（Unix秒级时间戳）
+}
+
+// FileReference 笔记中引用的文件。
+// 导入时如果提供了Path或URL，会重新上传文件并替换UUID；否则沿用原UUID。
+type FileReference struct {
+	UUID      string            `json:"uuid"`            // 文件UUID
+	FileType  string            `json:"file_type"`       // 文件类型：image、audio、pdf
+	Paragraph int               `json:"paragraph"`       // 所在段落序号（从1开始）
+	Attrs     map[string]string `json:"attrs,omitempty"` // 文件节点的其他属性
+	Path      string            `json:"path,omitempty"`  // 本地文件路径（导入时重新上传）
+	URL       string            `json:"url,omitempty"`   // 文件URL（导入时通过URL重新上传）
+}
+
+// NoteLink 笔记中的内链笔记及其解析结果
+type NoteLink struct {
+	NoteID    string `json:"note_id"`         // 被引用的笔记ID
+	Paragraph int    `json:"paragraph"`       // 所在段落序号（从1开始）
+	Title     string `json:"title,omitempty"` // 被引用笔记的标题
+	URL       string `json:"url,omitempty"`   // 被引用笔记的地址
+	Error     string `json:"error,omitempty"` // 无法解析时的原因，例如笔记已删除
+}
+
+// fileTypeCodes 文件节点类型到上传接口文件类型编号的映射
+var fileTypeCodes = map[string]int{
+	"image": 1,
+	"audio": 2,
+	"pdf":   3,
+}
+
+// BuildNoteBundle 根据笔记详情构建导出包
+func BuildNoteBundle(detail *NoteDetail, exportedAt time.Time) *NoteBundle {
+	tags := detail.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+
+	return &NoteBundle{
+		Format:     NoteBundleFormat,
+		Version:    NoteBundleVersion,
+		ExportedAt: exportedAt.UTC().Format(time.RFC3339),
+		Note: NoteBundleNote{
+			NoteID:    detail.NoteID,
+			Title:     detail.Title,
+			Body:      detail.Body,
+			Tags:      tags,
+			Privacy:   detail.Privacy,
+			CreatedAt: detail.CreatedAt,
+			UpdatedAt: detail.UpdatedAt,
+		},
+		Files: CollectFileReferences(detail.Body),
+	}
+}
+
+// CollectFileReferences 收集笔记内容中所有文件节点的引用
+func CollectFileReferences(body NoteAtom) []FileReference {
+	refs := []FileReference{}
+	for i, block := range body.Content {
+		collectFileReferences(block, i+1, &refs)
+	}
+	return refs
+}
+
+// collectFileReferences 递归收集节点及其子节点中的文件引用
+func collectFileReferences(atom NoteAtom, paragraph int, refs *[]FileReference) {
+	if isFileAtomType(atom.Type) {
+		ref := FileReference{
+			UUID:      atom.Attrs["uuid"],
+			FileType:  atom.Type,
+			Paragraph: paragraph,
+		}
+		for k, v := range atom.Attrs {
+			if k == "uuid" {
+				continue
+			}
+			if ref.Attrs == nil {
+				ref.Attrs = make(map[string]string)
+			}
+			ref.Attrs[k] = v
+		}
+		*refs = append(*refs, ref)
+	}
+	for _, child := range atom.Content {
+		collectFileReferences(child, paragraph, refs)
+	}
+}
+
+// CollectNoteLinks 收集笔记内容中所有内链笔记
+func CollectNoteLinks(body NoteAtom) []NoteLink {
+	links := []NoteLink{}
+	for i, block := range body.Content {
+		if block.Type == "note" {
+			links = append(links, NoteLink{NoteID: block.Attrs["uuid"], Paragraph: i + 1})
+		}
+	}
+	return links
+}
+
+// resolveNoteLinks 逐个获取内链笔记的标题并生成地址，
+// 无法获取的引用记录原因后继续处理其余引用。
+func (s *MowenMCPServer) resolveNoteLinks(ctx context.Context, links []NoteLink) []NoteLink {
+	resolved := make([]NoteLink, 0, len(links))
+	for _, link := range links {
+		result, err := s.mowenClient.GetNote(ctx, link.NoteID)
+		if err == nil {
+			var detail *NoteDetail
+			if detail, err = ParseNoteDetail(result); err == nil {
+				link.Title = detail.Title
+				link.URL = fmt.Sprintf(MowenNoteURLFormat, link.NoteID)
+			}
+		}
+		switch {
+		case isNoteNotFoundResponse(err, nil):
+			link.Error = "引用的笔记不存在或已删除"
+		case err != nil:
+			link.Error = err.Error()
+		}
+		resolved = append(resolved, link)
+	}
+	return resolved
+}
+
+// handleExportNoteBundle 处理导出笔记的MCP工具请求。
+// 它获取笔记详情，并输出包含内容、标签、隐私设置和文件引用的JSON导出包；
+// 要求解析内链时还会附上每个内链笔记的标题和地址。
+func (s *MowenMCPServer) handleExportNoteBundle(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args ExportNoteBundleArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse note: %w", err)
+	}
+
+	bundle := BuildNoteBundle(detail, time.Now())
+	if args.ResolveLinks {
+		bundle.Links = s.resolveNoteLinks(ctx, CollectNoteLinks(detail.Body))
+	}
+	bundleJSON, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal note bundle: %w", err)
+	}
+
+	return s.exportResult(string(bundleJSON), fmt.Sprintf("mowen://notes/%s/bundle.json", args.NoteID), "application/json"), nil
+}
+
+// UnrestoredReference 导入时未能恢复的文件引用
+type UnrestoredReference struct {
+	Reference FileReference
+	Reason    string
+}
+
+// ParseNoteBundle 解析并校验笔记导出包
+func ParseNoteBundle(data string) (*NoteBundle, error) {
+	var bundle NoteBundle
+	if err := json.Unmarshal([]byte(data), &bundle); err != nil {
+		return nil, fmt.Errorf("invalid note bundle: %w", err)
+	}
+	if bundle.Format != NoteBundleFormat {
+		return nil, fmt.Errorf("unsupported note bundle format %q", bundle.Format)
+	}
+	if bundle.Version > NoteBundleVersion {
+		return nil, fmt.Errorf("unsupported note bundle version %d (max %d)", bundle.Version, NoteBundleVersion)
+	}
+	return &bundle, nil
+}
+
+// restoreFileReferences 恢复导出包中的文件引用。
+// 提供了Path或URL的文件会重新上传，其余沿用原UUID；返回旧UUID到新UUID的映射和未能恢复的引用。
+func (s *MowenMCPServer) restoreFileReferences(ctx context.Context, refs []FileReference) (map[string]string, []UnrestoredReference) {
+	restored := make(map[string]string)
+	var unrestored []UnrestoredReference
+
+	for _, ref := range refs {
+		fileType, ok := fileTypeCodes[ref.FileType]
+		if !ok {
+			unrestored = append(unrestored, UnrestoredReference{Reference: ref, Reason: "unsupported file type " + ref.FileType})
+			continue
+		}
+
+		var result map[string]interface{}
+		var err error
+		switch {
+		case ref.Path != "":
+			result, err = s.mowenClient.UploadFile(ctx, ref.Path, fileType, filepath.Base(ref.Path))
+		case ref.URL != "":
+			result, err = s.mowenClient.UploadFileViaURL(ctx, ref.URL, fileType, "")
+		case ref.UUID != "":
+			// 假定原UUID仍然有效
+			restored[ref.UUID] = ref.UUID
+			continue
+		default:
+			unrestored = append(unrestored, UnrestoredReference{Reference: ref, Reason: "no uuid, path or url"})
+			continue
+		}
+		if err != nil {
+			unrestored = append(unrestored, UnrestoredReference{Reference: ref, Reason: err.Error()})
+			continue
+		}
+
+		newUUID := extractDataString(result, "uuid")
+		if newUUID == "" {
+			unrestored = append(unrestored, UnrestoredReference{Reference: ref, Reason: "upload response missing uuid"})
+			continue
+		}
+		restored[ref.UUID] = newUUID
+	}
+
+	return restored, unrestored
+}
+
+// unlistedFileReferences 返回笔记内容中引用、但导出包files列表中没有的文件，
+// 这些文件节点无法恢复，导入时会被移除。
+func unlistedFileReferences(body NoteAtom, files []FileReference) []UnrestoredReference {
+	listed := make(map[string]bool, len(files))
+	for _, ref := range files {
+		listed[ref.UUID] = true
+	}
+	var unlisted []UnrestoredReference
+	for _, ref := range CollectFileReferences(body) {
+		if !listed[ref.UUID] {
+			unlisted = append(unlisted, UnrestoredReference{Reference: ref, Reason: "not listed in bundle files"})
+		}
+	}
+	return unlisted
+}
+
+// rewriteFileReferences 按映射替换文件节点的UUID，并移除未能恢复的文件节点
+func rewriteFileReferences(atoms []NoteAtom, restored map[string]string) []NoteAtom {
+	result := make([]NoteAtom, 0, len(atoms))
+	for _, atom := range atoms {
+		if isFileAtomType(atom.Type) {
+			newUUID, ok := restored[atom.Attrs["uuid"]]
+			if !ok {
+				continue
+			}
+			attrs := make(map[string]string, len(atom.Attrs))
+			for k, v := range atom.Attrs {
+				attrs[k] = v
+			}
+			attrs["uuid"] = newUUID
+			atom.Attrs = attrs
+		}
+		if len(atom.Content) > 0 {
+			atom.Content = rewriteFileReferences(atom.Content, restored)
+		}
+		result = append(result, atom)
+	}
+	return result
+}
+
+// handleImportNoteBundle 处理导入笔记的MCP工具请求。
+// 它根据导出包重新创建笔记，恢复文件引用、标签和隐私设置，并报告未能恢复的引用。
+func (s *MowenMCPServer) handleImportNoteBundle(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args ImportNoteBundleArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	bundle, err := ParseNoteBundle(args.Bundle)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateNoteTimestamps(bundle.Note.CreatedAt, bundle.Note.UpdatedAt, time.Now()); err != nil {
+		return nil, fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	// 恢复文件引用并重写笔记内容
+	restored, unrestored := s.restoreFileReferences(ctx, bundle.Files)
+	body := bundle.Note.Body
+	unrestored = append(unrestored, unlistedFileReferences(body, bundle.Files)...)
+	body.Content = rewriteFileReferences(body.Content, restored)
+
+	createReq := NoteCreateRequest{
+		Body: body,
+		Settings: NoteCreateRequestSettings{
+			AutoPublish: args.AutoPublish,
+			Tags:        bundle.Note.Tags,
+			CreatedAt:   bundle.Note.CreatedAt,
+			UpdatedAt:   bundle.Note.UpdatedAt,
+		},
+	}
+	if err := s.noteQuota.reserve(); err != nil {
+		return nil, err
+	}
+	result, err := s.mowenClient.CreateNote(ctx, createReq)
+	if err != nil {
+		s.noteQuota.release()
+		return nil, fmt.Errorf("failed to create note: %w", err)
+	}
+	s.tagCache.invalidate()
+	noteID := s.extractNoteID(result)
+
+	var sb strings.Builder
+	sb.WriteString(s.withWarning(fmt.Sprintf("笔记导入成功！新笔记ID: %s", noteID), result))
+
+	// 恢复隐私设置
+	if bundle.Note.Privacy != nil && noteID != "" {
+		setReq := NoteSetRequest{
+			NoteID:   noteID,
+			Section:  SectionPrivacy,
+			Settings: &NoteSettings{Privacy: bundle.Note.Privacy},
+		}
+		if _, err := s.mowenClient.SetNotePrivacy(ctx, setReq); err != nil {
+			fmt.Fprintf(&sb, "\n\n⚠️ 隐私设置恢复失败：%v", err)
+		}
+	}
+
+	if len(unrestored) > 0 {
+		fmt.Fprintf(&sb, "\n\n以下 %d 个文件引用未能恢复，已从笔记中移除：", len(unrestored))
+		for _, item := range unrestored {
+			fmt.Fprintf(&sb, "\n- 第%d段 %s %s：%s", item.Reference.Paragraph, item.Reference.FileType, item.Reference.UUID, item.Reason)
+		}
+	}
+
+	return textResult(sb.String()), nil
+}
+
+// handleReplaceNoteFile 处理替换笔记中文件的MCP工具请求。
+// 它上传新文件，把笔记中指定旧UUID的文件节点替换为新文件，保留节点的其他属性，然后提交编辑。
+func (s *MowenMCPServer) handleReplaceNoteFile(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args ReplaceNoteFileArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	if (args.FilePath == "") == (args.FileURL == "") {
+		return nil, fmt.Errorf("exactly one of file_path or file_url is required")
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse note: %w", err)
+	}
+
+	// 其他文件保持不变，只替换目标文件
+	refs := CollectFileReferences(detail.Body)
+	mapping := make(map[string]string, len(refs))
+	var target *FileReference
+	for i, ref := range refs {
+		mapping[ref.UUID] = ref.UUID
+		if ref.UUID == args.OldUUID && target == nil {
+			target = &refs[i]
+		}
+	}
+	if target == nil {
+		uuids := make([]string, 0, len(refs))
+		for _, ref := range refs {
+			uuids = append(uuids, ref.UUID)
+		}
+		if len(uuids) == 0 {
+			return nil, fmt.Errorf("file %s not found in note %s: the note has no files", args.OldUUID, args.NoteID)
+		}
+		return nil, fmt.Errorf("file %s not found in note %s, files in note: %s", args.OldUUID, args.NoteID, strings.Join(uuids, ", "))
+	}
+
+	replacement := *target
+	replacement.Path = args.FilePath
+	replacement.URL = args.FileURL
+	uploaded, failed := s.restoreFileReferences(ctx, []FileReference{replacement})
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("failed to upload replacement file: %s", failed[0].Reason)
+	}
+	newUUID := uploaded[args.OldUUID]
+	mapping[args.OldUUID] = newUUID
+
+	body := detail.Body
+	body.Content = rewriteFileReferences(detail.Body.Content, mapping)
+	editResult, err := s.mowenClient.EditNote(ctx, NoteEditRequest{NoteID: args.NoteID, Body: body})
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit note: %w", err)
+	}
+
+	responseText := fmt.Sprintf("已替换笔记 %s 第%d段的%s文件：%s → %s", args.NoteID, target.Paragraph, target.FileType, args.OldUUID, newUUID)
+	return textResult(s.withWarning(responseText, editResult)), nil
+}
+
+// FileCheckResult 单个文件引用的校验结果
+type FileCheckResult struct {
+	Reference FileReference
+	Broken    bool   // 文件已失效
+	Unknown   bool   // 无法确定文件状态
+	Reason    string // 失效或无法确定的原因
+}
+
+// checkFileReference 通过文件处理状态接口判断文件引用是否有效。
+// 处理失败或接口认为UUID无效（400）时视为失效；其他错误无法确定状态。
+func (s *MowenMCPServer) checkFileReference(ctx context.Context, ref FileReference) (FileCheckResult, error) {
+	check := FileCheckResult{Reference: ref}
+	status, err := s.mowenClient.GetUploadStatus(ctx, ref.UUID)
+	switch {
+	case errors.Is(err, ErrNotSupported):
+		return check, err
+	case isBadRequest(err):
+		check.Broken, check.Reason = true, "文件UUID无效或文件已删除"
+	case err != nil:
+		check.Unknown, check.Reason = true, err.Error()
+	case status.Status == UploadStatusFailed:
+		check.Broken, check.Reason = true, "文件处理失败"
+		if status.Message != "" {
+			check.Reason += "：" + status.Message
+		}
+	}
+	return check, nil
+}
+
+// handleVerifyNoteFiles 处理校验笔记文件引用的MCP工具请求。
+// 它逐个查询笔记中文件的状态并报告失效的引用，RemoveBroken为true时从笔记中移除失效的文件节点。
+func (s *MowenMCPServer) handleVerifyNoteFiles(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args VerifyNoteFilesArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse note: %w", err)
+	}
+
+	refs := CollectFileReferences(detail.Body)
+	if len(refs) == 0 {
+		return textResult(fmt.Sprintf("笔记 %s 没有文件引用", args.NoteID)), nil
+	}
+
+	// 同一文件可能被多次引用，只查询一次
+	checked := make(map[string]FileCheckResult, len(refs))
+	keep := make(map[string]string, len(refs))
+	var checks []FileCheckResult
+	for _, ref := range refs {
+		check, ok := checked[ref.UUID]
+		if !ok {
+			check, err = s.checkFileReference(ctx, ref)
+			if errors.Is(err, ErrNotSupported) {
+				return textResult("当前墨问API不支持文件状态查询，无法校验文件引用"), nil
+			}
+			checked[ref.UUID] = check
+		}
+		check.Reference = ref
+		checks = append(checks, check)
+		if !check.Broken {
+			keep[ref.UUID] = ref.UUID
+		}
+	}
+
+	var sb strings.Builder
+	broken := 0
+	fmt.Fprintf(&sb, "笔记 %s 共 %d 个文件引用：", args.NoteID, len(checks))
+	for _, check := range checks {
+		state := "有效"
+		switch {
+		case check.Broken:
+			broken++
+			state = "失效，" + check.Reason
+		case check.Unknown:
+			state = "无法确定，" + check.Reason
+		}
+		fmt.Fprintf(&sb, "\n- 第%d段 %s %s：%s", check.Reference.Paragraph, check.Reference.FileType, check.Reference.UUID, state)
+	}
+
+	if broken == 0 {
+		sb.WriteString("\n\n没有发现失效的文件引用")
+		return textResult(sb.String()), nil
+	}
+	if !args.RemoveBroken {
+		fmt.Fprintf(&sb, "\n\n发现 %d 个失效的文件引用，设置 remove_broken 为true可从笔记中移除", broken)
+		return textResult(sb.String()), nil
+	}
+
+	body := detail.Body
+	body.Content = rewriteFileReferences(detail.Body.Content, keep)
+	editResult, err := s.mowenClient.EditNote(ctx, NoteEditRequest{NoteID: args.NoteID, Body: body})
+	if err != nil {
+		return nil, fmt.Errorf("failed to edit note: %w", err)
+	}
+	fmt.Fprintf(&sb, "\n\n已从笔记中移除 %d 个失效的文件引用", broken)
+	return textResult(s.withWarning(sb.String(), editResult)), nil
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// representativeNoteDetail 包含文本、引用、内链和文件的代表性笔记详情
+func representativeNoteDetail(t require.TestingT) map[string]interface{} {
+	return map[string]interface{}{
+		"noteId": "bundle-note-id",
+		"title":  "导出测试",
+		"body": mustConvert(t, []Paragraph{
+			{Texts: []TextNode{{Text: "正文", Bold: true}}},
+			{Type: "quote", Texts: []TextNode{{Text: "引用"}}},
+			{Type: "note", NoteID: "linked-note-id"},
+			{Type: "file", File: &FileNode{FileType: "image", SourceType: "upload", SourcePath: "image-uuid-1", Metadata: map[string]string{"alt": "封面"}}},
+			{Type: "file", File: &FileNode{FileType: "pdf", SourceType: "upload", SourcePath: "pdf-uuid-2"}},
+		}),
+		"tags": []string{"导出", "备份"},
+		"privacy": map[string]interface{}{
+			"type": "rule",
+			"rule": map[string]interface{}{"noShare": true, "expireAt": "1893456000"},
+		},
+	}
+}
+
+// TestCollectFileReferences 测试收集笔记中的文件引用
+func TestCollectFileReferences(t *testing.T) {
+	body := mustConvert(t, []Paragraph{
+		{Texts: []TextNode{{Text: "无文件段落"}}},
+		{Type: "file", File: &FileNode{FileType: "audio", SourceType: "upload", SourcePath: "audio-uuid", Metadata: map[string]string{"title": "录音"}}},
+	})
+
+	refs := CollectFileReferences(body)
+	require.Len(t, refs, 1)
+	assert.Equal(t, "audio-uuid", refs[0].UUID)
+	assert.Equal(t, "audio", refs[0].FileType)
+	assert.Equal(t, 2, refs[0].Paragraph)
+	assert.Equal(t, "录音", refs[0].Attrs["title"])
+	assert.NotContains(t, refs[0].Attrs, "uuid")
+
+	assert.Empty(t, CollectFileReferences(NoteAtom{Type: "doc"}))
+}
+
+// TestBuildNoteBundle 测试导出包包含所有部分
+func TestBuildNoteBundle(t *testing.T) {
+	detail, err := ParseNoteDetail(map[string]interface{}{"data": representativeNoteDetail(t)})
+	require.NoError(t, err)
+
+	exportedAt := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	bundle := BuildNoteBundle(detail, exportedAt)
+
+	assert.Equal(t, NoteBundleFormat, bundle.Format)
+	assert.Equal(t, NoteBundleVersion, bundle.Version)
+	assert.Equal(t, "2025-01-02T03:04:05Z", bundle.ExportedAt)
+	assert.Equal(t, "bundle-note-id", bundle.Note.NoteID)
+	assert.Equal(t, "导出测试", bundle.Note.Title)
+	assert.Len(t, bundle.Note.Body.Content, 5)
+	assert.Equal(t, []string{"导出", "备份"}, bundle.Note.Tags)
+	require.NotNil(t, bundle.Note.Privacy)
+	assert.Equal(t, "rule", bundle.Note.Privacy.Type)
+	assert.True(t, bundle.Note.Privacy.Rule.NoShare)
+	require.Len(t, bundle.Files, 2)
+	assert.Equal(t, "image-uuid-1", bundle.Files[0].UUID)
+	assert.Equal(t, 4, bundle.Files[0].Paragraph)
+	assert.Equal(t, "pdf-uuid-2", bundle.Files[1].UUID)
+}
+
+// TestHandleExportNoteBundle 测试导出笔记处理器输出完整的JSON导出包
+func (suite *ServerTestSuite) TestHandleExportNoteBundle() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(representativeNoteDetail(suite.T()))
+
+	text, err := suite.callTool(suite.mcpServer.handleExportNoteBundle, ExportNoteBundleArgs{NoteID: "bundle-note-id"})
+	require.NoError(suite.T(), err)
+
+	var bundle map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(text), &bundle))
+	for _, key := range []string{"format", "version", "exported_at", "note", "files"} {
+		assert.Contains(suite.T(), bundle, key)
+	}
+
+	note := bundle["note"].(map[string]interface{})
+	for _, key := range []string{"note_id", "body", "tags", "privacy"} {
+		assert.Contains(suite.T(), note, key)
+	}
+	assert.Len(suite.T(), bundle["files"], 2)
+}
+
+// TestHandleExportNoteBundleResolveLinks 测试导出时解析内链笔记，不存在的引用不影响导出
+func (suite *ServerTestSuite) TestHandleExportNoteBundleResolveLinks() {
+	detail := representativeNoteDetail(suite.T())
+	detail["body"] = mustConvert(suite.T(), []Paragraph{
+		{Texts: []TextNode{{Text: "正文"}}},
+		{Type: "note", NoteID: "linked-note-id"},
+		{Type: "note", NoteID: "missing-note-id"},
+		{Type: "note", NoteID: "deleted-note-id"},
+	})
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var req NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&req))
+		switch req.NoteID {
+		case "bundle-note-id":
+			mockSuccess(detail)(w, r)
+		case "linked-note-id":
+			mockSuccess(map[string]interface{}{"noteId": "linked-note-id", "title": "被引用的笔记"})(w, r)
+		case "deleted-note-id":
+			// HTTP 200但业务错误码为404
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 404, "message": "note not found"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleExportNoteBundle, ExportNoteBundleArgs{NoteID: "bundle-note-id", ResolveLinks: true})
+	require.NoError(suite.T(), err)
+
+	var bundle NoteBundle
+	require.NoError(suite.T(), json.Unmarshal([]byte(text), &bundle))
+	require.Len(suite.T(), bundle.Links, 3)
+	assert.Equal(suite.T(), NoteLink{
+		NoteID:    "linked-note-id",
+		Paragraph: 2,
+		Title:     "被引用的笔记",
+		URL:       "https://note.mowen.cn/detail/linked-note-id",
+	}, bundle.Links[0])
+	assert.Equal(suite.T(), "missing-note-id", bundle.Links[1].NoteID)
+	assert.Equal(suite.T(), 3, bundle.Links[1].Paragraph)
+	assert.Empty(suite.T(), bundle.Links[1].Title)
+	assert.Contains(suite.T(), bundle.Links[1].Error, "不存在")
+	assert.Equal(suite.T(), "引用的笔记不存在或已删除", bundle.Links[2].Error)
+
+	// 内链笔记本身保持不变
+	assert.Equal(suite.T(), "note", bundle.Note.Body.Content[1].Type)
+	assert.Equal(suite.T(), "missing-note-id", bundle.Note.Body.Content[2].Attrs["uuid"])
+
+	// 默认不解析内链
+	suite.routes[NoteDetailEndpoint] = mockSuccess(detail)
+	text, err = suite.callTool(suite.mcpServer.handleExportNoteBundle, ExportNoteBundleArgs{NoteID: "bundle-note-id"})
+	require.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), text, `"links"`)
+}
+
+// exportRepresentativeBundle 生成代表性笔记的导出包JSON
+func exportRepresentativeBundle(t require.TestingT, mutate func(bundle *NoteBundle)) string {
+	detail, err := ParseNoteDetail(map[string]interface{}{"data": representativeNoteDetail(t)})
+	require.NoError(t, err)
+
+	bundle := BuildNoteBundle(detail, time.Now())
+	if mutate != nil {
+		mutate(bundle)
+	}
+	bundleJSON, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	return string(bundleJSON)
+}
+
+// TestParseNoteBundle 测试导出包格式校验
+func TestParseNoteBundle(t *testing.T) {
+	_, err := ParseNoteBundle(`{"format":"other","version":1}`)
+	assert.Error(t, err)
+
+	_, err = ParseNoteBundle(`{"format":"mowen-note-bundle","version":99}`)
+	assert.Error(t, err)
+
+	_, err = ParseNoteBundle(`not json`)
+	assert.Error(t, err)
+}
+
+// TestHandleImportNoteBundle 测试完整导入笔记导出包
+func (suite *ServerTestSuite) TestHandleImportNoteBundle() {
+	var createReq NoteCreateRequest
+	var setReq NoteSetRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&createReq)
+		mockSuccess(map[string]interface{}{"note_id": "imported-note-id"})(w, r)
+	}
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&setReq)
+		mockSuccess(map[string]interface{}{"note_id": "imported-note-id"})(w, r)
+	}
+
+	bundleJSON := exportRepresentativeBundle(suite.T(), func(bundle *NoteBundle) {
+		// 第二个文件通过URL重新上传
+		bundle.Files[1].URL = "https://example.com/doc.pdf"
+		bundle.Note.CreatedAt = 1600000000
+		bundle.Note.UpdatedAt = 1650000000
+	})
+
+	text, err := suite.callTool(suite.mcpServer.handleImportNoteBundle, ImportNoteBundleArgs{Bundle: bundleJSON})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "新笔记ID: imported-note-id")
+	assert.NotContains(suite.T(), text, "未能恢复")
+
+	// 验证内容、标签和文件引用
+	require.Len(suite.T(), createReq.Body.Content, 5)
+	assert.Equal(suite.T(), "image-uuid-1", createReq.Body.Content[3].Attrs["uuid"])
+	assert.Equal(suite.T(), "封面", createReq.Body.Content[3].Attrs["alt"])
+	assert.Equal(suite.T(), "test-url-file-uuid-999", createReq.Body.Content[4].Attrs["uuid"])
+	assert.Equal(suite.T(), []string{"导出", "备份"}, createReq.Settings.Tags)
+	assert.Equal(suite.T(), int64(1600000000), createReq.Settings.CreatedAt)
+	assert.Equal(suite.T(), int64(1650000000), createReq.Settings.UpdatedAt)
+
+	// 验证隐私设置已恢复
+	assert.Equal(suite.T(), "imported-note-id", setReq.NoteID)
+	require.NotNil(suite.T(), setReq.Settings)
+	assert.Equal(suite.T(), "rule", setReq.Settings.Privacy.Type)
+}
+
+// TestHandleImportNoteBundleMissingReference 测试导入时文件引用无法恢复
+func (suite *ServerTestSuite) TestHandleImportNoteBundleMissingReference() {
+	var createReq NoteCreateRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&createReq)
+		mockSuccess(map[string]interface{}{"note_id": "imported-note-id"})(w, r)
+	}
+
+	bundleJSON := exportRepresentativeBundle(suite.T(), func(bundle *NoteBundle) {
+		// 本地文件已不存在，无法重新上传
+		bundle.Files[1].Path = filepath.Join(suite.T().TempDir(), "missing.pdf")
+	})
+
+	text, err := suite.callTool(suite.mcpServer.handleImportNoteBundle, ImportNoteBundleArgs{Bundle: bundleJSON})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "以下 1 个文件引用未能恢复")
+	assert.Contains(suite.T(), text, "第5段 pdf pdf-uuid-2")
+
+	// 无法恢复的文件节点已被移除，其余内容保留
+	require.Len(suite.T(), createReq.Body.Content, 4)
+	assert.Equal(suite.T(), "image-uuid-1", createReq.Body.Content[3].Attrs["uuid"])
+}
+
+// TestHandleImportNoteBundleUnlistedReference 测试笔记内容引用了files列表中没有的文件时在结果中列出
+func (suite *ServerTestSuite) TestHandleImportNoteBundleUnlistedReference() {
+	var createReq NoteCreateRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&createReq)
+		mockSuccess(map[string]interface{}{"note_id": "imported-note-id"})(w, r)
+	}
+
+	bundleJSON := exportRepresentativeBundle(suite.T(), func(bundle *NoteBundle) {
+		// 手动编辑导出包时漏掉了PDF文件
+		bundle.Files = bundle.Files[:1]
+	})
+
+	text, err := suite.callTool(suite.mcpServer.handleImportNoteBundle, ImportNoteBundleArgs{Bundle: bundleJSON})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "以下 1 个文件引用未能恢复")
+	assert.Contains(suite.T(), text, "第5段 pdf pdf-uuid-2：not listed in bundle files")
+
+	require.Len(suite.T(), createReq.Body.Content, 4)
+	assert.Equal(suite.T(), "image-uuid-1", createReq.Body.Content[3].Attrs["uuid"])
+}
+
+// TestHandleReplaceNoteFile 测试通过URL上传新文件并替换笔记中的文件
+func (suite *ServerTestSuite) TestHandleReplaceNoteFile() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(representativeNoteDetail(suite.T()))
+	var editReq NoteEditRequest
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&editReq))
+		mockSuccess(nil)(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleReplaceNoteFile, ReplaceNoteFileArgs{
+		NoteID:  "bundle-note-id",
+		OldUUID: "image-uuid-1",
+		FileURL: "https://example.com/new.png",
+	})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "第4段的image文件：image-uuid-1 → test-url-file-uuid-999")
+
+	require.Len(suite.T(), editReq.Body.Content, 5)
+	image := editReq.Body.Content[3]
+	assert.Equal(suite.T(), "image", image.Type)
+	assert.Equal(suite.T(), "test-url-file-uuid-999", image.Attrs["uuid"])
+	assert.Equal(suite.T(), "封面", image.Attrs["alt"])
+	// 其他文件保持不变
+	assert.Equal(suite.T(), "pdf-uuid-2", editReq.Body.Content[4].Attrs["uuid"])
+}
+
+// TestHandleReplaceNoteFileNotFound 测试旧UUID不在笔记中时返回错误且不上传、不编辑
+func (suite *ServerTestSuite) TestHandleReplaceNoteFileNotFound() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(representativeNoteDetail(suite.T()))
+	unexpected := func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Errorf("unexpected request to %s", r.URL.Path)
+	}
+	suite.routes[UploadURLEndpoint] = unexpected
+	suite.routes[NoteEditEndpoint] = unexpected
+
+	_, err := suite.callTool(suite.mcpServer.handleReplaceNoteFile, ReplaceNoteFileArgs{
+		NoteID:  "bundle-note-id",
+		OldUUID: "missing-uuid",
+		FileURL: "https://example.com/new.png",
+	})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "file missing-uuid not found in note bundle-note-id, files in note: image-uuid-1, pdf-uuid-2")
+
+	_, err = suite.callTool(suite.mcpServer.handleReplaceNoteFile, ReplaceNoteFileArgs{NoteID: "bundle-note-id", OldUUID: "image-uuid-1"})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "exactly one of file_path or file_url")
+}
+
+// verifyFilesNoteDetail 包含有效、失效和无效UUID文件引用的笔记详情
+func verifyFilesNoteDetail(t *testing.T) map[string]interface{} {
+	return map[string]interface{}{
+		"noteId": "files-note-id",
+		"body": mustConvert(t, []Paragraph{
+			{Texts: []TextNode{{Text: "正文"}}},
+			{Type: "file", File: &FileNode{FileType: "image", SourcePath: "image-ready-uuid"}},
+			{Type: "file", File: &FileNode{FileType: "audio", SourcePath: "file-failed"}},
+			{Type: "file", File: &FileNode{FileType: "pdf", SourcePath: "deleted-pdf-uuid"}},
+		}),
+	}
+}
+
+// routeVerifyUploadStatus 模拟文件状态接口：file-failed处理失败，deleted-pdf-uuid返回400，其他文件已就绪
+func (suite *ServerTestSuite) routeVerifyUploadStatus() {
+	suite.routes[UploadStatusEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var req UploadStatusRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&req))
+		switch req.UUID {
+		case "deleted-pdf-uuid":
+			w.WriteHeader(http.StatusBadRequest)
+		case "file-failed":
+			mockSuccess(map[string]interface{}{"uuid": req.UUID, "status": UploadStatusFailed, "message": "unsupported codec"})(w, r)
+		default:
+			mockSuccess(map[string]interface{}{"uuid": req.UUID, "status": UploadStatusReady})(w, r)
+		}
+	}
+}
+
+// TestHandleVerifyNoteFilesReportOnly 测试默认只报告失效的文件引用，不修改笔记
+func (suite *ServerTestSuite) TestHandleVerifyNoteFilesReportOnly() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(verifyFilesNoteDetail(suite.T()))
+	suite.routeVerifyUploadStatus()
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("report-only verification must not edit the note")
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleVerifyNoteFiles, VerifyNoteFilesArgs{NoteID: "files-note-id"})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 3 个文件引用")
+	assert.Contains(suite.T(), text, "第2段 image image-ready-uuid：有效")
+	assert.Contains(suite.T(), text, "第3段 audio file-failed：失效，文件处理失败：unsupported codec")
+	assert.Contains(suite.T(), text, "第4段 pdf deleted-pdf-uuid：失效，文件UUID无效或文件已删除")
+	assert.Contains(suite.T(), text, "发现 2 个失效的文件引用")
+}
+
+// TestHandleVerifyNoteFilesRemove 测试开启移除后只保留有效的文件引用
+func (suite *ServerTestSuite) TestHandleVerifyNoteFilesRemove() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(verifyFilesNoteDetail(suite.T()))
+	suite.routeVerifyUploadStatus()
+	var editReq NoteEditRequest
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&editReq))
+		mockSuccess(nil)(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleVerifyNoteFiles, VerifyNoteFilesArgs{NoteID: "files-note-id", RemoveBroken: true})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "已从笔记中移除 2 个失效的文件引用")
+	assert.Equal(suite.T(), "files-note-id", editReq.NoteID)
+	require.Len(suite.T(), editReq.Body.Content, 2)
+	assert.Equal(suite.T(), "image-ready-uuid", editReq.Body.Content[1].Attrs["uuid"])
+}
+
+// TestHandleVerifyNoteFilesNotSupported 测试文件状态接口不存在时返回说明而不是错误
+func (suite *ServerTestSuite) TestHandleVerifyNoteFilesNotSupported() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(verifyFilesNoteDetail(suite.T()))
+	suite.routes[UploadStatusEndpoint] = http.NotFound
+
+	text, err := suite.callTool(suite.mcpServer.handleVerifyNoteFiles, VerifyNoteFilesArgs{NoteID: "files-note-id", RemoveBroken: true})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "不支持文件状态查询")
+}
+
+// TestHandleExportNoteBundleAsResource 测试导出包超过阈值时作为嵌入资源返回，未超过时仍返回文本
+func (suite *ServerTestSuite) TestHandleExportNoteBundleAsResource() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(representativeNoteDetail(suite.T()))
+	call := func() *protocol.CallToolResult {
+		argsJSON, err := json.Marshal(ExportNoteBundleArgs{NoteID: "bundle-note-id"})
+		require.NoError(suite.T(), err)
+		result, err := suite.mcpServer.handleExportNoteBundle(context.Background(), &protocol.CallToolRequest{RawArguments: argsJSON})
+		require.NoError(suite.T(), err)
+		return result
+	}
+
+	suite.mcpServer.resourceThreshold = 100
+	result := call()
+	require.Len(suite.T(), result.Content, 2)
+	summary, ok := result.Content[0].(*protocol.TextContent)
+	require.True(suite.T(), ok)
+	assert.Contains(suite.T(), summary.Text, "已作为资源 mowen://notes/bundle-note-id/bundle.json 返回")
+
+	embedded, ok := result.Content[1].(*protocol.EmbeddedResource)
+	require.True(suite.T(), ok)
+	assert.Equal(suite.T(), "resource", embedded.GetType())
+	resource, ok := embedded.Resource.(*protocol.TextResourceContents)
+	require.True(suite.T(), ok)
+	assert.Equal(suite.T(), "mowen://notes/bundle-note-id/bundle.json", resource.URI)
+	assert.Equal(suite.T(), "application/json", resource.MimeType)
+	var bundle NoteBundle
+	require.NoError(suite.T(), json.Unmarshal([]byte(resource.Text), &bundle))
+	assert.Equal(suite.T(), NoteBundleFormat, bundle.Format)
+
+	// 未超过阈值或关闭时返回文本
+	for _, threshold := range []int{len(resource.Text), 0} {
+		suite.mcpServer.resourceThreshold = threshold
+		result = call()
+		require.Len(suite.T(), result.Content, 1)
+		text, ok := result.Content[0].(*protocol.TextContent)
+		require.True(suite.T(), ok)
+		assert.Equal(suite.T(), resource.Text[:20], text.Text[:20])
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// maxFileExtensionLength 从文件名或下载地址推断扩展名时，超过该长度的后缀不视为扩展名
+const maxFileExtensionLength = 8
+
+// fileExtension 返回名称中的扩展名（含点），没有或过长时返回空字符串
+func fileExtension(name string) string {
+	ext := path.Ext(name)
+	if len(ext) < 2 || len(ext) > maxFileExtensionLength || strings.ContainsAny(ext, " ") {
+		return ""
+	}
+	return ext
+}
+
+// downloadFilename 生成保存文件使用的文件名。
+// 依次使用文件节点的title、alt元数据和上传时的文件名，都没有时使用UUID；
+// 名称没有扩展名时依次从上传时的文件名和下载地址推断，PDF默认使用.pdf。
+func downloadFilename(ref FileReference, info *FileDownloadInfo, used map[string]bool) string {
+	name := ""
+	for _, candidate := range []string{ref.Attrs["title"], ref.Attrs["alt"], info.FileName} {
+		if strings.TrimSpace(candidate) != "" {
+			name = candidate
+			break
+		}
+	}
+	name = markdownFilename(name, ref.UUID)
+
+	ext := fileExtension(name)
+	base := strings.TrimSuffix(name, ext)
+	if ext == "" {
+		ext = fileExtension(info.FileName)
+	}
+	if ext == "" {
+		if u, err := url.Parse(info.URL); err == nil {
+			ext = fileExtension(u.Path)
+		}
+	}
+	if ext == "" && ref.FileType == "pdf" {
+		ext = ".pdf"
+	}
+	return uniqueFilename(base, used) + ext
+}
+
+// downloadTo 下载文件到指定路径，先写入同目录下的临时文件，完成后再重命名，失败时不留下不完整的文件
+func (s *MowenMCPServer) downloadTo(ctx context.Context, fileURL, target string) (int64, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".download-*")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	n, err := s.mowenClient.DownloadFile(ctx, fileURL, tmp)
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to write file: %w", closeErr)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp.Name(), target); err != nil {
+		return 0, fmt.Errorf("failed to save file: %w", err)
+	}
+	return n, nil
+}
+
+// handleDownloadNoteFiles 处理下载笔记中全部文件的MCP工具请求。
+// 同一文件被多次引用时只下载一次；单个文件下载失败不影响其他文件，最终返回各文件的处理结果。
+func (s *MowenMCPServer) handleDownloadNoteFiles(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args DownloadNoteFilesArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	outputDir := strings.TrimSpace(args.OutputDir)
+	if outputDir == "" {
+		outputDir = os.Getenv("MOWEN_EXPORT_DIR")
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("output_dir is required when MOWEN_EXPORT_DIR is not set")
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if isNoteNotFoundResponse(err, result) {
+		return textResult(fmt.Sprintf("笔记 %s 不存在", args.NoteID)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, err
+	}
+
+	refs := make(map[string]FileReference)
+	var uuids []string
+	for _, ref := range CollectFileReferences(detail.Body) {
+		if _, seen := refs[ref.UUID]; !seen && ref.UUID != "" {
+			refs[ref.UUID] = ref
+			uuids = append(uuids, ref.UUID)
+		}
+	}
+	if len(uuids) == 0 {
+		return textResult(fmt.Sprintf("笔记 %s 没有文件", args.NoteID)), nil
+	}
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	used := make(map[string]bool, len(uuids))
+	var written []string
+	notSupported := false
+	results := runBatch(uuids, false, func(uuid string) BatchItemResult {
+		if notSupported {
+			return BatchItemResult{Status: BatchItemSkipped, Reason: "当前墨问API不支持获取文件下载地址"}
+		}
+		ref := refs[uuid]
+		info, err := s.mowenClient.GetFileDownload(ctx, uuid)
+		if errors.Is(err, ErrNotSupported) {
+			notSupported = true
+			return BatchItemResult{Status: BatchItemSkipped, Reason: "当前墨问API不支持获取文件下载地址"}
+		}
+		if err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+
+		filename := downloadFilename(ref, info, used)
+		size, err := s.downloadTo(ctx, info.URL, filepath.Join(outputDir, filename))
+		if err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		written = append(written, fmt.Sprintf("第%d段 %s %s → %s（%d 字节）", ref.Paragraph, ref.FileType, uuid, filename, size))
+		return BatchItemResult{Status: BatchItemSucceeded}
+	})
+
+	responseText := RenderBatchReport(fmt.Sprintf("下载笔记 %s 的文件到 %s", args.NoteID, outputDir), results)
+	if len(written) > 0 {
+		responseText += "\n\n下载的文件：\n- " + strings.Join(written, "\n- ")
+	}
+	if notSupported {
+		responseText += "\n\n当前墨问API不支持获取文件下载地址，未下载的文件已跳过"
+	}
+	return textResult(responseText), nil
+}
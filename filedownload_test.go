@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleDownloadNoteFiles 测试按元数据或UUID命名下载笔记中的文件，重复引用只下载一次，单个文件失败不影响其他文件
+func (suite *ServerTestSuite) TestHandleDownloadNoteFiles() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(map[string]interface{}{
+		"noteId": "note-1",
+		"body": mustConvert(suite.T(), []Paragraph{
+			{Type: "file", File: &FileNode{FileType: "image", SourceType: "upload", SourcePath: "image-uuid-1", Metadata: map[string]string{"alt": "封面/图"}}},
+			{Texts: []TextNode{{Text: "正文"}}},
+			{Type: "file", File: &FileNode{FileType: "pdf", SourceType: "upload", SourcePath: "pdf-uuid-22", Metadata: map[string]string{"title": "报告.pdf"}}},
+			{Type: "file", File: &FileNode{FileType: "audio", SourceType: "upload", SourcePath: "audio-uuid-3"}},
+			{Type: "file", File: &FileNode{FileType: "image", SourceType: "upload", SourcePath: "image-uuid-1", Metadata: map[string]string{"alt": "封面/图"}}},
+			{Type: "file", File: &FileNode{FileType: "image", SourceType: "upload", SourcePath: "broken-uuid"}},
+		}),
+	})
+	var requested []string
+	suite.routes[FileDownloadEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var downloadReq FileDownloadRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&downloadReq))
+		requested = append(requested, downloadReq.UUID)
+		info := FileDownloadInfo{URL: suite.mockHTTPServer.URL + "/files/" + downloadReq.UUID + ".bin"}
+		switch downloadReq.UUID {
+		case "image-uuid-1":
+			info = FileDownloadInfo{URL: suite.mockHTTPServer.URL + "/files/cover.png?sig=abc", FileName: "cover.png"}
+		case "audio-uuid-3":
+			info.URL = suite.mockHTTPServer.URL + "/files/voice.m4a"
+		}
+		mockSuccess(info)(w, r)
+	}
+	for name, content := range map[string]string{"cover.png": "png-data", "pdf-uuid-22.bin": "pdf-data", "voice.m4a": "m4a-data"} {
+		content := content
+		suite.routes["/files/"+name] = func(w http.ResponseWriter, r *http.Request) {
+			assert.Empty(suite.T(), r.Header.Get("Authorization"))
+			w.Write([]byte(content))
+		}
+	}
+	suite.routes["/files/broken-uuid.bin"] = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}
+
+	dir := filepath.Join(suite.T().TempDir(), "files")
+	text, err := suite.callTool(suite.mcpServer.handleDownloadNoteFiles, DownloadNoteFilesArgs{NoteID: "note-1", OutputDir: dir})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 4 项，成功 3 项，跳过 0 项，失败 1 项")
+	assert.Contains(suite.T(), text, "- broken-uuid：download request failed with status 403")
+	assert.Contains(suite.T(), text, "- 第1段 image image-uuid-1 → 封面_图.png（8 字节）")
+	assert.Contains(suite.T(), text, "- 第3段 pdf pdf-uuid-22 → 报告.pdf（8 字节）")
+	assert.Contains(suite.T(), text, "- 第4段 audio audio-uuid-3 → audio-uuid-3.m4a（8 字节）")
+	assert.Equal(suite.T(), []string{"image-uuid-1", "pdf-uuid-22", "audio-uuid-3", "broken-uuid"}, requested)
+
+	for name, content := range map[string]string{"封面_图.png": "png-data", "报告.pdf": "pdf-data", "audio-uuid-3.m4a": "m4a-data"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(suite.T(), err)
+		assert.Equal(suite.T(), content, string(data))
+	}
+	entries, err := os.ReadDir(dir)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, 3, "下载失败时不应留下文件")
+}
+
+// TestHandleDownloadNoteFilesNotSupported 测试墨问API不支持下载地址接口时跳过全部文件，缺少输出目录时返回错误
+func (suite *ServerTestSuite) TestHandleDownloadNoteFilesNotSupported() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(map[string]interface{}{
+		"noteId": "note-1",
+		"body": mustConvert(suite.T(), []Paragraph{
+			{Type: "file", File: &FileNode{FileType: "image", SourceType: "upload", SourcePath: "image-uuid-1"}},
+			{Type: "file", File: &FileNode{FileType: "pdf", SourceType: "upload", SourcePath: "pdf-uuid-22"}},
+		}),
+	})
+
+	text, err := suite.callTool(suite.mcpServer.handleDownloadNoteFiles, DownloadNoteFilesArgs{NoteID: "note-1", OutputDir: suite.T().TempDir()})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 2 项，成功 0 项，跳过 2 项，失败 0 项")
+	assert.Contains(suite.T(), text, "当前墨问API不支持获取文件下载地址")
+
+	suite.T().Setenv("MOWEN_EXPORT_DIR", "")
+	_, err = suite.callTool(suite.mcpServer.handleDownloadNoteFiles, DownloadNoteFilesArgs{NoteID: "note-1"})
+	assert.ErrorContains(suite.T(), err, "output_dir is required")
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// describePrivacy 将笔记的隐私设置描述为文本，接口未返回时为"未提供"
+func describePrivacy(privacy *NotePrivacySet) string {
+	if privacy == nil || privacy.Type == "" {
+		return "未提供"
+	}
+	switch privacy.Type {
+	case "public":
+		return "公开"
+	case "private":
+		return "私有"
+	case "rule":
+		var details []string
+		if privacy.Rule != nil && privacy.Rule.NoShare {
+			details = append(details, "禁止分享")
+		}
+		if privacy.Rule != nil {
+			if expireAt := parseAPITimestamp(privacy.Rule.ExpireAt); !expireAt.IsZero() {
+				details = append(details, "公开至 "+formatNoteTimestamp(expireAt))
+			}
+		}
+		if len(details) == 0 {
+			return "规则公开"
+		}
+		return "规则公开（" + strings.Join(details, "，") + "）"
+	}
+	return privacy.Type
+}
+
+// RenderNoteReadable 将笔记的设置和正文渲染为便于阅读的文本，正文使用与Markdown导出相同的格式
+func RenderNoteReadable(detail *NoteDetail, timestamps NoteTimestamps) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "笔记 %s", detail.NoteID)
+	if detail.Title != "" {
+		fmt.Fprintf(&sb, "\n标题: %s", detail.Title)
+	}
+	tags := "无"
+	if len(detail.Tags) > 0 {
+		tags = strings.Join(detail.Tags, ", ")
+	}
+	fmt.Fprintf(&sb, "\n标签: %s", tags)
+	fmt.Fprintf(&sb, "\n隐私: %s", describePrivacy(detail.Privacy))
+	fmt.Fprintf(&sb, "\n创建时间: %s", formatNoteTimestamp(timestamps.CreatedAt))
+	fmt.Fprintf(&sb, "\n更新时间: %s", formatNoteTimestamp(timestamps.UpdatedAt))
+
+	if len(detail.Body.Content) == 0 {
+		sb.WriteString("\n\n正文：（空）")
+		return sb.String()
+	}
+	sb.WriteString("\n\n正文：")
+	for _, block := range detail.Body.Content {
+		sb.WriteString("\n\n")
+		sb.WriteString(renderMarkdownBlock(block))
+	}
+	return sb.String()
+}
+
+// handleGetNote 处理读取笔记内容的MCP工具请求，返回笔记的设置和正文。
+// 笔记不存在时返回说明而不是错误，其他请求失败时返回错误。
+func (s *MowenMCPServer) handleGetNote(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args GetNoteArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if isNoteNotFoundResponse(err, result) {
+		return textResult(fmt.Sprintf("笔记 %s 不存在", args.NoteID)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, err
+	}
+	timestamps, err := ParseNoteTimestamps(result)
+	if err != nil {
+		return nil, err
+	}
+	if detail.NoteID == "" {
+		detail.NoteID = args.NoteID
+	}
+	return textResult(RenderNoteReadable(detail, timestamps)), nil
+}
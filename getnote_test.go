@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDescribePrivacy 测试各种隐私设置的描述
+func TestDescribePrivacy(t *testing.T) {
+	assert.Equal(t, "未提供", describePrivacy(nil))
+	assert.Equal(t, "公开", describePrivacy(&NotePrivacySet{Type: "public"}))
+	assert.Equal(t, "私有", describePrivacy(&NotePrivacySet{Type: "private"}))
+	assert.Equal(t, "规则公开", describePrivacy(&NotePrivacySet{Type: "rule"}))
+	assert.Equal(t, "规则公开（禁止分享，公开至 2024-05-01T08:00:00Z）",
+		describePrivacy(&NotePrivacySet{Type: "rule", Rule: &NotePrivacySetRule{NoShare: true, ExpireAt: "1714550400"}}))
+}
+
+// TestHandleGetNote 测试读取笔记的设置和正文，笔记不存在与请求失败分别处理
+func (suite *ServerTestSuite) TestHandleGetNote() {
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var detailReq NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&detailReq))
+		switch detailReq.NoteID {
+		case "note-read":
+			mockSuccess(map[string]interface{}{
+				"noteId": detailReq.NoteID,
+				"title":  "周报",
+				"tags":   []string{"工作", "周报"},
+				"body": mustConvert(suite.T(), []Paragraph{
+					{Type: "heading", Level: 1, Texts: []TextNode{{Text: "本周"}}},
+					{Texts: []TextNode{{Text: "完成", Bold: true}, {Text: " 发布"}}},
+					{Type: "quote", Texts: []TextNode{{Text: "备注"}}},
+				}),
+				"privacy":   map[string]interface{}{"type": "rule", "rule": map[string]interface{}{"noShare": true}},
+				"createdAt": 1714550400,
+			})(w, r)
+		case "note-empty":
+			mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID, "body": map[string]interface{}{"type": "doc"}})(w, r)
+		case "note-code-404":
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 404, "message": "note not found"})
+		case "note-error":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleGetNote, GetNoteArgs{NoteID: "note-read"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 note-read"+
+		"\n标题: 周报"+
+		"\n标签: 工作, 周报"+
+		"\n隐私: 规则公开（禁止分享）"+
+		"\n创建时间: 2024-05-01T08:00:00Z"+
+		"\n更新时间: 未提供"+
+		"\n\n正文：\n\n# 本周\n\n**完成** 发布\n\n> 备注", text)
+
+	text, err = suite.callTool(suite.mcpServer.handleGetNote, GetNoteArgs{NoteID: "note-empty"})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "标签: 无\n隐私: 未提供")
+	assert.Contains(suite.T(), text, "正文：（空）")
+
+	for _, id := range []string{"missing", "note-code-404"} {
+		text, err = suite.callTool(suite.mcpServer.handleGetNote, GetNoteArgs{NoteID: id})
+		require.NoError(suite.T(), err, id)
+		assert.Equal(suite.T(), "笔记 "+id+" 不存在", text)
+	}
+
+	_, err = suite.callTool(suite.mcpServer.handleGetNote, GetNoteArgs{NoteID: "note-error"})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "failed to get note")
+}
@@ -38,7 +38,10 @@ func (s *TestIntegrationMowenMCPServer) handleCreateNote(ctx context.Context, re
 	}
 
 	// 转换参数为正确的请求格式
-	noteAtom := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	noteAtom, err := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paragraphs: %w", err)
+	}
 	request := NoteCreateRequest{
 		Body: noteAtom,
 		Settings: NoteCreateRequestSettings{
@@ -47,7 +50,7 @@ func (s *TestIntegrationMowenMCPServer) handleCreateNote(ctx context.Context, re
 		},
 	}
 
-	result, err := s.mowenClient.CreateNote(request)
+	result, err := s.mowenClient.CreateNote(context.Background(), request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create note: %w", err)
 	}
@@ -78,13 +81,16 @@ func (s *TestIntegrationMowenMCPServer) handleEditNote(ctx context.Context, req
 	}
 
 	// 转换参数为正确的请求格式
-	noteAtom := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	noteAtom, err := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paragraphs: %w", err)
+	}
 	request := NoteEditRequest{
 		NoteID: args.NoteID,
 		Body:   noteAtom,
 	}
 
-	_, err := s.mowenClient.EditNote(request)
+	_, err = s.mowenClient.EditNote(context.Background(), request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to edit note: %w", err)
 	}
@@ -128,7 +134,7 @@ func (s *TestIntegrationMowenMCPServer) handleSetNotePrivacy(ctx context.Context
 		},
 	}
 
-	_, err := s.mowenClient.SetNotePrivacy(request)
+	_, err := s.mowenClient.SetNotePrivacy(context.Background(), request)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set note privacy: %w", err)
 	}
@@ -150,7 +156,7 @@ func (s *TestIntegrationMowenMCPServer) handleResetAPIKey(ctx context.Context, r
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	result, err := s.mowenClient.ResetAPIKey()
+	result, err := s.mowenClient.ResetAPIKey(context.Background())
 	if err != nil {
 		return nil, fmt.Errorf("failed to reset API key: %w", err)
 	}
@@ -180,7 +186,7 @@ func (s *TestIntegrationMowenMCPServer) handleUploadFileViaURL(ctx context.Conte
 		return nil, fmt.Errorf("invalid arguments: %w", err)
 	}
 
-	result, err := s.mowenClient.UploadFileViaURL(args.FileURL, args.FileType, args.FileName)
+	result, err := s.mowenClient.UploadFileViaURL(context.Background(), args.FileURL, args.FileType, args.FileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload file via URL: %w", err)
 	}
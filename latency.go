@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// 延迟探测的采样次数
+const (
+	DefaultLatencySamples = 5
+	MaxLatencySamples     = 50
+)
+
+// DefaultLatencyProbe 默认的延迟探测接口
+const DefaultLatencyProbe = "list"
+
+// latencyProbes 可用于延迟探测的接口，均为只读请求或针对不存在资源的请求，不会修改任何数据
+var latencyProbes = map[string]capabilityProbe{
+	"list":          {Name: "搜索笔记", Endpoint: NoteListEndpoint, Body: NoteListRequest{PageSize: 1}},
+	"detail":        {Name: "读取笔记", Endpoint: NoteDetailEndpoint, Body: NoteDetailRequest{NoteID: selfCheckNoteID}},
+	"upload_status": {Name: "文件处理状态", Endpoint: UploadStatusEndpoint, Body: UploadStatusRequest{UUID: selfCheckNoteID}},
+}
+
+// LatencyStats 一组请求耗时的统计
+type LatencyStats struct {
+	Samples int           // 成功得到响应的请求数
+	Failed  int           // 未得到响应（网络错误）的请求数
+	Min     time.Duration // 最小耗时
+	Median  time.Duration // 中位数
+	P95     time.Duration // 第95百分位
+	Max     time.Duration // 最大耗时
+}
+
+// percentile 按最近秩法计算已排序耗时的百分位数
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	if rank > len(sorted) {
+		rank = len(sorted)
+	}
+	return sorted[rank-1]
+}
+
+// ComputeLatencyStats 计算耗时的最小值、中位数、P95和最大值
+func ComputeLatencyStats(durations []time.Duration, failed int) LatencyStats {
+	stats := LatencyStats{Samples: len(durations), Failed: failed}
+	if len(durations) == 0 {
+		return stats
+	}
+
+	sorted := append([]time.Duration{}, durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	stats.Min = sorted[0]
+	stats.Max = sorted[len(sorted)-1]
+	stats.P95 = percentile(sorted, 0.95)
+	if n := len(sorted); n%2 == 1 {
+		stats.Median = sorted[n/2]
+	} else {
+		stats.Median = (sorted[n/2-1] + sorted[n/2]) / 2
+	}
+	return stats
+}
+
+// MeasureLatency 向探测接口依次发送n次请求并记录耗时。
+// 得到HTTP响应的请求（包括4xx，例如读取不存在的笔记）都计入耗时，网络错误计为失败；探测不做重试。
+func (c *MowenClient) MeasureLatency(ctx context.Context, probe capabilityProbe, n int) ([]time.Duration, int) {
+	durations := make([]time.Duration, 0, n)
+	failed := 0
+	for i := 0; i < n; i++ {
+		start := time.Now()
+		_, err := c.makeRequest(ctx, "POST", probe.Endpoint, probe.Body)
+		elapsed := time.Since(start)
+
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			failed++
+			continue
+		}
+		durations = append(durations, elapsed)
+	}
+	return durations, failed
+}
+
+// latencyProbeNames 返回可用的探测接口名称
+func latencyProbeNames() []string {
+	names := make([]string, 0, len(latencyProbes))
+	for name := range latencyProbes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handleLatencyProbe 处理API延迟探测的MCP工具请求
+func (s *MowenMCPServer) handleLatencyProbe(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args LatencyProbeArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	samples := args.Samples
+	if samples == 0 {
+		samples = DefaultLatencySamples
+	}
+	if samples < 1 || samples > MaxLatencySamples {
+		return nil, fmt.Errorf("samples must be between 1 and %d", MaxLatencySamples)
+	}
+	name := args.Endpoint
+	if name == "" {
+		name = DefaultLatencyProbe
+	}
+	probe, ok := latencyProbes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown probe endpoint %q, available: %s", name, strings.Join(latencyProbeNames(), ", "))
+	}
+
+	durations, failed := s.mowenClient.MeasureLatency(ctx, probe, samples)
+	stats := ComputeLatencyStats(durations, failed)
+	if stats.Samples == 0 {
+		return textResult(fmt.Sprintf("延迟探测（%s）：%d 次请求均未得到响应，请检查网络连接", probe.Name, samples)), nil
+	}
+
+	round := func(d time.Duration) time.Duration { return d.Round(100 * time.Microsecond) }
+	responseText := fmt.Sprintf("延迟探测（%s，%s）：共 %d 次，成功 %d 次，失败 %d 次\n最小 %s，中位数 %s，P95 %s，最大 %s",
+		probe.Name, probe.Endpoint, samples, stats.Samples, stats.Failed,
+		round(stats.Min), round(stats.Median), round(stats.P95), round(stats.Max))
+	return textResult(responseText), nil
+}
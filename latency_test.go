@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestComputeLatencyStats 测试耗时统计
+func TestComputeLatencyStats(t *testing.T) {
+	ms := func(values ...int) []time.Duration {
+		durations := make([]time.Duration, 0, len(values))
+		for _, v := range values {
+			durations = append(durations, time.Duration(v)*time.Millisecond)
+		}
+		return durations
+	}
+
+	stats := ComputeLatencyStats(ms(30, 10, 50, 20, 40), 1)
+	assert.Equal(t, LatencyStats{Samples: 5, Failed: 1, Min: 10 * time.Millisecond, Median: 30 * time.Millisecond, P95: 50 * time.Millisecond, Max: 50 * time.Millisecond}, stats)
+
+	// 偶数个样本取中间两个的平均值，20个样本的P95为第19个
+	twenty := ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20)
+	stats = ComputeLatencyStats(twenty, 0)
+	assert.Equal(t, 10500*time.Microsecond, stats.Median)
+	assert.Equal(t, 19*time.Millisecond, stats.P95)
+
+	assert.Equal(t, LatencyStats{Failed: 3}, ComputeLatencyStats(nil, 3))
+}
+
+// TestHandleLatencyProbe 测试对注入延迟的模拟接口进行探测
+func (suite *ServerTestSuite) TestHandleLatencyProbe() {
+	delays := []time.Duration{20 * time.Millisecond, 5 * time.Millisecond, 10 * time.Millisecond}
+	calls := 0
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delays[calls%len(delays)])
+		calls++
+		// 不存在的笔记返回404同样计入耗时
+		w.WriteHeader(http.StatusNotFound)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleLatencyProbe, LatencyProbeArgs{Samples: 3, Endpoint: "detail"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, calls)
+	assert.Contains(suite.T(), text, "共 3 次，成功 3 次，失败 0 次")
+
+	durations, failed := suite.mcpServer.mowenClient.MeasureLatency(context.Background(), latencyProbes["detail"], 3)
+	stats := ComputeLatencyStats(durations, failed)
+	assert.GreaterOrEqual(suite.T(), stats.Min, 5*time.Millisecond)
+	assert.GreaterOrEqual(suite.T(), stats.Median, 10*time.Millisecond)
+	assert.GreaterOrEqual(suite.T(), stats.Max, 20*time.Millisecond)
+	assert.Less(suite.T(), stats.Min, 20*time.Millisecond)
+}
+
+// TestHandleLatencyProbeInvalidArgs 测试探测参数校验
+func (suite *ServerTestSuite) TestHandleLatencyProbeInvalidArgs() {
+	_, err := suite.callTool(suite.mcpServer.handleLatencyProbe, LatencyProbeArgs{Samples: MaxLatencySamples + 1})
+	assert.Error(suite.T(), err)
+
+	_, err = suite.callTool(suite.mcpServer.handleLatencyProbe, LatencyProbeArgs{Endpoint: "create"})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "available: detail, list, upload_status")
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+)
+
+// 并发超限时的处理方式
+const (
+	ConcurrencyModeQueue  = "queue"  // 排队等待空闲槽位
+	ConcurrencyModeReject = "reject" // 立即返回繁忙错误
+)
+
+// 工具调用优先级，由工具定义声明，未声明的工具均为interactive
+const (
+	PriorityInteractive = "interactive" // 交互式调用，优先获得执行槽位
+	PriorityBatch       = "batch"       // 批量调用，在没有交互式调用排队时才执行
+)
+
+// ErrServerBusy 并发工具调用数已达上限
+var ErrServerBusy = errors.New("server busy: too many concurrent tool calls, please retry later")
+
+// handlerLimiter 限制同时执行的工具处理器数量。
+// 槽位释放时优先交给排队中的交互式调用，其次是批量调用，同一优先级内先到先得。
+type handlerLimiter struct {
+	mu      sync.Mutex
+	max     int
+	inUse   int
+	reject  bool
+	waiters map[string][]chan struct{}
+}
+
+// newHandlerLimiter 创建并发限制器，maxConcurrency为0时表示不限制，返回nil
+func newHandlerLimiter(maxConcurrency int, mode string) (*handlerLimiter, error) {
+	switch mode {
+	case "", ConcurrencyModeQueue, ConcurrencyModeReject:
+	default:
+		return nil, fmt.Errorf("invalid MOWEN_CONCURRENCY_MODE value %q: must be %s or %s", mode, ConcurrencyModeQueue, ConcurrencyModeReject)
+	}
+	if maxConcurrency == 0 {
+		return nil, nil
+	}
+
+	return &handlerLimiter{
+		max:     maxConcurrency,
+		reject:  mode == ConcurrencyModeReject,
+		waiters: make(map[string][]chan struct{}),
+	}, nil
+}
+
+// acquire 以指定优先级获取一个执行槽位。排队模式下会等待直到分配到槽位或上下文结束。
+func (l *handlerLimiter) acquire(ctx context.Context, priority string) error {
+	if priority != PriorityBatch {
+		priority = PriorityInteractive
+	}
+
+	l.mu.Lock()
+	if l.inUse < l.max && l.queued() == 0 {
+		l.inUse++
+		l.mu.Unlock()
+		return nil
+	}
+	if l.reject {
+		l.mu.Unlock()
+		return ErrServerBusy
+	}
+	ready := make(chan struct{})
+	l.waiters[priority] = append(l.waiters[priority], ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if l.removeWaiter(priority, ready) {
+			return ctx.Err()
+		}
+		// 取消的同时已被分配槽位，转交给下一个等待者
+		l.releaseLocked()
+		return ctx.Err()
+	}
+}
+
+// release 释放一个执行槽位
+func (l *handlerLimiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.releaseLocked()
+}
+
+// releaseLocked 释放槽位，如有等待者则直接按优先级转交，调用方需持有锁
+func (l *handlerLimiter) releaseLocked() {
+	for _, priority := range []string{PriorityInteractive, PriorityBatch} {
+		if queue := l.waiters[priority]; len(queue) > 0 {
+			l.waiters[priority] = queue[1:]
+			close(queue[0])
+			return
+		}
+	}
+	l.inUse--
+}
+
+// queued 返回排队中的调用数，调用方需持有锁
+func (l *handlerLimiter) queued() int {
+	return len(l.waiters[PriorityInteractive]) + len(l.waiters[PriorityBatch])
+}
+
+// removeWaiter 从等待队列中移除指定等待者，返回是否仍在队列中，调用方需持有锁
+func (l *handlerLimiter) removeWaiter(priority string, ready chan struct{}) bool {
+	queue := l.waiters[priority]
+	for i, waiter := range queue {
+		if waiter == ready {
+			l.waiters[priority] = append(queue[:i], queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// middleware 返回限制工具处理器并发数的中间件，按priorityMiddleware标记的优先级排队
+func (l *handlerLimiter) middleware() server.ToolMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			if err := l.acquire(ctx, requestPriority(ctx)); err != nil {
+				return nil, err
+			}
+			defer l.release()
+			return next(ctx, req)
+		}
+	}
+}
+
+// requestPriorityKey 在context中携带工具调用优先级的键
+type requestPriorityKey struct{}
+
+// withRequestPriority 返回携带指定调用优先级的context
+func withRequestPriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, requestPriorityKey{}, priority)
+}
+
+// requestPriority 返回context中的调用优先级，未标记时为交互式优先级
+func requestPriority(ctx context.Context) string {
+	if priority, _ := ctx.Value(requestPriorityKey{}).(string); priority == PriorityBatch {
+		return PriorityBatch
+	}
+	return PriorityInteractive
+}
+
+// priorityMiddleware 返回按工具名称标记调用优先级的中间件，priorities中未列出的工具为交互式。
+// 需要先于并发限制中间件注册，标记的优先级同时用于墨问API请求限流的排队。
+func priorityMiddleware(priorities map[string]string) server.ToolMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			if priority, ok := priorities[req.Name]; ok {
+				ctx = withRequestPriority(ctx, priority)
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// requestThrottle 限制发往墨问API的请求频率，按固定间隔依次放行。
+// 排队时优先放行交互式调用发出的请求，其次是批量调用，同一优先级内先到先得。
+type requestThrottle struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+	waiters  map[string][]chan struct{}
+	timer    *time.Timer
+}
+
+// newRequestThrottle 创建每秒最多放行perSecond个请求的限流器，perSecond为0时表示不限制，返回nil
+func newRequestThrottle(perSecond int) *requestThrottle {
+	if perSecond == 0 {
+		return nil
+	}
+	return &requestThrottle{
+		interval: time.Second / time.Duration(perSecond),
+		waiters:  make(map[string][]chan struct{}),
+	}
+}
+
+// wait 阻塞直到允许发送下一个请求或上下文结束，排队优先级取自context。限流器为nil时立即返回
+func (t *requestThrottle) wait(ctx context.Context) error {
+	if t == nil {
+		return nil
+	}
+	priority := requestPriority(ctx)
+
+	t.mu.Lock()
+	now := time.Now()
+	if t.queued() == 0 && !now.Before(t.next) {
+		t.next = now.Add(t.interval)
+		t.mu.Unlock()
+		return nil
+	}
+	ready := make(chan struct{})
+	t.waiters[priority] = append(t.waiters[priority], ready)
+	if t.timer == nil {
+		t.timer = time.AfterFunc(t.next.Sub(now), t.dispatch)
+	}
+	t.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.removeWaiter(priority, ready)
+		return ctx.Err()
+	}
+}
+
+// dispatch 按优先级放行一个排队中的请求，仍有等待者时在一个间隔后再次放行
+func (t *requestThrottle) dispatch() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.timer = nil
+	for _, priority := range []string{PriorityInteractive, PriorityBatch} {
+		if queue := t.waiters[priority]; len(queue) > 0 {
+			t.waiters[priority] = queue[1:]
+			close(queue[0])
+			t.next = time.Now().Add(t.interval)
+			break
+		}
+	}
+	if t.queued() > 0 {
+		t.timer = time.AfterFunc(t.interval, t.dispatch)
+	}
+}
+
+// queued 返回排队中的请求数，调用方需持有锁
+func (t *requestThrottle) queued() int {
+	return len(t.waiters[PriorityInteractive]) + len(t.waiters[PriorityBatch])
+}
+
+// removeWaiter 从等待队列中移除指定等待者，调用方需持有锁
+func (t *requestThrottle) removeWaiter(priority string, ready chan struct{}) {
+	queue := t.waiters[priority]
+	for i, waiter := range queue {
+		if waiter == ready {
+			t.waiters[priority] = append(queue[:i], queue[i+1:]...)
+			return
+		}
+	}
+}
+
+// uploadSemaphore 限制同时进行的文件上传数，容量即并发上限，为nil时不限制
+type uploadSemaphore chan struct{}
+
+// newUploadSemaphore 创建最多允许max个上传同时进行的信号量，max为0时表示不限制，返回nil
+func newUploadSemaphore(max int) uploadSemaphore {
+	if max == 0 {
+		return nil
+	}
+	return make(uploadSemaphore, max)
+}
+
+// acquire 等待一个上传槽位，直到获得槽位或上下文结束
+func (s uploadSemaphore) acquire(ctx context.Context) error {
+	if s == nil {
+		return nil
+	}
+	select {
+	case s <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// release 释放一个上传槽位
+func (s uploadSemaphore) release() {
+	if s == nil {
+		return
+	}
+	<-s
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandlerLimiterEnforcesCap 测试并发调用时同时执行的处理器数量不超过上限
+func TestHandlerLimiterEnforcesCap(t *testing.T) {
+	limiter, err := newHandlerLimiter(2, ConcurrencyModeQueue)
+	require.NoError(t, err)
+
+	var running, maxRunning int32
+	handler := limiter.middleware()(func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			observed := atomic.LoadInt32(&maxRunning)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return textResult("ok"), nil
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := handler(context.Background(), &protocol.CallToolRequest{})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(2), maxRunning)
+}
+
+// TestHandlerLimiterReject 测试拒绝模式下超出上限的调用立即返回繁忙错误
+func TestHandlerLimiterReject(t *testing.T) {
+	limiter, err := newHandlerLimiter(1, ConcurrencyModeReject)
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	handler := limiter.middleware()(func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		close(started)
+		<-finish
+		return textResult("ok"), nil
+	})
+
+	go handler(context.Background(), &protocol.CallToolRequest{})
+	<-started
+
+	_, err = handler(context.Background(), &protocol.CallToolRequest{})
+	assert.ErrorIs(t, err, ErrServerBusy)
+	close(finish)
+}
+
+// TestHandlerLimiterQueueCancel 测试排队模式下上下文取消时停止等待
+func TestHandlerLimiterQueueCancel(t *testing.T) {
+	limiter, err := newHandlerLimiter(1, "")
+	require.NoError(t, err)
+	require.NoError(t, limiter.acquire(context.Background(), PriorityInteractive))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, limiter.acquire(ctx, PriorityInteractive), context.DeadlineExceeded)
+
+	// 取消的等待者已移出队列，释放后槽位可再次获取
+	limiter.release()
+	assert.NoError(t, limiter.acquire(context.Background(), PriorityBatch))
+}
+
+// TestHandlerLimiterPriority 测试交互式调用优先于排队中的批量调用执行
+func TestHandlerLimiterPriority(t *testing.T) {
+	limiter, err := newHandlerLimiter(1, ConcurrencyModeQueue)
+	require.NoError(t, err)
+
+	var mu sync.Mutex
+	var order []string
+	priorities := map[string]string{"bulk_tag_notes": PriorityBatch}
+	handler := priorityMiddleware(priorities)(limiter.middleware()(func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		mu.Lock()
+		order = append(order, string(req.RawArguments))
+		mu.Unlock()
+		return textResult("ok"), nil
+	}))
+
+	// 占用唯一的槽位
+	require.NoError(t, limiter.acquire(context.Background(), PriorityInteractive))
+
+	var wg sync.WaitGroup
+	call := func(name, args string) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := handler(context.Background(), &protocol.CallToolRequest{Name: name, RawArguments: []byte(args)})
+			assert.NoError(t, err)
+		}()
+	}
+	waitQueued := func(n int) {
+		require.Eventually(t, func() bool {
+			limiter.mu.Lock()
+			defer limiter.mu.Unlock()
+			return limiter.queued() == n
+		}, time.Second, time.Millisecond)
+	}
+
+	call("bulk_tag_notes", `{"n":1}`)
+	waitQueued(1)
+	call("bulk_tag_notes", `{"n":2}`)
+	waitQueued(2)
+	// 参数中的priority字段不影响优先级
+	call("get_note", `{"priority":"batch","n":3}`)
+	waitQueued(3)
+
+	limiter.release()
+	wg.Wait()
+
+	assert.Equal(t, []string{`{"priority":"batch","n":3}`, `{"n":1}`, `{"n":2}`}, order)
+}
+
+// TestPriorityMiddleware 测试按工具名称标记调用优先级，未列出的工具为交互式
+func TestPriorityMiddleware(t *testing.T) {
+	var got string
+	handler := priorityMiddleware(map[string]string{"cleanup_notes": PriorityBatch})(func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+		got = requestPriority(ctx)
+		return textResult("ok"), nil
+	})
+
+	_, err := handler(context.Background(), &protocol.CallToolRequest{Name: "cleanup_notes"})
+	require.NoError(t, err)
+	assert.Equal(t, PriorityBatch, got)
+
+	_, err = handler(context.Background(), &protocol.CallToolRequest{Name: "get_note"})
+	require.NoError(t, err)
+	assert.Equal(t, PriorityInteractive, got)
+	assert.Equal(t, PriorityInteractive, requestPriority(withRequestPriority(context.Background(), "unknown")))
+}
+
+// TestBatchToolsDeclarePriority 测试批量工具注册时声明批量优先级
+func (suite *ServerTestSuite) TestBatchToolsDeclarePriority() {
+	batchTools := []string{"export_notes_markdown", "import_markdown_dir", "bulk_tag_notes", "bulk_set_privacy", "cleanup_notes", "retry_batch"}
+	for _, name := range batchTools {
+		assert.Equal(suite.T(), PriorityBatch, suite.mcpServer.toolPriorities[name], name)
+	}
+	assert.Len(suite.T(), suite.mcpServer.toolPriorities, len(batchTools))
+}
+
+// TestNewHandlerLimiter 测试并发限制器配置
+func TestNewHandlerLimiter(t *testing.T) {
+	limiter, err := newHandlerLimiter(0, "")
+	assert.NoError(t, err)
+	assert.Nil(t, limiter)
+
+	_, err = newHandlerLimiter(2, "drop")
+	assert.Error(t, err)
+}
+
+// TestRequestThrottleSpacesRequests 测试请求限流器按固定间隔放行请求
+func TestRequestThrottleSpacesRequests(t *testing.T) {
+	assert.Nil(t, newRequestThrottle(0))
+	var disabled *requestThrottle
+	assert.NoError(t, disabled.wait(context.Background()))
+
+	throttle := newRequestThrottle(50) // 每20ms放行一个请求
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		require.NoError(t, throttle.wait(context.Background()))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond)
+}
+
+// TestRequestThrottlePriority 测试限流排队时交互式调用的请求先于批量调用放行
+func TestRequestThrottlePriority(t *testing.T) {
+	throttle := newRequestThrottle(20) // 每50ms放行一个请求
+	require.NoError(t, throttle.wait(context.Background()))
+
+	var mu sync.Mutex
+	var order []string
+	var wg sync.WaitGroup
+	wait := func(name, priority string, queued int) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, throttle.wait(withRequestPriority(context.Background(), priority)))
+			mu.Lock()
+			order = append(order, name)
+			mu.Unlock()
+		}()
+		require.Eventually(t, func() bool {
+			throttle.mu.Lock()
+			defer throttle.mu.Unlock()
+			return throttle.queued() == queued
+		}, time.Second, time.Millisecond)
+	}
+
+	wait("batch-1", PriorityBatch, 1)
+	wait("batch-2", PriorityBatch, 2)
+	wait("interactive", PriorityInteractive, 3)
+	wg.Wait()
+
+	assert.Equal(t, []string{"interactive", "batch-1", "batch-2"}, order)
+}
+
+// TestRequestThrottleCancel 测试限流排队时上下文取消后立即返回并移出队列
+func TestRequestThrottleCancel(t *testing.T) {
+	throttle := newRequestThrottle(1)
+	require.NoError(t, throttle.wait(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	assert.ErrorIs(t, throttle.wait(ctx), context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+
+	throttle.mu.Lock()
+	defer throttle.mu.Unlock()
+	assert.Equal(t, 0, throttle.queued())
+}
+
+// TestUploadConcurrencyCap 测试多个批量操作同时上传时，进行中的上传总数不超过全局上限
+func (suite *ServerTestSuite) TestUploadConcurrencyCap() {
+	suite.mcpServer.mowenClient.uploadSlots = newUploadSemaphore(2)
+
+	var running, maxRunning int32
+	suite.routes[UploadURLEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			observed := atomic.LoadInt32(&maxRunning)
+			if current <= observed || atomic.CompareAndSwapInt32(&maxRunning, observed, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		mockSuccess(map[string]interface{}{"uuid": "uploaded-uuid"})(w, r)
+	}
+
+	refs := []FileReference{
+		{UUID: "old-1", FileType: "image", URL: "https://example.com/1.png"},
+		{UUID: "old-2", FileType: "image", URL: "https://example.com/2.png"},
+		{UUID: "old-3", FileType: "pdf", URL: "https://example.com/3.pdf"},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			restored, unrestored := suite.mcpServer.restoreFileReferences(context.Background(), refs)
+			assert.Len(suite.T(), restored, len(refs))
+			assert.Empty(suite.T(), unrestored)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(suite.T(), int32(2), maxRunning)
+}
+
+// TestUploadSemaphoreCancel 测试等待上传槽位时上下文取消会返回错误，nil信号量不限制
+func TestUploadSemaphoreCancel(t *testing.T) {
+	slots := newUploadSemaphore(1)
+	require.NoError(t, slots.acquire(context.Background()))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, slots.acquire(ctx), context.DeadlineExceeded)
+
+	slots.release()
+	assert.NoError(t, slots.acquire(context.Background()))
+
+	var unlimited uploadSemaphore
+	assert.NoError(t, unlimited.acquire(context.Background()))
+	unlimited.release()
+	assert.Nil(t, newUploadSemaphore(0))
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileSourceLocal 文件段落的本地来源类型，source_path为本地文件路径时会在创建或编辑前自动上传
+const FileSourceLocal = "local"
+
+// dryRunUploadPrefix 试运行时代替本地文件UUID的占位符前缀
+const dryRunUploadPrefix = "dry-run-upload-"
+
+// localFilePath 判断文件段落是否引用本地文件，返回文件路径。
+// source_type为local且source_path指向存在的文件时才视为本地文件，已经上传得到的UUID保持不变。
+func localFilePath(para Paragraph) (string, bool) {
+	if para.Type != "file" || para.File == nil || para.File.SourceType != FileSourceLocal {
+		return "", false
+	}
+	info, err := os.Stat(para.File.SourcePath)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	return para.File.SourcePath, true
+}
+
+// resolveFileParagraphs 上传引用本地文件的文件段落，并把source_path替换为上传得到的UUID，返回段落副本。
+// 同一请求中相同的文件只上传一次；dryRun为true时不上传，用占位符代替UUID并给出提示，
+// 创建和编辑时先以试运行方式校验段落，再通过uploadLocalFiles上传。
+func (s *MowenMCPServer) resolveFileParagraphs(ctx context.Context, paragraphs []Paragraph, dryRun bool) ([]Paragraph, []ConversionWarning, error) {
+	var resolved []Paragraph
+	var warnings []ConversionWarning
+	uploaded := make(map[string]string)
+	for i, para := range paragraphs {
+		path, ok := localFilePath(para)
+		if !ok {
+			continue
+		}
+		if resolved == nil {
+			resolved = append([]Paragraph{}, paragraphs...)
+		}
+
+		key := path
+		if abs, err := filepath.Abs(path); err == nil {
+			key = abs
+		}
+		uuid, done := uploaded[key]
+		if !done {
+			fileType, known := fileTypeCodes[para.File.FileType]
+			if !known {
+				return nil, nil, fmt.Errorf("paragraph %d: cannot upload local file with file_type %q", i+1, para.File.FileType)
+			}
+			if dryRun {
+				uuid = fmt.Sprintf("%s%d", dryRunUploadPrefix, len(uploaded)+1)
+				warnings = append(warnings, ConversionWarning{Paragraph: i + 1, Message: fmt.Sprintf("本地文件 %s 将在提交时上传", path)})
+			} else {
+				result, err := s.mowenClient.UploadFile(ctx, path, fileType, filepath.Base(path))
+				if err != nil {
+					return nil, nil, fmt.Errorf("paragraph %d: failed to upload local file %s: %w", i+1, path, err)
+				}
+				if uuid = ParseUploadFileResult(result).UUID; uuid == "" {
+					return nil, nil, fmt.Errorf("paragraph %d: upload of local file %s returned no file UUID", i+1, path)
+				}
+			}
+			uploaded[key] = uuid
+		}
+
+		file := *para.File
+		file.SourcePath = uuid
+		resolved[i].File = &file
+	}
+	if resolved == nil {
+		return paragraphs, nil, nil
+	}
+	return resolved, warnings, nil
+}
+
+// uploadLocalFiles 上传段落中引用的本地文件并重新转换正文，没有本地文件时直接返回body。
+// 调用方需先用resolveFileParagraphs的试运行结果完成转换和其他校验，确认请求有效后再上传，
+// 避免校验失败的请求留下未被引用的上传文件。
+func (s *MowenMCPServer) uploadLocalFiles(ctx context.Context, paragraphs []Paragraph, body NoteAtom) (NoteAtom, error) {
+	hasLocal := false
+	for _, para := range paragraphs {
+		if _, ok := localFilePath(para); ok {
+			hasLocal = true
+			break
+		}
+	}
+	if !hasLocal {
+		return body, nil
+	}
+
+	resolved, _, err := s.resolveFileParagraphs(ctx, paragraphs, false)
+	if err != nil {
+		return NoteAtom{}, err
+	}
+	noteBody, _, err := ConvertParagraphsWithWarnings(resolved, s.convertOptions)
+	if err != nil {
+		return NoteAtom{}, fmt.Errorf("invalid paragraphs: %w", err)
+	}
+	return noteBody, nil
+}
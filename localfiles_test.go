@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleCreateNoteUploadsLocalFiles 测试创建笔记时自动上传本地文件，同一文件只上传一次，已有的UUID保持不变
+func (suite *ServerTestSuite) TestHandleCreateNoteUploadsLocalFiles() {
+	dir := suite.T().TempDir()
+	photo := filepath.Join(dir, "photo.png")
+	require.NoError(suite.T(), os.WriteFile(photo, []byte("png"), 0o644))
+
+	var prepared []UploadPrepareRequest
+	suite.routes[UploadPrepareEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var prepareReq UploadPrepareRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&prepareReq))
+		prepared = append(prepared, prepareReq)
+		suite.handleMockUploadPrepare(w, r)
+	}
+	suite.routes["/upload/dynamic"] = mockSuccess(map[string]interface{}{"uuid": "local-uuid-0001"})
+	var createReq NoteCreateRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&createReq))
+		mockSuccess(map[string]interface{}{"noteId": "note-local-1"})(w, r)
+	}
+
+	paragraphs := []Paragraph{
+		{Type: "file", File: &FileNode{FileType: "image", SourceType: FileSourceLocal, SourcePath: photo}},
+		{Type: "file", File: &FileNode{FileType: "image", SourceType: FileSourceLocal, SourcePath: filepath.Join(dir, ".", "photo.png")}},
+		{Type: "file", File: &FileNode{FileType: "pdf", SourceType: FileSourceLocal, SourcePath: "file-abcdef12"}},
+	}
+	text, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: paragraphs})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "ID: note-local-1")
+
+	assert.Equal(suite.T(), []UploadPrepareRequest{{FileType: 1, FileName: "photo.png"}}, prepared)
+	require.Len(suite.T(), createReq.Body.Content, 3)
+	assert.Equal(suite.T(), "local-uuid-0001", createReq.Body.Content[0].Attrs["uuid"])
+	assert.Equal(suite.T(), "local-uuid-0001", createReq.Body.Content[1].Attrs["uuid"])
+	assert.Equal(suite.T(), "file-abcdef12", createReq.Body.Content[2].Attrs["uuid"])
+	// 调用方的段落不被修改
+	assert.Equal(suite.T(), photo, paragraphs[0].File.SourcePath)
+}
+
+// TestHandleEditNoteLocalFileDryRun 测试试运行时不上传本地文件，只提示将要上传的文件
+func (suite *ServerTestSuite) TestHandleEditNoteLocalFileDryRun() {
+	dir := suite.T().TempDir()
+	audio := filepath.Join(dir, "voice.mp3")
+	require.NoError(suite.T(), os.WriteFile(audio, []byte("mp3"), 0o644))
+	suite.routes[UploadPrepareEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("dry run must not upload files")
+	}
+
+	args := EditNoteArgs{NoteID: "note-edit-1", DryRun: true, Paragraphs: []Paragraph{
+		{Type: "file", File: &FileNode{FileType: "audio", SourceType: FileSourceLocal, SourcePath: audio}},
+	}}
+	text, err := suite.callTool(suite.mcpServer.handleEditNote, args)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "试运行")
+	assert.Contains(suite.T(), text, "本地文件 "+audio+" 将在提交时上传")
+
+	// 无法上传的文件类型
+	args.DryRun = false
+	args.Paragraphs[0].File.FileType = "video"
+	_, err = suite.callTool(suite.mcpServer.handleEditNote, args)
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), `paragraph 1: cannot upload local file with file_type "video"`)
+}
+
+// TestLocalFilesUploadedAfterValidation 测试段落转换失败或创建配额用尽时不上传本地文件
+func (suite *ServerTestSuite) TestLocalFilesUploadedAfterValidation() {
+	photo := filepath.Join(suite.T().TempDir(), "photo.png")
+	require.NoError(suite.T(), os.WriteFile(photo, []byte("png"), 0o644))
+	suite.routes[UploadPrepareEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("invalid requests must not upload files")
+	}
+
+	local := Paragraph{Type: "file", File: &FileNode{FileType: "image", SourceType: FileSourceLocal, SourcePath: photo}}
+	invalid := []Paragraph{local, {Type: "note", NoteID: "TODO"}}
+	_, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: invalid})
+	assert.ErrorContains(suite.T(), err, "invalid paragraphs")
+	_, err = suite.callTool(suite.mcpServer.handleEditNote, EditNoteArgs{NoteID: "note-edit-1", Paragraphs: invalid})
+	assert.ErrorContains(suite.T(), err, "invalid paragraphs")
+
+	suite.mcpServer.noteQuota = newNoteQuota(1)
+	require.NoError(suite.T(), suite.mcpServer.noteQuota.reserve())
+	_, err = suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: []Paragraph{local}})
+	assert.Error(suite.T(), err)
+}
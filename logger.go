@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
+)
+
+// LogLevel 日志级别
+type LogLevel int
+
+// 日志级别，从低到高
+const (
+	LogLevelDebug LogLevel = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+// 日志组件名称，对应环境变量MOWEN_LOG_LEVEL_<组件名大写>
+const (
+	LogComponentClient = "client"
+	LogComponentServer = "server"
+)
+
+// logOutput 日志输出目标
+var logOutput io.Writer = os.Stderr
+
+var logLevelNames = map[LogLevel]string{
+	LogLevelDebug: "DEBUG",
+	LogLevelInfo:  "INFO",
+	LogLevelWarn:  "WARN",
+	LogLevelError: "ERROR",
+}
+
+// String 返回日志级别名称
+func (l LogLevel) String() string {
+	return logLevelNames[l]
+}
+
+// parseLogLevel 解析日志级别名称，不区分大小写
+func parseLogLevel(value string) (LogLevel, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return LogLevelDebug, nil
+	case "info":
+		return LogLevelInfo, nil
+	case "warn", "warning":
+		return LogLevelWarn, nil
+	case "error":
+		return LogLevelError, nil
+	}
+	return 0, fmt.Errorf("must be debug, info, warn or error")
+}
+
+// componentLogger 按组件过滤日志级别的日志记录器，为nil时丢弃所有日志
+type componentLogger struct {
+	component string
+	level     LogLevel
+	logger    *log.Logger
+}
+
+// newComponentLogger 创建指定组件的日志记录器。
+// 级别优先读取MOWEN_LOG_LEVEL_<组件名大写>，未设置时使用全局的MOWEN_LOG_LEVEL，默认为info。
+func newComponentLogger(component string) (*componentLogger, error) {
+	level := LogLevelInfo
+	for _, name := range []string{"MOWEN_LOG_LEVEL", "MOWEN_LOG_LEVEL_" + strings.ToUpper(component)} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseLogLevel(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: %w", name, value, err)
+		}
+		level = parsed
+	}
+
+	return &componentLogger{
+		component: component,
+		level:     level,
+		logger:    log.New(logOutput, "["+component+"] ", log.LstdFlags),
+	}, nil
+}
+
+// logf 在级别不低于配置级别时输出日志
+func (l *componentLogger) logf(level LogLevel, format string, args ...interface{}) {
+	if l == nil || level < l.level {
+		return
+	}
+	l.logger.Printf(level.String()+" "+format, args...)
+}
+
+// Debugf 输出调试日志
+func (l *componentLogger) Debugf(format string, args ...interface{}) {
+	l.logf(LogLevelDebug, format, args...)
+}
+
+// Infof 输出信息日志
+func (l *componentLogger) Infof(format string, args ...interface{}) {
+	l.logf(LogLevelInfo, format, args...)
+}
+
+// Warnf 输出警告日志
+func (l *componentLogger) Warnf(format string, args ...interface{}) {
+	l.logf(LogLevelWarn, format, args...)
+}
+
+// Errorf 输出错误日志
+func (l *componentLogger) Errorf(format string, args ...interface{}) {
+	l.logf(LogLevelError, format, args...)
+}
+
+// middleware 返回记录工具调用的中间件：调试级别记录每次调用，警告级别记录调用失败
+func (l *componentLogger) middleware() server.ToolMiddleware {
+	return func(next server.ToolHandlerFunc) server.ToolHandlerFunc {
+		return func(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+			l.Debugf("调用工具 %s", req.Name)
+			result, err := next(ctx, req)
+			if err != nil {
+				l.Warnf("工具 %s 调用失败: %v", req.Name, err)
+			}
+			return result, err
+		}
+	}
+}
+
+// redactedValue 调试日志中替换敏感字段值的占位符
+const redactedValue = "[REDACTED]"
+
+// sensitiveLogKeys 调试日志中需要脱敏的字段名，比较时忽略大小写、下划线和连字符
+var sensitiveLogKeys = map[string]bool{
+	"apikey":        true,
+	"token":         true,
+	"accesstoken":   true,
+	"secret":        true,
+	"password":      true,
+	"authorization": true,
+	"signature":     true,
+	"policy":        true,
+}
+
+// isSensitiveLogKey 判断字段名是否需要脱敏
+func isSensitiveLogKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	return sensitiveLogKeys[normalized]
+}
+
+// redactValue 递归替换敏感字段的值
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			if isSensitiveLogKey(key) {
+				v[key] = redactedValue
+				continue
+			}
+			v[key] = redactValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// maskKey 隐藏API密钥，只保留末4位，例如 ****c0de；不超过4个字符的密钥全部隐藏
+func maskKey(key string) string {
+	if key == "" {
+		return ""
+	}
+	runes := []rune(key)
+	if len(runes) <= 4 {
+		return "****"
+	}
+	return "****" + string(runes[len(runes)-4:])
+}
+
+// formatDebugBody 将JSON请求体或响应体脱敏并缩进，仅用于调试日志，不影响实际发送的内容。
+// 内容不是JSON时只输出长度，避免记录无法脱敏的数据。
+func formatDebugBody(body []byte) string {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return "(空)"
+	}
+
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return fmt.Sprintf("(非JSON内容，%d 字节)", len(body))
+	}
+
+	pretty, err := json.MarshalIndent(redactValue(value), "", "  ")
+	if err != nil {
+		return fmt.Sprintf("(无法格式化的内容，%d 字节)", len(body))
+	}
+	return string(pretty)
+}
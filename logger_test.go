@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestComponentLogLevels 测试按组件覆盖全局日志级别
+func TestComponentLogLevels(t *testing.T) {
+	var buf bytes.Buffer
+	original := logOutput
+	logOutput = &buf
+	defer func() { logOutput = original }()
+
+	os.Setenv("MOWEN_LOG_LEVEL", "info")
+	os.Setenv("MOWEN_LOG_LEVEL_CLIENT", "debug")
+	defer os.Unsetenv("MOWEN_LOG_LEVEL")
+	defer os.Unsetenv("MOWEN_LOG_LEVEL_CLIENT")
+
+	clientLogger, err := newComponentLogger(LogComponentClient)
+	require.NoError(t, err)
+	serverLogger, err := newComponentLogger(LogComponentServer)
+	require.NoError(t, err)
+
+	clientLogger.Debugf("client debug")
+	serverLogger.Debugf("server debug")
+	serverLogger.Infof("server info")
+
+	output := buf.String()
+	assert.Contains(t, output, "[client] ")
+	assert.Contains(t, output, "DEBUG client debug")
+	assert.NotContains(t, output, "server debug")
+	assert.Contains(t, output, "[server] ")
+	assert.Contains(t, output, "INFO server info")
+}
+
+// TestComponentLogLevelDefaults 测试日志级别默认值和非法配置
+func TestComponentLogLevelDefaults(t *testing.T) {
+	logger, err := newComponentLogger(LogComponentServer)
+	require.NoError(t, err)
+	assert.Equal(t, LogLevelInfo, logger.level)
+
+	os.Setenv("MOWEN_LOG_LEVEL", "WARN")
+	logger, err = newComponentLogger(LogComponentClient)
+	os.Unsetenv("MOWEN_LOG_LEVEL")
+	require.NoError(t, err)
+	assert.Equal(t, LogLevelWarn, logger.level)
+
+	os.Setenv("MOWEN_LOG_LEVEL_SERVER", "verbose")
+	_, err = newComponentLogger(LogComponentServer)
+	os.Unsetenv("MOWEN_LOG_LEVEL_SERVER")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "MOWEN_LOG_LEVEL_SERVER")
+
+	// nil日志记录器丢弃所有日志
+	var disabled *componentLogger
+	disabled.Errorf("ignored")
+}
+
+// TestClientDebugLogging 测试客户端在调试级别记录请求
+func (suite *ClientTestSuite) TestClientDebugLogging() {
+	var buf bytes.Buffer
+	original := logOutput
+	logOutput = &buf
+	defer func() { logOutput = original }()
+
+	os.Setenv("MOWEN_LOG_LEVEL_CLIENT", "debug")
+	defer os.Unsetenv("MOWEN_LOG_LEVEL_CLIENT")
+
+	client, err := NewMowenClient()
+	require.NoError(suite.T(), err)
+	client.baseURL = suite.testServer.URL
+
+	_, err = client.CreateNote(context.Background(), NoteCreateRequest{})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), buf.String(), "POST "+NoteCreateEndpoint+" -> 200")
+}
+
+// TestFormatDebugBody 测试调试日志中的请求体缩进并脱敏
+func TestFormatDebugBody(t *testing.T) {
+	body := []byte(`{"api_key":"secret-key","body":{"type":"doc","content":[{"type":"paragraph","attrs":{"Signature":"abc"}}]},"count":12345678901234567890}`)
+
+	formatted := formatDebugBody(body)
+	assert.Equal(t, `{
+  "api_key": "[REDACTED]",
+  "body": {
+    "content": [
+      {
+        "attrs": {
+          "Signature": "[REDACTED]"
+        },
+        "type": "paragraph"
+      }
+    ],
+    "type": "doc"
+  },
+  "count": 12345678901234567890
+}`, formatted)
+
+	assert.Equal(t, "(空)", formatDebugBody(nil))
+	assert.Equal(t, "(非JSON内容，9 字节)", formatDebugBody([]byte("token=abc")))
+}
+
+// TestClientDebugBodies 测试MOWEN_DEBUG开启时记录缩进、脱敏的请求体和响应体，实际发送的请求体保持紧凑
+func (suite *ClientTestSuite) TestClientDebugBodies() {
+	var buf bytes.Buffer
+	original := logOutput
+	logOutput = &buf
+	defer func() { logOutput = original }()
+
+	os.Setenv("MOWEN_DEBUG", "true")
+	defer os.Unsetenv("MOWEN_DEBUG")
+
+	var wireBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		wireBody = string(data)
+		w.Write([]byte(`{"code":0,"data":{"api_key":"new-secret-key"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewMowenClient()
+	require.NoError(suite.T(), err)
+	client.baseURL = server.URL
+
+	_, err = client.EditNote(context.Background(), NoteEditRequest{NoteID: "note-1", Body: NoteAtom{Type: "doc", Content: []NoteAtom{{Type: "paragraph"}}}})
+	require.NoError(suite.T(), err)
+
+	output := buf.String()
+	assert.Contains(suite.T(), output, "请求体:\n{\n  \"body\": {\n    \"content\": [")
+	assert.Contains(suite.T(), output, `"api_key": "[REDACTED]"`)
+	assert.NotContains(suite.T(), output, "new-secret-key")
+	assert.NotContains(suite.T(), wireBody, "\n")
+	assert.Contains(suite.T(), wireBody, `"noteId":"note-1"`)
+}
+
+// TestResetAPIKeyDebugBody 测试调试日志中重置接口响应的新密钥在所有可能的字段名下被隐藏
+func (suite *ClientTestSuite) TestResetAPIKeyDebugBody() {
+	var buf bytes.Buffer
+	original := logOutput
+	logOutput = &buf
+	defer func() { logOutput = original }()
+
+	os.Setenv("MOWEN_DEBUG", "true")
+	defer os.Unsetenv("MOWEN_DEBUG")
+
+	responses := []string{
+		`{"code":0,"data":{"key":"new-key-in-data"}}`,
+		`{"code":0,"data":{"apiKey":"new-camel-key"}}`,
+		`{"key":"new-top-level-key"}`,
+	}
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(responses[calls]))
+		calls++
+	}))
+	defer server.Close()
+
+	client, err := NewMowenClient()
+	require.NoError(suite.T(), err)
+	client.baseURL = server.URL
+
+	for range responses {
+		result, err := client.ResetAPIKey(context.Background())
+		require.NoError(suite.T(), err)
+		assert.NotEmpty(suite.T(), resetAPIKeyValue(result), "返回给调用方的结果不应脱敏")
+	}
+
+	output := buf.String()
+	assert.Equal(suite.T(), 3, strings.Count(output, `"[REDACTED]"`))
+	for _, key := range []string{"new-key-in-data", "new-camel-key", "new-top-level-key"} {
+		assert.NotContains(suite.T(), output, key)
+	}
+
+	// 其他接口响应中的key字段不受影响
+	assert.Contains(suite.T(), formatDebugBody([]byte(`{"form_data":{"key":"test-file-key"}}`)), "test-file-key")
+}
+
+// TestMaskKey 测试API密钥只保留末4位
+func TestMaskKey(t *testing.T) {
+	assert.Equal(t, "", maskKey(""))
+	assert.Equal(t, "****", maskKey("abcd"))
+	assert.Equal(t, "****-key", maskKey("test-api-key"))
+	assert.Equal(t, "****密钥末尾", maskKey("我的墨问密钥末尾"))
+}
+
+// TestMaskAPIKeyWholeValues 测试只隐藏作为独立取值出现的API密钥，包含密钥的其他单词保持不变
+func TestMaskAPIKeyWholeValues(t *testing.T) {
+	client := &MowenClient{apiKey: "ab12"}
+	assert.Equal(t, `{"api_key":"****","note":"tab12le","id":"ab12-x1"}`, client.maskAPIKey(`{"api_key":"ab12","note":"tab12le","id":"ab12-x1"}`))
+	assert.Equal(t, "Authorization: Bearer ****", client.maskAPIKey("Authorization: Bearer ab12"))
+	assert.Equal(t, "invalid key ****，请检查", client.maskAPIKey("invalid key ab12，请检查"))
+	assert.Equal(t, "密钥****无效", client.maskAPIKey("密钥ab12无效"))
+	assert.Equal(t, "no key here", (&MowenClient{}).maskAPIKey("no key here"))
+}
+
+// TestClientMasksAPIKeyInErrors 测试响应中回显的API密钥在错误信息和日志中被隐藏
+func (suite *ClientTestSuite) TestClientMasksAPIKeyInErrors() {
+	var buf bytes.Buffer
+	original := logOutput
+	logOutput = &buf
+	defer func() { logOutput = original }()
+
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprintf(w, `{"code":401,"message":"invalid key %s"}`, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+	}))
+	defer server.Close()
+	suite.client.baseURL = server.URL
+
+	_, err := suite.client.CreateNote(context.Background(), NoteCreateRequest{})
+	require.Error(suite.T(), err)
+	assert.NotContains(suite.T(), err.Error(), "test-api-key")
+	assert.Contains(suite.T(), err.Error(), "invalid key ****-key")
+
+	status = http.StatusOK
+	_, err = suite.client.CreateNote(context.Background(), NoteCreateRequest{})
+	require.Error(suite.T(), err)
+	assert.NotContains(suite.T(), err.Error(), "test-api-key")
+	assert.Contains(suite.T(), err.Error(), "invalid key ****-key")
+	assert.NotContains(suite.T(), buf.String(), "test-api-key")
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
@@ -29,11 +30,12 @@ func main() {
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
 
 	// 在goroutine中启动服务器
+	// stdio传输在标准输入关闭时正常返回，此时同样需要关闭服务器
 	go func() {
 		if err := server.Run(); err != nil {
 			log.Printf("服务器运行错误: %v", err)
-			cancel()
 		}
+		cancel()
 	}()
 
 	// 等待关闭信号
@@ -44,8 +46,10 @@ func main() {
 		log.Println("服务器上下文已取消")
 	}
 
-	// 优雅关闭服务器
-	if err := server.Shutdown(ctx); err != nil {
+	// 优雅关闭服务器，ctx此时可能已取消，关闭时使用单独的超时
+	shutdownCtx, stop := context.WithTimeout(context.Background(), 10*time.Second)
+	defer stop()
+	if err := server.Shutdown(shutdownCtx); err != nil {
 		log.Printf("关闭服务器时出错: %v", err)
 	} else {
 		log.Println("服务器已成功关闭")
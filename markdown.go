@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// MaxMarkdownFilenameLength 导出文件名（不含扩展名）的最大字符数
+const MaxMarkdownFilenameLength = 80
+
+// RenderNoteMarkdown 将笔记渲染为Markdown，开头的front matter记录笔记ID、标题、标签和时间。
+// 文件通过 mowen-file:<uuid> 引用，内链笔记渲染为笔记详情页链接。
+func RenderNoteMarkdown(detail *NoteDetail) string {
+	var sb strings.Builder
+	sb.WriteString("---\n")
+	fmt.Fprintf(&sb, "note_id: %s\n", strconv.Quote(detail.NoteID))
+	if detail.Title != "" {
+		fmt.Fprintf(&sb, "title: %s\n", strconv.Quote(detail.Title))
+	}
+	if len(detail.Tags) > 0 {
+		quoted := make([]string, 0, len(detail.Tags))
+		for _, tag := range detail.Tags {
+			quoted = append(quoted, strconv.Quote(tag))
+		}
+		fmt.Fprintf(&sb, "tags: [%s]\n", strings.Join(quoted, ", "))
+	}
+	if detail.CreatedAt != 0 {
+		fmt.Fprintf(&sb, "created_at: %s\n", time.Unix(detail.CreatedAt, 0).UTC().Format(time.RFC3339))
+	}
+	if detail.UpdatedAt != 0 {
+		fmt.Fprintf(&sb, "updated_at: %s\n", time.Unix(detail.UpdatedAt, 0).UTC().Format(time.RFC3339))
+	}
+	sb.WriteString("---\n")
+
+	for _, block := range detail.Body.Content {
+		sb.WriteString("\n")
+		sb.WriteString(renderMarkdownBlock(block))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// renderMarkdownBlock 将单个顶层段落节点渲染为Markdown
+func renderMarkdownBlock(block NoteAtom) string {
+	switch block.Type {
+	case "paragraph":
+		text := summarizeInline(block.Content)
+		if block.Attrs["blockquote"] == "true" {
+			return "> " + strings.ReplaceAll(text, "\n", "\n> ")
+		}
+		return text
+	case "note":
+		return fmt.Sprintf("[内链笔记](%s)", fmt.Sprintf(MowenNoteURLFormat, block.Attrs["uuid"]))
+	case "link_card":
+		return fmt.Sprintf("<%s>", block.Attrs["url"])
+	case "image":
+		return fmt.Sprintf("![%s](mowen-file:%s)", block.Attrs["alt"], block.Attrs["uuid"])
+	default:
+		if isFileAtomType(block.Type) {
+			label := block.Attrs["title"]
+			if label == "" {
+				label = block.Type
+			}
+			return fmt.Sprintf("[%s](mowen-file:%s)", label, block.Attrs["uuid"])
+		}
+		return summarizeBlock(block)
+	}
+}
+
+// markdownFilename 根据标题生成安全的文件名（不含扩展名），标题为空时使用笔记ID。
+// 路径分隔符、Windows保留字符和控制字符替换为下划线，首尾的空格和点会被去掉。
+func markdownFilename(title, noteID string) string {
+	var sb strings.Builder
+	count := 0
+	for _, r := range strings.TrimSpace(title) {
+		if count >= MaxMarkdownFilenameLength {
+			break
+		}
+		if unicode.IsControl(r) || strings.ContainsRune(`/\:*?"<>|`, r) {
+			r = '_'
+		}
+		sb.WriteRune(r)
+		count++
+	}
+
+	name := strings.Trim(sb.String(), " .")
+	if name == "" {
+		name = strings.Trim(noteID, " .")
+	}
+	if name == "" {
+		name = "note"
+	}
+	return name
+}
+
+// uniqueFilename 在本次导出已使用的文件名中避免重名，重名时依次追加 -2、-3……
+func uniqueFilename(name string, used map[string]bool) string {
+	candidate := name
+	for i := 2; used[strings.ToLower(candidate)]; i++ {
+		candidate = fmt.Sprintf("%s-%d", name, i)
+	}
+	used[strings.ToLower(candidate)] = true
+	return candidate
+}
+
+// handleExportNotesMarkdown 处理将全部笔记导出为Markdown文件的MCP工具请求。
+// 单篇笔记导出失败不会中断整个导出，最终返回各篇的处理结果和写入的文件。
+func (s *MowenMCPServer) handleExportNotesMarkdown(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args ExportNotesMarkdownArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	outputDir := strings.TrimSpace(args.OutputDir)
+	if outputDir == "" {
+		outputDir = os.Getenv("MOWEN_EXPORT_DIR")
+	}
+	if outputDir == "" {
+		return nil, fmt.Errorf("output_dir is required when MOWEN_EXPORT_DIR is not set")
+	}
+	if err := os.MkdirAll(outputDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	notes, err := s.searchAllNotes(ctx, NoteListRequest{Query: args.Query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list notes: %w", err)
+	}
+	if len(notes) == 0 {
+		return textResult("没有找到可导出的笔记"), nil
+	}
+
+	ids := make([]string, 0, len(notes))
+	titles := make(map[string]string, len(notes))
+	for _, note := range notes {
+		ids = append(ids, note.NoteID)
+		titles[note.NoteID] = note.Title
+	}
+
+	used := make(map[string]bool, len(notes))
+	var written []string
+	done := 0
+	results := runBatch(ids, false, func(id string) BatchItemResult {
+		done++
+		s.logger.Infof("导出笔记 %d/%d: %s", done, len(ids), id)
+
+		result, err := s.mowenClient.GetNote(ctx, id)
+		if err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		detail, err := ParseNoteDetail(result)
+		if err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		if detail.NoteID == "" {
+			detail.NoteID = id
+		}
+		title := detail.Title
+		if title == "" {
+			title = titles[id]
+		}
+
+		filename := uniqueFilename(markdownFilename(title, id), used) + ".md"
+		if err := os.WriteFile(filepath.Join(outputDir, filename), []byte(RenderNoteMarkdown(detail)), 0o644); err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		written = append(written, fmt.Sprintf("%s → %s", id, filename))
+		return BatchItemResult{Status: BatchItemSucceeded}
+	})
+
+	responseText := RenderBatchReport(fmt.Sprintf("导出笔记到 %s", outputDir), results)
+	if len(written) > 0 {
+		responseText += "\n\n写入的文件：\n- " + strings.Join(written, "\n- ")
+	}
+	return textResult(responseText), nil
+}
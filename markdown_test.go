@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRenderNoteMarkdown 测试笔记渲染为Markdown
+func TestRenderNoteMarkdown(t *testing.T) {
+	detail := &NoteDetail{
+		NoteID:    "note-1",
+		Title:     `周报 "第1周"`,
+		Tags:      []string{"工作", "周报"},
+		CreatedAt: 1700000000,
+		Body: mustConvert(t, []Paragraph{
+			{Texts: []TextNode{{Text: "普通"}, {Text: "加粗", Bold: true}, {Text: "链接", Link: "https://example.com"}}},
+			{Type: "quote", Texts: []TextNode{{Text: "引用"}}},
+			{Type: "heading", Level: 2, Texts: []TextNode{{Text: "小节"}}},
+			{Type: "note", NoteID: "linked-note-id"},
+			{Type: "file", File: &FileNode{FileType: "image", SourcePath: "image-uuid-1", Metadata: map[string]string{"alt": "封面"}}},
+			{Type: "file", File: &FileNode{FileType: "pdf", SourcePath: "pdf-uuid-1"}},
+			{Type: "link_card", URL: "https://example.com/card"},
+		}),
+	}
+
+	assert.Equal(t, `---
+note_id: "note-1"
+title: "周报 \"第1周\""
+tags: ["工作", "周报"]
+created_at: 2023-11-14T22:13:20Z
+---
+
+普通**加粗**[链接](https://example.com)
+
+> 引用
+
+## 小节
+
+[内链笔记](https://note.mowen.cn/detail/linked-note-id)
+
+![封面](mowen-file:image-uuid-1)
+
+[pdf](mowen-file:pdf-uuid-1)
+
+<https://example.com/card>
+`, RenderNoteMarkdown(detail))
+}
+
+// TestMarkdownFilename 测试由标题生成安全文件名和重名处理
+func TestMarkdownFilename(t *testing.T) {
+	assert.Equal(t, "a_b_c_ d", markdownFilename(`a/b\c: d`, "note-1"))
+	assert.Equal(t, "note-1", markdownFilename(" ..  ", "note-1"))
+	assert.Equal(t, "note", markdownFilename("", ""))
+	assert.Equal(t, "a_b", markdownFilename("a\nb", "note-1"))
+	assert.Len(t, []rune(markdownFilename(strings.Repeat("长", 100), "id")), MaxMarkdownFilenameLength)
+
+	used := map[string]bool{}
+	assert.Equal(t, "周报", uniqueFilename("周报", used))
+	assert.Equal(t, "周报-2", uniqueFilename("周报", used))
+	assert.Equal(t, "周报-3", uniqueFilename("周报", used))
+}
+
+// TestHandleExportNotesMarkdown 测试批量导出笔记到目录，重名标题生成不同文件
+func (suite *ServerTestSuite) TestHandleExportNotesMarkdown() {
+	suite.routes[NoteListEndpoint] = mockSuccess(map[string]interface{}{"notes": []map[string]interface{}{
+		{"noteId": "note-1", "title": "周报"},
+		{"noteId": "note-2", "title": "周报"},
+		{"noteId": "note-3"},
+		{"noteId": "missing"},
+	}})
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var req NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&req))
+		if req.NoteID == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mockSuccess(map[string]interface{}{
+			"noteId": req.NoteID,
+			"body": map[string]interface{}{"type": "doc", "content": []interface{}{
+				map[string]interface{}{"type": "paragraph", "content": []interface{}{map[string]interface{}{"type": "text", "text": "内容 " + req.NoteID}}},
+			}},
+		})(w, r)
+	}
+	dir := filepath.Join(suite.T().TempDir(), "backup")
+
+	text, err := suite.callTool(suite.mcpServer.handleExportNotesMarkdown, ExportNotesMarkdownArgs{OutputDir: dir})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 4 项，成功 3 项，跳过 0 项，失败 1 项")
+	assert.Contains(suite.T(), text, "- missing：")
+	assert.Contains(suite.T(), text, "note-2 → 周报-2.md")
+
+	expected := map[string]string{"周报.md": "note-1", "周报-2.md": "note-2", "note-3.md": "note-3"}
+	entries, err := os.ReadDir(dir)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), entries, len(expected))
+	for filename, noteID := range expected {
+		content, err := os.ReadFile(filepath.Join(dir, filename))
+		require.NoError(suite.T(), err, filename)
+		assert.Contains(suite.T(), string(content), `note_id: "`+noteID+`"`)
+		assert.Contains(suite.T(), string(content), "\n内容 "+noteID+"\n")
+	}
+}
+
+// TestHandleExportNotesMarkdownRequiresDir 测试未指定输出目录时报错
+func (suite *ServerTestSuite) TestHandleExportNotesMarkdownRequiresDir() {
+	_, err := suite.callTool(suite.mcpServer.handleExportNotesMarkdown, ExportNotesMarkdownArgs{})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "MOWEN_EXPORT_DIR")
+}
@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// MarkdownNote 从Markdown文件解析出的笔记
+type MarkdownNote struct {
+	Title       string            // front matter中的title，未设置时为空
+	Tags        []string          // front matter中的tags
+	AutoPublish *bool             // front matter中的auto_publish，未设置时为nil
+	PrivacyType string            // front matter中的privacy_type（public/private），未设置时为空
+	Metadata    map[string]string // front matter中其他无法对应到笔记设置的字段，不会提交给墨问
+	Warnings    []string          // front matter格式错误或字段值无效时的提示，对应内容已被忽略
+	Paragraphs  []Paragraph       // 正文段落
+}
+
+// 墨问笔记没有表格，Markdown表格的转换方式，通过MOWEN_MARKDOWN_TABLES选择
+const (
+	// MarkdownTableCode 保留表格原文，整体作为行内代码，与代码块的处理方式相同
+	MarkdownTableCode = "code"
+	// MarkdownTableText 去掉分隔行后逐行输出单元格，单元格之间以 " | " 分隔，表头加粗，单元格中的行内标记照常解析
+	MarkdownTableText = "text"
+)
+
+// MarkdownOptions Markdown解析选项
+type MarkdownOptions struct {
+	// TableFallback 表格的转换方式，为空时使用MarkdownTableCode
+	TableFallback string
+}
+
+// markdownTableDelimiterPattern 表格表头下的分隔行，例如 "| --- | :---: |"
+var markdownTableDelimiterPattern = regexp.MustCompile(`^\|?\s*:?-+:?\s*(\|\s*:?-+:?\s*)*\|?$`)
+
+// markdownHeadingPattern ATX标题，例如 "## 小节"
+var markdownHeadingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)(?:\s+#+)?\s*$`)
+
+// markdownLinkCardPattern 单独成段的 <https://...> 自动链接，转换为链接卡片
+var markdownLinkCardPattern = regexp.MustCompile(`^<(https?://\S+)>$`)
+
+// ParseMarkdownNote 解析Markdown文本，开头的front matter提供标题、标签、是否发布和隐私类型。
+// 支持标题、引用、代码块、<链接> 卡片以及 **加粗**、*斜体*、~~删除线~~、`代码`、==高亮==、[文本](链接) 等行内标记，
+// 与export_notes_markdown的输出格式对应；其余内容按普通段落保留原文。
+func ParseMarkdownNote(text string) MarkdownNote {
+	return ParseMarkdownNoteWithOptions(text, MarkdownOptions{})
+}
+
+// ParseMarkdownNoteWithOptions 按指定选项解析Markdown文本，表格转换的提示加入Warnings
+func ParseMarkdownNoteWithOptions(text string, opts MarkdownOptions) MarkdownNote {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	var note MarkdownNote
+	fields, body, warning := splitFrontMatter(text)
+	if warning != "" {
+		note.Warnings = append(note.Warnings, warning)
+	}
+	for _, key := range sortedKeys(fields) {
+		value := fields[key]
+		switch key {
+		case "title":
+			note.Title = frontMatterScalar(value)
+		case "tags":
+			note.Tags = frontMatterList(value)
+		case "auto_publish":
+			autoPublish, err := strconv.ParseBool(frontMatterScalar(value))
+			if err != nil {
+				note.Warnings = append(note.Warnings, fmt.Sprintf("front matter中auto_publish的值 %q 无效，应为true或false，已忽略", value))
+				continue
+			}
+			note.AutoPublish = &autoPublish
+		case "privacy_type", "privacy":
+			privacy := strings.ToLower(frontMatterScalar(value))
+			if privacy != "public" && privacy != "private" {
+				note.Warnings = append(note.Warnings, fmt.Sprintf("front matter中%s的值 %q 无效，仅支持public或private，已忽略", key, value))
+				continue
+			}
+			note.PrivacyType = privacy
+		default:
+			if note.Metadata == nil {
+				note.Metadata = make(map[string]string)
+			}
+			note.Metadata[key] = frontMatterScalar(value)
+		}
+	}
+	paragraphs, warnings := markdownToParagraphs(body, opts)
+	note.Paragraphs = paragraphs
+	note.Warnings = append(note.Warnings, warnings...)
+	return note
+}
+
+// splitFrontMatter 拆分开头以 --- 包围的front matter，返回其中的字段和之后的正文。
+// 字段值保留原文，列表写成多行 "- 值" 时以换行拼接，缩进的嵌套内容会被忽略。
+// front matter未闭合时全文按正文处理；存在无法解析的行时忽略整个front matter，两种情况都返回提示。
+func splitFrontMatter(text string) (map[string]string, string, string) {
+	rest, ok := strings.CutPrefix(text, "---")
+	if !ok || !strings.HasPrefix(rest, "\n") {
+		return nil, text, ""
+	}
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return nil, text, "front matter缺少结束的 ---，已按正文处理"
+	}
+	body := rest[end+len("\n---"):]
+	if i := strings.IndexByte(body, '\n'); i >= 0 {
+		body = body[i+1:]
+	} else {
+		body = ""
+	}
+
+	fields := make(map[string]string)
+	key := ""
+	for i, line := range strings.Split(rest[:end], "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if item, isItem := strings.CutPrefix(trimmed, "- "); isItem && key != "" {
+			fields[key] = strings.TrimPrefix(fields[key]+"\n"+item, "\n")
+			continue
+		}
+		if line != strings.TrimLeft(line, " \t") && key != "" {
+			continue
+		}
+		name, value, found := strings.Cut(line, ":")
+		if !found || strings.TrimSpace(name) == "" || line != strings.TrimLeft(line, " \t") {
+			// rest以开头 --- 后的换行开始，i恰好是该行在文件中的行号减一
+			return nil, body, fmt.Sprintf("front matter第 %d 行 %q 无法解析，已忽略front matter", i+1, trimmed)
+		}
+		key = strings.ToLower(strings.TrimSpace(name))
+		fields[key] = strings.TrimSpace(value)
+	}
+	return fields, body, ""
+}
+
+// frontMatterScalar 去除front matter字段值两侧的引号
+func frontMatterScalar(value string) string {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			return unquoted
+		}
+	}
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// frontMatterList 解析front matter中的列表，支持 [a, "b"]、多行 "- a" 以及单个值
+func frontMatterList(value string) []string {
+	value = strings.TrimSpace(value)
+	var items []string
+	switch {
+	case strings.HasPrefix(value, "[") && strings.HasSuffix(value, "]"):
+		items = strings.Split(value[1:len(value)-1], ",")
+	case strings.Contains(value, "\n"):
+		items = strings.Split(value, "\n")
+	default:
+		items = []string{value}
+	}
+
+	var result []string
+	for _, item := range items {
+		if item = frontMatterScalar(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// MarkdownToParagraphs 将Markdown正文转换为段落列表，空行分隔段落，段落内的换行保留在文本中
+func MarkdownToParagraphs(text string) []Paragraph {
+	paragraphs, _ := markdownToParagraphs(text, MarkdownOptions{})
+	return paragraphs
+}
+
+// markdownToParagraphs 将Markdown正文转换为段落列表，同时返回表格等无法直接对应的内容的转换提示
+func markdownToParagraphs(text string, opts MarkdownOptions) ([]Paragraph, []string) {
+	var paragraphs []Paragraph
+	var warnings []string
+	kind := ""
+	var lines []string
+	flush := func() {
+		if len(lines) > 0 {
+			paragraphs = append(paragraphs, Paragraph{Type: kind, Texts: parseInlineMarkdown(strings.Join(lines, "\n"), TextNode{})})
+		}
+		kind, lines = "", nil
+	}
+
+	all := strings.Split(text, "\n")
+	for i := 0; i < len(all); i++ {
+		line := strings.TrimRight(all[i], " \t")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			flush()
+		case strings.HasPrefix(trimmed, "```"):
+			// 代码块没有对应的段落类型，整体作为行内代码保留
+			flush()
+			var code []string
+			for i++; i < len(all) && !strings.HasPrefix(strings.TrimSpace(all[i]), "```"); i++ {
+				code = append(code, all[i])
+			}
+			if len(code) > 0 {
+				paragraphs = append(paragraphs, Paragraph{Texts: []TextNode{{Text: strings.Join(code, "\n"), InlineCode: true}}})
+			}
+		case markdownHeadingPattern.MatchString(trimmed):
+			flush()
+			match := markdownHeadingPattern.FindStringSubmatch(trimmed)
+			if texts := parseInlineMarkdown(match[2], TextNode{}); len(texts) > 0 {
+				level := len(match[1])
+				if level > MaxHeadingLevel {
+					level = MaxHeadingLevel
+				}
+				paragraphs = append(paragraphs, Paragraph{Type: "heading", Level: level, Texts: texts})
+			}
+		case strings.HasPrefix(trimmed, ">"):
+			if kind != "quote" {
+				flush()
+				kind = "quote"
+			}
+			quoted := strings.TrimPrefix(trimmed, ">")
+			lines = append(lines, strings.TrimPrefix(quoted, " "))
+		case len(lines) == 0 && strings.Contains(trimmed, "|") && i+1 < len(all) && isMarkdownTableDelimiter(all[i+1]):
+			start := i + 1
+			rows := []string{trimmed, strings.TrimSpace(all[i+1])}
+			for i += 2; i < len(all) && strings.Contains(all[i], "|") && strings.TrimSpace(all[i]) != ""; i++ {
+				rows = append(rows, strings.TrimSpace(all[i]))
+			}
+			i--
+			para, warning := markdownTableFallback(rows, opts.TableFallback)
+			paragraphs = append(paragraphs, para)
+			warnings = append(warnings, fmt.Sprintf("第%d行的表格不受支持，%s", start, warning))
+		case len(lines) == 0 && markdownLinkCardPattern.MatchString(trimmed):
+			paragraphs = append(paragraphs, Paragraph{Type: "link_card", URL: markdownLinkCardPattern.FindStringSubmatch(trimmed)[1]})
+		default:
+			if kind == "quote" {
+				flush()
+			}
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return paragraphs, warnings
+}
+
+// isMarkdownTableDelimiter 判断是否为表格的分隔行，至少包含一个竖线以免把 --- 分割线当成表格
+func isMarkdownTableDelimiter(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.Contains(trimmed, "|") && markdownTableDelimiterPattern.MatchString(trimmed)
+}
+
+// splitMarkdownTableRow 拆分表格行的单元格，去掉首尾的竖线，\| 按普通竖线保留
+func splitMarkdownTableRow(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	var cells []string
+	var cell strings.Builder
+	for i := 0; i < len(row); i++ {
+		switch {
+		case row[i] == '\\' && i+1 < len(row) && row[i+1] == '|':
+			cell.WriteByte('|')
+			i++
+		case row[i] == '|':
+			cells = append(cells, strings.TrimSpace(cell.String()))
+			cell.Reset()
+		default:
+			cell.WriteByte(row[i])
+		}
+	}
+	return append(cells, strings.TrimSpace(cell.String()))
+}
+
+// markdownTableFallback 按转换方式把表格的各行（依次为表头、分隔行和数据行）转换为段落，返回段落和转换方式的说明
+func markdownTableFallback(rows []string, mode string) (Paragraph, string) {
+	if mode != MarkdownTableText {
+		return Paragraph{Texts: []TextNode{{Text: strings.Join(rows, "\n"), InlineCode: true}}}, "已保留原文转换为代码"
+	}
+
+	var texts []TextNode
+	for i, row := range rows {
+		if i == 1 {
+			continue
+		}
+		if i > 0 {
+			texts = appendMerged(texts, TextNode{Text: "\n"})
+		}
+		style := TextNode{Bold: i == 0}
+		for j, cell := range splitMarkdownTableRow(row) {
+			if j > 0 {
+				texts = appendMerged(texts, TextNode{Text: " | ", Bold: style.Bold})
+			}
+			for _, node := range parseInlineMarkdown(cell, style) {
+				texts = appendMerged(texts, node)
+			}
+		}
+	}
+	return Paragraph{Texts: texts}, "已转换为纯文本"
+}
+
+// markdownDelimiters 成对出现的行内标记，较长的标记在前以免被较短的提前匹配
+var markdownDelimiters = []struct {
+	marker string
+	apply  func(node *TextNode)
+}{
+	{"***", func(node *TextNode) { node.Bold, node.Italic = true, true }},
+	{"**", func(node *TextNode) { node.Bold = true }},
+	{"~~", func(node *TextNode) { node.Strikethrough = true }},
+	{"==", func(node *TextNode) { node.Highlight = true }},
+	{"*", func(node *TextNode) { node.Italic = true }},
+}
+
+// parseInlineMarkdown 将一段Markdown行内文本解析为文本节点，style为外层标记。
+// 行内代码中的内容不再解析；没有闭合的标记按原文保留，反斜杠可转义标记符号。
+func parseInlineMarkdown(text string, style TextNode) []TextNode {
+	var nodes []TextNode
+	var plain strings.Builder
+	flush := func() {
+		if plain.Len() > 0 {
+			node := style
+			node.Text = plain.String()
+			nodes = append(nodes, node)
+			plain.Reset()
+		}
+	}
+
+	for i := 0; i < len(text); {
+		rest := text[i:]
+		if rest[0] == '\\' && len(rest) > 1 && strings.ContainsRune("\\`*~=[]()<>#_", rune(rest[1])) {
+			plain.WriteByte(rest[1])
+			i += 2
+			continue
+		}
+		if rest[0] == '`' {
+			if end := strings.IndexByte(rest[1:], '`'); end > 0 {
+				flush()
+				node := style
+				node.Text = rest[1 : 1+end]
+				node.InlineCode = true
+				nodes = append(nodes, node)
+				i += end + 2
+				continue
+			}
+		}
+		if rest[0] == '[' {
+			if label, href, n, ok := parseMarkdownLink(rest); ok {
+				flush()
+				linked := style
+				linked.Link = href
+				nodes = append(nodes, parseInlineMarkdown(label, linked)...)
+				i += n
+				continue
+			}
+		}
+
+		matched := false
+		for _, d := range markdownDelimiters {
+			if !strings.HasPrefix(rest, d.marker) {
+				continue
+			}
+			inner := rest[len(d.marker):]
+			end := indexUnescaped(inner, d.marker)
+			if end <= 0 {
+				continue
+			}
+			flush()
+			marked := style
+			d.apply(&marked)
+			nodes = append(nodes, parseInlineMarkdown(inner[:end], marked)...)
+			i += 2*len(d.marker) + end
+			matched = true
+			break
+		}
+		if matched {
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		plain.WriteRune(r)
+		i += size
+	}
+	flush()
+	return nodes
+}
+
+// indexUnescaped 返回marker在text中首次出现且未被反斜杠转义的位置，没有时返回-1
+func indexUnescaped(text, marker string) int {
+	for i := 0; i < len(text); i++ {
+		if text[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(text[i:], marker) {
+			return i
+		}
+	}
+	return -1
+}
+
+// parseMarkdownLink 解析以 [ 开头的 [文本](链接)，返回文本、链接和消耗的字节数
+func parseMarkdownLink(text string) (string, string, int, bool) {
+	closeLabel := strings.Index(text, "](")
+	if closeLabel < 1 {
+		return "", "", 0, false
+	}
+	closeURL := strings.IndexByte(text[closeLabel+2:], ')')
+	if closeURL < 1 {
+		return "", "", 0, false
+	}
+	href := text[closeLabel+2 : closeLabel+2+closeURL]
+	if strings.ContainsAny(href, " \n") {
+		return "", "", 0, false
+	}
+	return text[1:closeLabel], href, closeLabel + 3 + closeURL, true
+}
+
+// markdownNoteParagraphs 返回创建笔记使用的段落：正文前插入标题段落，正文已以同名一级标题开头时不重复插入
+func markdownNoteParagraphs(title string, paragraphs []Paragraph) []Paragraph {
+	if len(paragraphs) > 0 && paragraphs[0].Type == "heading" && headingLevel(paragraphs[0].Level) == 1 &&
+		strings.TrimSpace(paragraphsPlainText(paragraphs[:1])) == title {
+		return paragraphs
+	}
+	heading := Paragraph{Type: "heading", Level: 1, Texts: []TextNode{{Text: title}}}
+	return append([]Paragraph{heading}, paragraphs...)
+}
+
+// createMarkdownNote 根据Markdown文件创建笔记，返回笔记ID和需要提示的问题。
+// 标题取front matter中的title，未设置时使用去掉扩展名的文件名；front matter中的标签与extraTags合并，
+// auto_publish优先于autoPublish，设置了privacy_type时在创建后修改隐私设置，修改失败只作为提示返回。
+func (s *MowenMCPServer) createMarkdownNote(ctx context.Context, filename, text string, extraTags []string, autoPublish bool) (string, []string, error) {
+	note := ParseMarkdownNoteWithOptions(text, s.markdownOptions)
+	for _, warning := range note.Warnings {
+		s.logger.Warnf("Markdown文件 %s：%s", filename, warning)
+	}
+	title := strings.TrimSpace(note.Title)
+	if title == "" {
+		title = strings.TrimSuffix(filename, filepath.Ext(filename))
+	}
+	paragraphs := markdownNoteParagraphs(title, note.Paragraphs)
+	if note.AutoPublish != nil {
+		autoPublish = *note.AutoPublish
+	}
+
+	noteBody, err := ConvertParagraphsToNoteAtomWithOptions(paragraphs, s.convertOptions)
+	if err != nil {
+		return "", note.Warnings, fmt.Errorf("invalid paragraphs: %w", err)
+	}
+	tags := dedupeTags(append(append([]string{}, note.Tags...), extraTags...))
+	createReq := NoteCreateRequest{
+		Body: noteBody,
+		Settings: NoteCreateRequestSettings{
+			AutoPublish: autoPublish,
+			Tags:        applyAutoTags(tags, paragraphs, s.autoTagRules),
+		},
+	}
+
+	if err := s.noteQuota.reserve(); err != nil {
+		return "", note.Warnings, err
+	}
+	result, err := s.mowenClient.CreateNote(ctx, createReq)
+	if err != nil {
+		s.noteQuota.release()
+		return "", note.Warnings, fmt.Errorf("failed to create note: %w", err)
+	}
+	s.tagCache.invalidate()
+	noteID := s.extractNoteID(result)
+
+	warnings := note.Warnings
+	if note.PrivacyType != "" && noteID != "" {
+		setReq := NoteSetRequest{
+			NoteID:   noteID,
+			Section:  SectionPrivacy,
+			Settings: &NoteSettings{Privacy: &NotePrivacySet{Type: note.PrivacyType}},
+		}
+		if _, err := s.mowenClient.SetNotePrivacy(ctx, setReq); err != nil {
+			warnings = append(warnings, fmt.Sprintf("隐私设置失败：%v", err))
+		}
+	}
+	return noteID, warnings, nil
+}
+
+// handleImportMarkdownDir 处理从目录中的Markdown文件批量创建笔记的MCP工具请求。
+// 按文件名顺序处理目录下（不含子目录）所有.md文件，单个文件失败不影响其他文件，最终返回各文件的处理结果。
+func (s *MowenMCPServer) handleImportMarkdownDir(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args ImportMarkdownDirArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	dir := strings.TrimSpace(args.Dir)
+	if dir == "" {
+		return nil, fmt.Errorf("dir is required")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory: %w", err)
+	}
+	var files []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.EqualFold(filepath.Ext(entry.Name()), ".md") {
+			files = append(files, entry.Name())
+		}
+	}
+	if len(files) == 0 {
+		return textResult(fmt.Sprintf("目录 %s 中没有Markdown文件", dir)), nil
+	}
+
+	var created, notices []string
+	results := runBatch(files, args.StopOnError, func(name string) BatchItemResult {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		noteID, warnings, err := s.createMarkdownNote(ctx, name, string(data), args.Tags, args.AutoPublish)
+		for _, warning := range warnings {
+			notices = append(notices, fmt.Sprintf("%s：%s", name, warning))
+		}
+		if err != nil {
+			return BatchItemResult{Status: BatchItemFailed, Reason: err.Error()}
+		}
+		created = append(created, fmt.Sprintf("%s → %s", name, noteID))
+		return BatchItemResult{Status: BatchItemSucceeded}
+	})
+
+	responseText := RenderBatchReport(fmt.Sprintf("从 %s 导入Markdown文件", dir), results)
+	if len(created) > 0 {
+		responseText += "\n\n创建的笔记：\n- " + strings.Join(created, "\n- ")
+	}
+	if len(notices) > 0 {
+		responseText += "\n\n导入提示：\n- " + strings.Join(notices, "\n- ")
+	}
+	return textResult(responseText), nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseMarkdownNote 测试解析front matter、段落类型和行内标记
+func TestParseMarkdownNote(t *testing.T) {
+	note := ParseMarkdownNote("---\r\n" +
+		"title: \"周报 第1周\"\r\n" +
+		"tags: [\"工作\", 周报]\r\n" +
+		"---\r\n" +
+		"\r\n" +
+		"## 本周进展\r\n" +
+		"\r\n" +
+		"完成了**接口**和*文档*，\n修复~~旧~~`bug`，见[说明](https://example.com)。\n" +
+		"\n" +
+		"> 引用第一行\n> 引用第二行\n" +
+		"\n" +
+		"<https://example.com/card>\n" +
+		"\n" +
+		"```\nfmt.Println(\"**不解析**\")\n```\n" +
+		"\n" +
+		"没有闭合的*星号和 \\*转义\\*\n")
+
+	assert.Equal(t, "周报 第1周", note.Title)
+	assert.Equal(t, []string{"工作", "周报"}, note.Tags)
+	assert.Equal(t, []Paragraph{
+		{Type: "heading", Level: 2, Texts: []TextNode{{Text: "本周进展"}}},
+		{Texts: []TextNode{
+			{Text: "完成了"},
+			{Text: "接口", Bold: true},
+			{Text: "和"},
+			{Text: "文档", Italic: true},
+			{Text: "，\n修复"},
+			{Text: "旧", Strikethrough: true},
+			{Text: "bug", InlineCode: true},
+			{Text: "，见"},
+			{Text: "说明", Link: "https://example.com"},
+			{Text: "。"},
+		}},
+		{Type: "quote", Texts: []TextNode{{Text: "引用第一行\n引用第二行"}}},
+		{Type: "link_card", URL: "https://example.com/card"},
+		{Texts: []TextNode{{Text: `fmt.Println("**不解析**")`, InlineCode: true}}},
+		{Texts: []TextNode{{Text: "没有闭合的*星号和 *转义*"}}},
+	}, note.Paragraphs)
+
+	// 多行列表形式的标签，没有front matter时全文都是正文
+	note = ParseMarkdownNote("---\ntags:\n  - 读书\n  - '笔记'\n---\n正文")
+	assert.Equal(t, []string{"读书", "笔记"}, note.Tags)
+	assert.Equal(t, []Paragraph{{Texts: []TextNode{{Text: "正文"}}}}, note.Paragraphs)
+
+	note = ParseMarkdownNote("--- 不是front matter\n正文")
+	assert.Empty(t, note.Tags)
+	assert.Len(t, note.Paragraphs, 1)
+}
+
+// TestParseMarkdownNoteSettings 测试front matter中的发布和隐私设置，其他字段作为元数据保留
+func TestParseMarkdownNoteSettings(t *testing.T) {
+	note := ParseMarkdownNote("---\n" +
+		"title: 公开笔记\n" +
+		"tags: [分享]\n" +
+		"auto_publish: true\n" +
+		"privacy_type: Public\n" +
+		"author: '张三'\n" +
+		"date: 2024-05-01\n" +
+		"extra:\n  nested: 忽略\n" +
+		"---\n正文")
+	assert.Equal(t, "公开笔记", note.Title)
+	assert.Equal(t, []string{"分享"}, note.Tags)
+	require.NotNil(t, note.AutoPublish)
+	assert.True(t, *note.AutoPublish)
+	assert.Equal(t, "public", note.PrivacyType)
+	assert.Equal(t, map[string]string{"author": "张三", "date": "2024-05-01", "extra": ""}, note.Metadata)
+	assert.Empty(t, note.Warnings)
+
+	// 无效的字段值被忽略并给出提示，其他字段仍然生效
+	note = ParseMarkdownNote("---\ntags: 读书\nauto_publish: 也许\nprivacy: rule\n---\n正文")
+	assert.Equal(t, []string{"读书"}, note.Tags)
+	assert.Nil(t, note.AutoPublish)
+	assert.Empty(t, note.PrivacyType)
+	require.Len(t, note.Warnings, 2)
+	assert.Contains(t, note.Warnings[0], "auto_publish")
+	assert.Contains(t, note.Warnings[1], "privacy")
+}
+
+// TestParseMarkdownNoteMalformedFrontMatter 测试格式错误的front matter被忽略并给出提示
+func TestParseMarkdownNoteMalformedFrontMatter(t *testing.T) {
+	note := ParseMarkdownNote("---\ntitle: 标题\n这一行不是字段\ntags: [a]\n---\n正文")
+	assert.Empty(t, note.Title)
+	assert.Empty(t, note.Tags)
+	assert.Equal(t, []string{`front matter第 3 行 "这一行不是字段" 无法解析，已忽略front matter`}, note.Warnings)
+	assert.Equal(t, []Paragraph{{Texts: []TextNode{{Text: "正文"}}}}, note.Paragraphs)
+
+	// 缺少结束的 --- 时全文按正文处理
+	note = ParseMarkdownNote("---\ntitle: 标题\n正文")
+	assert.Empty(t, note.Title)
+	require.Len(t, note.Warnings, 1)
+	assert.Contains(t, note.Warnings[0], "缺少结束的 ---")
+	assert.Equal(t, []Paragraph{{Texts: []TextNode{{Text: "---\ntitle: 标题\n正文"}}}}, note.Paragraphs)
+}
+
+// TestParseMarkdownNoteExported 测试export_notes_markdown导出的内容可以被解析回相同的段落
+func TestParseMarkdownNoteExported(t *testing.T) {
+	paragraphs := []Paragraph{
+		{Type: "heading", Level: 1, Texts: []TextNode{{Text: "标题"}}},
+		{Texts: []TextNode{
+			{Text: "普通"},
+			{Text: "加粗斜体", Bold: true, Italic: true},
+			{Text: "高亮链接", Highlight: true, Link: "https://example.com"},
+		}},
+		{Type: "quote", Texts: []TextNode{{Text: "引用"}}},
+		{Type: "link_card", URL: "https://example.com/card"},
+	}
+	markdown := RenderNoteMarkdown(&NoteDetail{NoteID: "note-1", Title: "标题", Tags: []string{"导出"}, Body: mustConvert(t, paragraphs)})
+
+	note := ParseMarkdownNote(markdown)
+	assert.Equal(t, "标题", note.Title)
+	assert.Equal(t, []string{"导出"}, note.Tags)
+	assert.Equal(t, paragraphs, note.Paragraphs)
+	// 正文已以同名一级标题开头时不重复插入标题
+	assert.Equal(t, paragraphs, markdownNoteParagraphs(note.Title, note.Paragraphs))
+}
+
+// TestHandleImportMarkdownDir 测试从目录中的Markdown文件批量创建笔记并报告每个文件的结果
+func (suite *ServerTestSuite) TestHandleImportMarkdownDir() {
+	dir := suite.T().TempDir()
+	files := map[string]string{
+		"b-读书.md":   "---\ntitle: 读书笔记\ntags: [读书]\n---\n第一章",
+		"a-周报.md":   "本周完成了**导入**",
+		"c-失败.md":   "这篇会失败",
+		"d-公开.md":   "---\nprivacy_type: public\nauto_publish: true\nsource: 博客\n---\n公开内容",
+		"e-格式错误.md": "---\n标题\n---\n格式错误",
+		"notes.txt": "不是Markdown",
+	}
+	for name, content := range files {
+		require.NoError(suite.T(), os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644))
+	}
+	require.NoError(suite.T(), os.Mkdir(filepath.Join(dir, "子目录.md"), 0o755))
+
+	var created []NoteCreateRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var createReq NoteCreateRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&createReq))
+		if summarizeBlock(createReq.Body.Content[1]) == "这篇会失败" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		created = append(created, createReq)
+		mockSuccess(map[string]interface{}{"noteId": "note-" + createReq.Settings.Tags[0]})(w, r)
+	}
+
+	var privacy []NoteSetRequest
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var setReq NoteSetRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		privacy = append(privacy, setReq)
+		mockSuccess(map[string]interface{}{})(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleImportMarkdownDir, ImportMarkdownDirArgs{Dir: dir, Tags: []string{"导入"}})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 5 项，成功 4 项，跳过 0 项，失败 1 项")
+	assert.Contains(suite.T(), text, "- c-失败.md：")
+	assert.Contains(suite.T(), text, "创建的笔记：\n- a-周报.md → note-导入\n- b-读书.md → note-读书\n- d-公开.md → note-导入\n- e-格式错误.md → note-导入")
+	assert.Contains(suite.T(), text, "导入提示：\n- e-格式错误.md：front matter第 2 行")
+
+	require.Len(suite.T(), created, 4)
+	// front matter中的设置只作用于对应的文件
+	assert.False(suite.T(), created[0].Settings.AutoPublish)
+	assert.True(suite.T(), created[2].Settings.AutoPublish)
+	assert.Equal(suite.T(), []string{"# d-公开", "公开内容"}, summarizeBlocks(created[2].Body.Content))
+	assert.Equal(suite.T(), []string{"# e-格式错误", "格式错误"}, summarizeBlocks(created[3].Body.Content))
+	require.Len(suite.T(), privacy, 1)
+	assert.Equal(suite.T(), "note-导入", privacy[0].NoteID)
+	assert.Equal(suite.T(), "public", privacy[0].Settings.Privacy.Type)
+	// 没有front matter时以文件名作为标题
+	assert.Equal(suite.T(), []string{"# a-周报", "本周完成了**导入**"}, summarizeBlocks(created[0].Body.Content))
+	assert.Equal(suite.T(), []string{"导入"}, created[0].Settings.Tags)
+	assert.Equal(suite.T(), []string{"# 读书笔记", "第一章"}, summarizeBlocks(created[1].Body.Content))
+	assert.Equal(suite.T(), []string{"读书", "导入"}, created[1].Settings.Tags)
+
+	_, err = suite.callTool(suite.mcpServer.handleImportMarkdownDir, ImportMarkdownDirArgs{Dir: filepath.Join(dir, "missing")})
+	assert.Error(suite.T(), err)
+
+	text, err = suite.callTool(suite.mcpServer.handleImportMarkdownDir, ImportMarkdownDirArgs{Dir: filepath.Join(dir, "子目录.md")})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "没有Markdown文件")
+}
+
+// TestParseMarkdownTables 测试表格按配置转换为代码或纯文本并给出提示，没有分隔行的竖线文本按普通段落处理
+func TestParseMarkdownTables(t *testing.T) {
+	markdown := "前言\n\n| 名称 | 说明 |\n| :--- | ---: |\n| **go** | 语言 \\| 工具 |\n| mcp | 协议 |\n\na | b 不是表格"
+
+	note := ParseMarkdownNote(markdown)
+	require.Len(t, note.Paragraphs, 3)
+	assert.Equal(t, Paragraph{Texts: []TextNode{{
+		Text:       "| 名称 | 说明 |\n| :--- | ---: |\n| **go** | 语言 \\| 工具 |\n| mcp | 协议 |",
+		InlineCode: true,
+	}}}, note.Paragraphs[1])
+	assert.Equal(t, []TextNode{{Text: "a | b 不是表格"}}, note.Paragraphs[2].Texts)
+	assert.Equal(t, []string{"第3行的表格不受支持，已保留原文转换为代码"}, note.Warnings)
+
+	note = ParseMarkdownNoteWithOptions(markdown, MarkdownOptions{TableFallback: MarkdownTableText})
+	require.Len(t, note.Paragraphs, 3)
+	assert.Equal(t, []TextNode{
+		{Text: "名称 | 说明", Bold: true},
+		{Text: "\n"},
+		{Text: "go", Bold: true},
+		{Text: " | 语言 | 工具\nmcp | 协议"},
+	}, note.Paragraphs[1].Texts)
+	assert.Equal(t, []string{"第3行的表格不受支持，已转换为纯文本"}, note.Warnings)
+}
+
+// TestHandleImportMarkdownDirTables 测试MOWEN_MARKDOWN_TABLES控制导入时表格的转换方式，转换提示出现在导入结果中
+func (suite *ServerTestSuite) TestHandleImportMarkdownDirTables() {
+	suite.T().Setenv("MOWEN_MARKDOWN_TABLES", "text")
+	server, err := NewMowenMCPServer()
+	require.NoError(suite.T(), err)
+	server.mowenClient.baseURL = suite.mockHTTPServer.URL
+
+	dir := suite.T().TempDir()
+	require.NoError(suite.T(), os.WriteFile(filepath.Join(dir, "表格.md"), []byte("| a | b |\n|---|---|\n| 1 | 2 |"), 0o644))
+
+	var created []NoteCreateRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var createReq NoteCreateRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&createReq))
+		created = append(created, createReq)
+		mockSuccess(map[string]interface{}{"noteId": "table-note"})(w, r)
+	}
+
+	text, err := suite.callTool(server.handleImportMarkdownDir, ImportMarkdownDirArgs{Dir: dir})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "- 表格.md：第1行的表格不受支持，已转换为纯文本")
+	require.Len(suite.T(), created, 1)
+	assert.Equal(suite.T(), []string{"# 表格", "**a | b**\n1 | 2"}, summarizeBlocks(created[0].Body.Content))
+
+	suite.T().Setenv("MOWEN_MARKDOWN_TABLES", "html")
+	_, err = NewMowenMCPServer()
+	assert.ErrorContains(suite.T(), err, "invalid MOWEN_MARKDOWN_TABLES")
+}
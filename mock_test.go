@@ -28,12 +28,12 @@ func (m *MockHTTPClient) Do(req *http.Request) (*http.Response, error) {
 
 // MowenClientInterface 墨问客户端接口
 type MowenClientInterface interface {
-	CreateNote(req NoteCreateRequest) (map[string]interface{}, error)
-	EditNote(req NoteEditRequest) (map[string]interface{}, error)
-	SetNotePrivacy(req NoteSetRequest) (map[string]interface{}, error)
-	ResetAPIKey() (map[string]interface{}, error)
-	UploadFile(filePath string, fileType int, fileName string) (map[string]interface{}, error)
-	UploadFileViaURL(fileURL string, fileType int, fileName string) (map[string]interface{}, error)
+	CreateNote(ctx context.Context, req NoteCreateRequest) (map[string]interface{}, error)
+	EditNote(ctx context.Context, req NoteEditRequest) (map[string]interface{}, error)
+	SetNotePrivacy(ctx context.Context, req NoteSetRequest) (map[string]interface{}, error)
+	ResetAPIKey(ctx context.Context) (map[string]interface{}, error)
+	UploadFile(ctx context.Context, filePath string, fileType int, fileName string) (map[string]interface{}, error)
+	UploadFileViaURL(ctx context.Context, fileURL string, fileType int, fileName string) (map[string]interface{}, error)
 }
 
 // MockMowenClient 模拟墨问客户端
@@ -42,37 +42,37 @@ type MockMowenClient struct {
 }
 
 // CreateNote 模拟创建笔记
-func (m *MockMowenClient) CreateNote(req NoteCreateRequest) (map[string]interface{}, error) {
+func (m *MockMowenClient) CreateNote(ctx context.Context, req NoteCreateRequest) (map[string]interface{}, error) {
 	args := m.Called(req)
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
 // EditNote 模拟编辑笔记
-func (m *MockMowenClient) EditNote(req NoteEditRequest) (map[string]interface{}, error) {
+func (m *MockMowenClient) EditNote(ctx context.Context, req NoteEditRequest) (map[string]interface{}, error) {
 	args := m.Called(req)
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
 // SetNotePrivacy 模拟设置笔记隐私
-func (m *MockMowenClient) SetNotePrivacy(req NoteSetRequest) (map[string]interface{}, error) {
+func (m *MockMowenClient) SetNotePrivacy(ctx context.Context, req NoteSetRequest) (map[string]interface{}, error) {
 	args := m.Called(req)
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
 // ResetAPIKey 模拟重置API密钥
-func (m *MockMowenClient) ResetAPIKey() (map[string]interface{}, error) {
+func (m *MockMowenClient) ResetAPIKey(ctx context.Context) (map[string]interface{}, error) {
 	args := m.Called()
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
 // UploadFile 模拟本地文件上传
-func (m *MockMowenClient) UploadFile(filePath string, fileType int, fileName string) (map[string]interface{}, error) {
+func (m *MockMowenClient) UploadFile(ctx context.Context, filePath string, fileType int, fileName string) (map[string]interface{}, error) {
 	args := m.Called(filePath, fileType, fileName)
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
 
 // UploadFileViaURL 模拟URL文件上传
-func (m *MockMowenClient) UploadFileViaURL(fileURL string, fileType int, fileName string) (map[string]interface{}, error) {
+func (m *MockMowenClient) UploadFileViaURL(ctx context.Context, fileURL string, fileType int, fileName string) (map[string]interface{}, error) {
 	args := m.Called(fileURL, fileType, fileName)
 	return args.Get(0).(map[string]interface{}), args.Error(1)
 }
@@ -90,7 +90,10 @@ func (s *TestMowenMCPServer) handleCreateNote(ctx context.Context, req *protocol
 	}
 
 	// 转换参数为墨问API格式
-	noteBody := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	noteBody, err := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paragraphs: %w", err)
+	}
 	createReq := NoteCreateRequest{
 		Body: noteBody,
 		Settings: NoteCreateRequestSettings{
@@ -100,7 +103,7 @@ func (s *TestMowenMCPServer) handleCreateNote(ctx context.Context, req *protocol
 	}
 
 	// 调用墨问API
-	result, err := s.mowenClient.CreateNote(createReq)
+	result, err := s.mowenClient.CreateNote(ctx, createReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create note: %w", err)
 	}
@@ -126,14 +129,17 @@ func (s *TestMowenMCPServer) handleEditNote(ctx context.Context, req *protocol.C
 	}
 
 	// 转换参数为墨问API格式
-	noteBody := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	noteBody, err := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paragraphs: %w", err)
+	}
 	editReq := NoteEditRequest{
 		NoteID: args.NoteID,
 		Body:   noteBody,
 	}
 
 	// 调用墨问API
-	result, err := s.mowenClient.EditNote(editReq)
+	result, err := s.mowenClient.EditNote(ctx, editReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to edit note: %w", err)
 	}
@@ -178,14 +184,14 @@ func (s *TestMowenMCPServer) handleSetNotePrivacy(ctx context.Context, req *prot
 	// 构建请求
 	setReq := NoteSetRequest{
 		NoteID:  args.NoteID,
-		Section: 1, // 1表示笔记隐私设置
+		Section: SectionPrivacy,
 		Settings: &NoteSettings{
 			Privacy: privacySet,
 		},
 	}
 
 	// 调用墨问API
-	result, err := s.mowenClient.SetNotePrivacy(setReq)
+	result, err := s.mowenClient.SetNotePrivacy(ctx, setReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set note privacy: %w", err)
 	}
@@ -211,7 +217,7 @@ func (s *TestMowenMCPServer) handleResetAPIKey(ctx context.Context, req *protoco
 	}
 
 	// 调用墨问API
-	result, err := s.mowenClient.ResetAPIKey()
+	result, err := s.mowenClient.ResetAPIKey(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reset API key: %w", err)
 	}
@@ -237,7 +243,7 @@ func (s *TestMowenMCPServer) handleUploadFileViaURL(ctx context.Context, req *pr
 	}
 
 	// 调用墨问API通过URL上传文件
-	result, err := s.mowenClient.UploadFileViaURL(args.FileURL, args.FileType, args.FileName)
+	result, err := s.mowenClient.UploadFileViaURL(ctx, args.FileURL, args.FileType, args.FileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload file via URL: %w", err)
 	}
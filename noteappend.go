@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// edit_note的编辑方式
+const (
+	EditModeReplace = "replace" // 用新段落完全替换原有内容，默认方式
+	EditModeAppend  = "append"  // 把新段落追加到原有内容末尾
+)
+
+// parseEditMode 校验编辑方式，为空时使用replace
+func parseEditMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return EditModeReplace, nil
+	case EditModeReplace, EditModeAppend:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid mode %q: must be %s or %s", mode, EditModeReplace, EditModeAppend)
+}
+
+// endsWithBlocks 判断笔记内容末尾是否已经是要追加的段落。
+// 按转换后的完整段落（类型、属性、文本和标记）比较，只是文字相同而格式或文件不同的段落不算重复。
+func endsWithBlocks(content, blocks []NoteAtom) bool {
+	if len(blocks) == 0 || len(blocks) > len(content) {
+		return false
+	}
+	existing := content[len(content)-len(blocks):]
+	for i := range blocks {
+		if !equalBlocks(existing[i], blocks[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalBlocks 按JSON编码比较两个段落，忽略空字段是nil还是空值的差别
+func equalBlocks(a, b NoteAtom) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(encodedA, encodedB)
+}
+
+// appendToNoteBody 读取笔记当前内容并在末尾追加段落，返回合并后的内容。
+// allowDuplicate为false且笔记末尾已经是这些段落时返回duplicate为true，避免重试等情况下重复追加；
+// 笔记不存在时返回包装了ErrNoteNotFound的错误。
+func (s *MowenMCPServer) appendToNoteBody(ctx context.Context, noteID string, blocks []NoteAtom, allowDuplicate bool) (body NoteAtom, duplicate bool, err error) {
+	result, err := s.mowenClient.GetNote(ctx, noteID)
+	if isNoteNotFoundResponse(err, result) {
+		return NoteAtom{}, false, fmt.Errorf("failed to get note %s: %w", noteID, ErrNoteNotFound)
+	}
+	if err != nil {
+		return NoteAtom{}, false, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return NoteAtom{}, false, err
+	}
+
+	body = detail.Body
+	if body.Type == "" {
+		body.Type = "doc"
+	}
+	if !allowDuplicate && endsWithBlocks(body.Content, blocks) {
+		return body, true, nil
+	}
+	body.Content = append(append([]NoteAtom{}, body.Content...), blocks...)
+	return body, false, nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleEditNoteAppend 测试追加模式读取原有内容后合并提交，末尾已是完全相同的段落时不重复追加，
+// allow_duplicate可以跳过该检查
+func (suite *ServerTestSuite) TestHandleEditNoteAppend() {
+	existing := mustConvert(suite.T(), []Paragraph{
+		{Type: "heading", Level: 1, Texts: []TextNode{{Text: "周报"}}},
+		{Texts: []TextNode{{Text: "周一：", Bold: true}, {Text: "需求评审"}}},
+	})
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		mockSuccess(map[string]interface{}{"noteId": "note-1", "body": existing})(w, r)
+	}
+	var editReqs []NoteEditRequest
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var editReq NoteEditRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&editReq))
+		editReqs = append(editReqs, editReq)
+		mockSuccess(nil)(w, r)
+	}
+
+	args := EditNoteArgs{
+		NoteID:     "note-1",
+		Mode:       EditModeAppend,
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "周二：", Bold: true}, {Text: "开发"}}}},
+	}
+	text, err := suite.callTool(suite.mcpServer.handleEditNote, args)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "note-1")
+	require.Len(suite.T(), editReqs, 1)
+	assert.Equal(suite.T(), "note-1", editReqs[0].NoteID)
+	assert.Equal(suite.T(), []string{"# 周报", "**周一：**需求评审", "**周二：**开发"}, summarizeBlocks(editReqs[0].Body.Content))
+
+	// 同样的追加请求再次到达时，笔记末尾已经是这些段落
+	existing = editReqs[0].Body
+	text, err = suite.callTool(suite.mcpServer.handleEditNote, args)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 note-1 的末尾已经是要追加的 1 个段落，未重复追加；如确实需要再次追加，请设置allow_duplicate为true", text)
+	assert.Len(suite.T(), editReqs, 1)
+
+	// 文字相同但格式不同的段落不算重复
+	plain := args
+	plain.Paragraphs = []Paragraph{{Texts: []TextNode{{Text: "周二：", Italic: true}, {Text: "开发"}}}}
+	_, err = suite.callTool(suite.mcpServer.handleEditNote, plain)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), editReqs, 2)
+	assert.Len(suite.T(), editReqs[1].Body.Content, 4)
+
+	// allow_duplicate为true时有意重复追加
+	args.AllowDuplicate = true
+	_, err = suite.callTool(suite.mcpServer.handleEditNote, args)
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), editReqs, 3)
+	assert.Equal(suite.T(), []string{"# 周报", "**周一：**需求评审", "**周二：**开发", "**周二：**开发"}, summarizeBlocks(editReqs[2].Body.Content))
+	args.AllowDuplicate = false
+	editReqs = editReqs[:1]
+
+	// 试运行不读取也不修改笔记
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("试运行不应读取笔记")
+	}
+	args.DryRun = true
+	text, err = suite.callTool(suite.mcpServer.handleEditNote, args)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "追加模式：以下段落将追加到笔记原有内容的末尾")
+	assert.Len(suite.T(), editReqs, 1)
+}
+
+// TestHandleEditNoteAppendErrors 测试追加模式下笔记不存在和编辑方式无效时返回错误
+func (suite *ServerTestSuite) TestHandleEditNoteAppendErrors() {
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("出错时不应编辑笔记")
+	}
+	paragraphs := []Paragraph{{Texts: []TextNode{{Text: "内容"}}}}
+
+	_, err := suite.callTool(suite.mcpServer.handleEditNote, EditNoteArgs{NoteID: "missing", Mode: EditModeAppend, Paragraphs: paragraphs})
+	assert.ErrorIs(suite.T(), err, ErrNoteNotFound)
+	assert.ErrorContains(suite.T(), err, "note missing does not exist")
+
+	_, err = suite.callTool(suite.mcpServer.handleEditNote, EditNoteArgs{NoteID: "note-1", Mode: "prepend", Paragraphs: paragraphs})
+	assert.ErrorContains(suite.T(), err, `invalid mode "prepend"`)
+}
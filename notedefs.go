@@ -0,0 +1,157 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// NoteDefinitionIssue 笔记定义校验发现的问题
+type NoteDefinitionIssue struct {
+	Index   int    // 笔记定义在数组中的下标，从0开始
+	Message string // 问题说明
+	Warning bool   // 为true时只是提示，不影响创建
+}
+
+// String 返回带下标的问题说明
+func (i NoteDefinitionIssue) String() string {
+	if i.Warning {
+		return fmt.Sprintf("[%d] 提示：%s", i.Index, i.Message)
+	}
+	return fmt.Sprintf("[%d] %s", i.Index, i.Message)
+}
+
+// noteHasContent 判断笔记是否有内容：至少有一个段落含有文本，或者有内链笔记、文件、链接卡片等非文本段落。
+// 只包含一张图片等非文本段落的笔记也是有效的。
+func noteHasContent(doc NoteAtom) bool {
+	for _, block := range doc.Content {
+		if block.Type != "paragraph" || len(block.Content) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateNoteDefinitions 离线校验JSON数组中的笔记定义，每一项的格式同create_note的参数。
+// 检查字段类型和未知字段、段落内容、文本链接、标签以及时间戳，并按limits检查请求大小，不调用墨问API。
+// 内容不是JSON数组时返回错误，单项的问题以下标记录在返回的问题列表中。
+func ValidateNoteDefinitions(data []byte, opts ConvertOptions, limits NoteLimits, now time.Time) (int, []NoteDefinitionIssue, error) {
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return 0, nil, fmt.Errorf("note definitions must be a JSON array: %w", err)
+	}
+
+	var issues []NoteDefinitionIssue
+	for index, item := range items {
+		report := func(warning bool, format string, args ...interface{}) {
+			issues = append(issues, NoteDefinitionIssue{Index: index, Message: fmt.Sprintf(format, args...), Warning: warning})
+		}
+
+		var args CreateNoteArgs
+		decoder := json.NewDecoder(bytes.NewReader(item))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&args); err != nil {
+			report(false, "格式错误：%v", err)
+			continue
+		}
+
+		for i, para := range args.Paragraphs {
+			for _, text := range para.allTexts() {
+				if text.Link != "" && !isBareURL(text.Link) {
+					report(false, "第 %d 段：无效的链接 %q", i+1, text.Link)
+				}
+			}
+		}
+		noteBody, warnings, convertErr := ConvertParagraphsWithWarnings(args.Paragraphs, opts)
+		switch {
+		case len(args.Paragraphs) == 0:
+			report(false, "没有段落")
+		case convertErr != nil:
+			report(false, "段落无效：%v", convertErr)
+		case !noteHasContent(noteBody):
+			report(false, "没有内容：所有段落都没有文本")
+		}
+		for _, w := range warnings {
+			report(true, "%s", w)
+		}
+
+		seen := make(map[string]bool, len(args.Tags))
+		for _, tag := range args.Tags {
+			switch {
+			case strings.TrimSpace(tag) == "":
+				report(false, "标签不能为空")
+			case seen[tag]:
+				report(true, "重复的标签 %q", tag)
+			}
+			seen[tag] = true
+		}
+
+		if err := ValidateNoteTimestamps(args.CreatedAt, args.UpdatedAt, now); err != nil {
+			report(false, "时间戳无效：%v", err)
+		}
+
+		// 段落无法转换时无法计算请求大小
+		if convertErr != nil {
+			continue
+		}
+		checks, err := CheckNoteLimits(NoteCreateRequest{
+			Body:     noteBody,
+			Settings: NoteCreateRequestSettings{Tags: dedupeTags(args.Tags)},
+		}, limits)
+		if err != nil {
+			report(false, "%v", err)
+			continue
+		}
+		for _, check := range checks {
+			if check.Exceeded() {
+				report(false, "%s %d 超过上限 %d", check.Name, check.Value, check.Limit)
+			}
+		}
+	}
+	return len(items), issues, nil
+}
+
+// RenderNoteDefinitionIssues 将笔记定义的校验结果渲染为文本
+func RenderNoteDefinitionIssues(total int, issues []NoteDefinitionIssue) string {
+	invalid := make(map[int]bool)
+	for _, issue := range issues {
+		if !issue.Warning {
+			invalid[issue.Index] = true
+		}
+	}
+
+	var sb strings.Builder
+	if len(invalid) == 0 {
+		fmt.Fprintf(&sb, "校验通过：%d 篇笔记定义均有效", total)
+	} else {
+		fmt.Fprintf(&sb, "校验未通过：%d 篇笔记定义中有 %d 篇存在问题", total, len(invalid))
+	}
+	for _, issue := range issues {
+		sb.WriteString("\n- " + issue.String())
+	}
+	return sb.String()
+}
+
+// handleValidateNoteFile 处理离线校验笔记定义JSON文件的MCP工具请求，不会调用墨问API
+func (s *MowenMCPServer) handleValidateNoteFile(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args ValidateNoteFileArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	data, err := os.ReadFile(args.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note definitions file: %w", err)
+	}
+	total, issues, err := ValidateNoteDefinitions(data, s.convertOptions, s.noteLimits, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("invalid note definitions file %s: %w", args.Path, err)
+	}
+	return textResult(RenderNoteDefinitionIssues(total, issues)), nil
+}
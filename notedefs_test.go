@@ -0,0 +1,88 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// noteDefinitionsFixture 包含有效和无效笔记定义的JSON数组
+const noteDefinitionsFixture = `[
+	{"paragraphs": [{"texts": [{"text": "有效笔记", "link": "https://example.com"}]}], "tags": ["周报"]},
+	{"paragraphs": [{"texts": [{"text": "坏链接", "link": "not a url"}]}], "tags": ["", "周报", "周报"]},
+	{"paragraphs": "不是数组"},
+	{"paragraphs": [{"type": "link_card", "url": "ftp:/bad"}], "title": "未知字段"},
+	{"paragraphs": [], "created_at": 1700000000, "updated_at": 1600000000},
+	{"paragraphs": [{"type": "unknown", "texts": [{"text": "提示"}]}]}
+]`
+
+// TestValidateNoteDefinitions 测试按下标报告每篇笔记定义的问题，提示不算作问题
+func TestValidateNoteDefinitions(t *testing.T) {
+	now := time.Unix(1800000000, 0)
+	total, issues, err := ValidateNoteDefinitions([]byte(noteDefinitionsFixture), ConvertOptions{}, DefaultNoteLimits(), now)
+	require.NoError(t, err)
+	assert.Equal(t, 6, total)
+
+	byIndex := make(map[int][]string)
+	for _, issue := range issues {
+		byIndex[issue.Index] = append(byIndex[issue.Index], issue.String())
+	}
+	assert.NotContains(t, byIndex, 0)
+	assert.Equal(t, []string{
+		`[1] 第 1 段：无效的链接 "not a url"`,
+		`[1] 标签不能为空`,
+		`[1] 提示：重复的标签 "周报"`,
+	}, byIndex[1])
+	require.Len(t, byIndex[2], 1)
+	assert.Contains(t, byIndex[2][0], "格式错误")
+	require.Len(t, byIndex[3], 1)
+	assert.Contains(t, byIndex[3][0], `unknown field "title"`)
+	require.Len(t, byIndex[4], 2)
+	assert.Equal(t, "[4] 没有段落", byIndex[4][0])
+	assert.Contains(t, byIndex[4][1], "时间戳无效")
+	assert.Equal(t, []string{`[5] 提示：第 1 段：未知的段落类型 "unknown"，已按普通段落处理`}, byIndex[5])
+
+	report := RenderNoteDefinitionIssues(total, issues)
+	assert.True(t, strings.HasPrefix(report, "校验未通过：6 篇笔记定义中有 4 篇存在问题"))
+
+	_, _, err = ValidateNoteDefinitions([]byte(`{"paragraphs": []}`), ConvertOptions{}, DefaultNoteLimits(), now)
+	assert.Error(t, err)
+}
+
+// TestValidateNoteDefinitionsNonTextOnly 测试只有一个非文本段落的笔记是有效内容，只有空段落的笔记无效
+func TestValidateNoteDefinitionsNonTextOnly(t *testing.T) {
+	valid := map[string]string{
+		"file":      `{"paragraphs": [{"type": "file", "file": {"file_type": "image", "source_type": "local", "source_path": "file-abcdef12"}}]}`,
+		"note":      `{"paragraphs": [{"type": "note", "note_id": "note-abcdef12"}]}`,
+		"link_card": `{"paragraphs": [{"type": "link_card", "url": "https://example.com"}]}`,
+	}
+	for name, definition := range valid {
+		total, issues, err := ValidateNoteDefinitions([]byte("["+definition+"]"), DefaultConvertOptions(), DefaultNoteLimits(), time.Now())
+		require.NoError(t, err, name)
+		assert.Equal(t, 1, total, name)
+		assert.Empty(t, issues, name)
+	}
+
+	_, issues, err := ValidateNoteDefinitions([]byte(`[{"paragraphs": [{}, {"texts": [{"text": ""}]}]}]`), ConvertOptions{}, DefaultNoteLimits(), time.Now())
+	require.NoError(t, err)
+	require.NotEmpty(t, issues)
+	assert.Equal(t, "[0] 没有内容：所有段落都没有文本", issues[0].String())
+}
+
+// TestHandleValidateNoteFile 测试校验工具读取文件并报告结果
+func (suite *ServerTestSuite) TestHandleValidateNoteFile() {
+	path := filepath.Join(suite.T().TempDir(), "notes.json")
+	require.NoError(suite.T(), os.WriteFile(path, []byte(`[{"paragraphs": [{"texts": [{"text": "你好"}]}]}]`), 0o644))
+
+	text, err := suite.callTool(suite.mcpServer.handleValidateNoteFile, ValidateNoteFileArgs{Path: path})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "校验通过：1 篇笔记定义均有效", text)
+
+	_, err = suite.callTool(suite.mcpServer.handleValidateNoteFile, ValidateNoteFileArgs{Path: path + ".missing"})
+	assert.Error(suite.T(), err)
+}
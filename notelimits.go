@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// 笔记限制的默认值，均为保守估计，可通过环境变量调整
+const (
+	DefaultMaxBodyBytes  = 1 << 20 // 创建请求体的最大字节数
+	DefaultMaxParagraphs = 1000    // 笔记的最大段落数
+	DefaultMaxTags       = 10      // 笔记的最大标签数
+	DefaultMaxTagLength  = 30      // 单个标签的最大字符数
+)
+
+// NoteLimits 提交前检查的笔记限制，0表示不限制
+type NoteLimits struct {
+	MaxBodyBytes  int // 创建请求体（JSON）的最大字节数
+	MaxParagraphs int // 顶层段落的最大数量
+	MaxTags       int // 标签的最大数量（含自动标签）
+	MaxTagLength  int // 单个标签的最大字符数
+}
+
+// DefaultNoteLimits 返回默认的笔记限制
+func DefaultNoteLimits() NoteLimits {
+	return NoteLimits{
+		MaxBodyBytes:  DefaultMaxBodyBytes,
+		MaxParagraphs: DefaultMaxParagraphs,
+		MaxTags:       DefaultMaxTags,
+		MaxTagLength:  DefaultMaxTagLength,
+	}
+}
+
+// validateTags 去除标签首尾空白、空标签和重复标签，并检查标签数和单个标签长度，
+// 使超过限制的标签在本地就返回明确的错误，而不是提交后被墨问API拒绝
+func (l NoteLimits) validateTags(tags []string) ([]string, error) {
+	cleaned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	cleaned = dedupeTags(cleaned)
+
+	for _, tag := range cleaned {
+		if l.MaxTagLength > 0 && utf8.RuneCountInString(tag) > l.MaxTagLength {
+			return nil, fmt.Errorf("tag %q exceeds %d chars", tag, l.MaxTagLength)
+		}
+	}
+	if l.MaxTags > 0 && len(cleaned) > l.MaxTags {
+		return nil, fmt.Errorf("too many tags: %d exceeds the maximum of %d", len(cleaned), l.MaxTags)
+	}
+	return cleaned, nil
+}
+
+// LimitCheck 单项限制的检查结果
+type LimitCheck struct {
+	Name  string // 检查项名称
+	Value int    // 实际值
+	Limit int    // 限制值，0表示不限制
+}
+
+// Exceeded 判断实际值是否超过限制
+func (c LimitCheck) Exceeded() bool {
+	return c.Limit > 0 && c.Value > c.Limit
+}
+
+// CheckNoteLimits 检查创建请求的请求体大小、段落数和标签数是否超过限制
+func CheckNoteLimits(req NoteCreateRequest, limits NoteLimits) ([]LimitCheck, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+	return []LimitCheck{
+		{Name: "请求体大小（字节）", Value: len(data), Limit: limits.MaxBodyBytes},
+		{Name: "段落数", Value: len(req.Body.Content), Limit: limits.MaxParagraphs},
+		{Name: "标签数", Value: len(req.Settings.Tags), Limit: limits.MaxTags},
+	}, nil
+}
+
+// RenderLimitChecks 将限制检查结果渲染为文本
+func RenderLimitChecks(checks []LimitCheck) string {
+	var exceeded []string
+	var sb strings.Builder
+	for _, check := range checks {
+		limit := "不限制"
+		if check.Limit > 0 {
+			limit = fmt.Sprintf("上限 %d", check.Limit)
+		}
+		status := "✓"
+		if check.Exceeded() {
+			status = "✗ 超出"
+			exceeded = append(exceeded, check.Name)
+		}
+		fmt.Fprintf(&sb, "\n- %s：%d（%s）%s", check.Name, check.Value, limit, status)
+	}
+
+	header := "检查通过：未超过任何限制"
+	if len(exceeded) > 0 {
+		header = fmt.Sprintf("检查未通过：%s超过限制", strings.Join(exceeded, "、"))
+	}
+	return header + sb.String()
+}
+
+// handleCheckNoteLimits 处理笔记提交前限制检查的MCP工具请求。
+// 它按create_note相同的方式转换段落并应用自动标签，但不调用墨问API。
+func (s *MowenMCPServer) handleCheckNoteLimits(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args CheckNoteLimitsArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	noteBody, warnings, err := ConvertParagraphsWithWarnings(args.Paragraphs, s.convertOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paragraphs: %w", err)
+	}
+	createReq := NoteCreateRequest{
+		Body: noteBody,
+		Settings: NoteCreateRequestSettings{
+			Tags: applyAutoTags(args.Tags, args.Paragraphs, s.autoTagRules),
+		},
+		SchemaVersion: s.mowenClient.schemaVersion,
+	}
+
+	checks, err := CheckNoteLimits(createReq, s.noteLimits)
+	if err != nil {
+		return nil, err
+	}
+	return textResult(appendConversionWarnings(RenderLimitChecks(checks), warnings)), nil
+}
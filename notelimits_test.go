@@ -0,0 +1,115 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCheckNoteLimits 测试限制内的笔记通过检查，超过每一项限制时都被指出
+func TestCheckNoteLimits(t *testing.T) {
+	limits := NoteLimits{MaxBodyBytes: 500, MaxParagraphs: 3, MaxTags: 2}
+	request := func(paragraphs []Paragraph, tags []string) NoteCreateRequest {
+		return NoteCreateRequest{Body: mustConvert(t, paragraphs), Settings: NoteCreateRequestSettings{Tags: tags}}
+	}
+	exceeded := func(checks []LimitCheck) []string {
+		var names []string
+		for _, check := range checks {
+			if check.Exceeded() {
+				names = append(names, check.Name)
+			}
+		}
+		return names
+	}
+	short := []Paragraph{{Texts: []TextNode{{Text: "短文本"}}}}
+
+	checks, err := CheckNoteLimits(request(short, []string{"a"}), limits)
+	require.NoError(t, err)
+	assert.Empty(t, exceeded(checks))
+	assert.Equal(t, 1, checks[1].Value)
+	assert.Contains(t, RenderLimitChecks(checks), "检查通过")
+
+	cases := []struct {
+		name string
+		req  NoteCreateRequest
+	}{
+		{"请求体大小（字节）", request([]Paragraph{{Texts: []TextNode{{Text: strings.Repeat("长", 200)}}}}, nil)},
+		{"段落数", request(append(append([]Paragraph{}, short...), short[0], short[0], short[0]), nil)},
+		{"标签数", request(short, []string{"a", "b", "c"})},
+	}
+	for _, c := range cases {
+		checks, err := CheckNoteLimits(c.req, limits)
+		require.NoError(t, err)
+		assert.Equal(t, []string{c.name}, exceeded(checks), c.name)
+		assert.Contains(t, RenderLimitChecks(checks), "检查未通过："+c.name+"超过限制")
+	}
+
+	// 限制为0时不检查
+	checks, err = CheckNoteLimits(request(append(append([]Paragraph{}, short...), short[0], short[0], short[0]), nil), NoteLimits{})
+	require.NoError(t, err)
+	assert.Empty(t, exceeded(checks))
+}
+
+// TestHandleCheckNoteLimits 测试限制检查工具计入自动标签且不调用墨问API
+func (suite *ServerTestSuite) TestHandleCheckNoteLimits() {
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("check_note_limits must not create a note")
+	}
+	suite.mcpServer.noteLimits = NoteLimits{MaxTags: 1}
+	suite.mcpServer.autoTagRules = []AutoTagRule{{Keyword: "会议", Tag: "会议纪要"}}
+
+	text, err := suite.callTool(suite.mcpServer.handleCheckNoteLimits, CheckNoteLimitsArgs{
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "周一会议"}}}},
+		Tags:       []string{"工作"},
+	})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "检查未通过：标签数超过限制")
+	assert.Contains(suite.T(), text, "- 标签数：2（上限 1）✗ 超出")
+	assert.Contains(suite.T(), text, "- 段落数：1（不限制）✓")
+}
+
+// TestValidateTags 测试标签去除空白和重复后再检查数量和长度，长度按字符计
+func TestValidateTags(t *testing.T) {
+	limits := NoteLimits{MaxTags: 2, MaxTagLength: 4}
+
+	tags, err := limits.validateTags([]string{" 工作 ", "", "工作", "会议纪要"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"工作", "会议纪要"}, tags)
+
+	_, err = limits.validateTags([]string{"工作", "周会纪要表"})
+	assert.EqualError(t, err, `tag "周会纪要表" exceeds 4 chars`)
+
+	_, err = limits.validateTags([]string{"a", "b", " c"})
+	assert.EqualError(t, err, "too many tags: 3 exceeds the maximum of 2")
+
+	// 限制为0时只清理不检查
+	tags, err = NoteLimits{}.validateTags([]string{"a", "b", "c", strings.Repeat("长", 50)})
+	require.NoError(t, err)
+	assert.Len(t, tags, 4)
+}
+
+// TestHandleCreateNoteTagLimits 测试标签超过限制时在本地返回错误，不调用墨问API
+func (suite *ServerTestSuite) TestHandleCreateNoteTagLimits() {
+	var created []NoteCreateRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var createReq NoteCreateRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&createReq))
+		created = append(created, createReq)
+		mockSuccess(map[string]interface{}{"noteId": "note-1"})(w, r)
+	}
+	paragraphs := []Paragraph{{Texts: []TextNode{{Text: "内容"}}}}
+
+	_, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: paragraphs, Tags: []string{strings.Repeat("长", DefaultMaxTagLength+1)}})
+	assert.ErrorContains(suite.T(), err, fmt.Sprintf("exceeds %d chars", DefaultMaxTagLength))
+	assert.Empty(suite.T(), created)
+
+	_, err = suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: paragraphs, Tags: []string{" 工作", "工作 ", "周报"}})
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), created, 1)
+	assert.Equal(suite.T(), []string{"工作", "周报"}, created[0].Settings.Tags)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// ErrInvalidNoteReference 内链笔记段落引用的笔记不存在
+var ErrInvalidNoteReference = errors.New("referenced note does not exist")
+
+// NoteReference 内链笔记段落对其他笔记的引用
+type NoteReference struct {
+	Paragraph int    // 段落序号，从1开始
+	NoteID    string // 被引用的笔记ID
+}
+
+// collectNoteReferences 按段落顺序列出所有内链笔记段落的引用，note_id为空的段落会被忽略
+func collectNoteReferences(paragraphs []Paragraph) []NoteReference {
+	var refs []NoteReference
+	for i, p := range paragraphs {
+		if p.Type != "note" {
+			continue
+		}
+		if id := strings.TrimSpace(p.NoteID); id != "" {
+			refs = append(refs, NoteReference{Paragraph: i + 1, NoteID: id})
+		}
+	}
+	return refs
+}
+
+// findInvalidNoteReferences 逐个读取被引用的笔记，返回指向不存在笔记的引用。
+// 同一笔记被多次引用时只读取一次；读取遇到404以外的错误时直接返回错误，不会把笔记当作不存在。
+func (s *MowenMCPServer) findInvalidNoteReferences(ctx context.Context, refs []NoteReference) ([]NoteReference, error) {
+	exists := make(map[string]bool, len(refs))
+	var invalid []NoteReference
+	for _, ref := range refs {
+		found, checked := exists[ref.NoteID]
+		if !checked {
+			result, err := s.mowenClient.GetNote(ctx, ref.NoteID)
+			switch {
+			case isNoteNotFoundResponse(err, result):
+				found = false
+			case err != nil:
+				return nil, fmt.Errorf("failed to check note %s: %w", ref.NoteID, err)
+			default:
+				found = true
+			}
+			exists[ref.NoteID] = found
+		}
+		if !found {
+			invalid = append(invalid, ref)
+		}
+	}
+	return invalid, nil
+}
+
+// checkNoteReferences 检查段落中引用的笔记是否存在，存在无效引用时返回包装了ErrInvalidNoteReference的错误
+func (s *MowenMCPServer) checkNoteReferences(ctx context.Context, paragraphs []Paragraph) error {
+	invalid, err := s.findInvalidNoteReferences(ctx, collectNoteReferences(paragraphs))
+	if err != nil {
+		return err
+	}
+	if len(invalid) == 0 {
+		return nil
+	}
+	parts := make([]string, len(invalid))
+	for i, ref := range invalid {
+		parts[i] = fmt.Sprintf("paragraph %d: %s", ref.Paragraph, ref.NoteID)
+	}
+	return fmt.Errorf("%w: %s", ErrInvalidNoteReference, strings.Join(parts, ", "))
+}
+
+// handleCheckNoteReferences 处理检查内链笔记引用的MCP工具请求，列出引用了不存在笔记的段落
+func (s *MowenMCPServer) handleCheckNoteReferences(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args CheckNoteReferencesArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	refs := collectNoteReferences(args.Paragraphs)
+	if len(refs) == 0 {
+		return textResult("段落中没有内链笔记引用"), nil
+	}
+	invalid, err := s.findInvalidNoteReferences(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	if len(invalid) == 0 {
+		return textResult(fmt.Sprintf("检查通过：%d 个内链笔记引用均有效", len(refs))), nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "检查未通过：%d 个内链笔记引用中有 %d 个无效", len(refs), len(invalid))
+	for _, ref := range invalid {
+		fmt.Fprintf(&sb, "\n- 第 %d 段：笔记 %s 不存在", ref.Paragraph, ref.NoteID)
+	}
+	return textResult(sb.String()), nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// referenceParagraphs 包含一个有效引用、一个无效引用以及重复引用的段落
+var referenceParagraphs = []Paragraph{
+	{Texts: []TextNode{{Text: "相关笔记："}}},
+	{Type: "note", NoteID: "note-exists"},
+	{Type: "note", NoteID: "note-missing"},
+	{Type: "note", NoteID: "note-exists"},
+}
+
+// TestCollectNoteReferences 测试按段落顺序收集内链笔记引用
+func TestCollectNoteReferences(t *testing.T) {
+	assert.Equal(t, []NoteReference{
+		{Paragraph: 2, NoteID: "note-exists"},
+		{Paragraph: 3, NoteID: "note-missing"},
+		{Paragraph: 4, NoteID: "note-exists"},
+	}, collectNoteReferences(referenceParagraphs))
+	assert.Empty(t, collectNoteReferences([]Paragraph{{Type: "note"}, {Texts: []TextNode{{Text: "正文"}}}}))
+}
+
+// mockNoteExists 模拟笔记详情接口，只有note-exists存在，并记录每篇笔记被读取的次数
+func (suite *ServerTestSuite) mockNoteExists() map[string]int {
+	lookups := make(map[string]int)
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var detailReq NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&detailReq))
+		lookups[detailReq.NoteID]++
+		if detailReq.NoteID != "note-exists" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID})(w, r)
+	}
+	return lookups
+}
+
+// TestHandleCheckNoteReferences 测试检查工具列出无效引用，重复引用只读取一次
+func (suite *ServerTestSuite) TestHandleCheckNoteReferences() {
+	lookups := suite.mockNoteExists()
+
+	text, err := suite.callTool(suite.mcpServer.handleCheckNoteReferences, CheckNoteReferencesArgs{Paragraphs: referenceParagraphs})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "检查未通过：3 个内链笔记引用中有 1 个无效\n- 第 3 段：笔记 note-missing 不存在", text)
+	assert.Equal(suite.T(), map[string]int{"note-exists": 1, "note-missing": 1}, lookups)
+
+	text, err = suite.callTool(suite.mcpServer.handleCheckNoteReferences, CheckNoteReferencesArgs{Paragraphs: referenceParagraphs[:2]})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "检查通过：1 个内链笔记引用均有效", text)
+}
+
+// TestHandleCreateNoteCheckReferences 测试开启check_references时有无效引用不创建笔记，默认不检查
+func (suite *ServerTestSuite) TestHandleCreateNoteCheckReferences() {
+	lookups := suite.mockNoteExists()
+	created := 0
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		created++
+		mockSuccess(map[string]interface{}{"noteId": "new-note"})(w, r)
+	}
+
+	_, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: referenceParagraphs, CheckReferences: true})
+	require.Error(suite.T(), err)
+	assert.True(suite.T(), errors.Is(err, ErrInvalidNoteReference))
+	assert.Contains(suite.T(), err.Error(), "paragraph 3: note-missing")
+	assert.Equal(suite.T(), 0, created)
+
+	_, err = suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: referenceParagraphs[:2], CheckReferences: true})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, created)
+
+	// 默认不读取被引用的笔记
+	lookups["note-missing"] = 0
+	_, err = suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: referenceParagraphs})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, created)
+	assert.Equal(suite.T(), 0, lookups["note-missing"])
+}
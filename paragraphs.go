@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// NoteAtomToParagraphs 将NoteAtom文档转换回段落列表，是ConvertParagraphsToNoteAtom的逆操作。
+// 无法用段落表示的节点会被跳过，需要了解跳过了哪些节点时使用NoteAtomToParagraphsWithWarnings。
+func NoteAtomToParagraphs(atom NoteAtom) ([]Paragraph, error) {
+	paragraphs, _, err := NoteAtomToParagraphsWithWarnings(atom)
+	return paragraphs, err
+}
+
+// NoteAtomToParagraphsWithWarnings 将NoteAtom文档转换回段落列表，并收集无法表示的节点：
+// 未知的节点类型、段落中的非文本节点、未知的文本标记和段落属性。
+// 根节点必须是doc，否则返回错误。
+func NoteAtomToParagraphsWithWarnings(atom NoteAtom) ([]Paragraph, []ConversionWarning, error) {
+	if atom.Type != "doc" {
+		return nil, nil, fmt.Errorf("root node must be of type \"doc\", got %q", atom.Type)
+	}
+
+	paragraphs := make([]Paragraph, 0, len(atom.Content))
+	var warnings []ConversionWarning
+	for i, block := range atom.Content {
+		warn := func(format string, args ...interface{}) {
+			warnings = append(warnings, ConversionWarning{Paragraph: i + 1, Message: fmt.Sprintf(format, args...)})
+		}
+
+		switch {
+		case block.Type == "paragraph":
+			para := Paragraph{Texts: atomsToTextNodes(block.Content, warn)}
+			for _, k := range sortedKeys(block.Attrs) {
+				if k == "blockquote" && block.Attrs[k] == "true" {
+					para.Type = "quote"
+					continue
+				}
+				warn("段落属性 %s=%q 无法表示，已忽略", k, block.Attrs[k])
+			}
+			paragraphs = append(paragraphs, para)
+		case block.Type == "heading":
+			para := Paragraph{Type: "heading", Level: atomHeadingLevel(block), Texts: atomsToTextNodes(block.Content, warn)}
+			for _, k := range sortedKeys(block.Attrs) {
+				if k == "level" && block.Attrs[k] == strconv.Itoa(para.Level) {
+					continue
+				}
+				warn("标题属性 %s=%q 无法表示，已忽略", k, block.Attrs[k])
+			}
+			paragraphs = append(paragraphs, para)
+		case block.Type == "bulletList" || block.Type == "orderedList":
+			para := Paragraph{Type: "bullet_list"}
+			if block.Type == "orderedList" {
+				para.Type = "ordered_list"
+			}
+			for _, item := range block.Content {
+				if item.Type != "listItem" {
+					warn("列表中的 %q 节点无法表示，已跳过", item.Type)
+					continue
+				}
+				var texts []TextNode
+				for _, child := range item.Content {
+					if child.Type != "paragraph" {
+						warn("列表项中的 %q 节点无法表示，已跳过", child.Type)
+						continue
+					}
+					// 列表项包含多个段落时以换行连接
+					if len(texts) > 0 {
+						texts = append(texts, TextNode{Text: "\n"})
+					}
+					texts = append(texts, atomsToTextNodes(child.Content, warn)...)
+				}
+				para.Items = append(para.Items, texts)
+			}
+			for _, k := range sortedKeys(block.Attrs) {
+				warn("列表属性 %s=%q 无法表示，已忽略", k, block.Attrs[k])
+			}
+			paragraphs = append(paragraphs, para)
+		case block.Type == "note":
+			paragraphs = append(paragraphs, Paragraph{Type: "note", NoteID: block.Attrs["uuid"]})
+		case block.Type == "link_card":
+			paragraphs = append(paragraphs, Paragraph{Type: "link_card", URL: block.Attrs["url"]})
+		case isFileAtomType(block.Type):
+			file := &FileNode{
+				FileType:   block.Type,
+				SourceType: block.Attrs["sourceType"],
+				SourcePath: block.Attrs["uuid"],
+			}
+			for _, k := range sortedKeys(block.Attrs) {
+				if k == "uuid" || k == "sourceType" {
+					continue
+				}
+				if file.Metadata == nil {
+					file.Metadata = make(map[string]string)
+				}
+				file.Metadata[k] = block.Attrs[k]
+			}
+			paragraphs = append(paragraphs, Paragraph{Type: "file", File: file})
+		default:
+			warn("无法表示的节点类型 %q，已跳过", block.Type)
+		}
+	}
+
+	return paragraphs, warnings, nil
+}
+
+// atomsToTextNodes 将段落内容转换为文本节点，非文本节点和未知标记通过warn报告
+func atomsToTextNodes(content []NoteAtom, warn func(format string, args ...interface{})) []TextNode {
+	var texts []TextNode
+	for _, inline := range content {
+		if inline.Type != "text" {
+			warn("段落中的 %q 节点无法表示，已跳过", inline.Type)
+			continue
+		}
+		text := TextNode{Text: inline.Text}
+		for _, mark := range inline.Marks {
+			switch mark.Type {
+			case "bold":
+				text.Bold = true
+			case "italic":
+				text.Italic = true
+			case "strikethrough":
+				text.Strikethrough = true
+			case "code":
+				text.InlineCode = true
+			case "highlight":
+				text.Highlight = true
+			case "textColor":
+				text.Color = mark.Attrs["color"]
+			case "link":
+				text.Link = mark.Attrs["href"]
+			default:
+				warn("文本标记 %q 无法表示，已忽略", mark.Type)
+			}
+		}
+		texts = append(texts, text)
+	}
+	return texts
+}
+
+// handleNoteJSONToParagraphs 处理将NoteAtom JSON转换为段落列表的MCP工具请求，不会调用墨问API
+func (s *MowenMCPServer) handleNoteJSONToParagraphs(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args NoteJSONToParagraphsArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	var atom NoteAtom
+	if err := json.Unmarshal([]byte(args.NoteJSON), &atom); err != nil {
+		return nil, fmt.Errorf("invalid note_json: %w", err)
+	}
+	paragraphs, warnings, err := NoteAtomToParagraphsWithWarnings(atom)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note_json: %w", err)
+	}
+
+	header := fmt.Sprintf("已转换为 %d 个段落，可直接用于create_note或edit_note的paragraphs参数：", len(paragraphs))
+	responseText, err := renderParagraphsResult(header, paragraphs, warnings)
+	if err != nil {
+		return nil, err
+	}
+	return textResult(responseText), nil
+}
+
+// renderParagraphsResult 在header之后渲染转换得到的段落JSON，无法表示的内容按段落序号列在后面
+func renderParagraphsResult(header string, paragraphs []Paragraph, warnings []ConversionWarning) (string, error) {
+	data, err := json.MarshalIndent(paragraphs, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal paragraphs: %w", err)
+	}
+	responseText := header + "\n" + string(data)
+	if len(warnings) > 0 {
+		responseText += fmt.Sprintf("\n\n无法表示的内容（%d 条）：", len(warnings))
+		for _, w := range warnings {
+			responseText += "\n- " + w.String()
+		}
+	}
+	return responseText, nil
+}
+
+// handleGetNoteAsParagraphs 处理读取笔记并转换为段落列表的MCP工具请求，结果可修改后直接通过edit_note提交。
+// 笔记不存在时返回说明而不是错误。
+func (s *MowenMCPServer) handleGetNoteAsParagraphs(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args GetNoteAsParagraphsArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if isNoteNotFoundResponse(err, result) {
+		return textResult(fmt.Sprintf("笔记 %s 不存在", args.NoteID)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, err
+	}
+	paragraphs, warnings, err := NoteAtomToParagraphsWithWarnings(detail.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert note %s: %w", args.NoteID, err)
+	}
+
+	header := fmt.Sprintf("笔记 %s 共 %d 个段落，修改后可直接用于edit_note的paragraphs参数：", args.NoteID, len(paragraphs))
+	responseText, err := renderParagraphsResult(header, paragraphs, warnings)
+	if err != nil {
+		return nil, err
+	}
+	return textResult(responseText), nil
+}
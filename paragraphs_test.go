@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNoteAtomToParagraphsRoundTrip 测试ConvertParagraphsToNoteAtom的输出能还原为原段落
+func TestNoteAtomToParagraphsRoundTrip(t *testing.T) {
+	paragraphs := []Paragraph{
+		{Texts: []TextNode{
+			{Text: "普通文本"},
+			{Text: "加粗高亮", Bold: true, Highlight: true},
+			{Text: "链接", Link: "https://example.com"},
+			{Text: "斜体删除线", Italic: true, Strikethrough: true},
+			{Text: "代码", InlineCode: true},
+		}},
+		{Type: "quote", Texts: []TextNode{{Text: "引用内容"}}},
+		{Type: "heading", Level: 2, Texts: []TextNode{{Text: "二级标题"}}},
+		{Type: "bullet_list", Items: [][]TextNode{{{Text: "无序"}}, {{Text: "加粗项", Bold: true}}}},
+		{Type: "ordered_list", Items: [][]TextNode{{{Text: "有序"}}}},
+		{},
+		{Type: "note", NoteID: "note-abcdef12"},
+		{Type: "file", File: &FileNode{
+			FileType:   "image",
+			SourceType: "local",
+			SourcePath: "file-abcdef12",
+			Metadata:   map[string]string{"alt": "示意图", "align": "center"},
+		}},
+		{Type: "file", File: &FileNode{FileType: "pdf", SourceType: "url", SourcePath: "file-12345678"}},
+		{Type: "link_card", URL: "https://example.com/article"},
+	}
+
+	atom := mustConvert(t, paragraphs)
+	restored, warnings, err := NoteAtomToParagraphsWithWarnings(atom)
+	require.NoError(t, err)
+	assert.Empty(t, warnings)
+	assert.Equal(t, paragraphs, restored)
+
+	// 再次转换得到相同的NoteAtom
+	assert.Equal(t, atom, mustConvert(t, restored))
+}
+
+// TestNoteAtomToParagraphsUnrepresentable 测试无法表示的节点被跳过并报告
+func TestNoteAtomToParagraphsUnrepresentable(t *testing.T) {
+	atom := NoteAtom{Type: "doc", Content: []NoteAtom{
+		{Type: "paragraph", Attrs: map[string]string{"align": "center"}, Content: []NoteAtom{
+			{Type: "text", Text: "下划线", Marks: []NoteAtom{{Type: "underline"}, {Type: "bold"}}},
+			{Type: "hard_break"},
+		}},
+		{Type: "table"},
+		{Type: "paragraph", Content: []NoteAtom{{Type: "text", Text: "保留"}}},
+		{Type: "orderedList", Attrs: map[string]string{"start": "3"}, Content: []NoteAtom{
+			{Type: "listItem", Content: []NoteAtom{
+				{Type: "paragraph", Content: []NoteAtom{{Type: "text", Text: "第一段"}}},
+				{Type: "bulletList"},
+				{Type: "paragraph", Content: []NoteAtom{{Type: "text", Text: "第二段"}}},
+			}},
+			{Type: "paragraph"},
+		}},
+	}}
+
+	paragraphs, warnings, err := NoteAtomToParagraphsWithWarnings(atom)
+	require.NoError(t, err)
+	assert.Equal(t, []Paragraph{
+		{Texts: []TextNode{{Text: "下划线", Bold: true}}},
+		{Texts: []TextNode{{Text: "保留"}}},
+		{Type: "ordered_list", Items: [][]TextNode{{{Text: "第一段"}, {Text: "\n"}, {Text: "第二段"}}}},
+	}, paragraphs)
+
+	messages := make([]string, 0, len(warnings))
+	for _, w := range warnings {
+		messages = append(messages, w.String())
+	}
+	assert.Equal(t, []string{
+		`第 1 段：文本标记 "underline" 无法表示，已忽略`,
+		`第 1 段：段落中的 "hard_break" 节点无法表示，已跳过`,
+		`第 1 段：段落属性 align="center" 无法表示，已忽略`,
+		`第 2 段：无法表示的节点类型 "table"，已跳过`,
+		`第 4 段：列表项中的 "bulletList" 节点无法表示，已跳过`,
+		`第 4 段：列表中的 "paragraph" 节点无法表示，已跳过`,
+		`第 4 段：列表属性 start="3" 无法表示，已忽略`,
+	}, messages)
+
+	_, err = NoteAtomToParagraphs(NoteAtom{Type: "paragraph"})
+	assert.Error(t, err)
+}
+
+// TestHandleNoteJSONToParagraphs 测试转换工具输出段落JSON和无法表示的内容
+func (suite *ServerTestSuite) TestHandleNoteJSONToParagraphs() {
+	atom := mustConvert(suite.T(), []Paragraph{
+		{Texts: []TextNode{{Text: "你好", Bold: true}}},
+		{Type: "link_card", URL: "https://example.com"},
+	})
+	atom.Content = append(atom.Content, NoteAtom{Type: "table"})
+	data, err := json.Marshal(atom)
+	require.NoError(suite.T(), err)
+
+	text, err := suite.callTool(suite.mcpServer.handleNoteJSONToParagraphs, NoteJSONToParagraphsArgs{NoteJSON: string(data)})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "已转换为 2 个段落")
+	assert.Contains(suite.T(), text, `"bold": true`)
+	assert.Contains(suite.T(), text, `"url": "https://example.com"`)
+	assert.Contains(suite.T(), text, `第 3 段：无法表示的节点类型 "table"`)
+
+	_, err = suite.callTool(suite.mcpServer.handleNoteJSONToParagraphs, NoteJSONToParagraphsArgs{NoteJSON: "{not json"})
+	assert.Error(suite.T(), err)
+}
+
+// TestHandleGetNoteAsParagraphs 测试创建的笔记读回后得到与提交时相同的段落，无法表示的内容单独列出
+func (suite *ServerTestSuite) TestHandleGetNoteAsParagraphs() {
+	paragraphs := []Paragraph{
+		{Type: "heading", Level: 2, Texts: []TextNode{{Text: "标题"}}},
+		{Texts: []TextNode{{Text: "加粗", Bold: true}, {Text: "链接", Link: "https://example.com"}}},
+		{Type: "quote", Texts: []TextNode{{Text: "引用"}}},
+		{Type: "bullet_list", Items: [][]TextNode{{{Text: "一"}}, {{Text: "二", Italic: true}}}},
+		{Type: "note", NoteID: "note-abcdef12"},
+		{Type: "file", File: &FileNode{FileType: "image", SourceType: "upload", SourcePath: "file-abcdef12", Metadata: map[string]string{"alt": "图"}}},
+		{Type: "link_card", URL: "https://example.com/article"},
+	}
+
+	var created NoteCreateRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&created))
+		mockSuccess(map[string]interface{}{"noteId": "note-roundtrip"})(w, r)
+	}
+	_, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: paragraphs})
+	require.NoError(suite.T(), err)
+
+	body := created.Body
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var detailReq NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&detailReq))
+		if detailReq.NoteID != "note-roundtrip" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID, "body": body})(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleGetNoteAsParagraphs, GetNoteAsParagraphsArgs{NoteID: "note-roundtrip"})
+	require.NoError(suite.T(), err)
+	header, data, found := strings.Cut(text, "\n")
+	require.True(suite.T(), found)
+	assert.Equal(suite.T(), "笔记 note-roundtrip 共 7 个段落，修改后可直接用于edit_note的paragraphs参数：", header)
+	var restored []Paragraph
+	require.NoError(suite.T(), json.Unmarshal([]byte(data), &restored))
+	assert.Equal(suite.T(), paragraphs, restored)
+
+	// 无法表示的节点被跳过并列出
+	body.Content = append(body.Content, NoteAtom{Type: "table"})
+	text, err = suite.callTool(suite.mcpServer.handleGetNoteAsParagraphs, GetNoteAsParagraphsArgs{NoteID: "note-roundtrip"})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 7 个段落")
+	assert.Contains(suite.T(), text, "无法表示的内容（1 条）：\n- 第 8 段：无法表示的节点类型 \"table\"")
+
+	text, err = suite.callTool(suite.mcpServer.handleGetNoteAsParagraphs, GetNoteAsParagraphsArgs{NoteID: "missing"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 missing 不存在", text)
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// formatRemaining 把剩余时长描述为天、小时或分钟，不足1分钟按1分钟计
+func formatRemaining(d time.Duration) string {
+	days := int(d / (24 * time.Hour))
+	hours := int(d % (24 * time.Hour) / time.Hour)
+	switch {
+	case days > 0 && hours > 0:
+		return fmt.Sprintf("%d天%d小时", days, hours)
+	case days > 0:
+		return fmt.Sprintf("%d天", days)
+	case hours > 0:
+		return fmt.Sprintf("%d小时", hours)
+	}
+	minutes := int(d / time.Minute)
+	if minutes < 1 {
+		minutes = 1
+	}
+	return fmt.Sprintf("%d分钟", minutes)
+}
+
+// explainPrivacy 用通俗的语言说明隐私设置的效果。
+// expireAt为Unix秒级时间戳，为nil或0表示永不过期；noShare和expireAt只对rule类型有效。
+func explainPrivacy(privacyType string, noShare bool, expireAt *int64, now time.Time) string {
+	switch privacyType {
+	case "public":
+		return "公开：任何人都可以查看这篇笔记，也可以分享，永不过期"
+	case "private":
+		return "私有：只有你自己可以查看这篇笔记"
+	}
+
+	var sb strings.Builder
+	sb.WriteString("规则公开：任何人都可以查看这篇笔记")
+	if expireAt == nil || *expireAt == 0 {
+		sb.WriteString("，永不过期")
+	} else {
+		expires := time.Unix(*expireAt, 0)
+		fmt.Fprintf(&sb, "，直到 %s（还有%s），之后只有你自己可以查看", formatNoteTimestamp(expires), formatRemaining(expires.Sub(now)))
+	}
+	if noShare {
+		sb.WriteString("；禁止分享")
+	} else {
+		sb.WriteString("；允许分享")
+	}
+	return sb.String()
+}
+
+// handlePreviewPrivacy 处理预览隐私设置效果的MCP工具请求。
+// 按set_note_privacy相同的规则计算并校验截止时间，但不调用墨问API。
+func (s *MowenMCPServer) handlePreviewPrivacy(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args PreviewPrivacyArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	now := time.Now()
+	switch args.PrivacyType {
+	case "public", "private":
+		text := explainPrivacy(args.PrivacyType, false, nil, now)
+		if args.NoShare != nil || args.ExpireAt != nil || args.ExpireIn != "" {
+			text += "\n\n注意：no_share、expire_at和expire_in仅对rule类型有效，已忽略"
+		}
+		return textResult(text), nil
+	case "rule":
+	default:
+		return nil, fmt.Errorf("invalid privacy_type %q: must be public, private or rule", args.PrivacyType)
+	}
+
+	expireAt, err := s.resolveExpireAt(args.ExpireAt, args.ExpireIn, now)
+	if err != nil {
+		return nil, err
+	}
+	if expireAt != nil {
+		if _, err := FormatExpireAt(*expireAt, s.expireAtUnit, now); err != nil {
+			return nil, err
+		}
+	}
+	text := explainPrivacy(args.PrivacyType, args.NoShare != nil && *args.NoShare, expireAt, now)
+	if args.ExpireAt == nil && args.ExpireIn == "" && expireAt != nil {
+		text += fmt.Sprintf("\n\n未指定截止时间，使用MOWEN_DEFAULT_EXPIRE_IN配置的默认公开时长 %s", s.defaultExpireIn)
+	}
+	return textResult(text), nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestExplainPrivacy 测试公开、私有、永不过期和带截止时间的规则公开的说明
+func TestExplainPrivacy(t *testing.T) {
+	now := time.Date(2024, 12, 29, 12, 0, 0, 0, time.UTC)
+	expireAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC).Unix()
+	never := int64(0)
+
+	assert.Equal(t, "公开：任何人都可以查看这篇笔记，也可以分享，永不过期", explainPrivacy("public", false, nil, now))
+	assert.Equal(t, "私有：只有你自己可以查看这篇笔记", explainPrivacy("private", false, nil, now))
+	assert.Equal(t, "规则公开：任何人都可以查看这篇笔记，永不过期；允许分享", explainPrivacy("rule", false, nil, now))
+	assert.Equal(t, "规则公开：任何人都可以查看这篇笔记，永不过期；禁止分享", explainPrivacy("rule", true, &never, now))
+	assert.Equal(t, "规则公开：任何人都可以查看这篇笔记，直到 2025-01-01T00:00:00Z（还有2天12小时），之后只有你自己可以查看；禁止分享",
+		explainPrivacy("rule", true, &expireAt, now))
+}
+
+// TestFormatRemaining 测试剩余时长的描述
+func TestFormatRemaining(t *testing.T) {
+	assert.Equal(t, "3天", formatRemaining(72*time.Hour))
+	assert.Equal(t, "5小时", formatRemaining(5*time.Hour+20*time.Minute))
+	assert.Equal(t, "20分钟", formatRemaining(20*time.Minute))
+	assert.Equal(t, "1分钟", formatRemaining(10*time.Second))
+}
+
+// TestHandlePreviewPrivacy 测试预览隐私设置不调用API，并按set_note_privacy的规则校验截止时间
+func (suite *ServerTestSuite) TestHandlePreviewPrivacy() {
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("预览隐私设置不应调用API")
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handlePreviewPrivacy, PreviewPrivacyArgs{PrivacyType: "public"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "公开：任何人都可以查看这篇笔记，也可以分享，永不过期", text)
+
+	noShare := true
+	text, err = suite.callTool(suite.mcpServer.handlePreviewPrivacy, PreviewPrivacyArgs{PrivacyType: "private", NoShare: &noShare})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "私有：只有你自己可以查看这篇笔记")
+	assert.Contains(suite.T(), text, "仅对rule类型有效，已忽略")
+
+	text, err = suite.callTool(suite.mcpServer.handlePreviewPrivacy, PreviewPrivacyArgs{PrivacyType: "rule"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "规则公开：任何人都可以查看这篇笔记，永不过期；允许分享", text)
+
+	text, err = suite.callTool(suite.mcpServer.handlePreviewPrivacy, PreviewPrivacyArgs{PrivacyType: "rule", NoShare: &noShare, ExpireIn: "7d"})
+	require.NoError(suite.T(), err)
+	expires := formatNoteTimestamp(time.Unix(time.Now().Add(7*24*time.Hour).Unix(), 0))
+	assert.Contains(suite.T(), text, "直到 "+expires)
+	assert.Contains(suite.T(), text, "之后只有你自己可以查看；禁止分享")
+
+	suite.mcpServer.defaultExpireIn = 36 * time.Hour
+	text, err = suite.callTool(suite.mcpServer.handlePreviewPrivacy, PreviewPrivacyArgs{PrivacyType: "rule"})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "（还有1天")
+	assert.Contains(suite.T(), text, "使用MOWEN_DEFAULT_EXPIRE_IN配置的默认公开时长 36h0m0s")
+
+	past := time.Now().Add(-time.Hour).Unix()
+	_, err = suite.callTool(suite.mcpServer.handlePreviewPrivacy, PreviewPrivacyArgs{PrivacyType: "rule", ExpireAt: &past})
+	assert.ErrorContains(suite.T(), err, "is not in the future")
+
+	_, err = suite.callTool(suite.mcpServer.handlePreviewPrivacy, PreviewPrivacyArgs{PrivacyType: "shared"})
+	assert.ErrorContains(suite.T(), err, `invalid privacy_type "shared"`)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrNoteQuotaExceeded 本次运行中创建的笔记数已达到MOWEN_MAX_NOTES_PER_SESSION
+var ErrNoteQuotaExceeded = errors.New("note creation limit for this session reached")
+
+// noteQuota 限制服务本次运行中可创建的笔记数，防止失控的自动化调用批量创建笔记。
+// 计数只保存在内存中，重启服务后清零。
+type noteQuota struct {
+	mu      sync.Mutex
+	max     int
+	created int // 已创建和正在创建的笔记数
+}
+
+// newNoteQuota 创建笔记数量限制，max为0时表示不限制，返回nil
+func newNoteQuota(max int) *noteQuota {
+	if max == 0 {
+		return nil
+	}
+	return &noteQuota{max: max}
+}
+
+// reserve 在创建笔记前占用一个名额，名额用完时返回包装了ErrNoteQuotaExceeded的错误。
+// 正在进行的创建请求也占用名额，避免并发调用超出上限。
+func (q *noteQuota) reserve() error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.created >= q.max {
+		return fmt.Errorf("%w: already created %d notes (MOWEN_MAX_NOTES_PER_SESSION=%d), restart the server to reset", ErrNoteQuotaExceeded, q.created, q.max)
+	}
+	q.created++
+	return nil
+}
+
+// release 归还创建失败的请求占用的名额
+func (q *noteQuota) release() {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.created > 0 {
+		q.created--
+	}
+}
+
+// usage 返回已使用的名额和上限
+func (q *noteQuota) usage() (int, int) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.created, q.max
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCreateNoteQuota 测试创建到上限后拒绝后续创建，创建失败不占用名额
+func (suite *ServerTestSuite) TestCreateNoteQuota() {
+	suite.T().Setenv("MOWEN_MAX_NOTES_PER_SESSION", "2")
+	mcpServer, err := NewMowenMCPServer()
+	require.NoError(suite.T(), err)
+	mcpServer.mowenClient.baseURL = suite.mockHTTPServer.URL
+
+	calls := 0
+	fail := false
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if fail {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mockSuccess(map[string]interface{}{"note_id": "note-quota"})(w, r)
+	}
+	args := CreateNoteArgs{Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "内容"}}}}}
+
+	fail = true
+	_, err = suite.callTool(mcpServer.handleCreateNote, args)
+	require.Error(suite.T(), err)
+	fail = false
+
+	for i := 0; i < 2; i++ {
+		_, err = suite.callTool(mcpServer.handleCreateNote, args)
+		require.NoError(suite.T(), err)
+	}
+	_, err = suite.callTool(mcpServer.handleCreateNote, args)
+	require.Error(suite.T(), err)
+	assert.True(suite.T(), errors.Is(err, ErrNoteQuotaExceeded))
+	assert.Contains(suite.T(), err.Error(), "MOWEN_MAX_NOTES_PER_SESSION=2")
+	assert.Equal(suite.T(), 3, calls, "rejected creation must not reach the API")
+
+	// 默认不限制
+	assert.Nil(suite.T(), suite.mcpServer.noteQuota)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// rateLimitHeaders 记录的限流响应头：常见的X-RateLimit-*、IETF草案的RateLimit-*以及Retry-After
+var rateLimitHeaders = []string{
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+	"RateLimit-Policy",
+	"RateLimit-Limit",
+	"RateLimit-Remaining",
+	"RateLimit-Reset",
+	"Retry-After",
+}
+
+// RateLimitHeader 响应中的一个限流响应头
+type RateLimitHeader struct {
+	Name  string
+	Value string
+}
+
+// RateLimitStatus 最近一次墨问API响应的限流信息
+type RateLimitStatus struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+	ReceivedAt time.Time
+	Headers    []RateLimitHeader // 按rateLimitHeaders的顺序排列，响应中没有限流响应头时为空
+}
+
+// rateLimitTracker 保存最近一次响应的限流信息，可被并发的工具调用共享
+type rateLimitTracker struct {
+	mu   sync.Mutex
+	now  func() time.Time
+	last *RateLimitStatus
+}
+
+// newRateLimitTracker 创建限流信息记录器
+func newRateLimitTracker() *rateLimitTracker {
+	return &rateLimitTracker{now: time.Now}
+}
+
+// record 记录响应中的限流响应头。每个响应都会覆盖上一次的记录，以反映最新状态。
+func (t *rateLimitTracker) record(method, endpoint string, resp *http.Response) {
+	if t == nil {
+		return
+	}
+	status := &RateLimitStatus{
+		Method:     method,
+		Endpoint:   endpoint,
+		StatusCode: resp.StatusCode,
+		ReceivedAt: t.now(),
+	}
+	for _, name := range rateLimitHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			status.Headers = append(status.Headers, RateLimitHeader{Name: name, Value: value})
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = status
+}
+
+// snapshot 返回最近一次响应的限流信息，尚未收到响应时返回false
+func (t *rateLimitTracker) snapshot() (RateLimitStatus, bool) {
+	if t == nil {
+		return RateLimitStatus{}, false
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.last == nil {
+		return RateLimitStatus{}, false
+	}
+	return *t.last, true
+}
+
+// RenderRateLimitStatus 将限流信息渲染为文本
+func RenderRateLimitStatus(status RateLimitStatus) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "最近一次响应：%s %s，状态码 %d，时间 %s",
+		status.Method, status.Endpoint, status.StatusCode, status.ReceivedAt.Format(time.RFC3339))
+	if len(status.Headers) == 0 {
+		sb.WriteString("\n该响应未包含限流相关的响应头")
+		return sb.String()
+	}
+	for _, header := range status.Headers {
+		fmt.Fprintf(&sb, "\n- %s: %s", header.Name, header.Value)
+	}
+	return sb.String()
+}
+
+// handleRateLimitStatus 处理查看最近一次响应限流信息的MCP工具请求，不会调用墨问API
+func (s *MowenMCPServer) handleRateLimitStatus(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args RateLimitStatusArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	status, ok := s.mowenClient.rateLimits.snapshot()
+	if !ok {
+		return textResult("尚未收到墨问API的响应，请在调用其他工具后再查看"), nil
+	}
+	return textResult(RenderRateLimitStatus(status)), nil
+}
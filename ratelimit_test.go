@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHandleRateLimitStatus 测试报告最近一次响应中的限流响应头，以及没有响应或没有限流响应头的情况
+func (suite *ServerTestSuite) TestHandleRateLimitStatus() {
+	suite.mcpServer.mowenClient.rateLimits.now = func() time.Time {
+		return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleRateLimitStatus, RateLimitStatusArgs{})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "尚未收到墨问API的响应")
+
+	suite.routes[NoteShareEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Limit", "100")
+		w.Header().Set("X-RateLimit-Remaining", "42")
+		w.Header().Set("X-RateLimit-Reset", "1767323045")
+		mockSuccess(map[string]interface{}{"share_url": "https://mowen.cn/s/abc"})(w, r)
+	}
+	_, err = suite.mcpServer.mowenClient.GetNoteShare(context.Background(), "note-1")
+	require.NoError(suite.T(), err)
+
+	text, err = suite.callTool(suite.mcpServer.handleRateLimitStatus, RateLimitStatusArgs{})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "最近一次响应：POST "+NoteShareEndpoint+"，状态码 200，时间 2026-01-02T03:04:05Z"+
+		"\n- X-RateLimit-Limit: 100\n- X-RateLimit-Remaining: 42\n- X-RateLimit-Reset: 1767323045", text)
+
+	// 之后的响应没有限流响应头时覆盖之前的记录
+	suite.routes[NoteShareEndpoint] = mockSuccess(map[string]interface{}{"share_url": "https://mowen.cn/s/abc"})
+	_, err = suite.mcpServer.mowenClient.GetNoteShare(context.Background(), "note-1")
+	require.NoError(suite.T(), err)
+	text, err = suite.callTool(suite.mcpServer.handleRateLimitStatus, RateLimitStatusArgs{})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "该响应未包含限流相关的响应头")
+	assert.NotContains(suite.T(), text, "X-RateLimit-Remaining")
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"syscall"
+	"time"
+)
+
+// 自动重试的默认配置
+const (
+	// DefaultMaxRetries 默认的最大重试次数，不含首次请求。默认不重试，与早期版本的行为保持一致
+	DefaultMaxRetries = 0
+	// DefaultRetryBackoff 首次重试前的等待时间，之后每次翻倍
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// IdempotencyKeyHeader 携带幂等键的请求头
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// DefaultPrepareTimeout 上传准备请求的默认超时时间，准备接口只返回上传地址，应当很快响应
+	DefaultPrepareTimeout = 10 * time.Second
+	// DefaultPrepareRetries 上传准备请求超时或遇到临时性错误时的默认重试次数
+	DefaultPrepareRetries = 2
+)
+
+// DefaultRetryableStatuses 默认视为临时性错误的HTTP状态码：429和全部5xx，可通过MOWEN_RETRY_STATUSES调整
+var DefaultRetryableStatuses = func() []int {
+	statuses := []int{http.StatusTooManyRequests}
+	for code := 500; code <= 599; code++ {
+		statuses = append(statuses, code)
+	}
+	return statuses
+}()
+
+// apiOperation 描述一次API调用是否可以安全地自动重试。
+// 每个客户端方法显式选择分类：读取和覆盖式设置重复执行结果相同，可以重试；
+// 创建等操作重复执行会产生重复数据，只有携带幂等键时才重试。
+type apiOperation struct {
+	idempotent     bool   // 重复执行结果相同
+	idempotencyKey string // 非幂等操作的幂等键，由服务端据此去重
+	// 以下为独立的超时和重试策略，ownPolicy为false时使用客户端的默认超时和MOWEN_MAX_RETRIES
+	ownPolicy  bool
+	timeout    time.Duration // 单次请求的超时时间，为0时只受客户端默认超时限制
+	maxRetries int           // 不含首次请求的最大重试次数
+}
+
+var (
+	// idempotentOperation 读取以及编辑、设置等覆盖式写入
+	idempotentOperation = apiOperation{idempotent: true}
+	// nonIdempotentOperation 创建笔记、上传文件、重置密钥等重复执行有副作用的操作
+	nonIdempotentOperation = apiOperation{}
+)
+
+// withIdempotencyKey 返回携带幂等键的操作，key为空时保持不变
+func (op apiOperation) withIdempotencyKey(key string) apiOperation {
+	op.idempotencyKey = key
+	return op
+}
+
+// withPolicy 返回使用独立超时和重试次数的操作，用于响应时间与其他请求差别较大的接口
+func (op apiOperation) withPolicy(timeout time.Duration, maxRetries int) apiOperation {
+	op.ownPolicy = true
+	op.timeout = timeout
+	op.maxRetries = maxRetries
+	return op
+}
+
+// retryable 判断操作失败后是否允许自动重试
+func (op apiOperation) retryable() bool {
+	return op.idempotent || op.idempotencyKey != ""
+}
+
+// headers 返回操作需要附加的请求头
+func (op apiOperation) headers() map[string]string {
+	if op.idempotencyKey == "" {
+		return nil
+	}
+	return map[string]string{IdempotencyKeyHeader: op.idempotencyKey}
+}
+
+// isRetryableError 判断错误是否为临时性错误：网络超时或连接错误，或HTTP状态码在statuses中
+func isRetryableError(err error, statuses map[int]bool) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statuses[statusErr.StatusCode]
+	}
+	return isTransientNetworkError(err)
+}
+
+// isTransientNetworkError 判断请求发送失败是否为临时性的网络问题：超时、连接被重置或拒绝、响应中途断开。
+// 重定向策略返回的错误（如超过MOWEN_MAX_REDIRECTS）、TLS和证书错误以及无效的URL都是永久性的，不会重试。
+func isTransientNetworkError(err error) bool {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED)
+}
+
+// sortedStatuses 返回按升序排列的状态码
+func sortedStatuses(statuses map[int]bool) []int {
+	codes := make([]int, 0, len(statuses))
+	for code := range statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	return codes
+}
+
+// doOperation 发送POST请求，操作可安全重试且遇到临时性错误时按指数退避自动重试。
+// 操作指定了独立策略时按其超时和重试次数执行；ctx取消后不再重试，等待中的退避也会立即结束。
+func (c *MowenClient) doOperation(ctx context.Context, op apiOperation, endpoint string, body interface{}) ([]byte, error) {
+	attempts := 1
+	switch {
+	case op.ownPolicy:
+		attempts += op.maxRetries
+	case op.retryable():
+		attempts += c.maxRetries
+	}
+
+	var respBody []byte
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := c.retryBackoff << (attempt - 1)
+			c.logger.Warnf("POST %s 第 %d 次重试（%s 后）: %v", endpoint, attempt, delay, err)
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("retry of %s canceled: %w", endpoint, ctx.Err())
+			case <-timer.C:
+			}
+		}
+		respBody, err = c.makeRequestWithHeaders(ctx, "POST", endpoint, body, op.headers(), op.timeout)
+		if err == nil || ctx.Err() != nil || !isRetryableError(err, c.retryStatuses) {
+			break
+		}
+	}
+	return respBody, err
+}
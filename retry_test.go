@@ -0,0 +1,213 @@
+package main
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyClient 创建连接到测试服务器的客户端，服务器前failures次请求返回status，之后返回成功
+func newFlakyClient(t *testing.T, failures, status int) (*MowenClient, *int, *[]string) {
+	calls := 0
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		keys = append(keys, r.Header.Get(IdempotencyKeyHeader))
+		if calls <= failures {
+			w.WriteHeader(status)
+			return
+		}
+		w.Write([]byte(`{"code":0,"data":{"note_id":"note-1"}}`))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("MOWEN_API_KEY", "test-api-key")
+	client, err := NewMowenClient()
+	require.NoError(t, err)
+	client.baseURL = server.URL
+	client.maxRetries = 2
+	client.retryBackoff = time.Millisecond
+	return client, &calls, &keys
+}
+
+// TestIsRetryableError 测试临时性错误的判断
+func TestIsRetryableError(t *testing.T) {
+	defaults, err := loadRetryStatuses()
+	require.NoError(t, err)
+	assert.True(t, isRetryableError(&HTTPStatusError{StatusCode: http.StatusServiceUnavailable}, defaults))
+	assert.True(t, isRetryableError(&HTTPStatusError{StatusCode: http.StatusTooManyRequests}, defaults))
+	assert.True(t, isRetryableError(&HTTPStatusError{StatusCode: http.StatusInternalServerError}, defaults))
+	assert.True(t, isRetryableError(&HTTPStatusError{StatusCode: 599}, defaults))
+	assert.False(t, isRetryableError(&HTTPStatusError{StatusCode: http.StatusBadRequest}, defaults))
+	assert.False(t, isRetryableError(assert.AnError, defaults))
+
+	// 只有网络超时和连接错误才重试，重定向策略、证书和URL错误是永久性的
+	sendErr := func(err error) error {
+		return fmt.Errorf("failed to send request: %w", &url.Error{Op: "Post", URL: "https://open.mowen.cn/api", Err: err})
+	}
+	assert.True(t, isRetryableError(sendErr(context.DeadlineExceeded), defaults))
+	assert.True(t, isRetryableError(sendErr(&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), defaults))
+	assert.True(t, isRetryableError(sendErr(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), defaults))
+	assert.True(t, isRetryableError(sendErr(io.ErrUnexpectedEOF), defaults))
+	assert.False(t, isRetryableError(sendErr(errors.New("stopped after 10 redirects")), defaults))
+	assert.False(t, isRetryableError(sendErr(x509.UnknownAuthorityError{}), defaults))
+	assert.False(t, isRetryableError(sendErr(errors.New(`unsupported protocol scheme ""`)), defaults))
+}
+
+// TestRedirectLimitNotRetried 测试超过重定向次数上限的请求不会重试
+func TestRedirectLimitNotRetried(t *testing.T) {
+	t.Setenv("MOWEN_API_KEY", "test-api-key")
+	t.Setenv("MOWEN_MAX_REDIRECTS", "1")
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		http.Redirect(w, r, r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer server.Close()
+
+	client, err := NewMowenClient(WithRetry(3, time.Millisecond))
+	require.NoError(t, err)
+	client.baseURL = server.URL
+	_, err = client.GetNoteShare(context.Background(), "note-1")
+	assert.ErrorContains(t, err, "stopped after 1 redirects")
+	assert.Equal(t, 2, calls, "初次请求和一次重定向，不应重试")
+}
+
+// TestCreateNoteNotRetriedWithoutKey 测试没有幂等键的创建请求不会重试
+func TestCreateNoteNotRetriedWithoutKey(t *testing.T) {
+	client, calls, _ := newFlakyClient(t, 1, http.StatusServiceUnavailable)
+
+	_, err := client.CreateNote(context.Background(), NoteCreateRequest{})
+	require.Error(t, err)
+	assert.Equal(t, 1, *calls)
+}
+
+// TestCreateNoteRetriedWithKey 测试携带幂等键的创建请求会重试，且每次都发送幂等键
+func TestCreateNoteRetriedWithKey(t *testing.T) {
+	client, calls, keys := newFlakyClient(t, 1, http.StatusServiceUnavailable)
+
+	result, err := client.CreateNote(context.Background(), NoteCreateRequest{IdempotencyKey: "import-42"})
+	require.NoError(t, err)
+	assert.Equal(t, "note-1", extractDataString(result, "note_id"))
+	assert.Equal(t, 2, *calls)
+	assert.Equal(t, []string{"import-42", "import-42"}, *keys)
+}
+
+// TestReadRetried 测试读取请求遇到临时性错误时重试，超过次数后返回错误
+func TestReadRetried(t *testing.T) {
+	client, calls, keys := newFlakyClient(t, 2, http.StatusBadGateway)
+
+	_, err := client.GetNoteShare(context.Background(), "note-1")
+	require.NoError(t, err)
+	assert.Equal(t, 3, *calls)
+	assert.Equal(t, []string{"", "", ""}, *keys)
+
+	client, calls, _ = newFlakyClient(t, 10, http.StatusBadGateway)
+	client.maxRetries = 1
+	_, err = client.GetNoteShare(context.Background(), "note-1")
+	require.Error(t, err)
+	assert.Equal(t, 2, *calls)
+
+	// 非临时性错误不重试
+	client, calls, _ = newFlakyClient(t, 10, http.StatusBadRequest)
+	_, err = client.GetNoteShare(context.Background(), "note-1")
+	require.Error(t, err)
+	assert.Equal(t, 1, *calls)
+}
+
+// TestConfiguredRetryStatuses 测试只在配置的状态码上重试
+func TestConfiguredRetryStatuses(t *testing.T) {
+	t.Setenv("MOWEN_RETRY_STATUSES", "500, 503")
+	client, calls, _ := newFlakyClient(t, 1, http.StatusInternalServerError)
+	_, err := client.GetNoteShare(context.Background(), "note-1")
+	require.NoError(t, err)
+	assert.Equal(t, 2, *calls)
+
+	// 默认状态码502不在配置中，不再重试
+	client, calls, _ = newFlakyClient(t, 1, http.StatusBadGateway)
+	_, err = client.GetNoteShare(context.Background(), "note-1")
+	require.Error(t, err)
+	assert.Equal(t, 1, *calls)
+}
+
+// TestLoadRetryStatuses 测试重试状态码的解析和校验
+func TestLoadRetryStatuses(t *testing.T) {
+	statuses, err := loadRetryStatuses()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultRetryableStatuses, sortedStatuses(statuses))
+
+	t.Setenv("MOWEN_RETRY_STATUSES", " 503,429 ,503")
+	statuses, err = loadRetryStatuses()
+	require.NoError(t, err)
+	assert.Equal(t, []int{429, 503}, sortedStatuses(statuses))
+
+	for _, value := range []string{"abc", "429,", "99", "600", "404", "408"} {
+		t.Setenv("MOWEN_RETRY_STATUSES", value)
+		_, err = loadRetryStatuses()
+		assert.Error(t, err, value)
+	}
+}
+
+// TestClientOptions 测试通过选项覆盖超时和重试策略
+func TestClientOptions(t *testing.T) {
+	t.Setenv("MOWEN_API_KEY", "test-api-key")
+	client, err := NewMowenClient()
+	require.NoError(t, err)
+	assert.Equal(t, DefaultHTTPTimeout, client.httpClient.Timeout)
+	assert.Equal(t, 0, client.maxRetries, "默认不重试")
+	assert.Equal(t, DefaultRetryBackoff, client.retryBackoff)
+
+	client, err = NewMowenClient(WithTimeout(5*time.Second), WithRetry(4, time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, client.httpClient.Timeout)
+	assert.Equal(t, 3, client.maxRetries)
+	assert.Equal(t, time.Second, client.retryBackoff)
+
+	// 选项覆盖环境变量，maxAttempts小于1时按不重试处理
+	t.Setenv("MOWEN_MAX_RETRIES", "5")
+	client, err = NewMowenClient(WithRetry(0, time.Millisecond))
+	require.NoError(t, err)
+	assert.Equal(t, 0, client.maxRetries)
+}
+
+// TestRetryStopsWhenContextCanceled 测试请求的context取消后不再重试
+func TestRetryStopsWhenContextCanceled(t *testing.T) {
+	client, calls, _ := newFlakyClient(t, 10, http.StatusServiceUnavailable)
+	WithRetry(5, time.Hour)(client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.doOperation(ctx, idempotentOperation, NoteShareEndpoint, struct{}{})
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-done:
+		require.Error(t, err)
+		assert.True(t, errors.Is(err, context.Canceled))
+	case <-time.After(5 * time.Second):
+		t.Fatal("retry backoff ignored context cancellation")
+	}
+	assert.Equal(t, 1, *calls)
+
+	// 已取消的context不会发出请求
+	_, err := client.doOperation(ctx, idempotentOperation, NoteShareEndpoint, struct{}{})
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, 1, *calls)
+}
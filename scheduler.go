@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// MaxScheduleHorizon 定时发布时间距今的最大跨度。
+// 墨问API的规则公开只支持公开截止时间，不支持定时公开，定时发布由服务进程内的计时器完成，过长的计划容易因重启丢失。
+const MaxScheduleHorizon = 30 * 24 * time.Hour
+
+// scheduledTimer 可取消的计时器，*time.Timer满足该接口
+type scheduledTimer interface {
+	Stop() bool
+}
+
+// publishScheduler 笔记定时发布调度器，同一笔记只保留最后一次计划。
+// 计划只保存在内存中，服务重启后未执行的计划会丢失。
+type publishScheduler struct {
+	mu        sync.Mutex
+	timers    map[string]scheduledTimer
+	afterFunc func(delay time.Duration, fn func()) scheduledTimer
+}
+
+// newPublishScheduler 创建定时发布调度器
+func newPublishScheduler() *publishScheduler {
+	return &publishScheduler{
+		timers: make(map[string]scheduledTimer),
+		afterFunc: func(delay time.Duration, fn func()) scheduledTimer {
+			return time.AfterFunc(delay, fn)
+		},
+	}
+}
+
+// schedule 在delay之后执行fn，若该笔记已有计划则取消旧计划并返回true
+func (p *publishScheduler) schedule(noteID string, delay time.Duration, fn func()) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	previous, replaced := p.timers[noteID]
+	if replaced {
+		previous.Stop()
+	}
+
+	var timer scheduledTimer
+	timer = p.afterFunc(delay, func() {
+		p.mu.Lock()
+		// 计划可能已被新的计划替换
+		if p.timers[noteID] != timer {
+			p.mu.Unlock()
+			return
+		}
+		delete(p.timers, noteID)
+		p.mu.Unlock()
+		fn()
+	})
+	p.timers[noteID] = timer
+	return replaced
+}
+
+// pending 返回尚未执行的计划数量
+func (p *publishScheduler) pending() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.timers)
+}
+
+// ValidatePublishAt 校验Unix秒级定时发布时间，必须在未来且不超过MaxScheduleHorizon
+func ValidatePublishAt(publishAt int64, now time.Time) (time.Time, error) {
+	if publishAt <= 0 {
+		return time.Time{}, fmt.Errorf("invalid publish_at %d: must be a positive Unix timestamp in seconds", publishAt)
+	}
+	if publishAt > 1e11 {
+		return time.Time{}, fmt.Errorf("invalid publish_at %d: looks like milliseconds, expected a Unix timestamp in seconds", publishAt)
+	}
+	at := time.Unix(publishAt, 0)
+	if !at.After(now) {
+		return time.Time{}, fmt.Errorf("invalid publish_at %d: %s is not in the future", publishAt, at.UTC().Format(time.RFC3339))
+	}
+	if at.After(now.Add(MaxScheduleHorizon)) {
+		return time.Time{}, fmt.Errorf("invalid publish_at %d: %s is more than %d days in the future", publishAt, at.UTC().Format(time.RFC3339), int(MaxScheduleHorizon.Hours()/24))
+	}
+	return at, nil
+}
+
+// privacySetRequest 构建只包含隐私类型的笔记设置请求
+func privacySetRequest(noteID, privacyType string) NoteSetRequest {
+	return NoteSetRequest{
+		NoteID:  noteID,
+		Section: SectionPrivacy,
+		Settings: &NoteSettings{
+			Privacy: &NotePrivacySet{Type: privacyType},
+		},
+	}
+}
+
+// handleSchedulePublish 处理定时发布笔记的MCP工具请求。
+// 它先将笔记设为私有，再在发布时间到达时将笔记设为公开。
+func (s *MowenMCPServer) handleSchedulePublish(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args SchedulePublishArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	now := time.Now()
+	publishAt, err := ValidatePublishAt(args.PublishAt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.mowenClient.SetNotePrivacy(ctx, privacySetRequest(args.NoteID, "private"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to set note private: %w", err)
+	}
+
+	noteID := args.NoteID
+	replaced := s.scheduler.schedule(noteID, publishAt.Sub(now), func() {
+		// 定时任务在工具调用结束后执行，不能使用已结束请求的ctx
+		if _, err := s.mowenClient.SetNotePrivacy(context.Background(), privacySetRequest(noteID, "public")); err != nil {
+			s.logger.Errorf("定时发布笔记 %s 失败: %v", noteID, err)
+			return
+		}
+		s.logger.Infof("已定时发布笔记 %s", noteID)
+	})
+
+	text := fmt.Sprintf("笔记已设为私有，将于 %s 公开，笔记ID: %s。定时计划保存在服务进程中，服务重启前未执行的计划会丢失", publishAt.Format(time.RFC3339), noteID)
+	if replaced {
+		text += "\n已取消该笔记之前的定时发布计划"
+	}
+	return textResult(s.withWarning(text, result)), nil
+}
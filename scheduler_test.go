@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTimer 记录是否被取消的测试计时器
+type fakeTimer struct {
+	delay   time.Duration
+	fn      func()
+	stopped bool
+}
+
+// Stop 取消计时器
+func (t *fakeTimer) Stop() bool {
+	t.stopped = true
+	return true
+}
+
+// useFakeTimers 将调度器的计时器替换为手动触发的测试计时器
+func useFakeTimers(scheduler *publishScheduler) *[]*fakeTimer {
+	timers := &[]*fakeTimer{}
+	scheduler.afterFunc = func(delay time.Duration, fn func()) scheduledTimer {
+		timer := &fakeTimer{delay: delay, fn: fn}
+		*timers = append(*timers, timer)
+		return timer
+	}
+	return timers
+}
+
+// TestValidatePublishAt 测试定时发布时间校验
+func TestValidatePublishAt(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	at, err := ValidatePublishAt(now.Add(time.Hour).Unix(), now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(time.Hour), at)
+
+	cases := map[int64]string{
+		0:               "must be a positive",
+		now.UnixMilli(): "looks like milliseconds",
+		now.Unix():      "is not in the future",
+		now.Add(MaxScheduleHorizon + time.Hour).Unix(): "days in the future",
+	}
+	for publishAt, message := range cases {
+		_, err := ValidatePublishAt(publishAt, now)
+		require.Error(t, err, publishAt)
+		assert.Contains(t, err.Error(), message, publishAt)
+	}
+}
+
+// TestPublishSchedulerReplace 测试同一笔记的新计划替换旧计划
+func TestPublishSchedulerReplace(t *testing.T) {
+	scheduler := newPublishScheduler()
+	timers := useFakeTimers(scheduler)
+
+	var fired []string
+	assert.False(t, scheduler.schedule("note-1", time.Hour, func() { fired = append(fired, "first") }))
+	assert.True(t, scheduler.schedule("note-1", 2*time.Hour, func() { fired = append(fired, "second") }))
+	require.Len(t, *timers, 2)
+	assert.True(t, (*timers)[0].stopped)
+	assert.Equal(t, 1, scheduler.pending())
+
+	// 被替换的计划即使触发也不会执行
+	(*timers)[0].fn()
+	(*timers)[1].fn()
+	assert.Equal(t, []string{"second"}, fired)
+	assert.Equal(t, 0, scheduler.pending())
+}
+
+// TestPrivacySetRequest 测试隐私设置请求的构建
+func TestPrivacySetRequest(t *testing.T) {
+	req := privacySetRequest("note-1", "public")
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"noteId":"note-1","section":1,"settings":{"privacy":{"type":"public"}}}`, string(data))
+}
+
+// TestHandleSchedulePublish 测试定时发布先设为私有，到时再设为公开
+func (suite *ServerTestSuite) TestHandleSchedulePublish() {
+	var privacyTypes []string
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var setReq NoteSetRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		assert.Equal(suite.T(), "test-note-id-123", setReq.NoteID)
+		privacyTypes = append(privacyTypes, setReq.Settings.Privacy.Type)
+		mockSuccess(nil)(w, r)
+	}
+	timers := useFakeTimers(suite.mcpServer.scheduler)
+
+	publishAt := time.Now().Add(2 * time.Hour).Unix()
+	text, err := suite.callTool(suite.mcpServer.handleSchedulePublish, SchedulePublishArgs{NoteID: "test-note-id-123", PublishAt: publishAt})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, time.Unix(publishAt, 0).Format(time.RFC3339))
+	assert.Equal(suite.T(), []string{"private"}, privacyTypes)
+
+	require.Len(suite.T(), *timers, 1)
+	assert.InDelta(suite.T(), (2 * time.Hour).Seconds(), (*timers)[0].delay.Seconds(), 5)
+	(*timers)[0].fn()
+	assert.Equal(suite.T(), []string{"private", "public"}, privacyTypes)
+
+	// 过去的时间在调用API前被拒绝
+	_, err = suite.callTool(suite.mcpServer.handleSchedulePublish, SchedulePublishArgs{NoteID: "test-note-id-123", PublishAt: time.Now().Add(-time.Hour).Unix()})
+	require.Error(suite.T(), err)
+	assert.Len(suite.T(), privacyTypes, 2)
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// 自检中单项能力的探测结果
+const (
+	CapabilitySupported    = "supported"    // 接口存在且可用
+	CapabilityUnsupported  = "unsupported"  // 接口不存在
+	CapabilityUnauthorized = "unauthorized" // API密钥无效或无权限
+	CapabilityUnreachable  = "unreachable"  // 网络错误或服务端错误
+	CapabilitySkipped      = "skipped"      // 无法无副作用地探测，未探测
+)
+
+// selfCheckNoteID 自检时使用的不存在的笔记ID，确保编辑等探测不会修改真实笔记
+const selfCheckNoteID = "mowen-mcp-self-check"
+
+// capabilityProbe 单项能力的探测方式。Endpoint为空表示该能力无法无副作用地探测。
+type capabilityProbe struct {
+	Name     string
+	Endpoint string
+	Body     interface{}
+}
+
+// CapabilityResult 单项能力的探测结果
+type CapabilityResult struct {
+	Name   string
+	Status string
+	Detail string
+}
+
+// selfCheckProbes 自检探测列表，均为只读请求或针对不存在资源的请求
+var selfCheckProbes = []capabilityProbe{
+	{Name: "搜索笔记", Endpoint: NoteListEndpoint, Body: NoteListRequest{PageSize: 1}},
+	{Name: "读取笔记", Endpoint: NoteDetailEndpoint, Body: NoteDetailRequest{NoteID: selfCheckNoteID}},
+	{Name: "编辑笔记", Endpoint: NoteEditEndpoint, Body: NoteEditRequest{NoteID: selfCheckNoteID, Body: NoteAtom{Type: "doc"}}},
+	{Name: "创建笔记"},
+	{Name: "上传文件", Endpoint: UploadPrepareEndpoint, Body: UploadPrepareRequest{FileType: 1, FileName: "self-check.png"}},
+	{Name: "文件处理状态", Endpoint: UploadStatusEndpoint, Body: UploadStatusRequest{UUID: selfCheckNoteID}},
+}
+
+// classifyProbe 根据探测请求的结果判断能力状态。
+// 除404和认证失败外的4xx说明接口存在、只是拒绝了探测参数，同样视为可用。
+// HTTP 200但业务错误码不为0的响应说明接口存在：401和403视为无权限，5xx视为服务端错误，
+// 其余（包括探测的笔记不存在时的404）视为可用。
+func classifyProbe(err error) (status, detail string) {
+	if err == nil {
+		return CapabilitySupported, ""
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		switch code := apiErr.Code; {
+		case code == http.StatusUnauthorized || code == http.StatusForbidden:
+			return CapabilityUnauthorized, fmt.Sprintf("错误码 %d", code)
+		case code >= 500 && code < 600:
+			return CapabilityUnreachable, fmt.Sprintf("错误码 %d", code)
+		default:
+			return CapabilitySupported, ""
+		}
+	}
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		return CapabilityUnreachable, err.Error()
+	}
+	switch code := statusErr.StatusCode; {
+	case code == http.StatusNotFound:
+		return CapabilityUnsupported, ""
+	case code == http.StatusUnauthorized || code == http.StatusForbidden:
+		return CapabilityUnauthorized, fmt.Sprintf("HTTP %d", code)
+	case code >= 500:
+		return CapabilityUnreachable, fmt.Sprintf("HTTP %d", code)
+	default:
+		return CapabilitySupported, ""
+	}
+}
+
+// RunSelfCheck 依次探测各项能力
+func (c *MowenClient) RunSelfCheck(ctx context.Context) []CapabilityResult {
+	results := make([]CapabilityResult, 0, len(selfCheckProbes))
+	for _, probe := range selfCheckProbes {
+		if probe.Endpoint == "" {
+			results = append(results, CapabilityResult{Name: probe.Name, Status: CapabilitySkipped, Detail: "无法无副作用地探测，API密钥有效即可使用"})
+			continue
+		}
+		_, err := c.makeRequest(ctx, "POST", probe.Endpoint, probe.Body)
+		status, detail := classifyProbe(err)
+		results = append(results, CapabilityResult{Name: probe.Name, Status: status, Detail: detail})
+	}
+	return results
+}
+
+// RenderSelfCheckReport 将自检结果渲染为可读的报告
+func RenderSelfCheckReport(baseURL string, results []CapabilityResult) string {
+	keyStatus := "未知（所有探测均未得到有效响应）"
+	for _, result := range results {
+		if result.Status == CapabilityUnauthorized {
+			keyStatus = "❌ 无效或无权限"
+			break
+		}
+		if result.Status == CapabilitySupported {
+			keyStatus = "✅ 有效"
+		}
+	}
+
+	labels := map[string]string{
+		CapabilitySupported:    "✅ 可用",
+		CapabilityUnsupported:  "➖ 不支持",
+		CapabilityUnauthorized: "❌ 无权限",
+		CapabilityUnreachable:  "⚠️ 不可达",
+		CapabilitySkipped:      "未探测",
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "墨问API自检报告（%s）\nAPI密钥：%s\n", baseURL, keyStatus)
+	for _, result := range results {
+		fmt.Fprintf(&sb, "\n- %s：%s", result.Name, labels[result.Status])
+		if result.Detail != "" {
+			fmt.Fprintf(&sb, "（%s）", result.Detail)
+		}
+	}
+	return sb.String()
+}
+
+// handleSelfCheck 处理自检的MCP工具请求，所有探测均不会创建或修改笔记
+func (s *MowenMCPServer) handleSelfCheck(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	results := s.mowenClient.RunSelfCheck(ctx)
+	return textResult(RenderSelfCheckReport(s.mowenClient.baseURL, results)), nil
+}
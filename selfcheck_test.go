@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestClassifyProbe 测试探测结果分类
+func TestClassifyProbe(t *testing.T) {
+	cases := []struct {
+		err    error
+		status string
+	}{
+		{nil, CapabilitySupported},
+		{&HTTPStatusError{StatusCode: http.StatusBadRequest}, CapabilitySupported},
+		{&HTTPStatusError{StatusCode: http.StatusNotFound}, CapabilityUnsupported},
+		{&HTTPStatusError{StatusCode: http.StatusUnauthorized}, CapabilityUnauthorized},
+		{&HTTPStatusError{StatusCode: http.StatusForbidden}, CapabilityUnauthorized},
+		{&HTTPStatusError{StatusCode: http.StatusBadGateway}, CapabilityUnreachable},
+		{errors.New("connection refused"), CapabilityUnreachable},
+		{fmt.Errorf("wrapped: %w", &APIError{Code: http.StatusForbidden}), CapabilityUnauthorized},
+		{&APIError{Code: http.StatusUnauthorized}, CapabilityUnauthorized},
+		{&APIError{Code: http.StatusNotFound, Message: "note not found"}, CapabilitySupported},
+		{&APIError{Code: 1001, Message: "invalid params"}, CapabilitySupported},
+		{&APIError{Code: http.StatusInternalServerError}, CapabilityUnreachable},
+	}
+	for _, c := range cases {
+		status, _ := classifyProbe(c.err)
+		assert.Equal(t, c.status, status, "%v", c.err)
+	}
+}
+
+// TestHandleSelfCheck 测试自检报告，模拟API只支持部分接口
+func (suite *ServerTestSuite) TestHandleSelfCheck() {
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}
+	suite.routes[UploadStatusEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("self check must not create notes")
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleSelfCheck, SelfCheckArgs{})
+	suite.Require().NoError(err)
+
+	assert.Contains(suite.T(), text, "API密钥：✅ 有效")
+	assert.Contains(suite.T(), text, "- 搜索笔记：➖ 不支持")
+	assert.Contains(suite.T(), text, "- 读取笔记：✅ 可用")
+	assert.Contains(suite.T(), text, "- 编辑笔记：✅ 可用")
+	assert.Contains(suite.T(), text, "- 创建笔记：未探测")
+	assert.Contains(suite.T(), text, "- 上传文件：✅ 可用")
+	assert.Contains(suite.T(), text, "- 文件处理状态：➖ 不支持")
+}
+
+// TestHandleSelfCheckInvalidKey 测试API密钥无效时的自检报告
+func (suite *ServerTestSuite) TestHandleSelfCheckInvalidKey() {
+	unauthorized := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}
+	for _, endpoint := range []string{NoteListEndpoint, NoteDetailEndpoint, NoteEditEndpoint, UploadPrepareEndpoint, UploadStatusEndpoint} {
+		suite.routes[endpoint] = unauthorized
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleSelfCheck, SelfCheckArgs{})
+	suite.Require().NoError(err)
+	assert.Contains(suite.T(), text, "API密钥：❌ 无效或无权限")
+	assert.Contains(suite.T(), text, "- 读取笔记：❌ 无权限（HTTP 401）")
+}
+
+// TestHandleSelfCheckBusinessCode 测试HTTP 200但业务错误码不为0时按错误码判断能力状态，而不是视为不可达
+func (suite *ServerTestSuite) TestHandleSelfCheckBusinessCode() {
+	forbidden := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":403,"message":"forbidden"}`))
+	}
+	for _, endpoint := range []string{NoteListEndpoint, NoteEditEndpoint, UploadPrepareEndpoint, UploadStatusEndpoint} {
+		suite.routes[endpoint] = forbidden
+	}
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":404,"message":"note not found"}`))
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleSelfCheck, SelfCheckArgs{})
+	suite.Require().NoError(err)
+	assert.Contains(suite.T(), text, "API密钥：❌ 无效或无权限")
+	assert.Contains(suite.T(), text, "- 搜索笔记：❌ 无权限（错误码 403）")
+	assert.Contains(suite.T(), text, "- 读取笔记：✅ 可用")
+	assert.NotContains(suite.T(), text, "不可达")
+}
@@ -2,43 +2,72 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
 	"os"
-	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
 	"github.com/ThinkInAIXYZ/go-mcp/server"
-	"github.com/ThinkInAIXYZ/go-mcp/transport"
 )
 
 // MowenMCPServer 墨问MCP服务器
 type MowenMCPServer struct {
-	mcpServer   *server.Server
-	mowenClient *MowenClient
+	mcpServer      *server.Server
+	mowenClient    *MowenClient
+	convertOptions ConvertOptions
+	autoTagRules   []AutoTagRule
+	logger         *componentLogger
+	templates      map[string]NoteTemplate
+	showWarnings   bool   // 是否在工具结果中展示成功响应携带的提示信息
+	expireAtUnit   string // 墨问API期望的公开截止时间单位
+	scheduler      *publishScheduler
+	tagCache       *tagCache
+	noteIDPaths    []string // 从响应中提取笔记ID的路径，按优先级排列
+	transportMode  string   // MCP传输方式：http或stdio
+	listenAddr     string   // HTTP传输监听地址
+	noteLimits     NoteLimits
+	limiter        *handlerLimiter
+	noteQuota      *noteQuota // 本次运行可创建的笔记数，为nil时不限制
+	// resourceThreshold 导出内容超过该字节数时作为嵌入资源返回，0表示始终返回文本
+	resourceThreshold int
+	// tagNormalization normalize_note_tags使用的标签规范化规则
+	tagNormalization TagNormalization
+	// defaultExpireIn 规则公开未指定截止时间时默认的公开时长，0表示永不过期
+	defaultExpireIn time.Duration
+	// dailyLog append_daily_log使用的每日日志配置和状态
+	dailyLog *dailyLog
+	// toolFilter 按名称筛选注册的工具，registeredTools为实际注册的工具名称
+	toolFilter      toolFilter
+	registeredTools []string
+	// markdownOptions import_markdown_dir解析Markdown文件的选项
+	markdownOptions MarkdownOptions
+	// toolPriorities 声明为批量优先级的工具名称到优先级的映射，注册工具时填充
+	toolPriorities map[string]string
 }
 
 // NewMowenMCPServer 创建并初始化一个新的墨问MCP服务器。
 // 它会创建墨问API客户端，设置传输层，并注册所有MCP工具。
 func NewMowenMCPServer() (*MowenMCPServer, error) {
-	// 创建墨问API客户端
+	// 创建墨问API客户端，所有MCP会话共享同一个客户端及其连接池和请求限流器
 	mowenClient, err := NewMowenClient()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create mowen client: %w", err)
 	}
 
-	// 创建传输服务器
-	// 获取端口配置，优先使用环境变量PORT，默认为8080
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// 创建传输服务器，默认使用HTTP，MOWEN_TRANSPORT=stdio时使用标准输入输出
+	transportMode, err := loadTransportMode()
+	if err != nil {
+		return nil, err
+	}
+	var listenAddr string
+	if transportMode == TransportHTTP {
+		if listenAddr, err = loadListenAddr(); err != nil {
+			return nil, err
+		}
 	}
-	
-	// 使用0.0.0.0监听所有网络接口，以支持外部访问
-	transportServer := transport.NewStreamableHTTPServerTransport(
-		"0.0.0.0:"+port,
-		transport.WithStreamableHTTPServerTransportOptionStateMode(transport.Stateful),
-	)
+	transportServer := newServerTransport(transportMode, listenAddr)
 
 	// 创建MCP服务器
 	mcpServer, err := server.NewServer(transportServer)
@@ -46,88 +75,557 @@ func NewMowenMCPServer() (*MowenMCPServer, error) {
 		return nil, fmt.Errorf("failed to create MCP server: %w", err)
 	}
 
-	mowenMCPServer := &MowenMCPServer{
-		mcpServer:   mcpServer,
-		mowenClient: mowenClient,
+	logger, err := newComponentLogger(LogComponentServer)
+	if err != nil {
+		return nil, err
 	}
+	mcpServer.Use(logger.middleware())
 
-	// 注册工具
-	if err := mowenMCPServer.registerTools(); err != nil {
-		return nil, fmt.Errorf("failed to register tools: %w", err)
+	// 按工具定义标记调用优先级，供并发限制和请求限流排队使用
+	toolPriorities := make(map[string]string)
+	mcpServer.Use(priorityMiddleware(toolPriorities))
+
+	// 限制同时执行的工具处理器数量，默认不限制
+	maxConcurrency, err := envInt("MOWEN_MAX_CONCURRENCY", 0)
+	if err != nil {
+		return nil, err
+	}
+	limiter, err := newHandlerLimiter(maxConcurrency, os.Getenv("MOWEN_CONCURRENCY_MODE"))
+	if err != nil {
+		return nil, err
+	}
+	if limiter != nil {
+		mcpServer.Use(limiter.middleware())
 	}
 
-	return mowenMCPServer, nil
-}
+	convertOptions, err := loadConvertOptions()
+	if err != nil {
+		return nil, err
+	}
 
-// registerTools 注册所有墨问MCP服务器支持的工具。
-// 这些工具包括创建笔记、编辑笔记、设置笔记隐私、重置API密钥和文件上传。
-func (s *MowenMCPServer) registerTools() error {
-	// 注册创建笔记工具
-	createNoteTool, err := protocol.NewTool(
-		"create_note",
-		"创建一篇新的墨问笔记，使用统一的富文本格式",
-		CreateNoteArgs{},
-	)
+	markdownOptions, err := loadMarkdownOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	// 自动标签规则，未配置时不启用
+	autoTagRules, err := parseAutoTagRules(os.Getenv("MOWEN_AUTO_TAG_RULES"))
 	if err != nil {
-		return fmt.Errorf("failed to create create_note tool: %w", err)
+		return nil, fmt.Errorf("invalid MOWEN_AUTO_TAG_RULES: %w", err)
 	}
-	s.mcpServer.RegisterTool(createNoteTool, s.handleCreateNote)
 
-	// 注册编辑笔记工具
-	editNoteTool, err := protocol.NewTool(
-		"edit_note",
-		"编辑已存在的笔记内容，使用统一的富文本格式",
-		EditNoteArgs{},
-	)
+	// 标签规范化规则，供normalize_note_tags使用
+	tagNormalization, err := loadTagNormalization()
 	if err != nil {
-		return fmt.Errorf("failed to create edit_note tool: %w", err)
+		return nil, err
 	}
-	s.mcpServer.RegisterTool(editNoteTool, s.handleEditNote)
 
-	// 注册设置笔记隐私工具
-	setPrivacyTool, err := protocol.NewTool(
-		"set_note_privacy",
-		"设置笔记的隐私权限",
-		SetNotePrivacyArgs{},
-	)
+	// 笔记模板，未配置时为空
+	templates, err := loadNoteTemplates(os.Getenv("MOWEN_TEMPLATES_FILE"))
 	if err != nil {
-		return fmt.Errorf("failed to create set_note_privacy tool: %w", err)
+		return nil, err
 	}
-	s.mcpServer.RegisterTool(setPrivacyTool, s.handleSetNotePrivacy)
 
-	// 注册重置API密钥工具
-	resetKeyTool, err := protocol.NewTool(
-		"reset_api_key",
-		"重置墨问API密钥",
-		ResetAPIKeyArgs{},
-	)
+	// 成功响应中的提示信息默认展示为警告
+	showWarnings, err := envBool("MOWEN_SHOW_WARNINGS", true)
 	if err != nil {
-		return fmt.Errorf("failed to create reset_api_key tool: %w", err)
+		return nil, err
 	}
-	s.mcpServer.RegisterTool(resetKeyTool, s.handleResetAPIKey)
 
-	// 注册本地文件上传工具
-	uploadFileTool, err := protocol.NewTool(
-		"upload_file",
-		"上传本地文件到墨问笔记，支持图片、音频和PDF",
-		UploadFileArgs{},
-	)
+	expireAtUnit, err := loadExpireAtUnit()
+	if err != nil {
+		return nil, err
+	}
+	defaultExpireIn, err := loadDefaultExpireIn()
 	if err != nil {
-		return fmt.Errorf("failed to create upload_file tool: %w", err)
+		return nil, err
 	}
-	s.mcpServer.RegisterTool(uploadFileTool, s.handleUploadFile)
 
-	// 注册基于URL的文件上传工具
-	uploadFileViaURLTool, err := protocol.NewTool(
-		"upload_file_via_url",
-		"通过URL上传文件到墨问笔记，支持图片、音频和PDF",
-		UploadFileViaURLArgs{},
-	)
+	noteIDPaths, err := loadNoteIDPaths()
 	if err != nil {
-		return fmt.Errorf("failed to create upload_file_via_url tool: %w", err)
+		return nil, err
 	}
-	s.mcpServer.RegisterTool(uploadFileViaURLTool, s.handleUploadFileViaURL)
 
+	noteLimits, err := loadNoteLimits()
+	if err != nil {
+		return nil, err
+	}
+
+	// MOWEN_MAX_NOTES_PER_SESSION 本次运行最多创建的笔记数，0表示不限制
+	maxNotesPerSession, err := envInt("MOWEN_MAX_NOTES_PER_SESSION", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// MOWEN_RESOURCE_THRESHOLD 导出内容超过该字节数时作为嵌入资源返回，0表示始终返回文本
+	resourceThreshold, err := envInt("MOWEN_RESOURCE_THRESHOLD", DefaultResourceThreshold)
+	if err != nil {
+		return nil, err
+	}
+
+	// MOWEN_TAG_CACHE_TTL 已有标签列表的缓存秒数，0表示不缓存
+	tagCacheSeconds, err := envInt("MOWEN_TAG_CACHE_TTL", int(DefaultTagCacheTTL/time.Second))
+	if err != nil {
+		return nil, err
+	}
+	tagCacheTTL := time.Duration(tagCacheSeconds) * time.Second
+
+	mowenMCPServer := &MowenMCPServer{
+		mcpServer:      mcpServer,
+		mowenClient:    mowenClient,
+		convertOptions: convertOptions,
+		autoTagRules:   autoTagRules,
+		logger:         logger,
+		templates:      templates,
+		showWarnings:   showWarnings,
+		expireAtUnit:   expireAtUnit,
+		scheduler:      newPublishScheduler(),
+		tagCache:       newTagCache(tagCacheTTL),
+		noteIDPaths:    noteIDPaths,
+		transportMode:  transportMode,
+		listenAddr:     listenAddr,
+		noteLimits:     noteLimits,
+		limiter:        limiter,
+		noteQuota:      newNoteQuota(maxNotesPerSession),
+
+		resourceThreshold: resourceThreshold,
+		tagNormalization:  tagNormalization,
+		defaultExpireIn:   defaultExpireIn,
+		markdownOptions:   markdownOptions,
+		dailyLog:          newDailyLog(os.Getenv("MOWEN_DAILY_LOG_TAG")),
+		toolFilter:        loadToolFilter(),
+		toolPriorities:    toolPriorities,
+	}
+
+	// 注册工具
+	if err := mowenMCPServer.registerTools(); err != nil {
+		return nil, fmt.Errorf("failed to register tools: %w", err)
+	}
+
+	return mowenMCPServer, nil
+}
+
+// toolDefinition 一个MCP工具的名称、说明、参数结构体和处理器
+type toolDefinition struct {
+	Name        string
+	Description string
+	Args        interface{} // 参数结构体，用于生成输入schema
+	Handler     server.ToolHandlerFunc
+	Priority    string // 调用优先级，批量工具为PriorityBatch，为空时为交互式
+}
+
+// toolRegistrar 可注册MCP工具的服务器，*server.Server满足该接口
+type toolRegistrar interface {
+	RegisterTool(tool *protocol.Tool, toolHandler server.ToolHandlerFunc, middlewares ...server.ToolMiddleware)
+}
+
+// toolDefinitions 返回墨问MCP服务器支持的全部工具。
+// 这些工具包括创建笔记、编辑笔记、设置笔记隐私、重置API密钥和文件上传等。
+func (s *MowenMCPServer) toolDefinitions() []toolDefinition {
+	return []toolDefinition{
+		// 创建笔记工具
+		{
+			Name:        "create_note",
+			Description: "创建一篇新的墨问笔记，使用统一的富文本格式",
+			Args:        CreateNoteArgs{},
+			Handler:     s.handleCreateNote,
+		},
+
+		// 基于模板创建笔记工具
+		{
+			Name:        "create_note_from_template",
+			Description: "使用预先配置的模板创建笔记，模板中的段落、标签和隐私设置会自动应用",
+			Args:        CreateNoteFromTemplateArgs{},
+			Handler:     s.handleCreateNoteFromTemplate,
+		},
+
+		// 每日日志工具
+		{
+			Name:        "append_daily_log",
+			Description: "向今天的日志笔记末尾追加一条带时间的记录，今天的笔记不存在时自动创建（以日期为标题，带有每日日志标签和日期标签）",
+			Args:        AppendDailyLogArgs{},
+			Handler:     s.handleAppendDailyLog,
+		},
+
+		// 读取笔记工具
+		{
+			Name:        "get_note",
+			Description: "读取已存在笔记的标题、标签、隐私设置、创建和更新时间以及正文（以Markdown形式展示），可用于核对编辑结果",
+			Args:        GetNoteArgs{},
+			Handler:     s.handleGetNote,
+		},
+
+		// 编辑笔记工具
+		{
+			Name:        "edit_note",
+			Description: "编辑已存在的笔记内容，使用统一的富文本格式",
+			Args:        EditNoteArgs{},
+			Handler:     s.handleEditNote,
+		},
+
+		// 删除笔记工具
+		{
+			Name:        "delete_note",
+			Description: "删除一篇墨问笔记，删除后不可恢复",
+			Args:        DeleteNoteArgs{},
+			Handler:     s.handleDeleteNote,
+		},
+
+		// 设置笔记隐私工具
+		{
+			Name:        "set_note_privacy",
+			Description: "设置笔记的隐私权限",
+			Args:        SetNotePrivacyArgs{},
+			Handler:     s.handleSetNotePrivacy,
+		},
+
+		// 预览隐私设置效果工具
+		{
+			Name:        "preview_privacy",
+			Description: "不调用API，用通俗的语言说明一组隐私参数的效果：谁能查看、能否分享、公开到什么时候",
+			Args:        PreviewPrivacyArgs{},
+			Handler:     s.handlePreviewPrivacy,
+		},
+
+		// 设置笔记置顶工具
+		{
+			Name:        "set_note_pinned",
+			Description: "置顶或取消置顶笔记",
+			Args:        SetNotePinnedArgs{},
+			Handler:     s.handleSetNotePinned,
+		},
+
+		// 定时发布笔记工具
+		{
+			Name:        "schedule_publish",
+			Description: "定时发布笔记：立即将笔记设为私有，到达指定时间后自动设为公开（计划保存在服务进程中，重启后丢失）",
+			Args:        SchedulePublishArgs{},
+			Handler:     s.handleSchedulePublish,
+		},
+
+		// 重置API密钥工具
+		{
+			Name:        "reset_api_key",
+			Description: "重置墨问API密钥",
+			Args:        ResetAPIKeyArgs{},
+			Handler:     s.handleResetAPIKey,
+		},
+
+		// 本地文件上传工具
+		{
+			Name:        "upload_file",
+			Description: "上传本地文件到墨问笔记，支持图片、音频和PDF",
+			Args:        UploadFileArgs{},
+			Handler:     s.handleUploadFile,
+		},
+
+		// 基于URL的文件上传工具
+		{
+			Name:        "upload_file_via_url",
+			Description: "通过URL上传文件到墨问笔记，支持图片、音频和PDF",
+			Args:        UploadFileViaURLArgs{},
+			Handler:     s.handleUploadFileViaURL,
+		},
+
+		// 文件处理状态查询工具
+		{
+			Name:        "get_upload_status",
+			Description: "查询已上传文件的处理状态（ready/processing/failed），音频和PDF可能需要等待处理完成后再嵌入笔记",
+			Args:        GetUploadStatusArgs{},
+			Handler:     s.handleGetUploadStatus,
+		},
+
+		// 笔记分享信息工具
+		{
+			Name:        "get_note_share",
+			Description: "获取公开笔记的分享短链接和二维码",
+			Args:        GetNoteShareArgs{},
+			Handler:     s.handleGetNoteShare,
+		},
+
+		// 笔记差异对比工具
+		{
+			Name:        "diff_note",
+			Description: "对比笔记当前内容与拟修改内容的差异，返回新增、删除和修改的段落（不会修改笔记）",
+			Args:        DiffNoteArgs{},
+			Handler:     s.handleDiffNote,
+		},
+
+		// 笔记限制检查工具
+		{
+			Name:        "check_note_limits",
+			Description: "提交前检查笔记的请求体大小、段落数和标签数是否超过限制（不会调用墨问API）",
+			Args:        CheckNoteLimitsArgs{},
+			Handler:     s.handleCheckNoteLimits,
+		},
+
+		// 笔记定义文件校验工具
+		{
+			Name:        "validate_note_file",
+			Description: "离线校验JSON文件中的一批笔记定义（格式同create_note参数），按下标报告每篇的问题（不会调用墨问API）",
+			Args:        ValidateNoteFileArgs{},
+			Handler:     s.handleValidateNoteFile,
+		},
+
+		// 检查内链笔记引用工具
+		{
+			Name:        "check_note_references",
+			Description: "检查段落中内链笔记（note）段落引用的笔记是否存在，列出无效的引用",
+			Args:        CheckNoteReferencesArgs{},
+			Handler:     s.handleCheckNoteReferences,
+		},
+
+		// 笔记统计工具
+		{
+			Name:        "note_stats",
+			Description: "统计笔记的词数、字符数、段落数、链接数和估算阅读时间，中日韩文字按字计数",
+			Args:        NoteStatsArgs{},
+			Handler:     s.handleNoteStats,
+		},
+
+		// 笔记时间工具
+		{
+			Name:        "get_note_timestamps",
+			Description: "获取笔记的创建时间和更新时间（RFC3339格式，UTC），用于排序和审计，接口未返回的时间显示为未提供",
+			Args:        NoteTimestampsArgs{},
+			Handler:     s.handleGetNoteTimestamps,
+		},
+
+		// 两篇笔记对比工具
+		{
+			Name:        "compare_notes",
+			Description: "对比两篇笔记的内容，返回以段落为单位的差异（不会修改笔记）",
+			Args:        CompareNotesArgs{},
+			Handler:     s.handleCompareNotes,
+		},
+
+		// 获取账号默认设置工具
+		{
+			Name:        "get_account_defaults",
+			Description: "获取当前账号的默认笔记设置，包括默认隐私、默认标签和是否默认发布",
+			Args:        GetAccountDefaultsArgs{},
+			Handler:     s.handleGetAccountDefaults,
+		},
+
+		// 自检工具
+		{
+			Name:        "self_check",
+			Description: "检查与墨问API的连通性和API密钥是否有效，并报告搜索、读取、编辑、上传等能力是否可用。所有探测均不会创建或修改笔记",
+			Args:        SelfCheckArgs{},
+			Handler:     s.handleSelfCheck,
+		},
+
+		// 配置诊断工具
+		{
+			Name:        "diagnostics",
+			Description: "返回服务当前生效的配置（API地址、超时、传输方式、限流、日志级别等），用于排查问题。不会返回API密钥",
+			Args:        DiagnosticsArgs{},
+			Handler:     s.handleDiagnostics,
+		},
+
+		// 限流信息查看工具
+		{
+			Name:        "rate_limit_status",
+			Description: "查看最近一次墨问API响应中的限流响应头（剩余次数、重置时间等），用于调整调用频率（不会调用墨问API）",
+			Args:        RateLimitStatusArgs{},
+			Handler:     s.handleRateLimitStatus,
+		},
+
+		// 批量导出Markdown工具
+		{
+			Name:        "export_notes_markdown",
+			Description: "将全部笔记（或搜索结果）导出为Markdown文件并写入指定目录，用于完整备份",
+			Args:        ExportNotesMarkdownArgs{},
+			Handler:     s.handleExportNotesMarkdown,
+			Priority:    PriorityBatch,
+		},
+
+		// 从Markdown目录批量创建笔记工具
+		{
+			Name:        "import_markdown_dir",
+			Description: "读取目录中的所有Markdown文件并逐个创建笔记，标题取front matter中的title或文件名，标签取front matter中的tags，返回每个文件的处理结果",
+			Args:        ImportMarkdownDirArgs{},
+			Handler:     s.handleImportMarkdownDir,
+			Priority:    PriorityBatch,
+		},
+
+		// 列出已有标签工具
+		{
+			Name:        "list_tags",
+			Description: "列出账号下已使用的标签及使用次数，便于保持标签一致（结果会缓存）",
+			Args:        ListTagsArgs{},
+			Handler:     s.handleListTags,
+		},
+
+		// 列出支持类型工具
+		{
+			Name:        "list_supported_types",
+			Description: "列出创建和编辑笔记时支持的段落类型和文本标记及其用法",
+			Args:        ListSupportedTypesArgs{},
+			Handler:     s.handleListSupportedTypes,
+		},
+
+		// API延迟探测工具
+		{
+			Name:        "latency_probe",
+			Description: "多次发送只读请求测量墨问API延迟，返回最小值、中位数、P95和最大值",
+			Args:        LatencyProbeArgs{},
+			Handler:     s.handleLatencyProbe,
+		},
+
+		// 文本标记预览工具
+		{
+			Name:        "preview_text_marks",
+			Description: "预览文本节点转换后的标记（" + strings.Join(typeInfoNames(SupportedMarkTypes), "、") + "）和显示效果，不会创建笔记",
+			Args:        PreviewTextMarksArgs{},
+			Handler:     s.handlePreviewTextMarks,
+		},
+
+		// NoteAtom JSON转换为段落工具
+		{
+			Name:        "note_json_to_paragraphs",
+			Description: "将墨问笔记的NoteAtom JSON转换回段落列表，便于修改后通过edit_note提交，不会调用墨问API",
+			Args:        NoteJSONToParagraphsArgs{},
+			Handler:     s.handleNoteJSONToParagraphs,
+		},
+
+		// 读取笔记并转换为段落工具
+		{
+			Name:        "get_note_as_paragraphs",
+			Description: "读取已存在的笔记并转换为段落列表，修改后可直接通过edit_note提交；无法用段落表示的内容会列出",
+			Args:        GetNoteAsParagraphsArgs{},
+			Handler:     s.handleGetNoteAsParagraphs,
+		},
+
+		// 批量添加标签工具
+		{
+			Name:        "bulk_tag_notes",
+			Description: "搜索笔记并为所有匹配的笔记添加标签，返回成功、跳过和失败的数量",
+			Args:        BulkTagNotesArgs{},
+			Handler:     s.handleBulkTagNotes,
+			Priority:    PriorityBatch,
+		},
+
+		// 标签规范化工具
+		{
+			Name:        "normalize_note_tags",
+			Description: "按配置的规则规范化笔记标签（去除前缀、转为小写、映射别名），用于整理从其他系统导入的笔记，返回调整前后的标签",
+			Args:        NormalizeNoteTagsArgs{},
+			Handler:     s.handleNormalizeNoteTags,
+		},
+
+		// 批量设置规则公开工具
+		{
+			Name:        "bulk_set_privacy",
+			Description: "为搜索或标签匹配的所有笔记设置规则公开（是否禁止分享、公开截止时间），返回成功和失败的数量",
+			Args:        BulkSetPrivacyArgs{},
+			Handler:     s.handleBulkSetPrivacy,
+			Priority:    PriorityBatch,
+		},
+
+		// 按标签清理笔记工具
+		{
+			Name:        "cleanup_notes",
+			Description: "删除带指定标签（如test）的全部笔记，用于清理测试环境。需要confirm与标签一致才会删除，dry_run只列出将被删除的笔记",
+			Args:        CleanupNotesArgs{},
+			Handler:     s.handleCleanupNotes,
+			Priority:    PriorityBatch,
+		},
+
+		// 重试批量操作工具
+		{
+			Name:        "retry_batch",
+			Description: "重试bulk_tag_notes、bulk_set_privacy、cleanup_notes批量结果中失败和未处理的条目，成功和跳过的条目保持不变，返回的结果可再次重试",
+			Args:        RetryBatchArgs{},
+			Handler:     s.handleRetryBatch,
+			Priority:    PriorityBatch,
+		},
+
+		// 调整段落顺序工具
+		{
+			Name:        "reorder_paragraphs",
+			Description: "调整已有笔记中段落的顺序，例如把第3段移动到第1段",
+			Args:        ReorderParagraphsArgs{},
+			Handler:     s.handleReorderParagraphs,
+		},
+
+		// 替换笔记文件工具
+		{
+			Name:        "replace_note_file",
+			Description: "上传新文件并替换笔记中指定UUID的图片、音频或PDF，其他内容保持不变",
+			Args:        ReplaceNoteFileArgs{},
+			Handler:     s.handleReplaceNoteFile,
+		},
+
+		// 校验笔记文件引用工具
+		{
+			Name:        "verify_note_files",
+			Description: "校验笔记中嵌入的图片、音频和PDF是否仍然有效，可选择移除失效的文件引用",
+			Args:        VerifyNoteFilesArgs{},
+			Handler:     s.handleVerifyNoteFiles,
+		},
+
+		// 下载笔记文件工具
+		{
+			Name:        "download_note_files",
+			Description: "把笔记中嵌入的图片、音频和PDF下载到本地目录，用于归档",
+			Args:        DownloadNoteFilesArgs{},
+			Handler:     s.handleDownloadNoteFiles,
+		},
+
+		// 笔记导出工具
+		{
+			Name:        "export_note_bundle",
+			Description: "将笔记导出为可移植的JSON导出包，包含内容、标签、隐私设置和引用的文件",
+			Args:        ExportNoteBundleArgs{},
+			Handler:     s.handleExportNoteBundle,
+		},
+
+		// 笔记导入工具
+		{
+			Name:        "import_note_bundle",
+			Description: "根据export_note_bundle导出的JSON重新创建笔记，恢复文件引用、标签和隐私设置",
+			Args:        ImportNoteBundleArgs{},
+			Handler:     s.handleImportNoteBundle,
+		},
+	}
+}
+
+// registerToolDefinitions 先为全部工具生成schema，全部成功后才注册到服务器。
+// 任何一个工具创建失败时直接返回错误，不会注册任何工具，避免服务器以部分工具启动。
+func registerToolDefinitions(registrar toolRegistrar, definitions []toolDefinition) error {
+	tools := make([]*protocol.Tool, 0, len(definitions))
+	for _, def := range definitions {
+		tool, err := protocol.NewTool(def.Name, def.Description, def.Args)
+		if err != nil {
+			return fmt.Errorf("failed to create %s tool: %w", def.Name, err)
+		}
+		tools = append(tools, tool)
+	}
+
+	for i, tool := range tools {
+		registrar.RegisterTool(tool, definitions[i].Handler)
+	}
+	return nil
+}
+
+// registerTools 注册MOWEN_ENABLED_TOOLS和MOWEN_DISABLED_TOOLS允许的工具，任何工具创建失败时不注册任何工具。
+// 配置中不存在的工具名称只记录警告，不影响启动。
+func (s *MowenMCPServer) registerTools() error {
+	definitions, warnings := s.toolFilter.apply(s.toolDefinitions())
+	for _, warning := range warnings {
+		s.logger.Warnf("%s", warning)
+	}
+	if err := registerToolDefinitions(s.mcpServer, definitions); err != nil {
+		return err
+	}
+	s.registeredTools = make([]string, len(definitions))
+	for i, def := range definitions {
+		s.registeredTools[i] = def.Name
+		if def.Priority != "" && s.toolPriorities != nil {
+			s.toolPriorities[def.Name] = def.Priority
+		}
+	}
 	return nil
 }
 
@@ -138,25 +636,64 @@ func (s *MowenMCPServer) handleCreateNote(ctx context.Context, req *protocol.Cal
 	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %v", err)
 	}
+	if err := ValidateNoteTimestamps(args.CreatedAt, args.UpdatedAt, time.Now()); err != nil {
+		return nil, err
+	}
+	tags, err := s.noteLimits.validateTags(applyAutoTags(args.Tags, args.Paragraphs, s.autoTagRules))
+	if err != nil {
+		return nil, err
+	}
+
+	// 本地文件先用占位UUID代替，全部段落转换通过后才上传
+	paragraphs, uploadWarnings, err := s.resolveFileParagraphs(ctx, args.Paragraphs, true)
+	if err != nil {
+		return nil, err
+	}
 
 	// 转换参数为墨问API格式
-	noteBody := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	noteBody, warnings, err := ConvertParagraphsWithWarnings(paragraphs, s.convertOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paragraphs: %w", err)
+	}
+	if args.DryRun {
+		return textResult(RenderDryRun(noteBody, append(uploadWarnings, warnings...))), nil
+	}
+	if args.CheckReferences {
+		if err := s.checkNoteReferences(ctx, args.Paragraphs); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.noteQuota.reserve(); err != nil {
+		return nil, err
+	}
+	// 请求已通过全部校验，上传引用的本地文件
+	noteBody, err = s.uploadLocalFiles(ctx, args.Paragraphs, noteBody)
+	if err != nil {
+		s.noteQuota.release()
+		return nil, err
+	}
 	createReq := NoteCreateRequest{
 		Body: noteBody,
 		Settings: NoteCreateRequestSettings{
 			AutoPublish: args.AutoPublish,
-			Tags:        args.Tags,
+			Tags:        tags,
+			CreatedAt:   args.CreatedAt,
+			UpdatedAt:   args.UpdatedAt,
 		},
+		IdempotencyKey: args.IdempotencyKey,
 	}
 
 	// 调用墨问API
-	result, err := s.mowenClient.CreateNote(createReq)
+	result, err := s.mowenClient.CreateNote(ctx, createReq)
 	if err != nil {
+		s.noteQuota.release()
 		return nil, fmt.Errorf("failed to create note: %w", err)
 	}
+	s.tagCache.invalidate()
 
 	// 格式化响应
-	responseText := fmt.Sprintf("笔记创建成功！\n\n响应详情：\n%+v", result)
+	responseText := s.withWarning(renderNoteResult("创建", s.parseCreateNoteResult(result)), result)
+	responseText = appendConversionWarnings(responseText, warnings)
 
 	return &protocol.CallToolResult{
 		Content: []protocol.Content{
@@ -175,22 +712,69 @@ func (s *MowenMCPServer) handleEditNote(ctx context.Context, req *protocol.CallT
 	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %v", err)
 	}
+	mode, err := parseEditMode(args.Mode)
+	if err != nil {
+		return nil, err
+	}
+
+	// 本地文件先用占位UUID代替，全部段落转换通过后才上传
+	paragraphs, uploadWarnings, err := s.resolveFileParagraphs(ctx, args.Paragraphs, true)
+	if err != nil {
+		return nil, err
+	}
 
 	// 转换参数为墨问API格式
-	noteBody := ConvertParagraphsToNoteAtom(args.Paragraphs)
+	noteBody, warnings, err := ConvertParagraphsWithWarnings(paragraphs, s.convertOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paragraphs: %w", err)
+	}
+	if args.DryRun {
+		preview := RenderDryRun(noteBody, append(uploadWarnings, warnings...))
+		if mode == EditModeAppend {
+			preview = "追加模式：以下段落将追加到笔记原有内容的末尾\n\n" + preview
+		}
+		return textResult(preview), nil
+	}
+	noteBody, err = s.uploadLocalFiles(ctx, args.Paragraphs, noteBody)
+	if err != nil {
+		return nil, err
+	}
+
+	// 追加模式下与笔记原有内容合并，只修改正文，笔记的其他设置保持不变
+	if mode == EditModeAppend {
+		merged, duplicate, err := s.appendToNoteBody(ctx, args.NoteID, noteBody.Content, args.AllowDuplicate)
+		if errors.Is(err, ErrNoteNotFound) {
+			return nil, fmt.Errorf("note %s does not exist, check the note_id: %w", args.NoteID, ErrNoteNotFound)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if duplicate {
+			return textResult(fmt.Sprintf("笔记 %s 的末尾已经是要追加的 %d 个段落，未重复追加；如确实需要再次追加，请设置allow_duplicate为true", args.NoteID, len(noteBody.Content))), nil
+		}
+		noteBody = merged
+	}
 	editReq := NoteEditRequest{
 		NoteID: args.NoteID,
 		Body:   noteBody,
 	}
 
 	// 调用墨问API
-	result, err := s.mowenClient.EditNote(editReq)
+	result, err := s.mowenClient.EditNote(ctx, editReq)
+	if errors.Is(err, ErrNoteNotFound) {
+		return nil, fmt.Errorf("note %s does not exist, check the note_id: %w", args.NoteID, ErrNoteNotFound)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to edit note: %w", err)
 	}
 
-	// 格式化响应
-	responseText := fmt.Sprintf("笔记编辑成功！\n\n响应详情：\n%+v", result)
+	// 格式化响应，编辑接口的响应中可能没有笔记ID
+	noteResult := s.parseCreateNoteResult(result)
+	if noteResult.NoteID == "" {
+		noteResult = CreateNoteResult{NoteID: args.NoteID, URL: fmt.Sprintf(MowenNoteURLFormat, args.NoteID)}
+	}
+	responseText := s.withWarning(renderNoteResult("编辑", noteResult), result)
+	responseText = appendConversionWarnings(responseText, warnings)
 
 	return &protocol.CallToolResult{
 		Content: []protocol.Content{
@@ -216,13 +800,24 @@ func (s *MowenMCPServer) handleSetNotePrivacy(ctx context.Context, req *protocol
 	}
 
 	// 如果是规则公开，设置规则
+	now := time.Now()
+	var expireAtTS *int64
 	if args.PrivacyType == "rule" {
 		rule := &NotePrivacySetRule{}
 		if args.NoShare != nil {
 			rule.NoShare = *args.NoShare
 		}
-		if args.ExpireAt != nil {
-			rule.ExpireAt = strconv.FormatInt(*args.ExpireAt, 10)
+		var err error
+		expireAtTS, err = s.resolveExpireAt(args.ExpireAt, args.ExpireIn, now)
+		if err != nil {
+			return nil, err
+		}
+		if expireAtTS != nil {
+			expireAt, err := FormatExpireAt(*expireAtTS, s.expireAtUnit, now)
+			if err != nil {
+				return nil, err
+			}
+			rule.ExpireAt = expireAt
 		}
 		privacySet.Rule = rule
 	}
@@ -230,20 +825,21 @@ func (s *MowenMCPServer) handleSetNotePrivacy(ctx context.Context, req *protocol
 	// 构建请求
 	setReq := NoteSetRequest{
 		NoteID:  args.NoteID,
-		Section: 1, // 1表示笔记隐私设置
+		Section: SectionPrivacy,
 		Settings: &NoteSettings{
 			Privacy: privacySet,
 		},
 	}
 
 	// 调用墨问API
-	result, err := s.mowenClient.SetNotePrivacy(setReq)
+	result, err := s.mowenClient.SetNotePrivacy(ctx, setReq)
 	if err != nil {
 		return nil, fmt.Errorf("failed to set note privacy: %w", err)
 	}
 
 	// 格式化响应
-	responseText := fmt.Sprintf("笔记隐私设置成功！\n\n响应详情：\n%+v", result)
+	noShare := privacySet.Rule != nil && privacySet.Rule.NoShare
+	responseText := s.withWarning(fmt.Sprintf("笔记隐私设置成功！\n笔记ID: %s\n%s", args.NoteID, explainPrivacy(args.PrivacyType, noShare, expireAtTS, now)), result)
 
 	return &protocol.CallToolResult{
 		Content: []protocol.Content{
@@ -255,6 +851,78 @@ func (s *MowenMCPServer) handleSetNotePrivacy(ctx context.Context, req *protocol
 	}, nil
 }
 
+// resolveExpireAt 计算规则公开的截止时间（Unix秒级时间戳），expire_at和expire_in不能同时指定。
+// 两者都未指定时使用MOWEN_DEFAULT_EXPIRE_IN，也未配置时返回nil。
+func (s *MowenMCPServer) resolveExpireAt(expireAt *int64, expireIn string, now time.Time) (*int64, error) {
+	if expireAt != nil && expireIn != "" {
+		return nil, fmt.Errorf("expire_at and expire_in cannot be used together")
+	}
+	switch {
+	case expireAt != nil:
+		return expireAt, nil
+	case expireIn != "":
+		d, err := ParseExpireIn(expireIn)
+		if err != nil {
+			return nil, err
+		}
+		ts := now.Add(d).Unix()
+		return &ts, nil
+	case s.defaultExpireIn > 0:
+		ts := now.Add(s.defaultExpireIn).Unix()
+		return &ts, nil
+	}
+	return nil, nil
+}
+
+// handleSetNotePinned 处理置顶或取消置顶笔记的MCP工具请求
+func (s *MowenMCPServer) handleSetNotePinned(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args SetNotePinnedArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	result, err := s.mowenClient.SetNotePinned(ctx, args.NoteID, args.Pinned)
+	if errors.Is(err, ErrNotSupported) {
+		return textResult("当前墨问API不支持设置笔记置顶"), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	action := "已取消置顶"
+	if args.Pinned {
+		action = "已置顶"
+	}
+	return textResult(s.withWarning(fmt.Sprintf("笔记%s，笔记ID: %s", action, args.NoteID), result)), nil
+}
+
+// handleDeleteNote 处理删除笔记的MCP工具请求。
+// note_id为空时在调用墨问API之前直接返回错误。
+func (s *MowenMCPServer) handleDeleteNote(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args DeleteNoteArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	noteID := strings.TrimSpace(args.NoteID)
+	if noteID == "" {
+		return nil, fmt.Errorf("note_id must not be empty")
+	}
+
+	result, err := s.mowenClient.DeleteNote(ctx, noteID)
+	if errors.Is(err, ErrNotSupported) {
+		return textResult("当前墨问API不支持删除笔记"), nil
+	}
+	if errors.Is(err, ErrNoteNotFound) || isNoteNotFoundResponse(err, nil) {
+		return nil, fmt.Errorf("note %s does not exist, check the note_id: %w", noteID, ErrNoteNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.tagCache.invalidate()
+
+	return textResult(s.withWarning(fmt.Sprintf("笔记已删除，笔记ID: %s", noteID), result)), nil
+}
+
 // handleResetAPIKey 处理重置API密钥的MCP工具请求。
 // 它调用墨问API重置API密钥。
 func (s *MowenMCPServer) handleResetAPIKey(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
@@ -264,13 +932,18 @@ func (s *MowenMCPServer) handleResetAPIKey(ctx context.Context, req *protocol.Ca
 	}
 
 	// 调用墨问API
-	result, err := s.mowenClient.ResetAPIKey()
+	result, err := s.mowenClient.ResetAPIKey(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to reset API key: %w", err)
 	}
 
-	// 格式化响应
-	responseText := fmt.Sprintf("API密钥重置成功！\n\n⚠️ 注意：此操作会使当前密钥立即失效\n\n响应详情：\n%+v", result)
+	// 格式化响应，不输出旧密钥和完整的新密钥
+	message := "API密钥重置成功！"
+	if newKey := resetAPIKeyValue(result); newKey != "" {
+		message += fmt.Sprintf("\n新密钥（仅显示末4位）：%s", maskKey(newKey))
+	}
+	message += "\n\n⚠️ 注意：此操作会使当前密钥立即失效，请在墨问中查看完整的新密钥并更新MOWEN_API_KEY"
+	responseText := s.withWarning(message, result)
 
 	return &protocol.CallToolResult{
 		Content: []protocol.Content{
@@ -282,6 +955,20 @@ func (s *MowenMCPServer) handleResetAPIKey(ctx context.Context, req *protocol.Ca
 	}, nil
 }
 
+// resetAPIKeyValue 从重置接口的响应中取出新密钥，响应可能带data信封，字段名可能是api_key、apiKey或key
+func resetAPIKeyValue(result map[string]interface{}) string {
+	fields := result
+	if data, ok := result["data"].(map[string]interface{}); ok {
+		fields = data
+	}
+	for _, name := range resetAPIKeyFields {
+		if key, ok := fields[name].(string); ok && key != "" {
+			return key
+		}
+	}
+	return ""
+}
+
 // handleUploadFile 处理文件上传的MCP工具请求。
 // 它解析请求参数，然后调用墨问API上传文件。
 func (s *MowenMCPServer) handleUploadFile(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
@@ -289,15 +976,35 @@ func (s *MowenMCPServer) handleUploadFile(ctx context.Context, req *protocol.Cal
 	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %v", err)
 	}
+	if err := validateFileType(args.FileType); err != nil {
+		return nil, err
+	}
+
+	if args.DryRun {
+		prepareReq, plan, err := s.mowenClient.PreviewUpload(ctx, args.FilePath, args.FileType, args.FileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to preview upload: %w", err)
+		}
+		return textResult(RenderUploadPlan(prepareReq, plan)), nil
+	}
 
 	// 调用墨问API上传文件
-	result, err := s.mowenClient.UploadFile(args.FilePath, args.FileType, args.FileName)
+	result, err := s.mowenClient.UploadFile(ctx, args.FilePath, args.FileType, args.FileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload file: %w", err)
 	}
 
 	// 格式化响应
-	responseText := fmt.Sprintf("文件上传成功！\n\n响应详情：\n%+v", result)
+	uploaded := ParseUploadFileResult(result)
+	responseText := renderUploadResult("文件上传成功！", uploaded)
+	if args.WaitForReady {
+		notice, err := s.confirmUploadReady(ctx, uploaded)
+		if err != nil {
+			return nil, err
+		}
+		responseText += notice
+	}
+	responseText = s.withWarning(responseText, result)
 
 	return &protocol.CallToolResult{
 		Content: []protocol.Content{
@@ -315,15 +1022,27 @@ func (s *MowenMCPServer) handleUploadFileViaURL(ctx context.Context, req *protoc
 	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
 		return nil, fmt.Errorf("invalid arguments: %v", err)
 	}
+	if err := validateFileType(args.FileType); err != nil {
+		return nil, err
+	}
 
 	// 调用墨问API通过URL上传文件
-	result, err := s.mowenClient.UploadFileViaURL(args.FileURL, args.FileType, args.FileName)
+	result, err := s.mowenClient.UploadFileViaURL(ctx, args.FileURL, args.FileType, args.FileName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload file via URL: %w", err)
 	}
 
 	// 格式化响应
-	responseText := fmt.Sprintf("文件通过URL上传成功！\n\n响应详情：\n%+v", result)
+	uploaded := ParseUploadFileResult(result)
+	responseText := renderUploadResult("文件通过URL上传成功！", uploaded)
+	if args.WaitForReady {
+		notice, err := s.confirmUploadReady(ctx, uploaded)
+		if err != nil {
+			return nil, err
+		}
+		responseText += notice
+	}
+	responseText = s.withWarning(responseText, result)
 
 	return &protocol.CallToolResult{
 		Content: []protocol.Content{
@@ -335,9 +1054,381 @@ func (s *MowenMCPServer) handleUploadFileViaURL(ctx context.Context, req *protoc
 	}, nil
 }
 
+// confirmUploadReady 等待刚上传的文件处理完成，返回追加到上传结果后的说明。
+// 墨问API不支持状态查询时视为可以直接使用；超时或处理失败时返回错误。
+func (s *MowenMCPServer) confirmUploadReady(ctx context.Context, uploaded UploadFileResult) (string, error) {
+	if uploaded.UUID == "" {
+		return "", fmt.Errorf("cannot wait for file processing: upload response contains no file UUID")
+	}
+	err := s.mowenClient.waitUploadReady(ctx, uploaded.UUID)
+	if errors.Is(err, ErrNotSupported) {
+		return "\n当前墨问API不支持查询文件处理状态，文件上传成功后即可直接使用", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("file was uploaded but is not ready: %w", err)
+	}
+	return "\n文件已处理完成，可以嵌入笔记", nil
+}
+
+// handleGetUploadStatus 处理查询文件处理状态的MCP工具请求。
+// 当墨问API不支持状态查询时，返回说明而不是错误。
+func (s *MowenMCPServer) handleGetUploadStatus(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args GetUploadStatusArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	status, err := s.mowenClient.GetUploadStatus(ctx, args.FileUUID)
+	if errors.Is(err, ErrNotSupported) {
+		return textResult(fmt.Sprintf("当前墨问API不支持查询文件处理状态，文件 %s 上传成功后即可直接使用", args.FileUUID)), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upload status: %w", err)
+	}
+
+	var responseText string
+	switch status.Status {
+	case UploadStatusReady:
+		responseText = fmt.Sprintf("文件 %s 已就绪，可以嵌入笔记", status.UUID)
+	case UploadStatusProcessing:
+		responseText = fmt.Sprintf("文件 %s 正在处理中，请稍后再查询", status.UUID)
+	case UploadStatusFailed:
+		responseText = fmt.Sprintf("文件 %s 处理失败", status.UUID)
+		if status.Message != "" {
+			responseText += "：" + status.Message
+		}
+	default:
+		responseText = fmt.Sprintf("文件 %s 的处理状态未知：%s", status.UUID, status.Status)
+	}
+
+	return textResult(responseText), nil
+}
+
+// handleGetNoteShare 处理获取笔记分享信息的MCP工具请求。
+// 私有笔记无法分享，此时返回说明原因的错误。
+func (s *MowenMCPServer) handleGetNoteShare(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args GetNoteShareArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	// 先确认笔记已公开
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse note: %w", err)
+	}
+	if detail.Privacy != nil && detail.Privacy.Type == "private" {
+		return nil, fmt.Errorf("note %s is private and cannot be shared; make it public or rule-public with set_note_privacy first", args.NoteID)
+	}
+
+	share, err := s.mowenClient.GetNoteShare(ctx, args.NoteID)
+	if errors.Is(err, ErrNotSupported) {
+		return textResult("当前墨问API不支持获取笔记分享信息"), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note share info: %w", err)
+	}
+
+	responseText := fmt.Sprintf("笔记 %s 的分享信息：\n短链接: %s", args.NoteID, share.ShortURL)
+	if share.QRCode != "" {
+		responseText += "\n二维码: " + share.QRCode
+	}
+
+	return textResult(responseText), nil
+}
+
+// handleGetAccountDefaults 处理获取账号默认设置的MCP工具请求
+func (s *MowenMCPServer) handleGetAccountDefaults(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	defaults, err := s.mowenClient.GetAccountDefaults(ctx)
+	if errors.Is(err, ErrNotSupported) {
+		return textResult("当前墨问API不支持获取账号默认设置"), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account defaults: %w", err)
+	}
+
+	privacy := "未设置"
+	if defaults.Privacy != nil {
+		privacy = defaults.Privacy.Type
+		if rule := defaults.Privacy.Rule; rule != nil {
+			privacy += fmt.Sprintf("（禁止分享: %t，公开截止: %s）", rule.NoShare, rule.ExpireAt)
+		}
+	}
+	tags := "无"
+	if len(defaults.Tags) > 0 {
+		tags = strings.Join(defaults.Tags, ", ")
+	}
+
+	responseText := fmt.Sprintf("账号默认笔记设置：\n默认隐私: %s\n默认标签: %s\n默认发布: %t", privacy, tags, defaults.AutoPublish)
+	return textResult(responseText), nil
+}
+
+// handleDiffNote 处理笔记差异对比的MCP工具请求。
+// 它获取笔记当前内容，转换拟修改的段落，然后返回两者的文本差异。
+func (s *MowenMCPServer) handleDiffNote(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args DiffNoteArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	// 获取笔记当前内容
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse note: %w", err)
+	}
+
+	// 转换拟修改内容并计算差异
+	proposed, warnings, err := ConvertParagraphsWithWarnings(args.Paragraphs, s.convertOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paragraphs: %w", err)
+	}
+	changes := DiffNoteBodies(detail.Body, proposed)
+
+	responseText := fmt.Sprintf("笔记 %s 的内容差异：\n\n%s", args.NoteID, RenderParagraphDiff(changes))
+	responseText = appendConversionWarnings(responseText, warnings)
+
+	return textResult(responseText), nil
+}
+
+// handleCompareNotes 处理对比两篇笔记内容的MCP工具请求。
+// 任意一篇笔记不存在（HTTP状态码或业务错误码为404）时返回说明信息而不是报错。
+func (s *MowenMCPServer) handleCompareNotes(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args CompareNotesArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	var bodies []NoteAtom
+	var missing []string
+	for _, noteID := range []string{args.NoteID, args.OtherNoteID} {
+		result, err := s.mowenClient.GetNote(ctx, noteID)
+		if isNoteNotFoundResponse(err, nil) {
+			missing = append(missing, noteID)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get note %s: %w", noteID, err)
+		}
+		detail, err := ParseNoteDetail(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse note %s: %w", noteID, err)
+		}
+		bodies = append(bodies, detail.Body)
+	}
+	if len(missing) > 0 {
+		return textResult(fmt.Sprintf("无法对比：笔记 %s 不存在", strings.Join(missing, "、"))), nil
+	}
+
+	responseText := fmt.Sprintf("笔记 %s（-）与笔记 %s（+）的内容差异：\n\n%s", args.NoteID, args.OtherNoteID, RenderParagraphDiff(DiffNoteBodies(bodies[0], bodies[1])))
+	return textResult(responseText), nil
+}
+
+// handleListSupportedTypes 处理列出支持的段落类型和文本标记的MCP工具请求
+func (s *MowenMCPServer) handleListSupportedTypes(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var sb strings.Builder
+	sb.WriteString("支持的段落类型（paragraphs中的type字段）：")
+	for _, info := range SupportedParagraphTypes {
+		fmt.Fprintf(&sb, "\n- %s：%s", info.Name, info.Description)
+	}
+	sb.WriteString("\n\n支持的文本标记（texts中的字段）：")
+	for _, info := range SupportedMarkTypes {
+		fmt.Fprintf(&sb, "\n- %s：%s", info.Name, info.Description)
+	}
+	return textResult(sb.String()), nil
+}
+
+// typeInfoNames 返回支持类型的名称列表
+func typeInfoNames(infos []TypeInfo) []string {
+	names := make([]string, len(infos))
+	for i, info := range infos {
+		names[i] = info.Name
+	}
+	return names
+}
+
+// handlePreviewTextMarks 处理文本标记预览的MCP工具请求，只做转换不调用墨问API
+func (s *MowenMCPServer) handlePreviewTextMarks(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args PreviewTextMarksArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	if err := validateTextColors(args.Texts); err != nil {
+		return nil, err
+	}
+	content := convertTextsToContent(args.Texts, s.convertOptions.KeepEmptyText)
+	return textResult(RenderMarksPreview(content)), nil
+}
+
+// handleReorderParagraphs 处理调整段落顺序的MCP工具请求。
+// 它获取笔记当前内容，按移动列表调整段落顺序，然后提交编辑。
+func (s *MowenMCPServer) handleReorderParagraphs(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args ReorderParagraphsArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+	if len(args.Moves) == 0 {
+		return nil, fmt.Errorf("moves must not be empty")
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse note: %w", err)
+	}
+
+	body := detail.Body
+	body.Content, err = ReorderBlocks(detail.Body.Content, args.Moves)
+	if err != nil {
+		return nil, fmt.Errorf("invalid moves: %w", err)
+	}
+
+	editReq := NoteEditRequest{
+		NoteID: args.NoteID,
+		Body:   body,
+	}
+	if _, err := s.mowenClient.EditNote(ctx, editReq); err != nil {
+		return nil, fmt.Errorf("failed to edit note: %w", err)
+	}
+
+	return textResult(fmt.Sprintf("笔记 %s 的段落顺序已调整，共执行 %d 次移动", args.NoteID, len(args.Moves))), nil
+}
+
+// benignSuccessMessages 成功响应中不视为警告的message，比较时不区分大小写
+var benignSuccessMessages = map[string]bool{
+	"":        true,
+	"ok":      true,
+	"success": true,
+	"成功":      true,
+}
+
+// responseWarning 返回成功响应中携带的提示信息，例如"部分标签被忽略"；没有时返回空字符串
+func responseWarning(result map[string]interface{}) string {
+	message, _ := result["message"].(string)
+	message = strings.TrimSpace(message)
+	if benignSuccessMessages[strings.ToLower(message)] {
+		return ""
+	}
+	return message
+}
+
+// withWarning 在工具结果文本后追加响应中的提示信息
+func (s *MowenMCPServer) withWarning(text string, result map[string]interface{}) string {
+	if !s.showWarnings {
+		return text
+	}
+	if warning := responseWarning(result); warning != "" {
+		return text + "\n\n⚠️ 警告：" + warning
+	}
+	return text
+}
+
+// extractNoteID 从创建笔记等接口的响应中提取笔记ID，所有路径都不匹配时记录警告并返回空字符串
+func (s *MowenMCPServer) extractNoteID(result map[string]interface{}) string {
+	paths := s.noteIDPathsOrDefault()
+	if id, ok := ExtractNoteID(result, paths); ok {
+		return id
+	}
+	s.logger.Warnf("未能从响应中提取笔记ID，已尝试路径: %s，可通过MOWEN_NOTE_ID_PATHS配置", strings.Join(paths, ", "))
+	return ""
+}
+
+// noteIDPathsOrDefault 返回MOWEN_NOTE_ID_PATHS配置的笔记ID路径，未配置时返回默认路径
+func (s *MowenMCPServer) noteIDPathsOrDefault() []string {
+	if len(s.noteIDPaths) == 0 {
+		return DefaultNoteIDPaths
+	}
+	return s.noteIDPaths
+}
+
+// parseCreateNoteResult 使用配置的笔记ID路径解析创建或编辑笔记的响应
+func (s *MowenMCPServer) parseCreateNoteResult(result map[string]interface{}) CreateNoteResult {
+	return ParseCreateNoteResult(result, s.noteIDPathsOrDefault())
+}
+
+// renderNoteResult 渲染创建或编辑笔记成功的结果，action为"创建"或"编辑"
+func renderNoteResult(action string, result CreateNoteResult) string {
+	if result.NoteID == "" {
+		return fmt.Sprintf("笔记%s成功！响应中未包含笔记ID", action)
+	}
+	text := fmt.Sprintf("笔记%s成功！ID: %s", action, result.NoteID)
+	if result.URL != "" {
+		text += "\n链接: " + result.URL
+	}
+	return text
+}
+
+// renderUploadResult 渲染文件上传成功的结果，响应中没有文件UUID时给出说明
+func renderUploadResult(prefix string, result UploadFileResult) string {
+	if result.UUID == "" {
+		return prefix + "响应中未包含文件UUID"
+	}
+	text := prefix + "UUID: " + result.UUID
+	if result.URL != "" {
+		text += "\n链接: " + result.URL
+	}
+	return text
+}
+
+// appendConversionWarnings 在结果文本后附加转换提示
+func appendConversionWarnings(text string, warnings []ConversionWarning) string {
+	if rendered := RenderConversionWarnings(warnings); rendered != "" {
+		return text + "\n\n" + rendered
+	}
+	return text
+}
+
+// textResult 构建只包含一段文本的工具结果
+func textResult(text string) *protocol.CallToolResult {
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			&protocol.TextContent{
+				Type: "text",
+				Text: text,
+			},
+		},
+	}
+}
+
+// exportResult 构建导出内容的工具结果。内容超过resourceThreshold字节时作为嵌入资源返回，
+// 并在资源前附上一段简短说明，避免超长文本直接出现在对话中；threshold为0时始终返回文本。
+func (s *MowenMCPServer) exportResult(text, uri, mimeType string) *protocol.CallToolResult {
+	if s.resourceThreshold == 0 || len(text) <= s.resourceThreshold {
+		return textResult(text)
+	}
+	return &protocol.CallToolResult{
+		Content: []protocol.Content{
+			&protocol.TextContent{
+				Type: "text",
+				Text: fmt.Sprintf("导出内容共 %d 字节，超过 %d 字节，已作为资源 %s 返回", len(text), s.resourceThreshold, uri),
+			},
+			protocol.NewEmbeddedResource(&protocol.TextResourceContents{
+				URI:      uri,
+				Text:     text,
+				MimeType: mimeType,
+			}, nil),
+		},
+	}
+}
+
 // Run 启动墨问MCP服务器，开始监听传入的MCP请求。
 func (s *MowenMCPServer) Run() error {
-	log.Println("启动墨问MCP服务器...")
+	if s.transportMode == TransportStdio {
+		s.logger.Infof("启动墨问MCP服务器，通过标准输入输出通信...")
+	} else {
+		s.logger.Infof("启动墨问MCP服务器，监听 %s ...", s.listenAddr)
+	}
 	//log.Println("服务器地址: http://127.0.0.1:8080")
 	//log.Println("SSE端点: http://127.0.0.1:8080/sse")
 	return s.mcpServer.Run()
@@ -3,15 +3,20 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strconv"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/server"
 )
 
 // ServerTestSuite MCP服务器测试套件
@@ -20,6 +25,8 @@ type ServerTestSuite struct {
 	mcpServer      *MowenMCPServer
 	mockHTTPServer *httptest.Server
 	originalAPIKey string
+	// routes 单个测试覆盖的模拟接口，优先于默认模拟响应
+	routes map[string]http.HandlerFunc
 }
 
 // SetupSuite 测试套件初始化
@@ -44,6 +51,7 @@ func (suite *ServerTestSuite) TearDownSuite() {
 // SetupTest 每个测试前的初始化
 func (suite *ServerTestSuite) SetupTest() {
 	// 创建模拟HTTP服务器
+	suite.routes = make(map[string]http.HandlerFunc)
 	suite.mockHTTPServer = httptest.NewServer(http.HandlerFunc(suite.mockAPIHandler))
 	
 	// 创建MCP服务器实例
@@ -65,6 +73,11 @@ func (suite *ServerTestSuite) TearDownTest() {
 // mockAPIHandler 模拟墨问API处理器
 func (suite *ServerTestSuite) mockAPIHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
+
+	if handler, ok := suite.routes[r.URL.Path]; ok {
+		handler(w, r)
+		return
+	}
 	
 	switch r.URL.Path {
 	case NoteCreateEndpoint:
@@ -79,12 +92,44 @@ func (suite *ServerTestSuite) mockAPIHandler(w http.ResponseWriter, r *http.Requ
 		suite.handleMockUploadPrepare(w, r)
 	case UploadURLEndpoint:
 		suite.handleMockUploadURL(w, r)
+	case NoteDetailEndpoint:
+		suite.handleMockNoteDetail(w, r)
+	case UploadStatusEndpoint:
+		suite.handleMockUploadStatus(w, r)
 	default:
 		w.WriteHeader(http.StatusNotFound)
 		json.NewEncoder(w).Encode(map[string]string{"error": "endpoint not found"})
 	}
 }
 
+// mockSuccess 返回固定data的成功响应处理器
+func mockSuccess(data interface{}) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    0,
+			"data":    data,
+			"message": "success",
+		})
+	}
+}
+
+// callTool 使用给定参数调用工具处理器，返回结果中的文本内容
+func (suite *ServerTestSuite) callTool(handler server.ToolHandlerFunc, args interface{}) (string, error) {
+	argsJSON, err := json.Marshal(args)
+	require.NoError(suite.T(), err)
+
+	result, err := handler(context.Background(), &protocol.CallToolRequest{RawArguments: argsJSON})
+	if err != nil {
+		return "", err
+	}
+	require.NotNil(suite.T(), result)
+	require.NotEmpty(suite.T(), result.Content)
+
+	textContent, ok := result.Content[0].(*protocol.TextContent)
+	require.True(suite.T(), ok)
+	return textContent.Text, nil
+}
+
 // handleMockNoteCreate 模拟笔记创建响应
 func (suite *ServerTestSuite) handleMockNoteCreate(w http.ResponseWriter, r *http.Request) {
 	response := map[string]interface{}{
@@ -164,6 +209,56 @@ func (suite *ServerTestSuite) handleMockUploadURL(w http.ResponseWriter, r *http
 	json.NewEncoder(w).Encode(response)
 }
 
+// handleMockNoteDetail 模拟笔记详情响应
+func (suite *ServerTestSuite) handleMockNoteDetail(w http.ResponseWriter, r *http.Request) {
+	response := map[string]interface{}{
+		"code": 0,
+		"data": map[string]interface{}{
+			"noteId": "test-note-id-123",
+			"body": map[string]interface{}{
+				"type": "doc",
+				"content": []interface{}{
+					map[string]interface{}{
+						"type":    "paragraph",
+						"content": []interface{}{map[string]interface{}{"type": "text", "text": "第一段"}},
+					},
+					map[string]interface{}{
+						"type":    "paragraph",
+						"content": []interface{}{map[string]interface{}{"type": "text", "text": "第二段"}},
+					},
+				},
+			},
+			"tags": []string{"测试"},
+		},
+		"message": "success",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleMockUploadStatus 模拟文件处理状态响应，状态由请求中的UUID决定
+func (suite *ServerTestSuite) handleMockUploadStatus(w http.ResponseWriter, r *http.Request) {
+	var req UploadStatusRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	data := map[string]interface{}{"uuid": req.UUID}
+	switch req.UUID {
+	case "file-processing":
+		data["status"] = UploadStatusProcessing
+	case "file-failed":
+		data["status"] = UploadStatusFailed
+		data["message"] = "unsupported codec"
+	default:
+		data["status"] = UploadStatusReady
+	}
+
+	response := map[string]interface{}{
+		"code":    0,
+		"data":    data,
+		"message": "success",
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
 // TestNewMowenMCPServer 测试MCP服务器创建
 func (suite *ServerTestSuite) TestNewMowenMCPServer() {
 	server, err := NewMowenMCPServer()
@@ -240,6 +335,38 @@ func (suite *ServerTestSuite) TestHandleEditNote() {
 	assert.Contains(suite.T(), textContent.Text, "test-note-id-123")
 }
 
+// TestHandleEditNoteNotFound 测试编辑不存在的笔记时返回说明笔记不存在的错误，其他失败不受影响
+func (suite *ServerTestSuite) TestHandleEditNoteNotFound() {
+	args := EditNoteArgs{NoteID: "note-missing", Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "内容"}}}}}
+	responses := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusNotFound, `{"code":404,"message":"not found"}`},
+		{http.StatusOK, `{"code":404,"message":"笔记不存在"}`},
+		{http.StatusBadRequest, `{"code":404,"message":"笔记不存在"}`},
+	}
+	for _, response := range responses {
+		suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(response.status)
+			w.Write([]byte(response.body))
+		}
+		_, err := suite.callTool(suite.mcpServer.handleEditNote, args)
+		require.Error(suite.T(), err, response.body)
+		assert.True(suite.T(), errors.Is(err, ErrNoteNotFound), response.body)
+		assert.Contains(suite.T(), err.Error(), "note note-missing does not exist")
+	}
+
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":400,"message":"invalid body"}`))
+	}
+	_, err := suite.callTool(suite.mcpServer.handleEditNote, args)
+	require.Error(suite.T(), err)
+	assert.False(suite.T(), errors.Is(err, ErrNoteNotFound))
+	assert.Contains(suite.T(), err.Error(), "failed to edit note")
+}
+
 // TestHandleSetNotePrivacy 测试设置笔记隐私处理器
 func (suite *ServerTestSuite) TestHandleSetNotePrivacy() {
 	// 准备测试请求
@@ -264,7 +391,8 @@ func (suite *ServerTestSuite) TestHandleSetNotePrivacy() {
 	assert.Len(suite.T(), result.Content, 1)
 	textContent, ok := result.Content[0].(*protocol.TextContent)
 	assert.True(suite.T(), ok)
-	assert.Contains(suite.T(), textContent.Text, "test-note-id-123")
+	assert.Equal(suite.T(), "笔记隐私设置成功！\n笔记ID: test-note-id-123\n公开：任何人都可以查看这篇笔记，也可以分享，永不过期", textContent.Text)
+	assert.NotContains(suite.T(), textContent.Text, "响应详情")
 }
 
 // TestHandleResetAPIKey 测试重置API密钥处理器
@@ -288,7 +416,9 @@ func (suite *ServerTestSuite) TestHandleResetAPIKey() {
 	assert.Len(suite.T(), result.Content, 1)
 	textContent, ok := result.Content[0].(*protocol.TextContent)
 	assert.True(suite.T(), ok)
-	assert.Contains(suite.T(), textContent.Text, "new-test-api-key-456")
+	assert.Contains(suite.T(), textContent.Text, "新密钥（仅显示末4位）：****-456")
+	assert.NotContains(suite.T(), textContent.Text, "new-test-api-key-456")
+	assert.NotContains(suite.T(), textContent.Text, "test-api-key")
 }
 
 // TestHandleUploadFileViaURL 测试URL文件上传处理器
@@ -319,6 +449,575 @@ func (suite *ServerTestSuite) TestHandleUploadFileViaURL() {
 	assert.Contains(suite.T(), textContent.Text, "test-url-file-uuid-999")
 }
 
+// TestHandleDiffNote 测试笔记差异对比处理器
+func (suite *ServerTestSuite) TestHandleDiffNote() {
+	args := DiffNoteArgs{
+		NoteID: "test-note-id-123",
+		Paragraphs: []Paragraph{
+			{Texts: []TextNode{{Text: "第一段"}}},
+			{Texts: []TextNode{{Text: "修改后的第二段"}}},
+			{Texts: []TextNode{{Text: "新增的第三段"}}},
+		},
+	}
+
+	argsJSON, err := json.Marshal(args)
+	require.NoError(suite.T(), err)
+
+	req := &protocol.CallToolRequest{
+		RawArguments: argsJSON,
+	}
+
+	result, err := suite.mcpServer.handleDiffNote(context.Background(), req)
+	assert.NoError(suite.T(), err)
+	require.NotNil(suite.T(), result)
+
+	textContent, ok := result.Content[0].(*protocol.TextContent)
+	assert.True(suite.T(), ok)
+	assert.Contains(suite.T(), textContent.Text, "共 2 处差异")
+	assert.Contains(suite.T(), textContent.Text, "~ 第2段（修改）")
+	assert.Contains(suite.T(), textContent.Text, "- 第二段")
+	assert.Contains(suite.T(), textContent.Text, "+ 修改后的第二段")
+	assert.Contains(suite.T(), textContent.Text, "+ 第3段（新增）")
+}
+
+// TestHandleCompareNotes 测试对比两篇笔记的内容，以及其中一篇不存在时的提示
+func (suite *ServerTestSuite) TestHandleCompareNotes() {
+	notes := map[string][]Paragraph{
+		"note-a": {
+			{Texts: []TextNode{{Text: "相同的开头"}}},
+			{Texts: []TextNode{{Text: "旧的说法"}}},
+			{Type: "quote", Texts: []TextNode{{Text: "只在A中"}}},
+		},
+		"note-b": {
+			{Texts: []TextNode{{Text: "相同的开头"}}},
+			{Texts: []TextNode{{Text: "新的说法"}}},
+		},
+	}
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var detailReq NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&detailReq))
+		if detailReq.NoteID == "note-gone" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 404, "message": "note not found"})
+			return
+		}
+		paragraphs, ok := notes[detailReq.NoteID]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 404, "message": "note not found"})
+			return
+		}
+		mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID, "body": mustConvert(suite.T(), paragraphs)})(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleCompareNotes, CompareNotesArgs{NoteID: "note-a", OtherNoteID: "note-b"})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "笔记 note-a（-）与笔记 note-b（+）的内容差异")
+	assert.Contains(suite.T(), text, "共 2 处差异")
+	assert.Contains(suite.T(), text, "~ 第2段（修改）\n  - 旧的说法\n  + 新的说法")
+	assert.Contains(suite.T(), text, "- 第3段（删除）\n  - > 只在A中")
+	assert.NotContains(suite.T(), text, "相同的开头")
+
+	text, err = suite.callTool(suite.mcpServer.handleCompareNotes, CompareNotesArgs{NoteID: "note-a", OtherNoteID: "note-missing"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "无法对比：笔记 note-missing 不存在", text)
+
+	// HTTP 200但业务错误码为404时同样视为不存在
+	text, err = suite.callTool(suite.mcpServer.handleCompareNotes, CompareNotesArgs{NoteID: "note-gone", OtherNoteID: "note-b"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "无法对比：笔记 note-gone 不存在", text)
+}
+
+// TestHandleGetUploadStatus 测试查询文件处理状态处理器
+func (suite *ServerTestSuite) TestHandleGetUploadStatus() {
+	cases := map[string]string{
+		"file-ready":      "已就绪",
+		"file-processing": "正在处理中",
+		"file-failed":     "处理失败：unsupported codec",
+	}
+	for uuid, expected := range cases {
+		argsJSON, err := json.Marshal(GetUploadStatusArgs{FileUUID: uuid})
+		require.NoError(suite.T(), err)
+
+		result, err := suite.mcpServer.handleGetUploadStatus(context.Background(), &protocol.CallToolRequest{RawArguments: argsJSON})
+		assert.NoError(suite.T(), err)
+		require.NotNil(suite.T(), result)
+
+		textContent, ok := result.Content[0].(*protocol.TextContent)
+		assert.True(suite.T(), ok)
+		assert.Contains(suite.T(), textContent.Text, uuid)
+		assert.Contains(suite.T(), textContent.Text, expected)
+	}
+
+	// 接口不存在时返回说明而不是错误
+	suite.mcpServer.mowenClient.baseURL = suite.mockHTTPServer.URL + "/unsupported"
+	argsJSON, err := json.Marshal(GetUploadStatusArgs{FileUUID: "file-ready"})
+	require.NoError(suite.T(), err)
+	result, err := suite.mcpServer.handleGetUploadStatus(context.Background(), &protocol.CallToolRequest{RawArguments: argsJSON})
+	assert.NoError(suite.T(), err)
+	require.NotNil(suite.T(), result)
+	assert.Contains(suite.T(), result.Content[0].(*protocol.TextContent).Text, "不支持查询文件处理状态")
+}
+
+// TestHandleUploadWaitForReady 测试设置wait_for_ready后文件就绪才报告成功，处理失败或超时时返回错误
+func (suite *ServerTestSuite) TestHandleUploadWaitForReady() {
+	suite.mcpServer.mowenClient.uploadPollInterval = time.Millisecond
+	args := UploadFileViaURLArgs{FileURL: "https://example.com/a.mp3", FileType: 2, WaitForReady: true}
+
+	text, err := suite.callTool(suite.mcpServer.handleUploadFileViaURL, args)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "文件通过URL上传成功！UUID: test-url-file-uuid-999\n文件已处理完成，可以嵌入笔记", text)
+
+	suite.routes[UploadURLEndpoint] = mockSuccess(map[string]interface{}{"uuid": "file-failed"})
+	_, err = suite.callTool(suite.mcpServer.handleUploadFileViaURL, args)
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "unsupported codec")
+
+	suite.routes[UploadURLEndpoint] = mockSuccess(map[string]interface{}{"uuid": "file-processing"})
+	suite.mcpServer.mowenClient.uploadReadyTimeout = 20 * time.Millisecond
+	_, err = suite.callTool(suite.mcpServer.handleUploadFileViaURL, args)
+	assert.ErrorIs(suite.T(), err, ErrUploadNotReady)
+
+	// 不等待时直接返回
+	args.WaitForReady = false
+	text, err = suite.callTool(suite.mcpServer.handleUploadFileViaURL, args)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "文件通过URL上传成功！UUID: file-processing", text)
+
+	suite.routes[UploadStatusEndpoint] = http.NotFound
+	args.WaitForReady = true
+	text, err = suite.callTool(suite.mcpServer.handleUploadFileViaURL, args)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "不支持查询文件处理状态")
+}
+
+// TestHandleCreateNoteInvalidFileUUID 测试文件UUID为占位符时拒绝创建笔记
+func (suite *ServerTestSuite) TestHandleCreateNoteInvalidFileUUID() {
+	args := CreateNoteArgs{
+		Paragraphs: []Paragraph{
+			{Type: "file", File: &FileNode{FileType: "image", SourceType: "upload", SourcePath: "TODO"}},
+		},
+	}
+
+	_, err := suite.callTool(suite.mcpServer.handleCreateNote, args)
+	assert.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "invalid file source_path")
+}
+
+// TestHandleGetNoteShare 测试获取公开笔记的分享信息
+func (suite *ServerTestSuite) TestHandleGetNoteShare() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(map[string]interface{}{
+		"noteId":  "test-note-id-123",
+		"privacy": map[string]interface{}{"type": "public"},
+	})
+	suite.routes[NoteShareEndpoint] = mockSuccess(map[string]interface{}{
+		"short_url": "https://mowen.cn/s/abc123",
+		"qr_code":   "https://mowen.cn/qr/abc123.png",
+	})
+
+	text, err := suite.callTool(suite.mcpServer.handleGetNoteShare, GetNoteShareArgs{NoteID: "test-note-id-123"})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "短链接: https://mowen.cn/s/abc123")
+	assert.Contains(suite.T(), text, "二维码: https://mowen.cn/qr/abc123.png")
+}
+
+// TestHandleGetNoteSharePrivate 测试私有笔记返回说明原因的错误
+func (suite *ServerTestSuite) TestHandleGetNoteSharePrivate() {
+	suite.routes[NoteDetailEndpoint] = mockSuccess(map[string]interface{}{
+		"noteId":  "test-note-id-123",
+		"privacy": map[string]interface{}{"type": "private"},
+	})
+
+	_, err := suite.callTool(suite.mcpServer.handleGetNoteShare, GetNoteShareArgs{NoteID: "test-note-id-123"})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "is private and cannot be shared")
+}
+
+// TestHandleSetNotePrivacyExpireAtUnit 测试按配置的单位提交公开截止时间
+func (suite *ServerTestSuite) TestHandleSetNotePrivacyExpireAtUnit() {
+	var setReq NoteSetRequest
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		mockSuccess(nil)(w, r)
+	}
+	expireAt := time.Now().Add(24 * time.Hour).Unix()
+	args := SetNotePrivacyArgs{NoteID: "test-note-id-123", PrivacyType: "rule", ExpireAt: &expireAt}
+
+	text, err := suite.callTool(suite.mcpServer.handleSetNotePrivacy, args)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), strconv.FormatInt(expireAt, 10), setReq.Settings.Privacy.Rule.ExpireAt)
+	assert.Contains(suite.T(), text, "规则公开：任何人都可以查看这篇笔记，直到 "+formatNoteTimestamp(time.Unix(expireAt, 0)))
+
+	suite.mcpServer.expireAtUnit = ExpireAtUnitMilliseconds
+	_, err = suite.callTool(suite.mcpServer.handleSetNotePrivacy, args)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), strconv.FormatInt(expireAt*1000, 10), setReq.Settings.Privacy.Rule.ExpireAt)
+
+	// 已过去的时间在调用API前被拒绝
+	past := time.Now().Add(-time.Hour).Unix()
+	args.ExpireAt = &past
+	_, err = suite.callTool(suite.mcpServer.handleSetNotePrivacy, args)
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "is not in the future")
+}
+
+// TestHandleCreateNoteWarning 测试成功响应携带提示信息时在结果中展示警告
+func (suite *ServerTestSuite) TestHandleCreateNoteWarning() {
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"code":    0,
+			"data":    map[string]interface{}{"note_id": "test-note-id-123"},
+			"message": "部分标签被忽略",
+		})
+	}
+	args := CreateNoteArgs{Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "正文"}}}}, Tags: []string{"a", "b"}}
+
+	text, err := suite.callTool(suite.mcpServer.handleCreateNote, args)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "笔记创建成功")
+	assert.Contains(suite.T(), text, "⚠️ 警告：部分标签被忽略")
+
+	// 关闭后不展示
+	suite.mcpServer.showWarnings = false
+	text, err = suite.callTool(suite.mcpServer.handleCreateNote, args)
+	require.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), text, "⚠️ 警告")
+}
+
+// TestResponseWarning 测试区分成功提示与普通的成功信息
+func (suite *ServerTestSuite) TestResponseWarning() {
+	assert.Empty(suite.T(), responseWarning(map[string]interface{}{"message": "success"}))
+	assert.Empty(suite.T(), responseWarning(map[string]interface{}{"message": " OK "}))
+	assert.Empty(suite.T(), responseWarning(map[string]interface{}{}))
+	assert.Equal(suite.T(), "部分标签被忽略", responseWarning(map[string]interface{}{"message": "部分标签被忽略"}))
+}
+
+// TestHandleCreateNoteWithTimestamps 测试创建笔记时传递创建和更新时间
+func (suite *ServerTestSuite) TestHandleCreateNoteWithTimestamps() {
+	var createReq NoteCreateRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&createReq))
+		mockSuccess(map[string]interface{}{"note_id": "test-note-id-123"})(w, r)
+	}
+
+	_, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "历史笔记"}}}},
+		CreatedAt:  1600000000,
+		UpdatedAt:  1650000000,
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), int64(1600000000), createReq.Settings.CreatedAt)
+	assert.Equal(suite.T(), int64(1650000000), createReq.Settings.UpdatedAt)
+
+	// 不合理的时间戳在调用API前被拒绝
+	_, err = suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "历史笔记"}}}},
+		CreatedAt:  1600000000000,
+	})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "looks like milliseconds")
+}
+
+// TestHandleCreateNoteTimestampsNotSupported 测试接口不支持自定义时间时返回明确的错误
+func (suite *ServerTestSuite) TestHandleCreateNoteTimestampsNotSupported() {
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":400,"message":"unknown field createdAt"}`))
+	}
+
+	_, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "历史笔记"}}}},
+		CreatedAt:  1600000000,
+	})
+	require.Error(suite.T(), err)
+	assert.ErrorIs(suite.T(), err, ErrTimestampsNotSupported)
+	assert.Contains(suite.T(), err.Error(), "unknown field createdAt")
+}
+
+// TestHandleGetAccountDefaults 测试获取账号默认设置处理器
+func (suite *ServerTestSuite) TestHandleGetAccountDefaults() {
+	suite.routes[AccountSettingsEndpoint] = mockSuccess(AccountDefaults{
+		Privacy:     &NotePrivacySet{Type: "rule", Rule: &NotePrivacySetRule{NoShare: true}},
+		Tags:        []string{"收件箱", "待整理"},
+		AutoPublish: true,
+	})
+
+	text, err := suite.callTool(suite.mcpServer.handleGetAccountDefaults, GetAccountDefaultsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "默认隐私: rule（禁止分享: true")
+	assert.Contains(suite.T(), text, "默认标签: 收件箱, 待整理")
+	assert.Contains(suite.T(), text, "默认发布: true")
+
+	// 接口不存在时返回说明而不是错误
+	delete(suite.routes, AccountSettingsEndpoint)
+	text, err = suite.callTool(suite.mcpServer.handleGetAccountDefaults, GetAccountDefaultsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "不支持获取账号默认设置")
+}
+
+// TestHandleCreateNoteDryRun 测试试运行只返回转换预览和提示，不调用墨问API
+func (suite *ServerTestSuite) TestHandleCreateNoteDryRun() {
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("dry run must not create notes")
+	}
+	args := CreateNoteArgs{
+		Paragraphs: []Paragraph{
+			{Texts: []TextNode{{Text: "标题", Bold: true}}},
+			{Type: "headline", Texts: []TextNode{{Text: "正文"}}},
+		},
+		DryRun: true,
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleCreateNote, args)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "转换后共 2 个段落")
+	assert.Contains(suite.T(), text, "1. **标题**")
+	assert.Contains(suite.T(), text, `第 2 段：未知的段落类型 "headline"`)
+}
+
+// TestHandleResultsStructured 测试创建、编辑和上传的结果只包含解析出的ID和链接，响应缺少字段时给出说明
+func (suite *ServerTestSuite) TestHandleResultsStructured() {
+	paragraphs := []Paragraph{{Texts: []TextNode{{Text: "正文"}}}}
+
+	text, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: paragraphs})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记创建成功！ID: test-note-id-123\n链接: https://mowen.cn/note/test-note-id-123", text)
+
+	// 编辑接口没有返回笔记ID时使用请求中的笔记ID
+	suite.routes[NoteEditEndpoint] = mockSuccess(map[string]interface{}{})
+	text, err = suite.callTool(suite.mcpServer.handleEditNote, EditNoteArgs{NoteID: "note-edit-1", Paragraphs: paragraphs})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记编辑成功！ID: note-edit-1\n链接: https://note.mowen.cn/detail/note-edit-1", text)
+
+	text, err = suite.callTool(suite.mcpServer.handleUploadFileViaURL, UploadFileViaURLArgs{FileURL: "https://example.com/a.png", FileType: 1})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "文件通过URL上传成功！UUID: test-url-file-uuid-999", text)
+
+	suite.routes[NoteCreateEndpoint] = mockSuccess(map[string]interface{}{})
+	suite.routes[UploadURLEndpoint] = mockSuccess(map[string]interface{}{})
+	text, err = suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{Paragraphs: paragraphs})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记创建成功！响应中未包含笔记ID", text)
+	text, err = suite.callTool(suite.mcpServer.handleUploadFileViaURL, UploadFileViaURLArgs{FileURL: "https://example.com/a.png", FileType: 1})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "文件通过URL上传成功！响应中未包含文件UUID", text)
+}
+
+// TestHandleEditNoteConversionWarnings 测试编辑成功时附带转换提示
+func (suite *ServerTestSuite) TestHandleEditNoteConversionWarnings() {
+	args := EditNoteArgs{
+		NoteID:     "test-note-id-123",
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: ""}, {Text: "内容"}}}},
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleEditNote, args)
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "笔记编辑成功")
+	assert.Contains(suite.T(), text, "第 1 段：已跳过 1 个空文本节点")
+}
+
+// TestHandleListSupportedTypes 测试列出支持的段落类型和文本标记
+func (suite *ServerTestSuite) TestHandleListSupportedTypes() {
+	text, err := suite.callTool(suite.mcpServer.handleListSupportedTypes, ListSupportedTypesArgs{})
+	require.NoError(suite.T(), err)
+	for _, info := range append(append([]TypeInfo{}, SupportedParagraphTypes...), SupportedMarkTypes...) {
+		assert.Contains(suite.T(), text, "- "+info.Name+"：")
+	}
+}
+
+// TestNoteSetRequestSections 测试每种设置操作都使用对应的section值
+func (suite *ServerTestSuite) TestNoteSetRequestSections() {
+	var sections []int
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var setReq NoteSetRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		sections = append(sections, setReq.Section)
+		mockSuccess(nil)(w, r)
+	}
+	ctx := context.Background()
+	// 期望值使用字面量，避免常量被误改时测试随之一起通过
+	cases := []struct {
+		name    string
+		section int
+		run     func() error
+	}{
+		{"set_note_privacy", 1, func() error {
+			_, err := suite.callTool(suite.mcpServer.handleSetNotePrivacy, SetNotePrivacyArgs{NoteID: "note-1", PrivacyType: "private"})
+			return err
+		}},
+		{"scheduled privacy", 1, func() error {
+			_, err := suite.mcpServer.mowenClient.SetNotePrivacy(ctx, privacySetRequest("note-1", "public"))
+			return err
+		}},
+		{"tags", 2, func() error {
+			_, err := suite.mcpServer.mowenClient.SetNoteTags(ctx, "note-1", []string{"工作"})
+			return err
+		}},
+		{"set_note_pinned", 3, func() error {
+			_, err := suite.callTool(suite.mcpServer.handleSetNotePinned, SetNotePinnedArgs{NoteID: "note-1", Pinned: true})
+			return err
+		}},
+	}
+	for _, c := range cases {
+		sections = nil
+		require.NoError(suite.T(), c.run(), c.name)
+		assert.Equal(suite.T(), []int{c.section}, sections, c.name)
+	}
+	assert.Equal(suite.T(), []int{1, 2, 3}, []int{SectionPrivacy, SectionTags, SectionPinned})
+}
+
+// TestHandleSetNotePinned 测试置顶工具的确认信息，以及接口不支持置顶时返回提示
+func (suite *ServerTestSuite) TestHandleSetNotePinned() {
+	var pinned []bool
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var setReq NoteSetRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		assert.Equal(suite.T(), 3, setReq.Section)
+		require.NotNil(suite.T(), setReq.Settings.Pinned)
+		pinned = append(pinned, *setReq.Settings.Pinned)
+		mockSuccess(nil)(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleSetNotePinned, SetNotePinnedArgs{NoteID: "test-note-id-123", Pinned: true})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "笔记已置顶")
+	text, err = suite.callTool(suite.mcpServer.handleSetNotePinned, SetNotePinnedArgs{NoteID: "test-note-id-123", Pinned: false})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "笔记已取消置顶")
+	assert.Equal(suite.T(), []bool{true, false}, pinned)
+
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code":400,"message":"unknown section"}`, http.StatusBadRequest)
+	}
+	text, err = suite.callTool(suite.mcpServer.handleSetNotePinned, SetNotePinnedArgs{NoteID: "test-note-id-123", Pinned: true})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "不支持设置笔记置顶")
+}
+
+// TestHandleUploadFileDryRun 测试上传预览包含准备响应中的上传地址和表单字段，存储凭证被脱敏
+func (suite *ServerTestSuite) TestHandleUploadFileDryRun() {
+	suite.routes["/upload/dynamic"] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("dry run must not upload file content")
+	}
+	path := filepath.Join(suite.T().TempDir(), "photo.png")
+	require.NoError(suite.T(), os.WriteFile(path, []byte("fake image bytes"), 0o644))
+
+	text, err := suite.callTool(suite.mcpServer.handleUploadFile, UploadFileArgs{FilePath: path, FileType: 1, FileName: "photo.png", DryRun: true})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "POST "+UploadPrepareEndpoint)
+	assert.Contains(suite.T(), text, `"file_name": "photo.png"`)
+	assert.Contains(suite.T(), text, "POST "+suite.mockHTTPServer.URL+"/upload/dynamic")
+	assert.Contains(suite.T(), text, "文件字段名: file")
+	assert.Contains(suite.T(), text, "- key: test-file-key")
+	assert.Contains(suite.T(), text, "- policy: [REDACTED]")
+	assert.Contains(suite.T(), text, "- signature: [REDACTED]")
+	assert.NotContains(suite.T(), text, "test-policy")
+	assert.NotContains(suite.T(), text, "test-signature")
+
+	// 预签名地址的查询参数中带有签名
+	plan := RenderUploadPlan(UploadPrepareRequest{FileType: 1, FileName: "photo.png"}, &UploadPlan{
+		Method:      "PUT",
+		URL:         "https://storage.example.com/file?X-Amz-Signature=abc",
+		ContentType: "image/png",
+	})
+	assert.Contains(suite.T(), plan, "PUT https://storage.example.com/file?[REDACTED]")
+	assert.NotContains(suite.T(), plan, "X-Amz-Signature")
+}
+
+// recordingRegistrar 记录已注册工具名称的注册器
+type recordingRegistrar struct {
+	names []string
+}
+
+// RegisterTool 记录工具名称
+func (r *recordingRegistrar) RegisterTool(tool *protocol.Tool, toolHandler server.ToolHandlerFunc, middlewares ...server.ToolMiddleware) {
+	r.names = append(r.names, tool.Name)
+}
+
+// TestRegisterToolDefinitions 测试全部工具创建成功才注册，任何一个失败时不注册任何工具
+func (suite *ServerTestSuite) TestRegisterToolDefinitions() {
+	definitions := suite.mcpServer.toolDefinitions()
+
+	registrar := &recordingRegistrar{}
+	require.NoError(suite.T(), registerToolDefinitions(registrar, definitions))
+	require.Len(suite.T(), registrar.names, len(definitions))
+	assert.Contains(suite.T(), registrar.names, "create_note")
+
+	// 中间的工具参数不是结构体，schema生成失败
+	broken := append([]toolDefinition{}, definitions...)
+	broken[len(broken)/2].Args = 42
+	registrar = &recordingRegistrar{}
+	err := registerToolDefinitions(registrar, broken)
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "failed to create "+broken[len(broken)/2].Name+" tool")
+	assert.Empty(suite.T(), registrar.names)
+}
+
+// TestPreviewTextMarksDescription 测试文本标记预览工具的说明列出全部支持的标记
+func (suite *ServerTestSuite) TestPreviewTextMarksDescription() {
+	for _, def := range suite.mcpServer.toolDefinitions() {
+		if def.Name == "preview_text_marks" {
+			assert.Contains(suite.T(), def.Description, "（bold、italic、strikethrough、code、highlight、textColor、link）")
+			return
+		}
+	}
+	suite.T().Fatal("preview_text_marks is not defined")
+}
+
+// TestHandlePreviewTextMarks 测试文本标记预览处理器不调用墨问API
+func (suite *ServerTestSuite) TestHandlePreviewTextMarks() {
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("preview must not create a note")
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handlePreviewTextMarks, PreviewTextMarksArgs{
+		Texts: []TextNode{
+			{Text: "高亮链接", Highlight: true, Link: "https://example.com"},
+			{Text: ""},
+		},
+	})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 1 个文本片段")
+	assert.Contains(suite.T(), text, "highlight, link(https://example.com)")
+	assert.Contains(suite.T(), text, "预览：[==高亮链接==](https://example.com)")
+}
+
+// TestHandleReorderParagraphs 测试调整段落顺序处理器
+func (suite *ServerTestSuite) TestHandleReorderParagraphs() {
+	var editReq NoteEditRequest
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&editReq)
+		mockSuccess(map[string]interface{}{"note_id": "test-note-id-123"})(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleReorderParagraphs, ReorderParagraphsArgs{
+		NoteID: "test-note-id-123",
+		Moves:  []ParagraphMove{{From: 2, To: 1}},
+	})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "段落顺序已调整")
+
+	require.Len(suite.T(), editReq.Body.Content, 2)
+	assert.Equal(suite.T(), "第二段", editReq.Body.Content[0].Content[0].Text)
+	assert.Equal(suite.T(), "第一段", editReq.Body.Content[1].Content[0].Text)
+}
+
+// TestHandleReorderParagraphsOutOfRange 测试段落序号超出范围时不提交编辑
+func (suite *ServerTestSuite) TestHandleReorderParagraphsOutOfRange() {
+	edited := false
+	suite.routes[NoteEditEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		edited = true
+		mockSuccess(nil)(w, r)
+	}
+
+	_, err := suite.callTool(suite.mcpServer.handleReorderParagraphs, ReorderParagraphsArgs{
+		NoteID: "test-note-id-123",
+		Moves:  []ParagraphMove{{From: 1, To: 3}},
+	})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "to index 3 out of range")
+	assert.False(suite.T(), edited)
+}
+
 // TestInvalidArguments 测试无效参数处理
 func (suite *ServerTestSuite) TestInvalidArguments() {
 	// 测试无效的JSON参数
@@ -334,4 +1033,108 @@ func (suite *ServerTestSuite) TestInvalidArguments() {
 // TestServerTestSuite 运行服务器测试套件
 func TestServerTestSuite(t *testing.T) {
 	suite.Run(t, new(ServerTestSuite))
-}
\ No newline at end of file
+}
+// TestHandleCreateNoteAPIError 测试HTTP 200但code不为0时创建工具返回接口的业务错误
+func (suite *ServerTestSuite) TestHandleCreateNoteAPIError() {
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":1001,"message":"tag limit exceeded"}`))
+	}
+
+	_, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "内容"}}}},
+		Tags:       []string{"一", "二"},
+	})
+	require.Error(suite.T(), err)
+	var apiErr *APIError
+	require.True(suite.T(), errors.As(err, &apiErr))
+	assert.Equal(suite.T(), 1001, apiErr.Code)
+	assert.Contains(suite.T(), err.Error(), "tag limit exceeded")
+}
+
+// TestHandleDeleteNote 测试删除笔记返回被删除的笔记ID，note_id为空时不调用墨问API
+func (suite *ServerTestSuite) TestHandleDeleteNote() {
+	var deleted []string
+	suite.routes[NoteDeleteEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var deleteReq NoteDeleteRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&deleteReq))
+		switch deleteReq.NoteID {
+		case "note-missing":
+			w.Write([]byte(`{"code":404,"message":"笔记不存在"}`))
+			return
+		case "note-missing-http":
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":404,"message":"笔记不存在"}`))
+			return
+		}
+		deleted = append(deleted, deleteReq.NoteID)
+		mockSuccess(nil)(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleDeleteNote, DeleteNoteArgs{NoteID: "note-to-delete"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记已删除，笔记ID: note-to-delete", text)
+	assert.Equal(suite.T(), []string{"note-to-delete"}, deleted)
+
+	_, err = suite.callTool(suite.mcpServer.handleDeleteNote, DeleteNoteArgs{NoteID: "  "})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "note_id must not be empty")
+	assert.Len(suite.T(), deleted, 1)
+
+	_, err = suite.callTool(suite.mcpServer.handleDeleteNote, DeleteNoteArgs{NoteID: "note-missing"})
+	require.Error(suite.T(), err)
+	assert.True(suite.T(), errors.Is(err, ErrNoteNotFound))
+
+	// 以HTTP 404返回笔记不存在时，不能当作删除接口不存在
+	_, err = suite.callTool(suite.mcpServer.handleDeleteNote, DeleteNoteArgs{NoteID: "note-missing-http"})
+	require.Error(suite.T(), err)
+	assert.True(suite.T(), errors.Is(err, ErrNoteNotFound))
+	assert.Contains(suite.T(), err.Error(), "note note-missing-http does not exist")
+
+	// 删除接口不存在时说明不支持
+	delete(suite.routes, NoteDeleteEndpoint)
+	text, err = suite.callTool(suite.mcpServer.handleDeleteNote, DeleteNoteArgs{NoteID: "note-to-delete"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "当前墨问API不支持删除笔记", text)
+}
+
+// TestHandleSetNotePrivacyDefaultExpireIn 测试规则公开未指定截止时间时使用默认公开时长，显式指定时以参数为准
+func (suite *ServerTestSuite) TestHandleSetNotePrivacyDefaultExpireIn() {
+	var setReq NoteSetRequest
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		mockSuccess(nil)(w, r)
+	}
+	suite.mcpServer.defaultExpireIn = 7 * 24 * time.Hour
+	expireAtOf := func() int64 {
+		ts, err := strconv.ParseInt(setReq.Settings.Privacy.Rule.ExpireAt, 10, 64)
+		require.NoError(suite.T(), err)
+		return ts
+	}
+
+	args := SetNotePrivacyArgs{NoteID: "test-note-id-123", PrivacyType: "rule"}
+	_, err := suite.callTool(suite.mcpServer.handleSetNotePrivacy, args)
+	require.NoError(suite.T(), err)
+	assert.InDelta(suite.T(), time.Now().Add(7*24*time.Hour).Unix(), expireAtOf(), 5)
+
+	args.ExpireIn = "36h"
+	_, err = suite.callTool(suite.mcpServer.handleSetNotePrivacy, args)
+	require.NoError(suite.T(), err)
+	assert.InDelta(suite.T(), time.Now().Add(36*time.Hour).Unix(), expireAtOf(), 5)
+
+	// 显式的expire_at为0表示永不过期，不使用默认值
+	never := int64(0)
+	args = SetNotePrivacyArgs{NoteID: "test-note-id-123", PrivacyType: "rule", ExpireAt: &never}
+	_, err = suite.callTool(suite.mcpServer.handleSetNotePrivacy, args)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "0", setReq.Settings.Privacy.Rule.ExpireAt)
+
+	args.ExpireIn = "1h"
+	_, err = suite.callTool(suite.mcpServer.handleSetNotePrivacy, args)
+	assert.ErrorContains(suite.T(), err, "cannot be used together")
+
+	// 非rule类型不设置截止时间
+	setReq = NoteSetRequest{}
+	_, err = suite.callTool(suite.mcpServer.handleSetNotePrivacy, SetNotePrivacyArgs{NoteID: "test-note-id-123", PrivacyType: "public"})
+	require.NoError(suite.T(), err)
+	assert.Nil(suite.T(), setReq.Settings.Privacy.Rule)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
+)
+
+// MCP传输方式，通过MOWEN_TRANSPORT选择
+const (
+	// TransportHTTP 通过Streamable HTTP提供MCP服务，默认方式
+	TransportHTTP = "http"
+	// TransportStdio 通过标准输入输出提供MCP服务，适合由客户端启动子进程的集成方式
+	TransportStdio = "stdio"
+)
+
+// DefaultListenAddr 未设置MOWEN_LISTEN_ADDR和PORT时HTTP传输的监听地址，只接受本机连接
+const DefaultListenAddr = "127.0.0.1:8080"
+
+// loadTransportMode 读取MOWEN_TRANSPORT，未设置时使用HTTP
+func loadTransportMode() (string, error) {
+	mode := strings.ToLower(strings.TrimSpace(os.Getenv("MOWEN_TRANSPORT")))
+	switch mode {
+	case "":
+		return TransportHTTP, nil
+	case TransportHTTP, TransportStdio:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid MOWEN_TRANSPORT value %q: must be %s or %s", mode, TransportHTTP, TransportStdio)
+}
+
+// loadListenAddr 读取并校验HTTP传输的监听地址。
+// MOWEN_LISTEN_ADDR优先；其次在设置了PORT时（云平台托管部署）监听所有网络接口上的该端口；
+// 都未设置时只监听本机的DefaultListenAddr。
+func loadListenAddr() (string, error) {
+	name := "MOWEN_LISTEN_ADDR"
+	addr := strings.TrimSpace(os.Getenv(name))
+	if addr == "" {
+		port := strings.TrimSpace(os.Getenv("PORT"))
+		if port == "" {
+			return DefaultListenAddr, nil
+		}
+		name = "PORT"
+		addr = "0.0.0.0:" + port
+	}
+	if err := validateListenAddr(addr); err != nil {
+		return "", fmt.Errorf("invalid %s: %w", name, err)
+	}
+	return addr, nil
+}
+
+// validateListenAddr 检查监听地址是否为host:port形式，端口必须是0到65535之间的整数，host可以为空表示所有网络接口
+func validateListenAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("listen address %q must be host:port, e.g. 127.0.0.1:8080 or :8080: %v", addr, err)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("listen address %q has invalid port %q: must be an integer between 0 and 65535", addr, port)
+	}
+	return nil
+}
+
+// newServerTransport 按传输方式创建MCP传输层，stdio方式不使用listenAddr
+func newServerTransport(mode, listenAddr string) transport.ServerTransport {
+	if mode == TransportStdio {
+		return transport.NewStdioServerTransport()
+	}
+	return transport.NewStreamableHTTPServerTransport(
+		listenAddr,
+		transport.WithStreamableHTTPServerTransportOptionStateMode(transport.Stateful),
+	)
+}
+
+// transportName 诊断信息中显示的传输方式名称
+func transportName(mode string) string {
+	if mode == TransportStdio {
+		return "stdio"
+	}
+	return "streamable_http"
+}
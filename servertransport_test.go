@@ -0,0 +1,83 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadTransportMode 测试传输方式默认为HTTP，不认识的值返回错误
+func (suite *ServerTestSuite) TestLoadTransportMode() {
+	suite.T().Setenv("MOWEN_TRANSPORT", "")
+	mode, err := loadTransportMode()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), TransportHTTP, mode)
+
+	suite.T().Setenv("MOWEN_TRANSPORT", " STDIO ")
+	mode, err = loadTransportMode()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), TransportStdio, mode)
+
+	suite.T().Setenv("MOWEN_TRANSPORT", "sse")
+	_, err = loadTransportMode()
+	assert.ErrorContains(suite.T(), err, `invalid MOWEN_TRANSPORT value "sse"`)
+}
+
+// TestLoadListenAddr 测试默认只监听本机，设置PORT时监听所有网络接口，MOWEN_LISTEN_ADDR优先于PORT，格式错误的地址返回错误
+func (suite *ServerTestSuite) TestLoadListenAddr() {
+	suite.T().Setenv("MOWEN_LISTEN_ADDR", "")
+	suite.T().Setenv("PORT", "")
+	addr, err := loadListenAddr()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "127.0.0.1:8080", addr)
+
+	suite.T().Setenv("PORT", "9090")
+	addr, err = loadListenAddr()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "0.0.0.0:9090", addr)
+
+	suite.T().Setenv("MOWEN_LISTEN_ADDR", "127.0.0.1:18080")
+	addr, err = loadListenAddr()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "127.0.0.1:18080", addr)
+
+	suite.T().Setenv("MOWEN_LISTEN_ADDR", ":18080")
+	addr, err = loadListenAddr()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), ":18080", addr)
+
+	for _, invalid := range []string{"127.0.0.1", "localhost:http", "127.0.0.1:70000", "[::1:8080"} {
+		suite.T().Setenv("MOWEN_LISTEN_ADDR", invalid)
+		_, err = loadListenAddr()
+		assert.ErrorContains(suite.T(), err, "invalid MOWEN_LISTEN_ADDR", invalid)
+	}
+
+	suite.T().Setenv("MOWEN_LISTEN_ADDR", "")
+	suite.T().Setenv("PORT", "abc")
+	_, err = loadListenAddr()
+	assert.ErrorContains(suite.T(), err, "invalid PORT")
+	_, err = NewMowenMCPServer()
+	assert.Error(suite.T(), err)
+
+	// stdio传输不使用监听地址，不校验PORT
+	suite.T().Setenv("MOWEN_TRANSPORT", TransportStdio)
+	_, err = NewMowenMCPServer()
+	assert.NoError(suite.T(), err)
+}
+
+// TestNewMowenMCPServerStdio 测试MOWEN_TRANSPORT=stdio时使用标准输入输出传输，诊断信息中显示对应的传输方式
+func (suite *ServerTestSuite) TestNewMowenMCPServerStdio() {
+	suite.T().Setenv("MOWEN_TRANSPORT", TransportStdio)
+	server, err := NewMowenMCPServer()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), TransportStdio, server.transportMode)
+
+	server.mowenClient.baseURL = suite.mockHTTPServer.URL
+	text, err := suite.callTool(server.handleDiagnostics, DiagnosticsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "- transport: stdio")
+	assert.Contains(suite.T(), text, "- listen_addr: 不适用（stdio）")
+
+	suite.T().Setenv("MOWEN_TRANSPORT", "grpc")
+	_, err = NewMowenMCPServer()
+	assert.Error(suite.T(), err)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/client"
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+	"github.com/ThinkInAIXYZ/go-mcp/transport"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// freePort 返回一个当前空闲的本地端口
+func freePort(t *testing.T) int {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+// connectSession 建立一个新的MCP会话，服务尚未开始监听时重试
+func connectSession(t *testing.T, url string) *client.Client {
+	var lastErr error
+	for i := 0; i < 50; i++ {
+		clientTransport, err := transport.NewStreamableHTTPClientTransport(url)
+		require.NoError(t, err)
+		session, err := client.NewClient(clientTransport)
+		if err == nil {
+			return session
+		}
+		lastErr = err
+		time.Sleep(20 * time.Millisecond)
+	}
+	require.NoError(t, lastErr)
+	return nil
+}
+
+// TestSharedClientAcrossSessions 测试多个MCP会话共享同一个墨问API客户端及其连接池
+func TestSharedClientAcrossSessions(t *testing.T) {
+	t.Setenv("MOWEN_API_KEY", "test-api-key")
+	port := freePort(t)
+	t.Setenv("PORT", strconv.Itoa(port))
+
+	var mu sync.Mutex
+	remoteAddrs := make(map[string]int)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var detailReq NoteDetailRequest
+		json.NewDecoder(r.Body).Decode(&detailReq)
+		mu.Lock()
+		remoteAddrs[r.RemoteAddr]++
+		mu.Unlock()
+		// 模拟较慢的接口，使并发请求同时占用连接
+		time.Sleep(10 * time.Millisecond)
+		mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID, "body": NoteAtom{Type: "doc"}})(w, r)
+	}))
+	defer api.Close()
+
+	mcpServer, err := NewMowenMCPServer()
+	require.NoError(t, err)
+	mowenClient := mcpServer.mowenClient
+	mowenClient.baseURL = api.URL
+	go mcpServer.Run()
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		mcpServer.Shutdown(ctx)
+	}()
+
+	url := fmt.Sprintf("http://127.0.0.1:%d/mcp", port)
+	sessions := []*client.Client{connectSession(t, url), connectSession(t, url)}
+	defer func() {
+		for _, session := range sessions {
+			session.Close()
+		}
+	}()
+	callStats := func(session *client.Client, noteID string) error {
+		result, err := session.CallTool(context.Background(), protocol.NewCallToolRequest("note_stats", map[string]interface{}{"note_id": noteID}))
+		if err != nil {
+			return err
+		}
+		if result.IsError {
+			return fmt.Errorf("tool error: %v", result.Content)
+		}
+		return nil
+	}
+
+	// 不同会话先后发出的请求复用同一条连接
+	require.NoError(t, callStats(sessions[0], "note-session-1"))
+	require.NoError(t, callStats(sessions[1], "note-session-2"))
+	mu.Lock()
+	assert.Len(t, remoteAddrs, 1, "sessions must share the client's connection pool")
+	mu.Unlock()
+
+	// 每个并发请求使用各自的会话：go-mcp v0.2.21在同一会话内并发处理请求时，
+	// 更新会话活跃时间（session.State.updateLastActiveAt）没有加锁，会被-race报告为数据竞争
+	for len(sessions) < 8 {
+		sessions = append(sessions, connectSession(t, url))
+	}
+	var wg sync.WaitGroup
+	errs := make(chan error, len(sessions))
+	for i, session := range sessions {
+		wg.Add(1)
+		go func(i int, session *client.Client) {
+			defer wg.Done()
+			errs <- callStats(session, fmt.Sprintf("note-concurrent-%d", i))
+		}(i, session)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+
+	// 并发会话仍由服务启动时创建的客户端处理，连接数不超过同时进行的请求数
+	assert.Same(t, mowenClient, mcpServer.mowenClient)
+	mu.Lock()
+	defer mu.Unlock()
+	total := 0
+	for _, count := range remoteAddrs {
+		total += count
+	}
+	assert.Equal(t, 10, total)
+	assert.LessOrEqual(t, len(remoteAddrs), 8)
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// 估算阅读时间使用的阅读速度
+const (
+	CJKCharsPerMinute = 300 // 中日韩文字每分钟阅读的字数
+	WordsPerMinute    = 200 // 英文等以空白分词的文字每分钟阅读的词数
+)
+
+// NoteStats 笔记正文的统计信息
+type NoteStats struct {
+	Words       int // 词数：每个中日韩文字算一个词，其他文字按连续的字母和数字算一个词
+	CJKChars    int // 中日韩文字数
+	Characters  int // 不含空白的字符数
+	Paragraphs  int // 含有文本的段落数（含引用段落和标题）
+	Links       int // 链接数：文本链接和链接卡片，相邻的同一链接只计一次
+	ReadingTime int // 估算的阅读时间（分钟），有内容时至少为1
+}
+
+// isCJK 判断字符是否为中日韩文字，这些文字不以空白分词
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul)
+}
+
+// countText 统计一段文本的词数、中日韩文字数和不含空白的字符数
+func (st *NoteStats) countText(text string) {
+	inWord := false
+	for _, r := range text {
+		switch {
+		case unicode.IsSpace(r):
+			inWord = false
+			continue
+		case isCJK(r):
+			st.CJKChars++
+			st.Words++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				st.Words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+		st.Characters++
+	}
+}
+
+// countInline 统计段落内的文本和链接，相邻的同一链接只计一次，返回段落是否含有非空白文本
+func (st *NoteStats) countInline(content []NoteAtom) bool {
+	lastHref := ""
+	hasText := false
+	for _, inline := range content {
+		if inline.Type != "text" {
+			lastHref = ""
+			continue
+		}
+		if strings.TrimSpace(inline.Text) != "" {
+			hasText = true
+		}
+		st.countText(inline.Text)
+
+		href := ""
+		for _, mark := range inline.Marks {
+			if mark.Type == "link" {
+				href = mark.Attrs["href"]
+			}
+		}
+		if href != "" && href != lastHref {
+			st.Links++
+		}
+		lastHref = href
+	}
+	return hasText
+}
+
+// ComputeNoteStats 统计NoteAtom文档的词数、字符数、段落数、链接数和阅读时间
+func ComputeNoteStats(doc NoteAtom) NoteStats {
+	var st NoteStats
+	for _, block := range doc.Content {
+		switch block.Type {
+		case "paragraph", "heading":
+			if st.countInline(block.Content) {
+				st.Paragraphs++
+			}
+		case "bulletList", "orderedList":
+			// 每个含有文本的列表项按一个段落计数
+			for _, item := range block.Content {
+				hasText := false
+				for _, child := range item.Content {
+					if st.countInline(child.Content) {
+						hasText = true
+					}
+				}
+				if hasText {
+					st.Paragraphs++
+				}
+			}
+		case "link_card":
+			st.Links++
+		}
+	}
+
+	otherWords := st.Words - st.CJKChars
+	minutes := float64(st.CJKChars)/CJKCharsPerMinute + float64(otherWords)/WordsPerMinute
+	st.ReadingTime = int(minutes)
+	if float64(st.ReadingTime) < minutes {
+		st.ReadingTime++
+	}
+	return st
+}
+
+// RenderNoteStats 将笔记统计信息渲染为文本
+func RenderNoteStats(noteID string, st NoteStats) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "笔记 %s 的统计信息：", noteID)
+	fmt.Fprintf(&sb, "\n- 词数：%d（其中中日韩文字 %d 个）", st.Words, st.CJKChars)
+	fmt.Fprintf(&sb, "\n- 字符数（不含空白）：%d", st.Characters)
+	fmt.Fprintf(&sb, "\n- 段落数：%d", st.Paragraphs)
+	fmt.Fprintf(&sb, "\n- 链接数：%d", st.Links)
+	fmt.Fprintf(&sb, "\n- 阅读时间：约 %d 分钟", st.ReadingTime)
+	return sb.String()
+}
+
+// handleNoteStats 处理获取笔记统计信息的MCP工具请求，读取笔记正文后在本地统计
+func (s *MowenMCPServer) handleNoteStats(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args NoteStatsArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if isNoteNotFoundResponse(err, result) {
+		return textResult(fmt.Sprintf("笔记 %s 不存在", args.NoteID)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, err
+	}
+	return textResult(RenderNoteStats(args.NoteID, ComputeNoteStats(detail.Body))), nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsFixture 包含中英文混排、链接、空段落、链接卡片和文件的笔记
+func statsFixture(t require.TestingT) NoteAtom {
+	return mustConvert(t, []Paragraph{
+		{Texts: []TextNode{{Text: "你好，世界。"}, {Text: " Hello world", Bold: true}}},
+		{Type: "quote", Texts: []TextNode{
+			{Text: "阅读 "},
+			{Text: "文档", Link: "https://example.com/docs"},
+			{Text: "說明", Link: "https://example.com/docs", Bold: true},
+		}},
+		{},
+		{Type: "link_card", URL: "https://example.com/card"},
+		{Type: "file", File: &FileNode{FileType: "image", SourceType: "local", SourcePath: "file-abcdef12"}},
+		{Texts: []TextNode{{Text: "release notes v2"}}},
+	})
+}
+
+// TestComputeNoteStats 测试中日韩文字按字计数，其他文字按词计数，相邻的同一链接只计一次
+func TestComputeNoteStats(t *testing.T) {
+	assert.Equal(t, NoteStats{
+		Words:       15,
+		CJKChars:    10,
+		Characters:  36,
+		Paragraphs:  3,
+		Links:       2,
+		ReadingTime: 1,
+	}, ComputeNoteStats(statsFixture(t)))
+
+	// 阅读时间按中文每分钟300字、英文每分钟200词向上取整
+	long := mustConvert(t, []Paragraph{
+		{Texts: []TextNode{{Text: strings.Repeat("字", 900)}}},
+		{Texts: []TextNode{{Text: strings.Repeat("word ", 201)}}},
+	})
+	st := ComputeNoteStats(long)
+	assert.Equal(t, 1101, st.Words)
+	assert.Equal(t, 5, st.ReadingTime)
+
+	// 每个含有文本的列表项计为一个段落
+	list := mustConvert(t, []Paragraph{{Type: "ordered_list", Items: [][]TextNode{
+		{{Text: "第一步"}},
+		{{Text: "see "}, {Text: "docs", Link: "https://example.com/docs"}},
+	}}})
+	assert.Equal(t, NoteStats{Words: 5, CJKChars: 3, Characters: 10, Paragraphs: 2, Links: 1, ReadingTime: 1}, ComputeNoteStats(list))
+
+	assert.Equal(t, NoteStats{}, ComputeNoteStats(NoteAtom{Type: "doc"}))
+}
+
+// TestHandleNoteStats 测试读取笔记后输出统计信息，笔记不存在时给出提示
+func (suite *ServerTestSuite) TestHandleNoteStats() {
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var detailReq NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&detailReq))
+		switch detailReq.NoteID {
+		case "note-stats":
+		case "note-gone":
+			// HTTP 200但业务错误码为404
+			w.Write([]byte(`{"code":404,"message":"note not found"}`))
+			return
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID, "body": statsFixture(suite.T())})(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleNoteStats, NoteStatsArgs{NoteID: "note-stats"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 note-stats 的统计信息："+
+		"\n- 词数：15（其中中日韩文字 10 个）"+
+		"\n- 字符数（不含空白）：36"+
+		"\n- 段落数：3"+
+		"\n- 链接数：2"+
+		"\n- 阅读时间：约 1 分钟", text)
+
+	text, err = suite.callTool(suite.mcpServer.handleNoteStats, NoteStatsArgs{NoteID: "missing"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 missing 不存在", text)
+
+	text, err = suite.callTool(suite.mcpServer.handleNoteStats, NoteStatsArgs{NoteID: "note-gone"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 note-gone 不存在", text)
+}
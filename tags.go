@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// DefaultTagCacheTTL 已有标签列表的默认缓存时长
+const DefaultTagCacheTTL = 5 * time.Minute
+
+// DefaultTagStripPrefix 规范化标签时默认去除的前缀，常见于从其他系统导入的井号标签
+const DefaultTagStripPrefix = "#"
+
+// 标签列表的来源
+const (
+	TagSourceAPI   = "api"   // 墨问标签列表接口
+	TagSourceNotes = "notes" // 汇总笔记列表中的标签
+)
+
+// AutoTagRule 自动标签规则：笔记正文包含关键词时自动添加标签
+type AutoTagRule struct {
+	Keyword string // 关键词，匹配时不区分大小写
+	Tag     string // 要添加的标签
+}
+
+// parseAutoTagRules 解析自动标签规则配置，格式为逗号分隔的“关键词=标签”，例如 "会议=会议记录,bug=问题"
+func parseAutoTagRules(value string) ([]AutoTagRule, error) {
+	var rules []AutoTagRule
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		keyword, tag, ok := strings.Cut(item, "=")
+		keyword, tag = strings.TrimSpace(keyword), strings.TrimSpace(tag)
+		if !ok || keyword == "" || tag == "" {
+			return nil, fmt.Errorf("invalid auto tag rule %q: expected keyword=tag", item)
+		}
+		rules = append(rules, AutoTagRule{Keyword: keyword, Tag: tag})
+	}
+	return rules, nil
+}
+
+// applyAutoTags 根据规则扫描段落文本，把匹配的标签追加到用户标签之后并去重
+func applyAutoTags(tags []string, paragraphs []Paragraph, rules []AutoTagRule) []string {
+	if len(rules) == 0 {
+		return tags
+	}
+
+	text := strings.ToLower(paragraphsPlainText(paragraphs))
+	result := dedupeTags(tags)
+	seen := make(map[string]bool, len(result))
+	for _, tag := range result {
+		seen[tag] = true
+	}
+	for _, rule := range rules {
+		if seen[rule.Tag] || !strings.Contains(text, strings.ToLower(rule.Keyword)) {
+			continue
+		}
+		seen[rule.Tag] = true
+		result = append(result, rule.Tag)
+	}
+	return result
+}
+
+// TagNormalization 导入笔记标签的规范化规则，依次去除前缀、转为小写并映射别名
+type TagNormalization struct {
+	Lowercase     bool              // 是否把英文字母转为小写
+	StripPrefixes []string          // 要去除的前缀，例如 "#"、"evernote/"，按从长到短匹配，只去除一个
+	Aliases       map[string]string // 别名映射，键为去除前缀和转换大小写后的标签
+}
+
+// parseTagAliases 解析标签别名配置，格式为逗号分隔的“别名=标签”，例如 "js=javascript,前端开发=前端"
+func parseTagAliases(value string) (map[string]string, error) {
+	aliases := make(map[string]string)
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		alias, tag, ok := strings.Cut(item, "=")
+		alias, tag = strings.TrimSpace(alias), strings.TrimSpace(tag)
+		if !ok || alias == "" || tag == "" {
+			return nil, fmt.Errorf("invalid tag alias %q: expected alias=tag", item)
+		}
+		aliases[alias] = tag
+	}
+	return aliases, nil
+}
+
+// Normalize 规范化单个标签，结果可能为空字符串（例如标签只有前缀）
+func (n TagNormalization) Normalize(tag string) string {
+	tag = strings.TrimSpace(tag)
+	prefixes := append([]string{}, n.StripPrefixes...)
+	sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+	for _, prefix := range prefixes {
+		if prefix != "" && strings.HasPrefix(tag, prefix) {
+			tag = strings.TrimSpace(strings.TrimPrefix(tag, prefix))
+			break
+		}
+	}
+	if n.Lowercase {
+		tag = strings.ToLower(tag)
+	}
+	if alias, ok := n.Aliases[tag]; ok {
+		tag = alias
+	}
+	return tag
+}
+
+// NormalizeTags 规范化标签列表，去除规范化后为空的标签和重复标签，保持原有顺序
+func (n TagNormalization) NormalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = n.Normalize(tag); tag != "" {
+			result = append(result, tag)
+		}
+	}
+	return dedupeTags(result)
+}
+
+// dedupeTags 去除重复标签，保持原有顺序
+func dedupeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	result := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		result = append(result, tag)
+	}
+	return result
+}
+
+// paragraphsPlainText 提取段落中的纯文本，段落之间以换行分隔
+func paragraphsPlainText(paragraphs []Paragraph) string {
+	var sb strings.Builder
+	for _, para := range paragraphs {
+		for _, text := range para.allTexts() {
+			sb.WriteString(text.Text)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// containsTag 判断标签列表中是否已包含指定标签
+func containsTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// tagCache 缓存账号下已有的标签列表，ttl为0时不缓存
+type tagCache struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	now       func() time.Time
+	tags      []TagCount
+	source    string
+	fetchedAt time.Time
+}
+
+// newTagCache 创建标签缓存
+func newTagCache(ttl time.Duration) *tagCache {
+	return &tagCache{ttl: ttl, now: time.Now}
+}
+
+// get 返回未过期的缓存内容
+func (c *tagCache) get() ([]TagCount, string, time.Time, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tags == nil || c.ttl == 0 || c.now().Sub(c.fetchedAt) >= c.ttl {
+		return nil, "", time.Time{}, false
+	}
+	return c.tags, c.source, c.fetchedAt, true
+}
+
+// set 更新缓存内容，返回缓存时间
+func (c *tagCache) set(tags []TagCount, source string) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if tags == nil {
+		tags = []TagCount{}
+	}
+	c.tags, c.source, c.fetchedAt = tags, source, c.now()
+	return c.fetchedAt
+}
+
+// invalidate 清空缓存，在修改笔记标签后调用
+func (c *tagCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tags = nil
+}
+
+// aggregateTags 统计笔记列表中各标签的使用次数，按次数从多到少、同次数按名称排序
+func aggregateTags(notes []NoteSummary) []TagCount {
+	counts := make(map[string]int)
+	for _, note := range notes {
+		for _, tag := range dedupeTags(note.Tags) {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				counts[tag]++
+			}
+		}
+	}
+
+	tags := make([]TagCount, 0, len(counts))
+	for name, count := range counts {
+		tags = append(tags, TagCount{Name: name, Count: count})
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Count != tags[j].Count {
+			return tags[i].Count > tags[j].Count
+		}
+		return tags[i].Name < tags[j].Name
+	})
+	return tags
+}
+
+// listTags 获取已有标签，优先使用缓存。
+// 墨问API不提供标签列表接口时，汇总全部笔记的标签作为替代。
+func (s *MowenMCPServer) listTags(ctx context.Context, refresh bool) ([]TagCount, string, time.Time, error) {
+	if !refresh {
+		if tags, source, fetchedAt, ok := s.tagCache.get(); ok {
+			return tags, source, fetchedAt, nil
+		}
+	}
+
+	tags, err := s.mowenClient.ListTags(ctx)
+	source := TagSourceAPI
+	if errors.Is(err, ErrNotSupported) {
+		var notes []NoteSummary
+		notes, err = s.searchAllNotes(ctx, NoteListRequest{})
+		tags, source = aggregateTags(notes), TagSourceNotes
+	}
+	if err != nil {
+		return nil, "", time.Time{}, err
+	}
+
+	fetchedAt := s.tagCache.set(tags, source)
+	return tags, source, fetchedAt, nil
+}
+
+// handleListTags 处理列出已有标签的MCP工具请求
+func (s *MowenMCPServer) handleListTags(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args ListTagsArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	tags, source, fetchedAt, err := s.listTags(ctx, args.Refresh)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+	if len(tags) == 0 {
+		return textResult("还没有使用过任何标签"), nil
+	}
+
+	sourceName := "标签接口"
+	if source == TagSourceNotes {
+		sourceName = "笔记列表汇总"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "共 %d 个标签（来源：%s，获取于 %s）：", len(tags), sourceName, fetchedAt.Format(time.RFC3339))
+	for _, tag := range tags {
+		if tag.Count > 0 {
+			fmt.Fprintf(&sb, "\n- %s（%d 篇）", tag.Name, tag.Count)
+		} else {
+			fmt.Fprintf(&sb, "\n- %s", tag.Name)
+		}
+	}
+	return textResult(sb.String()), nil
+}
+
+// handleNormalizeNoteTags 处理按MOWEN_TAG_*配置规范化笔记标签的MCP工具请求，用于整理从其他系统导入的笔记。
+// 规范化后标签没有变化时不会调用更新接口。
+func (s *MowenMCPServer) handleNormalizeNoteTags(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args NormalizeNoteTagsArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get note: %w", err)
+	}
+	detail, err := ParseNoteDetail(result)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse note: %w", err)
+	}
+
+	before := detail.Tags
+	after := s.tagNormalization.NormalizeTags(before)
+	comparison := fmt.Sprintf("\n- 之前：%s\n- 之后：%s", formatTagList(before), formatTagList(after))
+	if equalTags(before, after) {
+		return textResult(fmt.Sprintf("笔记 %s 的标签无需调整：%s", args.NoteID, formatTagList(before))), nil
+	}
+	if args.DryRun {
+		return textResult(fmt.Sprintf("试运行：笔记 %s 的标签将调整为：", args.NoteID) + comparison), nil
+	}
+
+	setResult, err := s.mowenClient.SetNoteTags(ctx, args.NoteID, after)
+	if err != nil {
+		return nil, err
+	}
+	s.tagCache.invalidate()
+	return textResult(s.withWarning(fmt.Sprintf("笔记 %s 的标签已规范化：", args.NoteID)+comparison, setResult)), nil
+}
+
+// equalTags 判断两个标签列表是否完全相同（包括顺序）
+func equalTags(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// formatTagList 以顿号连接标签，没有标签时返回“无”
+func formatTagList(tags []string) string {
+	if len(tags) == 0 {
+		return "无"
+	}
+	return strings.Join(tags, "、")
+}
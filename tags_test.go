@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseAutoTagRules 测试自动标签规则解析
+func TestParseAutoTagRules(t *testing.T) {
+	rules, err := parseAutoTagRules(" 会议=会议记录, Bug = 问题 ,")
+	require.NoError(t, err)
+	assert.Equal(t, []AutoTagRule{{Keyword: "会议", Tag: "会议记录"}, {Keyword: "Bug", Tag: "问题"}}, rules)
+
+	rules, err = parseAutoTagRules("")
+	assert.NoError(t, err)
+	assert.Empty(t, rules)
+
+	_, err = parseAutoTagRules("会议")
+	assert.Error(t, err)
+	_, err = parseAutoTagRules("=标签")
+	assert.Error(t, err)
+}
+
+// TestApplyAutoTags 测试根据关键词自动添加标签
+func TestApplyAutoTags(t *testing.T) {
+	rules := []AutoTagRule{
+		{Keyword: "会议", Tag: "会议记录"},
+		{Keyword: "bug", Tag: "问题"},
+		{Keyword: "发布", Tag: "工作"},
+	}
+	paragraphs := []Paragraph{
+		{Texts: []TextNode{{Text: "今天的"}, {Text: "会议", Bold: true}}},
+		{Texts: []TextNode{{Text: "修复了一个 BUG"}}},
+	}
+
+	// 匹配的关键词添加标签，与用户标签去重
+	tags := applyAutoTags([]string{"工作", "问题", "工作"}, paragraphs, rules)
+	assert.Equal(t, []string{"工作", "问题", "会议记录"}, tags)
+
+	// 无匹配时不添加
+	tags = applyAutoTags([]string{"日记"}, []Paragraph{{Texts: []TextNode{{Text: "散步"}}}}, rules)
+	assert.Equal(t, []string{"日记"}, tags)
+
+	// 未配置规则时保持原样
+	tags = applyAutoTags(nil, paragraphs, nil)
+	assert.Nil(t, tags)
+}
+
+// TestHandleCreateNoteAutoTags 测试创建笔记时应用自动标签规则
+func (suite *ServerTestSuite) TestHandleCreateNoteAutoTags() {
+	var createReq NoteCreateRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&createReq)
+		mockSuccess(map[string]interface{}{"note_id": "test-note-id-123"})(w, r)
+	}
+	suite.mcpServer.autoTagRules = []AutoTagRule{{Keyword: "周报", Tag: "工作"}}
+
+	_, err := suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "本周周报"}}}},
+		Tags:       []string{"总结"},
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"总结", "工作"}, createReq.Settings.Tags)
+
+	_, err = suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "随手记"}}}},
+		Tags:       []string{"总结"},
+	})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"总结"}, createReq.Settings.Tags)
+}
+
+// TestAggregateTags 测试汇总笔记标签并按使用次数排序
+func TestAggregateTags(t *testing.T) {
+	tags := aggregateTags([]NoteSummary{
+		{NoteID: "n1", Tags: []string{"工作", "周报", "工作"}},
+		{NoteID: "n2", Tags: []string{"工作", " "}},
+		{NoteID: "n3", Tags: []string{"生活"}},
+	})
+	assert.Equal(t, []TagCount{{Name: "工作", Count: 2}, {Name: "周报", Count: 1}, {Name: "生活", Count: 1}}, tags)
+}
+
+// TestHandleListTagsCache 测试标签接口结果在缓存有效期内复用
+func (suite *ServerTestSuite) TestHandleListTagsCache() {
+	calls := 0
+	suite.routes[TagListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		mockSuccess(map[string]interface{}{"tags": []map[string]interface{}{{"name": "工作", "count": 3}, {"name": "生活"}}})(w, r)
+	}
+	now := time.Unix(1700000000, 0)
+	suite.mcpServer.tagCache = newTagCache(time.Minute)
+	suite.mcpServer.tagCache.now = func() time.Time { return now }
+
+	text, err := suite.callTool(suite.mcpServer.handleListTags, ListTagsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 2 个标签（来源：标签接口")
+	assert.Contains(suite.T(), text, "- 工作（3 篇）")
+	assert.Contains(suite.T(), text, "- 生活")
+
+	_, err = suite.callTool(suite.mcpServer.handleListTags, ListTagsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, calls)
+
+	// refresh忽略缓存
+	_, err = suite.callTool(suite.mcpServer.handleListTags, ListTagsArgs{Refresh: true})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, calls)
+
+	// 缓存过期后重新获取
+	now = now.Add(time.Minute)
+	_, err = suite.callTool(suite.mcpServer.handleListTags, ListTagsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 3, calls)
+}
+
+// TestHandleListTagsCacheAfterCreate 测试创建笔记后标签缓存失效，新标签能立即列出
+func (suite *ServerTestSuite) TestHandleListTagsCacheAfterCreate() {
+	tags := []map[string]interface{}{{"name": "工作", "count": 1}}
+	calls := 0
+	suite.routes[TagListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		mockSuccess(map[string]interface{}{"tags": tags})(w, r)
+	}
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		tags = append(tags, map[string]interface{}{"name": "新标签", "count": 1})
+		mockSuccess(map[string]interface{}{"noteId": "note-new-tag"})(w, r)
+	}
+	suite.mcpServer.tagCache = newTagCache(time.Hour)
+
+	text, err := suite.callTool(suite.mcpServer.handleListTags, ListTagsArgs{})
+	require.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), text, "新标签")
+
+	_, err = suite.callTool(suite.mcpServer.handleCreateNote, CreateNoteArgs{
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "正文"}}}},
+		Tags:       []string{"新标签"},
+	})
+	require.NoError(suite.T(), err)
+
+	text, err = suite.callTool(suite.mcpServer.handleListTags, ListTagsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, calls)
+	assert.Contains(suite.T(), text, "- 新标签（1 篇）")
+}
+
+// TestHandleListTagsFromNotes 测试标签接口不存在时汇总笔记列表中的标签
+func (suite *ServerTestSuite) TestHandleListTagsFromNotes() {
+	listCalls := 0
+	suite.routes[NoteListEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		listCalls++
+		mockSuccess(map[string]interface{}{"notes": []map[string]interface{}{
+			{"noteId": "n1", "tags": []string{"工作", "周报"}},
+			{"noteId": "n2", "tags": []string{"工作"}},
+		}})(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleListTags, ListTagsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "共 2 个标签（来源：笔记列表汇总")
+	assert.Contains(suite.T(), text, "- 工作（2 篇）\n- 周报（1 篇）")
+
+	_, err = suite.callTool(suite.mcpServer.handleListTags, ListTagsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 1, listCalls)
+
+	// 缓存失效（如批量添加标签后）重新汇总
+	suite.mcpServer.tagCache.invalidate()
+	_, err = suite.callTool(suite.mcpServer.handleListTags, ListTagsArgs{})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), 2, listCalls)
+}
+
+// TestTagNormalization 测试标签去除前缀、转为小写、映射别名并去重
+func TestTagNormalization(t *testing.T) {
+	n := TagNormalization{
+		Lowercase:     true,
+		StripPrefixes: []string{"#", "tag:"},
+		Aliases:       map[string]string{"js": "javascript", "todo": "待办"},
+	}
+	assert.Equal(t, "work", n.Normalize(" #Work "))
+	assert.Equal(t, "javascript", n.Normalize("JS"))
+	assert.Equal(t, "待办", n.Normalize("tag: TODO"))
+	assert.Equal(t, "", n.Normalize("#"))
+	assert.Equal(t, []string{"work", "javascript", "读书"}, n.NormalizeTags([]string{"#Work", "JS", "work", "#", "读书", "javascript"}))
+
+	// 不转小写时别名按原样匹配
+	n = TagNormalization{Aliases: map[string]string{"JS": "JavaScript"}}
+	assert.Equal(t, []string{"JavaScript", "js"}, n.NormalizeTags([]string{"JS", "js"}))
+}
+
+// TestLoadTagNormalization 测试从环境变量读取标签规范化规则
+func TestLoadTagNormalization(t *testing.T) {
+	n, err := loadTagNormalization()
+	require.NoError(t, err)
+	assert.Equal(t, TagNormalization{Lowercase: true, StripPrefixes: []string{"#"}, Aliases: map[string]string{}}, n)
+
+	t.Setenv("MOWEN_TAG_STRIP_PREFIXES", "#, @")
+	t.Setenv("MOWEN_TAG_ALIASES", "JS=javascript, 读书笔记 = 读书")
+	n, err = loadTagNormalization()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"#", "@"}, n.StripPrefixes)
+	assert.Equal(t, map[string]string{"js": "javascript", "读书笔记": "读书"}, n.Aliases)
+
+	t.Setenv("MOWEN_TAG_ALIASES", "javascript")
+	_, err = loadTagNormalization()
+	assert.Error(t, err)
+}
+
+// TestHandleNormalizeNoteTags 测试规范化笔记标签并报告调整前后的标签
+func (suite *ServerTestSuite) TestHandleNormalizeNoteTags() {
+	tags := []string{"#Work", "JS", "work"}
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		mockSuccess(map[string]interface{}{"noteId": "note-legacy", "tags": tags})(w, r)
+	}
+	var setReq NoteSetRequest
+	setCalls := 0
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		setCalls++
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		mockSuccess(map[string]interface{}{})(w, r)
+	}
+	suite.mcpServer.tagNormalization = TagNormalization{
+		Lowercase:     true,
+		StripPrefixes: []string{"#"},
+		Aliases:       map[string]string{"js": "javascript"},
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleNormalizeNoteTags, NormalizeNoteTagsArgs{NoteID: "note-legacy", DryRun: true})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "试运行：笔记 note-legacy 的标签将调整为：\n- 之前：#Work、JS、work\n- 之后：work、javascript", text)
+	assert.Equal(suite.T(), 0, setCalls)
+
+	text, err = suite.callTool(suite.mcpServer.handleNormalizeNoteTags, NormalizeNoteTagsArgs{NoteID: "note-legacy"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 note-legacy 的标签已规范化：\n- 之前：#Work、JS、work\n- 之后：work、javascript", text)
+	assert.Equal(suite.T(), 1, setCalls)
+	assert.Equal(suite.T(), "note-legacy", setReq.NoteID)
+	assert.Equal(suite.T(), 2, setReq.Section)
+	assert.Equal(suite.T(), []string{"work", "javascript"}, setReq.Settings.Tags)
+
+	tags = []string{"work", "javascript"}
+	text, err = suite.callTool(suite.mcpServer.handleNormalizeNoteTags, NormalizeNoteTagsArgs{NoteID: "note-legacy"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 note-legacy 的标签无需调整：work、javascript", text)
+	assert.Equal(suite.T(), 1, setCalls)
+}
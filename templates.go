@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// NoteTemplate 笔记模板，创建笔记时模板段落会放在正文之前
+type NoteTemplate struct {
+	Paragraphs []Paragraph     `json:"paragraphs"`        // 模板段落，例如会议纪要的固定标题
+	Tags       []string        `json:"tags,omitempty"`    // 模板标签，与调用时传入的标签合并
+	Privacy    *NotePrivacySet `json:"privacy,omitempty"` // 创建后应用的隐私设置
+}
+
+// loadNoteTemplates 从JSON文件读取笔记模板，文件内容为模板名称到模板的映射。path为空时返回空集合。
+func loadNoteTemplates(path string) (map[string]NoteTemplate, error) {
+	if path == "" {
+		return map[string]NoteTemplate{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates file: %w", err)
+	}
+
+	var templates map[string]NoteTemplate
+	if err := json.Unmarshal(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse templates file %s: %w", path, err)
+	}
+	for name, template := range templates {
+		if strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("templates file %s: template name must not be empty", path)
+		}
+		if _, err := ConvertParagraphsToNoteAtom(template.Paragraphs); err != nil {
+			return nil, fmt.Errorf("templates file %s: template %q: %w", path, name, err)
+		}
+	}
+	return templates, nil
+}
+
+// templateNames 返回按字母排序的模板名称
+func templateNames(templates map[string]NoteTemplate) []string {
+	names := make([]string, 0, len(templates))
+	for name := range templates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// handleCreateNoteFromTemplate 处理基于模板创建笔记的MCP工具请求。
+// 模板段落放在正文段落之前，模板标签与传入标签合并，模板指定了隐私设置时在创建后应用。
+func (s *MowenMCPServer) handleCreateNoteFromTemplate(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args CreateNoteFromTemplateArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	template, ok := s.templates[args.Template]
+	if !ok {
+		if len(s.templates) == 0 {
+			return nil, fmt.Errorf("unknown template %q: no templates configured, set MOWEN_TEMPLATES_FILE", args.Template)
+		}
+		return nil, fmt.Errorf("unknown template %q, available templates: %s", args.Template, strings.Join(templateNames(s.templates), ", "))
+	}
+
+	paragraphs := append(append([]Paragraph{}, template.Paragraphs...), args.Paragraphs...)
+	noteBody, err := ConvertParagraphsToNoteAtomWithOptions(paragraphs, s.convertOptions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid paragraphs: %w", err)
+	}
+	tags := dedupeTags(append(append([]string{}, template.Tags...), args.Tags...))
+	createReq := NoteCreateRequest{
+		Body: noteBody,
+		Settings: NoteCreateRequestSettings{
+			AutoPublish: args.AutoPublish,
+			Tags:        applyAutoTags(tags, paragraphs, s.autoTagRules),
+		},
+	}
+
+	if err := s.noteQuota.reserve(); err != nil {
+		return nil, err
+	}
+	result, err := s.mowenClient.CreateNote(ctx, createReq)
+	if err != nil {
+		s.noteQuota.release()
+		return nil, fmt.Errorf("failed to create note: %w", err)
+	}
+	s.tagCache.invalidate()
+	noteID := s.extractNoteID(result)
+
+	var sb strings.Builder
+	sb.WriteString(s.withWarning(fmt.Sprintf("已使用模板 %q 创建笔记！笔记ID: %s", args.Template, noteID), result))
+
+	if template.Privacy != nil && noteID != "" {
+		setReq := NoteSetRequest{
+			NoteID:   noteID,
+			Section:  SectionPrivacy,
+			Settings: &NoteSettings{Privacy: template.Privacy},
+		}
+		if _, err := s.mowenClient.SetNotePrivacy(ctx, setReq); err != nil {
+			fmt.Fprintf(&sb, "\n\n⚠️ 模板隐私设置应用失败：%v", err)
+		}
+	}
+
+	return textResult(sb.String()), nil
+}
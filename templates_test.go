@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoadNoteTemplates 测试从文件读取笔记模板
+func TestLoadNoteTemplates(t *testing.T) {
+	templates, err := loadNoteTemplates("")
+	require.NoError(t, err)
+	assert.Empty(t, templates)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "templates.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"meeting": {
+			"paragraphs": [{"texts": [{"text": "会议纪要", "bold": true}]}],
+			"tags": ["会议"],
+			"privacy": {"type": "private"}
+		}
+	}`), 0o644))
+
+	templates, err = loadNoteTemplates(path)
+	require.NoError(t, err)
+	require.Contains(t, templates, "meeting")
+	assert.Equal(t, []string{"会议"}, templates["meeting"].Tags)
+	assert.Equal(t, "private", templates["meeting"].Privacy.Type)
+
+	// 模板中的非法段落在启动时报错
+	require.NoError(t, os.WriteFile(path, []byte(`{"bad": {"paragraphs": [{"type": "note", "note_id": "TODO"}]}}`), 0o644))
+	_, err = loadNoteTemplates(path)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `template "bad"`)
+
+	_, err = loadNoteTemplates(filepath.Join(dir, "missing.json"))
+	assert.Error(t, err)
+}
+
+// TestHandleCreateNoteFromTemplate 测试使用模板创建笔记
+func (suite *ServerTestSuite) TestHandleCreateNoteFromTemplate() {
+	suite.mcpServer.templates = map[string]NoteTemplate{
+		"meeting": {
+			Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "会议纪要", Bold: true}}}},
+			Tags:       []string{"会议"},
+			Privacy:    &NotePrivacySet{Type: "private"},
+		},
+	}
+
+	var createReq NoteCreateRequest
+	var setReq NoteSetRequest
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&createReq))
+		mockSuccess(map[string]interface{}{"note_id": "template-note-id"})(w, r)
+	}
+	suite.routes[NoteSetEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&setReq))
+		mockSuccess(nil)(w, r)
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleCreateNoteFromTemplate, CreateNoteFromTemplateArgs{
+		Template:   "meeting",
+		Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "讨论了发布计划"}}}},
+		Tags:       []string{"会议", "发布"},
+	})
+	require.NoError(suite.T(), err)
+	assert.Contains(suite.T(), text, "笔记ID: template-note-id")
+
+	// 模板段落在正文之前，标签合并去重
+	require.Len(suite.T(), createReq.Body.Content, 2)
+	assert.Equal(suite.T(), "会议纪要", createReq.Body.Content[0].Content[0].Text)
+	assert.Equal(suite.T(), "讨论了发布计划", createReq.Body.Content[1].Content[0].Text)
+	assert.Equal(suite.T(), []string{"会议", "发布"}, createReq.Settings.Tags)
+
+	// 应用模板隐私设置
+	assert.Equal(suite.T(), "template-note-id", setReq.NoteID)
+	assert.Equal(suite.T(), "private", setReq.Settings.Privacy.Type)
+}
+
+// TestHandleCreateNoteFromUnknownTemplate 测试模板不存在时返回错误且不创建笔记
+func (suite *ServerTestSuite) TestHandleCreateNoteFromUnknownTemplate() {
+	suite.routes[NoteCreateEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		suite.T().Error("unexpected create request")
+	}
+	args := CreateNoteFromTemplateArgs{Template: "weekly", Paragraphs: []Paragraph{{Texts: []TextNode{{Text: "正文"}}}}}
+
+	_, err := suite.callTool(suite.mcpServer.handleCreateNoteFromTemplate, args)
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "no templates configured")
+
+	suite.mcpServer.templates = map[string]NoteTemplate{"meeting": {}, "daily": {}}
+	_, err = suite.callTool(suite.mcpServer.handleCreateNoteFromTemplate, args)
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), `unknown template "weekly", available templates: daily, meeting`)
+}
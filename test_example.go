@@ -32,7 +32,11 @@ func TestExample() {
 	}
 
 	// 转换为NoteAtom格式
-	noteAtom := ConvertParagraphsToNoteAtom(testParagraphs)
+	noteAtom, err := ConvertParagraphsToNoteAtom(testParagraphs)
+	if err != nil {
+		log.Printf("段落转换失败: %v", err)
+		return
+	}
 
 	// 序列化为JSON查看结果
 	jsonData, err := json.MarshalIndent(noteAtom, "", "  ")
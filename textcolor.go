@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TextColorPalette 文本颜色可以使用的颜色名称，也可以使用#RRGGBB格式的十六进制颜色
+var TextColorPalette = []string{"red", "orange", "yellow", "green", "blue", "purple", "gray"}
+
+// hexColorPattern 匹配#RRGGBB格式的十六进制颜色
+var hexColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// canonicalTextColor 返回颜色的规范形式：去除首尾空白并转为小写
+func canonicalTextColor(color string) string {
+	return strings.ToLower(strings.TrimSpace(color))
+}
+
+// validateTextColor 校验颜色是否为调色板中的名称或#RRGGBB格式的十六进制颜色
+func validateTextColor(color string) error {
+	color = canonicalTextColor(color)
+	if hexColorPattern.MatchString(color) {
+		return nil
+	}
+	for _, name := range TextColorPalette {
+		if color == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid color %q: must be #RRGGBB or one of %s", color, strings.Join(TextColorPalette, ", "))
+}
+
+// validateTextColors 校验文本节点中设置的颜色，颜色无效时返回错误而不是忽略该标记
+func validateTextColors(texts []TextNode) error {
+	for _, text := range texts {
+		if text.Color == "" {
+			continue
+		}
+		if err := validateTextColor(text.Color); err != nil {
+			return err
+		}
+	}
+	return nil
+}
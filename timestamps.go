@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ThinkInAIXYZ/go-mcp/protocol"
+)
+
+// NoteTimestamps 笔记的创建和更新时间，接口未返回时为零值
+type NoteTimestamps struct {
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ParseNoteTimestamps 从详情接口响应的data字段中读取createdAt和updatedAt。
+// 时间可以是Unix秒级或毫秒级时间戳（数字或数字字符串），也可以是RFC3339字符串；字段缺失、为空或无法识别时视为未提供。
+func ParseNoteTimestamps(result map[string]interface{}) (NoteTimestamps, error) {
+	data, ok := result["data"].(map[string]interface{})
+	if !ok {
+		return NoteTimestamps{}, fmt.Errorf("invalid note detail response format")
+	}
+	return NoteTimestamps{
+		CreatedAt: parseAPITimestamp(data["createdAt"]),
+		UpdatedAt: parseAPITimestamp(data["updatedAt"]),
+	}, nil
+}
+
+// parseAPITimestamp 解析接口返回的时间字段，大于1e11的数值按毫秒处理，无法识别时返回零值
+func parseAPITimestamp(value interface{}) time.Time {
+	var ts float64
+	switch v := value.(type) {
+	case float64:
+		ts = v
+	case string:
+		v = strings.TrimSpace(v)
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return t
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return time.Time{}
+		}
+		ts = n
+	default:
+		return time.Time{}
+	}
+	if ts <= 0 {
+		return time.Time{}
+	}
+	if ts > 1e11 {
+		return time.UnixMilli(int64(ts))
+	}
+	return time.Unix(int64(ts), 0)
+}
+
+// formatNoteTimestamp 以UTC的RFC3339格式输出时间，未提供时返回"未提供"
+func formatNoteTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "未提供"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// handleGetNoteTimestamps 处理获取笔记创建和更新时间的MCP工具请求，笔记不存在时给出提示
+func (s *MowenMCPServer) handleGetNoteTimestamps(ctx context.Context, req *protocol.CallToolRequest) (*protocol.CallToolResult, error) {
+	var args NoteTimestampsArgs
+	if err := protocol.VerifyAndUnmarshal(req.RawArguments, &args); err != nil {
+		return nil, fmt.Errorf("invalid arguments: %v", err)
+	}
+
+	result, err := s.mowenClient.GetNote(ctx, args.NoteID)
+	if isNoteNotFoundResponse(err, result) {
+		return textResult(fmt.Sprintf("笔记 %s 不存在", args.NoteID)), nil
+	}
+	if err != nil {
+		// GetNote的错误已带有"failed to get note"前缀
+		return nil, err
+	}
+	timestamps, err := ParseNoteTimestamps(result)
+	if err != nil {
+		return nil, err
+	}
+	return textResult(fmt.Sprintf("笔记 %s 的时间：\n创建时间: %s\n更新时间: %s",
+		args.NoteID, formatNoteTimestamp(timestamps.CreatedAt), formatNoteTimestamp(timestamps.UpdatedAt))), nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseNoteTimestamps 测试识别秒级、毫秒级时间戳和RFC3339字符串，缺失或无法识别的时间为零值
+func TestParseNoteTimestamps(t *testing.T) {
+	created := time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name  string
+		value interface{}
+		want  time.Time
+	}{
+		{"秒级", float64(created.Unix()), created},
+		{"毫秒级", float64(created.UnixMilli()), created},
+		{"数字字符串", "1714550400", created},
+		{"RFC3339", "2024-05-01T16:00:00+08:00", created},
+		{"缺失", nil, time.Time{}},
+		{"零", float64(0), time.Time{}},
+		{"无法识别", "昨天", time.Time{}},
+	}
+	for _, c := range cases {
+		timestamps, err := ParseNoteTimestamps(map[string]interface{}{"data": map[string]interface{}{"createdAt": c.value}})
+		require.NoError(t, err, c.name)
+		assert.True(t, c.want.Equal(timestamps.CreatedAt), "%s: %v", c.name, timestamps.CreatedAt)
+		assert.True(t, timestamps.UpdatedAt.IsZero(), c.name)
+	}
+
+	_, err := ParseNoteTimestamps(map[string]interface{}{})
+	assert.Error(t, err)
+}
+
+// TestHandleGetNoteTimestamps 测试以RFC3339格式输出笔记时间，未返回的时间显示为未提供
+func (suite *ServerTestSuite) TestHandleGetNoteTimestamps() {
+	suite.routes[NoteDetailEndpoint] = func(w http.ResponseWriter, r *http.Request) {
+		var detailReq NoteDetailRequest
+		require.NoError(suite.T(), json.NewDecoder(r.Body).Decode(&detailReq))
+		switch detailReq.NoteID {
+		case "note-times":
+			mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID, "createdAt": 1714550400, "updatedAt": 1717243200000})(w, r)
+		case "note-no-times":
+			mockSuccess(map[string]interface{}{"noteId": detailReq.NoteID})(w, r)
+		case "note-gone":
+			// HTTP 200但业务错误码为404
+			w.Write([]byte(`{"code":404,"message":"note not found"}`))
+		case "note-broken":
+			w.WriteHeader(http.StatusBadRequest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+
+	text, err := suite.callTool(suite.mcpServer.handleGetNoteTimestamps, NoteTimestampsArgs{NoteID: "note-times"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 note-times 的时间：\n创建时间: 2024-05-01T08:00:00Z\n更新时间: 2024-06-01T12:00:00Z", text)
+
+	text, err = suite.callTool(suite.mcpServer.handleGetNoteTimestamps, NoteTimestampsArgs{NoteID: "note-no-times"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 note-no-times 的时间：\n创建时间: 未提供\n更新时间: 未提供", text)
+
+	text, err = suite.callTool(suite.mcpServer.handleGetNoteTimestamps, NoteTimestampsArgs{NoteID: "missing"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 missing 不存在", text)
+
+	text, err = suite.callTool(suite.mcpServer.handleGetNoteTimestamps, NoteTimestampsArgs{NoteID: "note-gone"})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "笔记 note-gone 不存在", text)
+
+	_, err = suite.callTool(suite.mcpServer.handleGetNoteTimestamps, NoteTimestampsArgs{NoteID: "note-broken"})
+	require.Error(suite.T(), err)
+	assert.Equal(suite.T(), 1, strings.Count(err.Error(), "failed to get note"), err.Error())
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// toolFilter 按名称筛选要注册的工具：enabled非空时只注册其中的工具，disabled中的工具始终不注册
+type toolFilter struct {
+	enabled  []string
+	disabled []string
+}
+
+// parseToolNames 解析逗号分隔的工具名称，忽略空白项和重复项
+func parseToolNames(value string) []string {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return dedupeTags(names)
+}
+
+// loadToolFilter 从MOWEN_ENABLED_TOOLS和MOWEN_DISABLED_TOOLS读取工具筛选规则，均未设置时注册全部工具
+func loadToolFilter() toolFilter {
+	return toolFilter{
+		enabled:  parseToolNames(os.Getenv("MOWEN_ENABLED_TOOLS")),
+		disabled: parseToolNames(os.Getenv("MOWEN_DISABLED_TOOLS")),
+	}
+}
+
+// apply 返回允许注册的工具，保持原有顺序；配置中不存在的工具名称会在警告中列出
+func (f toolFilter) apply(definitions []toolDefinition) ([]toolDefinition, []string) {
+	known := make(map[string]bool, len(definitions))
+	for _, def := range definitions {
+		known[def.Name] = true
+	}
+	var warnings []string
+	toSet := func(env string, names []string) map[string]bool {
+		set := make(map[string]bool, len(names))
+		for _, name := range names {
+			if !known[name] {
+				warnings = append(warnings, fmt.Sprintf("%s中的工具 %q 不存在，已忽略", env, name))
+				continue
+			}
+			set[name] = true
+		}
+		return set
+	}
+	enabled := toSet("MOWEN_ENABLED_TOOLS", f.enabled)
+	disabled := toSet("MOWEN_DISABLED_TOOLS", f.disabled)
+
+	allowed := make([]toolDefinition, 0, len(definitions))
+	for _, def := range definitions {
+		if len(f.enabled) > 0 && !enabled[def.Name] {
+			continue
+		}
+		if disabled[def.Name] {
+			continue
+		}
+		allowed = append(allowed, def)
+	}
+	if len(allowed) == 0 {
+		warnings = append(warnings, "按MOWEN_ENABLED_TOOLS和MOWEN_DISABLED_TOOLS筛选后没有可用的工具")
+	}
+	return allowed, warnings
+}
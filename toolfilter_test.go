@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// toolNames 返回工具定义的名称列表
+func toolNames(definitions []toolDefinition) []string {
+	names := make([]string, len(definitions))
+	for i, def := range definitions {
+		names[i] = def.Name
+	}
+	return names
+}
+
+// TestToolFilterApply 测试启用列表和禁用列表的组合，以及对不存在的工具名称给出警告
+func TestToolFilterApply(t *testing.T) {
+	definitions := []toolDefinition{{Name: "create_note"}, {Name: "delete_note"}, {Name: "reset_api_key"}, {Name: "get_note"}}
+
+	allowed, warnings := toolFilter{}.apply(definitions)
+	assert.Equal(t, []string{"create_note", "delete_note", "reset_api_key", "get_note"}, toolNames(allowed))
+	assert.Empty(t, warnings)
+
+	allowed, warnings = toolFilter{disabled: []string{"reset_api_key", "delete_note"}}.apply(definitions)
+	assert.Equal(t, []string{"create_note", "get_note"}, toolNames(allowed))
+	assert.Empty(t, warnings)
+
+	// 启用列表中的工具再被禁用时不注册
+	allowed, warnings = toolFilter{enabled: []string{"get_note", "create_note", "list_notes"}, disabled: []string{"create_note", "drop_all"}}.apply(definitions)
+	assert.Equal(t, []string{"get_note"}, toolNames(allowed))
+	assert.Equal(t, []string{
+		`MOWEN_ENABLED_TOOLS中的工具 "list_notes" 不存在，已忽略`,
+		`MOWEN_DISABLED_TOOLS中的工具 "drop_all" 不存在，已忽略`,
+	}, warnings)
+
+	// 启用列表只包含不存在的工具时不注册任何工具
+	allowed, warnings = toolFilter{enabled: []string{"list_notes"}}.apply(definitions)
+	assert.Empty(t, allowed)
+	assert.Len(t, warnings, 2)
+
+	assert.Equal(t, []string{"get_note", "create_note"}, parseToolNames(" get_note,,create_note, get_note "))
+}
+
+// TestRegisterToolsWithFilter 测试按环境变量只注册允许的工具
+func (suite *ServerTestSuite) TestRegisterToolsWithFilter() {
+	all := toolNames(suite.mcpServer.toolDefinitions())
+	assert.Equal(suite.T(), all, suite.mcpServer.registeredTools)
+
+	suite.T().Setenv("MOWEN_DISABLED_TOOLS", "reset_api_key, delete_note, no_such_tool")
+	mcpServer, err := NewMowenMCPServer()
+	require.NoError(suite.T(), err)
+	assert.NotContains(suite.T(), mcpServer.registeredTools, "reset_api_key")
+	assert.NotContains(suite.T(), mcpServer.registeredTools, "delete_note")
+	assert.Contains(suite.T(), mcpServer.registeredTools, "create_note")
+	assert.Len(suite.T(), mcpServer.registeredTools, len(all)-2)
+
+	suite.T().Setenv("MOWEN_ENABLED_TOOLS", "get_note,create_note,delete_note")
+	mcpServer, err = NewMowenMCPServer()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"create_note", "get_note"}, mcpServer.registeredTools)
+}
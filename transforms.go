@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 可用的文本转换，名称用于ConvertOptions.Transforms和MOWEN_TEXT_TRANSFORMS
+const (
+	// TransformEmoji 把 :tada: 形式的短代码替换为表情符号，不认识的短代码保持不变
+	TransformEmoji = "emoji"
+	// TransformAutolink 为文本中的http(s)链接添加链接标记
+	TransformAutolink = "autolink"
+	// TransformMarkdownInline 把 **粗体**、*斜体*、~~删除线~~、`代码`、==高亮== 转换为对应的标记
+	TransformMarkdownInline = "markdown_inline"
+)
+
+// textTransform 对一个段落的文本节点做的转换，返回新的文本节点列表，不修改传入的切片
+type textTransform func(texts []TextNode) []TextNode
+
+// textTransforms 文本转换名称到实现的映射
+var textTransforms = map[string]textTransform{
+	TransformEmoji:          expandEmojiShortcodes,
+	TransformAutolink:       autolinkTexts,
+	TransformMarkdownInline: parseMarkdownInline,
+}
+
+// SupportedTextTransforms 可用的文本转换名称
+var SupportedTextTransforms = []string{TransformEmoji, TransformAutolink, TransformMarkdownInline}
+
+// ParseTextTransforms 解析逗号分隔的文本转换名称，保留书写顺序并去除重复项，名称未知时返回错误
+func ParseTextTransforms(value string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(value, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := textTransforms[name]; !ok {
+			return nil, fmt.Errorf("unknown text transform %q: must be one of %s", name, strings.Join(SupportedTextTransforms, ", "))
+		}
+		names = append(names, name)
+	}
+	return dedupeTags(names), nil
+}
+
+// textTransformPipeline 把按顺序排列的转换组合为一个转换，names为空时返回nil
+func textTransformPipeline(names []string) (textTransform, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	steps := make([]textTransform, 0, len(names))
+	for _, name := range names {
+		transform, ok := textTransforms[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown text transform %q", name)
+		}
+		steps = append(steps, transform)
+	}
+	return func(texts []TextNode) []TextNode {
+		for _, step := range steps {
+			texts = step(texts)
+		}
+		return texts
+	}, nil
+}
+
+// transformParagraph 对段落及其列表项的文本应用转换，返回转换后的副本
+func transformParagraph(para Paragraph, transform textTransform) Paragraph {
+	if transform == nil {
+		return para
+	}
+	if len(para.Texts) > 0 {
+		para.Texts = transform(para.Texts)
+	}
+	if len(para.Items) > 0 {
+		items := make([][]TextNode, len(para.Items))
+		for i, item := range para.Items {
+			items[i] = transform(item)
+		}
+		para.Items = items
+	}
+	return para
+}
+
+// sameMarks 判断两个文本节点的标记是否完全相同
+func (t TextNode) sameMarks(other TextNode) bool {
+	t.Text, other.Text = "", ""
+	return t == other
+}
+
+// appendMerged 追加文本节点，与前一个节点标记相同时合并，空文本不追加
+func appendMerged(nodes []TextNode, node TextNode) []TextNode {
+	if node.Text == "" {
+		return nodes
+	}
+	if n := len(nodes); n > 0 && nodes[n-1].sameMarks(node) {
+		nodes[n-1].Text += node.Text
+		return nodes
+	}
+	return append(nodes, node)
+}
+
+// splitTextNodes 在每个可转换的文本节点中查找pattern，匹配部分交给replace生成新节点，其余部分保留原有标记。
+// skip返回true的节点保持不变；replace收到带原有标记的节点副本和子匹配列表。
+func splitTextNodes(texts []TextNode, pattern *regexp.Regexp, skip func(TextNode) bool, replace func(base TextNode, match []string) []TextNode) []TextNode {
+	result := make([]TextNode, 0, len(texts))
+	for _, text := range texts {
+		matches := pattern.FindAllStringSubmatchIndex(text.Text, -1)
+		if skip(text) || len(matches) == 0 {
+			result = append(result, text)
+			continue
+		}
+		plain := func(s string) {
+			node := text
+			node.Text = s
+			result = appendMerged(result, node)
+		}
+		last := 0
+		for _, m := range matches {
+			plain(text.Text[last:m[0]])
+			groups := make([]string, len(m)/2)
+			for g := range groups {
+				if m[2*g] >= 0 {
+					groups[g] = text.Text[m[2*g]:m[2*g+1]]
+				}
+			}
+			for _, node := range replace(text, groups) {
+				result = appendMerged(result, node)
+			}
+			last = m[1]
+		}
+		plain(text.Text[last:])
+	}
+	return result
+}
+
+// isInlineCode 行内代码中的文字按原样保留，不做转换
+func isInlineCode(text TextNode) bool {
+	return text.InlineCode
+}
+
+// emojiShortcodes 支持的表情短代码
+var emojiShortcodes = map[string]string{
+	"+1":               "👍",
+	"thumbsup":         "👍",
+	"-1":               "👎",
+	"smile":            "😄",
+	"joy":              "😂",
+	"heart":            "❤️",
+	"tada":             "🎉",
+	"rocket":           "🚀",
+	"fire":             "🔥",
+	"star":             "⭐",
+	"bulb":             "💡",
+	"memo":             "📝",
+	"eyes":             "👀",
+	"warning":          "⚠️",
+	"white_check_mark": "✅",
+	"x":                "❌",
+	"question":         "❓",
+}
+
+// emojiShortcodePattern 表情短代码，例如 :tada:
+var emojiShortcodePattern = regexp.MustCompile(`:([a-z0-9_+-]+):`)
+
+// expandEmojiShortcodes 替换文本中的表情短代码
+func expandEmojiShortcodes(texts []TextNode) []TextNode {
+	return splitTextNodes(texts, emojiShortcodePattern, isInlineCode, func(base TextNode, m []string) []TextNode {
+		if emoji, ok := emojiShortcodes[m[1]]; ok {
+			base.Text = emoji
+		} else {
+			base.Text = m[0]
+		}
+		return []TextNode{base}
+	})
+}
+
+// inlineURLPattern 文本中的http(s)链接，遇到空白或中文标点时结束
+var inlineURLPattern = regexp.MustCompile(`https?://[^\s<>"'，。！？；：、（）【】《》]+`)
+
+// urlTrailingPunctuation 链接末尾通常属于句子而不是链接的标点
+const urlTrailingPunctuation = ".,;:!?)]}"
+
+// autolinkTexts 为文本中的链接拆出带链接标记的节点，已有链接的节点保持不变
+func autolinkTexts(texts []TextNode) []TextNode {
+	skip := func(text TextNode) bool { return text.InlineCode || text.Link != "" }
+	return splitTextNodes(texts, inlineURLPattern, skip, func(base TextNode, m []string) []TextNode {
+		url := strings.TrimRight(m[0], urlTrailingPunctuation)
+		tail := base
+		tail.Text = m[0][len(url):]
+		base.Text, base.Link = url, url
+		return []TextNode{base, tail}
+	})
+}
+
+// markdownInlinePattern 不嵌套的Markdown行内标记，代码优先匹配，其中的其他标记保持原样
+var markdownInlinePattern = regexp.MustCompile("`([^`]+)`|\\*\\*([^*]+)\\*\\*|~~([^~]+)~~|==([^=]+)==|\\*([^*\\s][^*]*)\\*")
+
+// parseMarkdownInline 把文本中的Markdown行内标记转换为文本节点的标记，新标记与节点原有的标记叠加
+func parseMarkdownInline(texts []TextNode) []TextNode {
+	return splitTextNodes(texts, markdownInlinePattern, isInlineCode, func(base TextNode, m []string) []TextNode {
+		switch {
+		case m[1] != "":
+			base.Text, base.InlineCode = m[1], true
+		case m[2] != "":
+			base.Text, base.Bold = m[2], true
+		case m[3] != "":
+			base.Text, base.Strikethrough = m[3], true
+		case m[4] != "":
+			base.Text, base.Highlight = m[4], true
+		default:
+			base.Text, base.Italic = m[5], true
+		}
+		return []TextNode{base}
+	})
+}
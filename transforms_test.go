@@ -0,0 +1,96 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestTextTransforms 测试各个文本转换单独使用时的结果
+func TestTextTransforms(t *testing.T) {
+	assert.Equal(t, []TextNode{{Text: "完成 🎉 :unknown:"}, {Text: ":tada:", InlineCode: true}},
+		expandEmojiShortcodes([]TextNode{{Text: "完成 :tada: :unknown:"}, {Text: ":tada:", InlineCode: true}}))
+
+	assert.Equal(t, []TextNode{
+		{Text: "见 "},
+		{Text: "https://example.com/a", Link: "https://example.com/a", Bold: true},
+		{Text: "。", Bold: true},
+	}, autolinkTexts([]TextNode{{Text: "见 "}, {Text: "https://example.com/a。", Bold: true}}))
+	assert.Equal(t, []TextNode{{Text: "访问 "}, {Text: "https://example.com", Link: "https://example.com"}, {Text: ")"}},
+		autolinkTexts([]TextNode{{Text: "访问 https://example.com)"}}))
+	// 已有链接的节点不变
+	linked := []TextNode{{Text: "https://a.example", Link: "https://b.example"}}
+	assert.Equal(t, linked, autolinkTexts(linked))
+
+	assert.Equal(t, []TextNode{
+		{Text: "粗体", Bold: true},
+		{Text: "、"},
+		{Text: "斜体", Italic: true},
+		{Text: "、"},
+		{Text: "删除", Strikethrough: true},
+		{Text: "、"},
+		{Text: "**代码**", InlineCode: true},
+		{Text: "、"},
+		{Text: "高亮", Highlight: true},
+		{Text: " 2 * 3"},
+	}, parseMarkdownInline([]TextNode{{Text: "**粗体**、*斜体*、~~删除~~、`**代码**`、==高亮== 2 * 3"}}))
+}
+
+// TestConvertTextTransformPipeline 测试两个转换组成的流水线按配置顺序依次应用
+func TestConvertTextTransformPipeline(t *testing.T) {
+	paragraphs := []Paragraph{
+		{Texts: []TextNode{{Text: "**发布** `:rocket:` 见 https://example.com"}}},
+		{Type: "bullet_list", Items: [][]TextNode{{{Text: ":tada: ==完成=="}}}},
+	}
+	convert := func(transforms ...string) []Paragraph {
+		opts := DefaultConvertOptions()
+		opts.Transforms = transforms
+		doc, err := ConvertParagraphsToNoteAtomWithOptions(paragraphs, opts)
+		require.NoError(t, err)
+		converted, err := NoteAtomToParagraphs(doc)
+		require.NoError(t, err)
+		return converted
+	}
+
+	converted := convert(TransformMarkdownInline, TransformAutolink)
+	assert.Equal(t, []TextNode{
+		{Text: "发布", Bold: true},
+		{Text: " "},
+		{Text: ":rocket:", InlineCode: true},
+		{Text: " 见 "},
+		{Text: "https://example.com", Link: "https://example.com"},
+	}, converted[0].Texts)
+	assert.Equal(t, [][]TextNode{{{Text: ":tada: "}, {Text: "完成", Highlight: true}}}, converted[1].Items)
+
+	// 先解析行内代码时代码中的短代码保持原样，先替换短代码时代码中也会替换
+	converted = convert(TransformMarkdownInline, TransformEmoji)
+	assert.Equal(t, TextNode{Text: ":rocket:", InlineCode: true}, converted[0].Texts[2])
+	assert.Equal(t, TextNode{Text: "🎉 "}, converted[1].Items[0][0])
+	converted = convert(TransformEmoji, TransformMarkdownInline)
+	assert.Equal(t, TextNode{Text: "🚀", InlineCode: true}, converted[0].Texts[2])
+
+	// 默认不转换，调用方的段落也不被修改
+	assert.Equal(t, paragraphs[0].Texts, convert()[0].Texts)
+	assert.Equal(t, "**发布** `:rocket:` 见 https://example.com", paragraphs[0].Texts[0].Text)
+
+	opts := DefaultConvertOptions()
+	opts.Transforms = []string{"shout"}
+	_, err := ConvertParagraphsToNoteAtomWithOptions(paragraphs, opts)
+	assert.Error(t, err)
+}
+
+// TestParseTextTransforms 测试按书写顺序解析转换名称并拒绝未知的转换
+func TestParseTextTransforms(t *testing.T) {
+	names, err := ParseTextTransforms(" Markdown_Inline, autolink,,markdown_inline ")
+	require.NoError(t, err)
+	assert.Equal(t, []string{TransformMarkdownInline, TransformAutolink}, names)
+
+	names, err = ParseTextTransforms("")
+	require.NoError(t, err)
+	assert.Empty(t, names)
+
+	_, err = ParseTextTransforms("emoji,shout")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown text transform "shout"`)
+}
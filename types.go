@@ -1,5 +1,16 @@
 package main
 
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
 // NoteAtom 笔记原子节点信息
 type NoteAtom struct {
 	Type    string            `json:"type"`              // 节点类型
@@ -13,18 +24,25 @@ type NoteAtom struct {
 type NoteCreateRequestSettings struct {
 	AutoPublish bool     `json:"autoPublish,omitempty"` // 自动发布
 	Tags        []string `json:"tags,omitempty"`        // 标签列表
+	CreatedAt   int64    `json:"createdAt,omitempty"`   // 创建时间（Unix秒级时间戳），用于导入历史笔记
+	UpdatedAt   int64    `json:"updatedAt,omitempty"`   // 更新时间（Unix秒级时间戳），用于导入历史笔记
 }
 
 // NoteCreateRequest 笔记创建请求
 type NoteCreateRequest struct {
 	Body     NoteAtom                  `json:"body"`               // 笔记内容
 	Settings NoteCreateRequestSettings `json:"settings,omitempty"` // 笔记设置
+	// SchemaVersion 笔记内容的结构版本，为空时使用客户端配置的MOWEN_SCHEMA_VERSION，均未设置时不发送
+	SchemaVersion string `json:"schemaVersion,omitempty"`
+	// IdempotencyKey 幂等键，通过请求头发送，设置后创建请求才会在临时性错误后自动重试
+	IdempotencyKey string `json:"-"`
 }
 
 // NoteEditRequest 笔记编辑请求
 type NoteEditRequest struct {
-	NoteID string   `json:"noteId"` // 笔记ID
-	Body   NoteAtom `json:"body"`   // 笔记内容
+	NoteID        string   `json:"noteId"`                  // 笔记ID
+	Body          NoteAtom `json:"body"`                    // 笔记内容
+	SchemaVersion string   `json:"schemaVersion,omitempty"` // 笔记内容的结构版本，规则同NoteCreateRequest
 }
 
 // NotePrivacySetRule 隐私规则
@@ -42,18 +60,138 @@ type NotePrivacySet struct {
 // NoteSettings 笔记设置项
 type NoteSettings struct {
 	Privacy *NotePrivacySet `json:"privacy,omitempty"` // 笔记隐私设置
+	Tags    []string        `json:"tags,omitempty"`    // 笔记标签
+	Pinned  *bool           `json:"pinned,omitempty"`  // 是否置顶，使用指针以便提交false（取消置顶）
 }
 
+// NoteSetRequest的设置类别，每类设置需要使用对应的section值
+const (
+	SectionPrivacy = 1 // 笔记隐私设置
+	SectionTags    = 2 // 笔记标签设置
+	SectionPinned  = 3 // 笔记置顶设置
+)
+
 // NoteSetRequest 笔记设置请求
 type NoteSetRequest struct {
 	NoteID   string        `json:"noteId"`   // 笔记ID
-	Section  int           `json:"section"`  // 设置类别: 1-笔记隐私
+	Section  int           `json:"section"`  // 设置类别，见SectionPrivacy等常量
 	Settings *NoteSettings `json:"settings"` // 设置项
 }
 
+// NoteDeleteRequest 笔记删除请求
+type NoteDeleteRequest struct {
+	NoteID string `json:"noteId"` // 笔记ID
+}
+
+// NoteDetailRequest 笔记详情请求
+type NoteDetailRequest struct {
+	NoteID string `json:"noteId"`           // 笔记ID
+	Cursor string `json:"cursor,omitempty"` // 分块游标，获取内容较长笔记的后续分块时使用
+}
+
+// NoteDetail 笔记详情（对应详情接口响应中的data字段）
+type NoteDetail struct {
+	NoteID    string          `json:"noteId"`              // 笔记ID
+	Title     string          `json:"title,omitempty"`     // 笔记标题
+	Body      NoteAtom        `json:"body"`                // 笔记内容
+	Tags      []string        `json:"tags,omitempty"`      // 标签列表
+	Privacy   *NotePrivacySet `json:"privacy,omitempty"`   // 隐私设置
+	CreatedAt int64           `json:"createdAt,omitempty"` // 创建时间（Unix秒级时间戳）
+	UpdatedAt int64           `json:"updatedAt,omitempty"` // 更新时间（Unix秒级时间戳）
+}
+
+// NoteListRequest 笔记列表查询请求
+type NoteListRequest struct {
+	Query    string `json:"query,omitempty"`    // 搜索关键词
+	Tag      string `json:"tag,omitempty"`      // 按标签筛选
+	Page     int    `json:"page,omitempty"`     // 页码，从1开始
+	PageSize int    `json:"pageSize,omitempty"` // 每页数量
+	Cursor   string `json:"cursor,omitempty"`   // 分页游标，接口使用游标分页时代替页码
+}
+
+// NoteSummary 笔记列表中的笔记摘要
+type NoteSummary struct {
+	NoteID string   `json:"noteId"`          // 笔记ID
+	Title  string   `json:"title,omitempty"` // 笔记标题
+	Tags   []string `json:"tags,omitempty"`  // 标签列表
+}
+
+// NoteListResult 笔记列表查询结果（对应列表接口响应中的data字段）
+type NoteListResult struct {
+	Notes      []NoteSummary `json:"notes"`                 // 当前页的笔记
+	Total      int           `json:"total,omitempty"`       // 符合条件的笔记总数
+	NextCursor string        `json:"next_cursor,omitempty"` // 下一页的游标，为空表示没有更多数据或接口使用页码分页
+}
+
+// NoteShareInfo 笔记分享信息
+type NoteShareInfo struct {
+	ShortURL string `json:"short_url"`         // 分享短链接
+	QRCode   string `json:"qr_code,omitempty"` // 二维码图片地址或base64数据
+}
+
+// AccountDefaults 账号的默认笔记设置
+type AccountDefaults struct {
+	Privacy     *NotePrivacySet `json:"defaultPrivacy,omitempty"`     // 新笔记的默认隐私设置
+	Tags        []string        `json:"defaultTags,omitempty"`        // 新笔记的默认标签
+	AutoPublish bool            `json:"defaultAutoPublish,omitempty"` // 新笔记是否默认发布
+}
+
+// TagCount 标签及使用该标签的笔记数量
+type TagCount struct {
+	Name  string `json:"name"`            // 标签名称
+	Count int    `json:"count,omitempty"` // 使用该标签的笔记数量，为0表示接口未提供
+}
+
+// 文件处理状态
+const (
+	UploadStatusReady      = "ready"      // 已就绪，可嵌入笔记
+	UploadStatusProcessing = "processing" // 处理中
+	UploadStatusFailed     = "failed"     // 处理失败
+)
+
+// UploadStatusRequest 文件处理状态查询请求
+type UploadStatusRequest struct {
+	UUID string `json:"uuid"` // 文件UUID
+}
+
+// UploadStatus 文件处理状态
+type UploadStatus struct {
+	UUID    string `json:"uuid"`              // 文件UUID
+	Status  string `json:"status"`            // 处理状态：ready、processing、failed
+	Message string `json:"message,omitempty"` // 状态说明，处理失败时为失败原因
+}
+
+// FileDownloadRequest 获取文件下载地址请求
+type FileDownloadRequest struct {
+	UUID string `json:"uuid"` // 文件UUID
+}
+
+// FileDownloadInfo 文件下载信息
+type FileDownloadInfo struct {
+	URL      string `json:"url"`                 // 下载地址
+	FileName string `json:"file_name,omitempty"` // 上传时的文件名
+}
+
 // KeyResetRequest API密钥重置请求
 type KeyResetRequest struct{}
 
+// 请求体字段命名：笔记相关接口使用驼峰命名（noteId、autoPublish），
+// 文件上传接口使用下划线命名（file_type、file_name）。所有请求都使用带显式json标签的结构体，
+// 各请求的字段名由 TestRequestWireFormat 校验，接口命名变化时只需修改标签和该测试。
+
+// UploadPrepareRequest 本地文件上传准备请求
+type UploadPrepareRequest struct {
+	FileType int    `json:"file_type"` // 文件类型：1-图片，2-音频，3-PDF
+	FileName string `json:"file_name"` // 文件名称
+}
+
+// UploadURLRequest 基于URL的文件上传请求
+type UploadURLRequest struct {
+	URL      string `json:"url"`                 // 文件URL
+	FileType int    `json:"file_type"`           // 文件类型：1-图片，2-音频，3-PDF
+	FileName string `json:"file_name,omitempty"` // 文件名称
+}
+
 // MCP工具参数结构体
 
 // CreateNoteArgs 创建笔记工具参数
@@ -61,12 +199,35 @@ type CreateNoteArgs struct {
 	Paragraphs  []Paragraph `json:"paragraphs" description:"富文本段落列表，每个段落包含文本节点"`
 	AutoPublish bool        `json:"auto_publish,omitempty" description:"是否自动发布，默认为false"`
 	Tags        []string    `json:"tags,omitempty" description:"笔记标签列表"`
+	CreatedAt   int64       `json:"created_at,omitempty" description:"创建时间（Unix秒级时间戳，可选），用于导入历史笔记时保留原始日期"`
+	UpdatedAt   int64       `json:"updated_at,omitempty" description:"更新时间（Unix秒级时间戳，可选），不能早于创建时间"`
+	DryRun      bool        `json:"dry_run,omitempty" description:"为true时只转换并预览段落和转换提示，不创建笔记"`
+	// CheckReferences 逐个读取内链笔记段落引用的笔记，会增加请求数，默认关闭
+	CheckReferences bool `json:"check_references,omitempty" description:"为true时创建前检查内链笔记段落引用的笔记是否存在，存在无效引用时不创建笔记"`
+	// IdempotencyKey 设置后遇到网络错误等临时性错误时会自动重试，服务端据此避免重复创建
+	IdempotencyKey string `json:"idempotency_key,omitempty" description:"幂等键（可选），相同的键只会创建一篇笔记，开启重试（MOWEN_MAX_RETRIES大于0）时创建失败会自动重试"`
+}
+
+// CreateNoteFromTemplateArgs 基于模板创建笔记工具参数
+type CreateNoteFromTemplateArgs struct {
+	Template    string      `json:"template" description:"模板名称，需在MOWEN_TEMPLATES_FILE中配置"`
+	Paragraphs  []Paragraph `json:"paragraphs" description:"正文段落列表，放在模板段落之后"`
+	Tags        []string    `json:"tags,omitempty" description:"额外的笔记标签，与模板标签合并"`
+	AutoPublish bool        `json:"auto_publish,omitempty" description:"是否自动发布，默认为false"`
+}
+
+// AppendDailyLogArgs 追加每日日志工具参数
+type AppendDailyLogArgs struct {
+	Text string `json:"text" description:"要记录的内容，会以当前时间（HH:MM）开头追加到今天的日志笔记末尾"`
 }
 
 // EditNoteArgs 编辑笔记工具参数
 type EditNoteArgs struct {
-	NoteID     string      `json:"note_id" description:"要编辑的笔记ID"`
-	Paragraphs []Paragraph `json:"paragraphs" description:"富文本段落列表，将完全替换原有内容"`
+	NoteID         string      `json:"note_id" description:"要编辑的笔记ID"`
+	Paragraphs     []Paragraph `json:"paragraphs" description:"富文本段落列表，replace模式下完全替换原有内容，append模式下追加到原有内容末尾"`
+	Mode           string      `json:"mode,omitempty" description:"编辑方式：replace（默认，完全替换原有内容）或append（读取笔记后把段落追加到末尾，末尾已是完全相同的段落时不重复追加）"`
+	DryRun         bool        `json:"dry_run,omitempty" description:"为true时只转换并预览段落和转换提示，不修改笔记"`
+	AllowDuplicate bool        `json:"allow_duplicate,omitempty" description:"append模式下末尾已是相同段落时仍然追加，默认为false"`
 }
 
 // SetNotePrivacyArgs 设置笔记隐私工具参数
@@ -75,17 +236,229 @@ type SetNotePrivacyArgs struct {
 	PrivacyType string `json:"privacy_type" description:"隐私类型（public/private/rule）"`
 	NoShare     *bool  `json:"no_share,omitempty" description:"是否禁止分享（仅rule类型有效）"`
 	ExpireAt    *int64 `json:"expire_at,omitempty" description:"过期时间戳（仅rule类型有效，0表示永不过期）"`
+	ExpireIn    string `json:"expire_in,omitempty" description:"从现在起的公开时长，如36h或7d（仅rule类型有效，不能与expire_at同时指定）"`
+}
+
+// PreviewPrivacyArgs 预览隐私设置效果工具参数，与set_note_privacy的隐私参数相同
+type PreviewPrivacyArgs struct {
+	PrivacyType string `json:"privacy_type" description:"隐私类型（public/private/rule）"`
+	NoShare     *bool  `json:"no_share,omitempty" description:"是否禁止分享（仅rule类型有效）"`
+	ExpireAt    *int64 `json:"expire_at,omitempty" description:"过期时间戳（仅rule类型有效，0表示永不过期）"`
+	ExpireIn    string `json:"expire_in,omitempty" description:"从现在起的公开时长，如36h或7d（仅rule类型有效，不能与expire_at同时指定）"`
+}
+
+// DeleteNoteArgs 删除笔记工具参数
+type DeleteNoteArgs struct {
+	NoteID string `json:"note_id" description:"要删除的笔记ID，删除后不可恢复"`
+}
+
+// SetNotePinnedArgs 设置笔记置顶工具参数
+type SetNotePinnedArgs struct {
+	NoteID string `json:"note_id" description:"笔记ID"`
+	Pinned bool   `json:"pinned" description:"true为置顶，false为取消置顶"`
 }
 
 // ResetAPIKeyArgs 重置API密钥工具参数
 type ResetAPIKeyArgs struct {
 }
 
+// GetAccountDefaultsArgs 获取账号默认设置工具参数
+type GetAccountDefaultsArgs struct {
+}
+
+// ExportNotesMarkdownArgs 批量导出Markdown工具参数
+type ExportNotesMarkdownArgs struct {
+	OutputDir string `json:"output_dir,omitempty" description:"输出目录，未指定时使用MOWEN_EXPORT_DIR"`
+	Query     string `json:"query,omitempty" description:"搜索关键词（可选），为空时导出全部笔记"`
+}
+
+// DownloadNoteFilesArgs 下载笔记中文件工具参数
+type DownloadNoteFilesArgs struct {
+	NoteID    string `json:"note_id" description:"笔记ID"`
+	OutputDir string `json:"output_dir,omitempty" description:"保存文件的目录，未指定时使用MOWEN_EXPORT_DIR"`
+}
+
+// ListTagsArgs 列出已有标签工具参数
+type ListTagsArgs struct {
+	Refresh bool `json:"refresh,omitempty" description:"为true时忽略缓存重新获取"`
+}
+
+// SchedulePublishArgs 定时发布笔记工具参数
+type SchedulePublishArgs struct {
+	NoteID    string `json:"note_id" description:"要定时发布的笔记ID"`
+	PublishAt int64  `json:"publish_at" description:"公开时间，Unix秒级时间戳，须在未来30天内"`
+}
+
+// ListSupportedTypesArgs 列出支持的段落类型和文本标记工具参数
+type ListSupportedTypesArgs struct {
+}
+
+// LatencyProbeArgs API延迟探测工具参数
+type LatencyProbeArgs struct {
+	Samples  int    `json:"samples,omitempty" description:"请求次数，默认5，最多50"`
+	Endpoint string `json:"endpoint,omitempty" description:"探测接口：list（搜索笔记，默认）、detail（读取笔记）、upload_status（文件处理状态），均为只读请求"`
+}
+
+// SelfCheckArgs 自检工具参数
+type SelfCheckArgs struct {
+}
+
+// DiagnosticsArgs 配置诊断工具参数
+type DiagnosticsArgs struct {
+}
+
+// RateLimitStatusArgs 限流信息查看工具参数
+type RateLimitStatusArgs struct {
+}
+
+// DiffNoteArgs 笔记差异对比工具参数
+type DiffNoteArgs struct {
+	NoteID     string      `json:"note_id" description:"要对比的笔记ID"`
+	Paragraphs []Paragraph `json:"paragraphs" description:"拟修改的富文本段落列表"`
+}
+
+// CheckNoteLimitsArgs 笔记限制检查工具参数
+type CheckNoteLimitsArgs struct {
+	Paragraphs []Paragraph `json:"paragraphs" description:"富文本段落列表，格式同create_note"`
+	Tags       []string    `json:"tags,omitempty" description:"笔记标签列表，会与自动标签规则合并后计数"`
+}
+
+// ValidateNoteFileArgs 离线校验笔记定义文件工具参数
+type ValidateNoteFileArgs struct {
+	Path string `json:"path" description:"服务端本地JSON文件路径，内容为笔记定义数组，每一项的格式同create_note的参数"`
+}
+
+// GetNoteArgs 读取笔记内容工具参数
+type GetNoteArgs struct {
+	NoteID string `json:"note_id" description:"要读取的笔记ID"`
+}
+
+// NoteStatsArgs 笔记统计工具参数
+type NoteStatsArgs struct {
+	NoteID string `json:"note_id" description:"要统计的笔记ID"`
+}
+
+// NoteTimestampsArgs 获取笔记创建和更新时间工具参数
+type NoteTimestampsArgs struct {
+	NoteID string `json:"note_id" description:"笔记ID"`
+}
+
+// CompareNotesArgs 对比两篇笔记工具参数
+type CompareNotesArgs struct {
+	NoteID      string `json:"note_id" description:"作为基准的笔记ID，差异中以-表示"`
+	OtherNoteID string `json:"other_note_id" description:"要对比的笔记ID，差异中以+表示"`
+}
+
+// ExportNoteBundleArgs 导出笔记工具参数
+type ExportNoteBundleArgs struct {
+	NoteID       string `json:"note_id" description:"要导出的笔记ID"`
+	ResolveLinks bool   `json:"resolve_links,omitempty" description:"是否解析内链笔记，附上被引用笔记的标题和地址，默认为false"`
+}
+
+// ImportMarkdownDirArgs 从目录中的Markdown文件批量创建笔记工具参数
+type ImportMarkdownDirArgs struct {
+	Dir         string   `json:"dir" description:"Markdown文件所在目录，只处理该目录下（不含子目录）的.md文件"`
+	Tags        []string `json:"tags,omitempty" description:"额外的笔记标签，与各文件front matter中的tags合并"`
+	AutoPublish bool     `json:"auto_publish,omitempty" description:"是否自动发布，默认为false，文件front matter中的auto_publish优先"`
+	StopOnError bool     `json:"stop_on_error,omitempty" description:"是否在首个失败后停止，默认为false（继续处理并汇总所有结果）"`
+}
+
+// ImportNoteBundleArgs 导入笔记工具参数
+type ImportNoteBundleArgs struct {
+	Bundle      string `json:"bundle" description:"export_note_bundle导出的JSON文本"`
+	AutoPublish bool   `json:"auto_publish,omitempty" description:"是否自动发布，默认为false"`
+}
+
+// BulkTagNotesArgs 按搜索结果批量添加标签工具参数
+type BulkTagNotesArgs struct {
+	Query       string `json:"query" description:"搜索关键词，匹配到的所有笔记都会添加标签"`
+	Tag         string `json:"tag" description:"要添加的标签"`
+	StopOnError bool   `json:"stop_on_error,omitempty" description:"是否在首个失败后停止，默认为false（继续处理并汇总所有结果）"`
+}
+
+// BulkSetPrivacyArgs 按搜索结果或标签批量设置规则公开工具参数
+type BulkSetPrivacyArgs struct {
+	Query       string `json:"query,omitempty" description:"搜索关键词，与tag至少指定一个"`
+	Tag         string `json:"tag,omitempty" description:"笔记标签，只处理带有该标签的笔记，与query至少指定一个"`
+	NoShare     bool   `json:"no_share,omitempty" description:"是否禁止分享，默认为false"`
+	ExpireAt    *int64 `json:"expire_at,omitempty" description:"公开截止时间（Unix秒级时间戳），必须晚于当前时间；0表示永不过期，未指定时使用MOWEN_DEFAULT_EXPIRE_IN，也未配置时永不过期"`
+	StopOnError bool   `json:"stop_on_error,omitempty" description:"是否在首个失败后停止，默认为false（继续处理并汇总所有结果）"`
+}
+
+// CheckNoteReferencesArgs 检查内链笔记引用工具参数
+type CheckNoteReferencesArgs struct {
+	Paragraphs []Paragraph `json:"paragraphs" description:"要检查的段落列表，与create_note的paragraphs格式相同"`
+}
+
+// RetryBatchArgs 重试批量操作失败条目工具参数
+type RetryBatchArgs struct {
+	Result      string `json:"result" description:"批量工具返回的批量结果JSON，也可以直接传入包含该JSON的完整输出"`
+	StopOnError bool   `json:"stop_on_error,omitempty" description:"是否在首个失败后停止，默认为false（继续处理并汇总所有结果）"`
+	Confirm     string `json:"confirm,omitempty" description:"重试cleanup_notes的删除时必须与清理标签完全一致"`
+}
+
+// NormalizeNoteTagsArgs 规范化笔记标签工具参数
+type NormalizeNoteTagsArgs struct {
+	NoteID string `json:"note_id" description:"要整理标签的笔记ID"`
+	DryRun bool   `json:"dry_run,omitempty" description:"为true时只返回规范化前后的标签，不修改笔记"`
+}
+
+// CleanupNotesArgs 按标签批量删除笔记工具参数
+type CleanupNotesArgs struct {
+	Tag     string `json:"tag,omitempty" description:"要清理的笔记标签，留空时使用MOWEN_CLEANUP_TAG"`
+	DryRun  bool   `json:"dry_run,omitempty" description:"为true时只列出将被删除的笔记，不删除"`
+	Confirm string `json:"confirm,omitempty" description:"确认删除，必须与要清理的标签完全一致；试运行时不需要"`
+}
+
+// PreviewTextMarksArgs 文本标记预览工具参数
+type PreviewTextMarksArgs struct {
+	Texts []TextNode `json:"texts" description:"要预览的文本节点列表，格式同段落中的texts"`
+}
+
+// NoteJSONToParagraphsArgs NoteAtom JSON转换为段落工具参数
+type NoteJSONToParagraphsArgs struct {
+	NoteJSON string `json:"note_json" description:"墨问笔记正文的NoteAtom JSON（根节点type为doc），例如导出得到的笔记内容"`
+}
+
+// GetNoteAsParagraphsArgs 读取笔记并转换为段落工具参数
+type GetNoteAsParagraphsArgs struct {
+	NoteID string `json:"note_id" description:"要读取的笔记ID"`
+}
+
+// ReplaceNoteFileArgs 替换笔记中文件工具参数
+type ReplaceNoteFileArgs struct {
+	NoteID   string `json:"note_id" description:"笔记ID"`
+	OldUUID  string `json:"old_uuid" description:"要替换的文件UUID"`
+	FilePath string `json:"file_path,omitempty" description:"新文件的本地路径（与file_url二选一）"`
+	FileURL  string `json:"file_url,omitempty" description:"新文件的URL（与file_path二选一）"`
+}
+
+// VerifyNoteFilesArgs 校验笔记文件引用工具参数
+type VerifyNoteFilesArgs struct {
+	NoteID       string `json:"note_id" description:"笔记ID"`
+	RemoveBroken bool   `json:"remove_broken,omitempty" description:"为true时从笔记中移除失效的文件引用，默认只报告"`
+}
+
+// ParagraphMove 段落移动：把第From段移动到第To段的位置（序号从1开始）
+type ParagraphMove struct {
+	From int `json:"from" description:"要移动的段落序号（从1开始）"`
+	To   int `json:"to" description:"移动后的段落序号（从1开始）"`
+}
+
+// ReorderParagraphsArgs 调整段落顺序工具参数
+type ReorderParagraphsArgs struct {
+	NoteID string          `json:"note_id" description:"笔记ID"`
+	Moves  []ParagraphMove `json:"moves" description:"按顺序依次执行的段落移动列表"`
+}
+
 // UploadFileArgs 本地文件上传参数
 type UploadFileArgs struct {
 	FilePath string `json:"file_path" description:"要上传的文件路径"`
 	FileType int    `json:"file_type" description:"文件类型：1-图片，2-音频，3-PDF"`
 	FileName string `json:"file_name" description:"文件名称"`
+	DryRun   bool   `json:"dry_run,omitempty" description:"为true时只调用准备接口并预览存储上传请求，不上传文件内容"`
+	// WaitForReady 上传后轮询文件处理状态，文件就绪后才返回成功
+	WaitForReady bool `json:"wait_for_ready,omitempty" description:"为true时等待文件处理完成后再返回，超时未就绪时返回错误"`
 }
 
 // UploadFileViaURLArgs 基于URL的文件上传参数
@@ -93,6 +466,18 @@ type UploadFileViaURLArgs struct {
 	FileURL  string `json:"file_url" description:"要上传的文件URL"`
 	FileType int    `json:"file_type" description:"文件类型：1-图片，2-音频，3-PDF"`
 	FileName string `json:"file_name,omitempty" description:"文件名称（可选）"`
+	// WaitForReady 规则同UploadFileArgs
+	WaitForReady bool `json:"wait_for_ready,omitempty" description:"为true时等待文件处理完成后再返回，超时未就绪时返回错误"`
+}
+
+// GetNoteShareArgs 获取笔记分享信息工具参数
+type GetNoteShareArgs struct {
+	NoteID string `json:"note_id" description:"笔记ID"`
+}
+
+// GetUploadStatusArgs 查询文件处理状态参数
+type GetUploadStatusArgs struct {
+	FileUUID string `json:"file_uuid" description:"上传后返回的文件UUID"`
 }
 
 // FileNode 文件节点
@@ -105,43 +490,256 @@ type FileNode struct {
 
 // Paragraph 段落结构
 type Paragraph struct {
-	Type   string     `json:"type,omitempty" description:"段落类型：quote（引用段落）、note（内链笔记）、file（文件）"`
-	Texts  []TextNode `json:"texts,omitempty" description:"文本节点列表"`
-	NoteID string     `json:"note_id,omitempty" description:"内链笔记ID（仅当type为note时使用）"`
-	File   *FileNode  `json:"file,omitempty" description:"文件节点（仅当type为file时使用）"`
+	Type   string       `json:"type,omitempty" description:"段落类型：quote（引用段落）、heading（标题）、bullet_list（无序列表）、ordered_list（有序列表）、note（内链笔记）、file（文件）、link_card（链接卡片）"`
+	Texts  []TextNode   `json:"texts,omitempty" description:"文本节点列表"`
+	Level  int          `json:"level,omitempty" description:"标题级别1-3（仅当type为heading时使用），默认为1"`
+	Items  [][]TextNode `json:"items,omitempty" description:"列表项，每一项是一组文本节点（仅当type为bullet_list或ordered_list时使用）"`
+	NoteID string       `json:"note_id,omitempty" description:"内链笔记ID（仅当type为note时使用）"`
+	File   *FileNode    `json:"file,omitempty" description:"文件节点（仅当type为file时使用）"`
+	URL    string       `json:"url,omitempty" description:"链接地址（仅当type为link_card时使用）"`
+}
+
+// allTexts 返回段落中的全部文本节点，列表段落依次返回每一项的文本节点
+func (p Paragraph) allTexts() []TextNode {
+	texts := p.Texts
+	for _, item := range p.Items {
+		texts = append(texts[:len(texts):len(texts)], item...)
+	}
+	return texts
 }
 
 // TextNode 文本节点
 type TextNode struct {
-	Text      string `json:"text" description:"文本内容"`
-	Bold      bool   `json:"bold,omitempty" description:"是否加粗"`
-	Highlight bool   `json:"highlight,omitempty" description:"是否高亮"`
-	Link      string `json:"link,omitempty" description:"链接地址"`
+	Text          string `json:"text" description:"文本内容"`
+	Bold          bool   `json:"bold,omitempty" description:"是否加粗"`
+	Italic        bool   `json:"italic,omitempty" description:"是否斜体"`
+	Strikethrough bool   `json:"strikethrough,omitempty" description:"是否添加删除线"`
+	InlineCode    bool   `json:"inline_code,omitempty" description:"是否显示为行内代码"`
+	Highlight     bool   `json:"highlight,omitempty" description:"是否高亮"`
+	Color         string `json:"color,omitempty" description:"文字颜色：#RRGGBB格式或red、orange、yellow、green、blue、purple、gray"`
+	Link          string `json:"link,omitempty" description:"链接地址"`
+}
+
+// TypeInfo 支持的段落类型或文本标记及其说明
+type TypeInfo struct {
+	Name        string // 类型名称，即段落的type字段或文本节点的标记字段
+	Description string // 简要说明和用法
+}
+
+// SupportedParagraphTypes ConvertParagraphsToNoteAtom支持的段落类型，新增段落类型时需同步更新
+var SupportedParagraphTypes = []TypeInfo{
+	{Name: "paragraph", Description: "普通段落（type留空即可），由texts中的文本节点组成"},
+	{Name: "quote", Description: "引用段落，texts中的文本显示为引用块"},
+	{Name: "note", Description: "内链笔记，通过note_id嵌入另一篇笔记"},
+	{Name: "file", Description: "文件段落，通过file嵌入已上传的图片（image）、音频（audio）或PDF（pdf）"},
+	{Name: "link_card", Description: "链接卡片，通过url显示为带预览的链接"},
+	{Name: "heading", Description: "标题，texts中的文本显示为标题，level为1-3级，默认为1级"},
+	{Name: "bullet_list", Description: "无序列表，items中的每一项显示为一个列表项"},
+	{Name: "ordered_list", Description: "有序列表，items中的每一项按顺序编号显示"},
+}
+
+// listAtomTypes 列表段落类型对应的NoteAtom节点类型
+var listAtomTypes = map[string]string{
+	"bullet_list":  "bulletList",
+	"ordered_list": "orderedList",
+}
+
+// SupportedMarkTypes convertTextsToContent支持的文本标记，新增标记时需同步更新
+var SupportedMarkTypes = []TypeInfo{
+	{Name: "bold", Description: "加粗，设置bold为true"},
+	{Name: "italic", Description: "斜体，设置italic为true"},
+	{Name: "strikethrough", Description: "删除线，设置strikethrough为true"},
+	{Name: "code", Description: "行内代码，设置inline_code为true"},
+	{Name: "highlight", Description: "高亮，设置highlight为true"},
+	{Name: "textColor", Description: "文字颜色，设置color为#RRGGBB或颜色名称"},
+	{Name: "link", Description: "链接，设置link为链接地址"},
 }
 
 // 转换函数：将MCP参数转换为墨问API格式
 
-// ConvertParagraphsToNoteAtom 将段落列表转换为NoteAtom格式
-func ConvertParagraphsToNoteAtom(paragraphs []Paragraph) NoteAtom {
+// ParseNoteDetail 从详情接口响应中解析笔记详情
+func ParseNoteDetail(result map[string]interface{}) (*NoteDetail, error) {
+	data, ok := result["data"].(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid note detail response format")
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal note detail: %w", err)
+	}
+
+	var detail NoteDetail
+	if err := json.Unmarshal(jsonData, &detail); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal note detail: %w", err)
+	}
+
+	return &detail, nil
+}
+
+// DefaultIDPattern 默认的文件UUID和内链笔记ID格式：8到64位字母、数字、下划线或连字符
+const DefaultIDPattern = `^[A-Za-z0-9_-]{8,64}$`
+
+var defaultIDPattern = regexp.MustCompile(DefaultIDPattern)
+
+// ConvertOptions 段落转换选项
+type ConvertOptions struct {
+	IDPattern          *regexp.Regexp // 文件UUID和内链笔记ID需匹配的格式，为nil时不校验
+	KeepEmptyText      bool           // 是否保留内容为空字符串的文本节点，默认跳过；只含空白的文本始终保留
+	AutoLinkCard       bool           // 是否把只包含一个URL的普通段落自动转换为链接卡片
+	MaxParagraphLength int            // 普通段落和引用段落的最大字符数，超过时在句子或空白处拆分为多个段落，0表示不拆分
+	TrimTrailingEmpty  bool           // 是否移除末尾没有文本的空段落，中间的空段落始终保留
+	// Transforms 转换前按顺序应用于段落文本的转换名称（见SupportedTextTransforms），为空时不转换
+	Transforms []string
+}
+
+// DefaultConvertOptions 返回默认的段落转换选项
+func DefaultConvertOptions() ConvertOptions {
+	return ConvertOptions{
+		IDPattern:         defaultIDPattern,
+		TrimTrailingEmpty: true,
+	}
+}
+
+// ConvertParagraphsToNoteAtom 使用默认选项将段落列表转换为NoteAtom格式
+func ConvertParagraphsToNoteAtom(paragraphs []Paragraph) (NoteAtom, error) {
+	return ConvertParagraphsToNoteAtomWithOptions(paragraphs, DefaultConvertOptions())
+}
+
+// ConvertParagraphsToNoteAtomWithOptions 将段落列表转换为NoteAtom格式。
+// 内链笔记ID或文件UUID不符合格式时返回错误，避免生成无法显示的嵌入内容。
+func ConvertParagraphsToNoteAtomWithOptions(paragraphs []Paragraph, opts ConvertOptions) (NoteAtom, error) {
+	doc, _, err := ConvertParagraphsWithWarnings(paragraphs, opts)
+	return doc, err
+}
+
+// ConversionWarning 转换过程中发现的不影响生成结果的问题
+type ConversionWarning struct {
+	Paragraph int    // 段落序号，从1开始
+	Message   string // 问题说明
+}
+
+// String 返回带段落序号的警告说明
+func (w ConversionWarning) String() string {
+	return fmt.Sprintf("第 %d 段：%s", w.Paragraph, w.Message)
+}
+
+// knownFileMetadataKeys 墨问文件节点可识别的元数据键，其他键仍会原样提交但可能被忽略
+var knownFileMetadataKeys = map[string]bool{
+	"alt":       true,
+	"align":     true,
+	"title":     true,
+	"show-note": true,
+}
+
+// ConvertParagraphsWithWarnings 将段落列表转换为NoteAtom格式，并收集不影响转换的问题：
+// 未知段落类型、被跳过的空文本节点或缺少文件的文件段落、空段落以及未知的文件元数据键。
+// 只有会生成无效内容的问题才返回错误。
+func ConvertParagraphsWithWarnings(paragraphs []Paragraph, opts ConvertOptions) (NoteAtom, []ConversionWarning, error) {
 	doc := NoteAtom{
 		Type:    "doc",
 		Content: make([]NoteAtom, 0, len(paragraphs)),
 	}
+	var warnings []ConversionWarning
+	warn := func(i int, format string, args ...interface{}) {
+		warnings = append(warnings, ConversionWarning{Paragraph: i + 1, Message: fmt.Sprintf(format, args...)})
+	}
+	textContent := func(i int, texts []TextNode) []NoteAtom {
+		content := convertTextsToContent(texts, opts.KeepEmptyText)
+		if skipped := emptyTextCount(texts); skipped > 0 && !opts.KeepEmptyText {
+			warn(i, "已跳过 %d 个空文本节点", skipped)
+		}
+		if len(content) == 0 {
+			warn(i, "段落没有文本，将显示为空行")
+		}
+		return content
+	}
+
+	splitTexts := func(i int, texts []TextNode) [][]TextNode {
+		chunks := splitLongTexts(texts, opts.MaxParagraphLength)
+		if len(chunks) > 1 {
+			warn(i, "段落超过 %d 个字符，已拆分为 %d 段", opts.MaxParagraphLength, len(chunks))
+		}
+		return chunks
+	}
+
+	transform, err := textTransformPipeline(opts.Transforms)
+	if err != nil {
+		return NoteAtom{}, nil, err
+	}
 
-	for _, para := range paragraphs {
+	if opts.TrimTrailingEmpty {
+		if end := trailingEmptyStart(paragraphs, opts.KeepEmptyText); end < len(paragraphs) {
+			warn(end, "已移除末尾的 %d 个空段落", len(paragraphs)-end)
+			paragraphs = paragraphs[:end]
+		}
+	}
+
+	for i, para := range paragraphs {
+		para = transformParagraph(para, transform)
+		if err := validateTextColors(para.allTexts()); err != nil {
+			return NoteAtom{}, nil, fmt.Errorf("paragraph %d: %w", i+1, err)
+		}
 		switch para.Type {
 		case "quote":
 			// 引用段落
-			quotePara := NoteAtom{
-				Type: "paragraph",
+			for _, texts := range splitTexts(i, para.Texts) {
+				quotePara := NoteAtom{
+					Type: "paragraph",
+					Attrs: map[string]string{
+						"blockquote": "true",
+					},
+					Content: textContent(i, texts),
+				}
+				doc.Content = append(doc.Content, quotePara)
+			}
+		case "heading":
+			// 标题
+			if len(para.Texts) == 0 {
+				return NoteAtom{}, nil, fmt.Errorf("paragraph %d: heading must have at least one text node", i+1)
+			}
+			if para.Level != 0 && headingLevel(para.Level) != para.Level {
+				warn(i, "标题级别 %d 超出 1-%d 的范围，已按 1 级标题处理", para.Level, MaxHeadingLevel)
+			}
+			headingPara := NoteAtom{
+				Type: "heading",
 				Attrs: map[string]string{
-					"blockquote": "true",
+					"level": strconv.Itoa(headingLevel(para.Level)),
 				},
-				Content: convertTextsToContent(para.Texts),
+				Content: textContent(i, para.Texts),
+			}
+			doc.Content = append(doc.Content, headingPara)
+		case "bullet_list", "ordered_list":
+			// 列表，每一项转换为包含一个段落的listItem，空列表项会被跳过
+			list := NoteAtom{Type: listAtomTypes[para.Type]}
+			emptyItems := 0
+			for _, item := range para.Items {
+				skipped := emptyTextCount(item)
+				if skipped == len(item) {
+					emptyItems++
+					continue
+				}
+				if skipped > 0 && !opts.KeepEmptyText {
+					warn(i, "已跳过 %d 个空文本节点", skipped)
+				}
+				content := convertTextsToContent(item, opts.KeepEmptyText)
+				list.Content = append(list.Content, NoteAtom{
+					Type:    "listItem",
+					Content: []NoteAtom{{Type: "paragraph", Content: content}},
+				})
+			}
+			if len(list.Content) == 0 {
+				return NoteAtom{}, nil, fmt.Errorf("paragraph %d: %s must have at least one non-empty item", i+1, para.Type)
+			}
+			if emptyItems > 0 {
+				warn(i, "已跳过 %d 个空列表项", emptyItems)
 			}
-			doc.Content = append(doc.Content, quotePara)
+			doc.Content = append(doc.Content, list)
 		case "note":
 			// 内链笔记
+			if err := opts.validateID(para.NoteID); err != nil {
+				return NoteAtom{}, nil, fmt.Errorf("paragraph %d: invalid note_id: %w", i+1, err)
+			}
 			notePara := NoteAtom{
 				Type: "note",
 				Attrs: map[string]string{
@@ -151,38 +749,472 @@ func ConvertParagraphsToNoteAtom(paragraphs []Paragraph) NoteAtom {
 			doc.Content = append(doc.Content, notePara)
 		case "file":
 			// 文件段落
-			if para.File != nil {
-				fileAtom := NoteAtom{
-					Type: para.File.FileType,
-					Attrs: map[string]string{
-						"uuid":       para.File.SourcePath, // 墨问API中文件UUID即为SourcePath
-						"sourceType": para.File.SourceType,
-					},
-				}
-				// 合并元数据
-				for k, v := range para.File.Metadata {
-					fileAtom.Attrs[k] = v
+			if para.File == nil {
+				warn(i, "文件段落缺少file，已跳过")
+				continue
+			}
+			if err := opts.validateID(para.File.SourcePath); err != nil {
+				return NoteAtom{}, nil, fmt.Errorf("paragraph %d: invalid file source_path: %w", i+1, err)
+			}
+			fileAtom := NoteAtom{
+				Type: para.File.FileType,
+				Attrs: map[string]string{
+					"uuid":       para.File.SourcePath, // 墨问API中文件UUID即为SourcePath
+					"sourceType": para.File.SourceType,
+				},
+			}
+			// 合并元数据
+			for _, k := range sortedKeys(para.File.Metadata) {
+				if !knownFileMetadataKeys[k] {
+					warn(i, "未知的文件元数据键 %q，可能不会生效", k)
 				}
-				doc.Content = append(doc.Content, fileAtom)
+				fileAtom.Attrs[k] = para.File.Metadata[k]
+			}
+			doc.Content = append(doc.Content, fileAtom)
+		case "link_card":
+			// 链接卡片
+			if !isBareURL(para.URL) {
+				return NoteAtom{}, nil, fmt.Errorf("paragraph %d: invalid link_card url %q", i+1, para.URL)
 			}
+			doc.Content = append(doc.Content, linkCardAtom(para.URL))
 		default:
+			if para.Type != "" && para.Type != "paragraph" {
+				warn(i, "未知的段落类型 %q，已按普通段落处理", para.Type)
+			}
+			// 只包含一个URL的普通段落可自动转换为链接卡片
+			if opts.AutoLinkCard {
+				if url, ok := bareURLParagraph(para.Texts); ok {
+					doc.Content = append(doc.Content, linkCardAtom(url))
+					continue
+				}
+			}
 			// 普通段落
-			normalPara := NoteAtom{
-				Type:    "paragraph",
-				Content: convertTextsToContent(para.Texts),
+			for _, texts := range splitTexts(i, para.Texts) {
+				normalPara := NoteAtom{
+					Type:    "paragraph",
+					Content: textContent(i, texts),
+				}
+				doc.Content = append(doc.Content, normalPara)
+			}
+		}
+	}
+
+	return doc, warnings, nil
+}
+
+// sentenceEnds 拆分长段落时优先选择的句末标点
+const sentenceEnds = "。！？；.!?;\n"
+
+// splitLongTexts 将总字符数超过maxLength的文本节点列表拆分为多段，maxLength为0时不拆分。
+// 拆分点优先选在句末标点之后，其次是空白之后，都没有时在maxLength处截断；
+// 被拆开的文本节点在两段中保留相同的标记。
+func splitLongTexts(texts []TextNode, maxLength int) [][]TextNode {
+	type textRune struct {
+		r    rune
+		node int
+	}
+	var runes []textRune
+	for i, text := range texts {
+		for _, r := range text.Text {
+			runes = append(runes, textRune{r: r, node: i})
+		}
+	}
+	if maxLength <= 0 || len(runes) <= maxLength {
+		return [][]TextNode{texts}
+	}
+
+	var chunks [][]TextNode
+	for len(runes) > 0 {
+		cut := len(runes)
+		if cut > maxLength {
+			cut = splitPoint(maxLength, func(i int) rune { return runes[i].r })
+		}
+
+		var chunk []TextNode
+		for start := 0; start < cut; {
+			end := start
+			for end < cut && runes[end].node == runes[start].node {
+				end++
+			}
+			node := texts[runes[start].node]
+			var sb strings.Builder
+			for _, tr := range runes[start:end] {
+				sb.WriteRune(tr.r)
+			}
+			node.Text = sb.String()
+			chunk = append(chunk, node)
+			start = end
+		}
+		chunks = append(chunks, chunk)
+		runes = runes[cut:]
+	}
+	return chunks
+}
+
+// splitPoint 返回不超过maxLength的拆分位置，at(i)为第i个字符。
+// 只在后半段中寻找句末标点或空白，避免产生过短的段落。
+func splitPoint(maxLength int, at func(i int) rune) int {
+	for _, isBoundary := range []func(r rune) bool{
+		func(r rune) bool { return strings.ContainsRune(sentenceEnds, r) },
+		unicode.IsSpace,
+	} {
+		for i := maxLength; i > maxLength/2; i-- {
+			if isBoundary(at(i - 1)) {
+				return i
 			}
-			doc.Content = append(doc.Content, normalPara)
 		}
 	}
+	return maxLength
+}
+
+// trailingEmptyStart 返回末尾连续空段落的起始下标，没有末尾空段落时返回len(paragraphs)。
+// 空段落指转换后没有任何文本的普通段落或引用段落，标题、内链笔记、文件和链接卡片段落不算空段落。
+func trailingEmptyStart(paragraphs []Paragraph, keepEmptyText bool) int {
+	end := len(paragraphs)
+	for end > 0 {
+		para := paragraphs[end-1]
+		switch para.Type {
+		case "heading", "bullet_list", "ordered_list", "note", "file", "link_card":
+			return end
+		}
+		if len(convertTextsToContent(para.Texts, keepEmptyText)) > 0 {
+			return end
+		}
+		end--
+	}
+	return end
+}
+
+// emptyTextCount 统计内容为空字符串的文本节点数量
+func emptyTextCount(texts []TextNode) int {
+	count := 0
+	for _, text := range texts {
+		if text.Text == "" {
+			count++
+		}
+	}
+	return count
+}
+
+// sortedKeys 返回按字典序排列的键，保证输出顺序稳定
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// RenderConversionWarnings 将转换警告渲染为文本，没有警告时返回空字符串
+func RenderConversionWarnings(warnings []ConversionWarning) string {
+	if len(warnings) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "转换提示（%d 条，不影响提交）：", len(warnings))
+	for _, w := range warnings {
+		sb.WriteString("\n- ")
+		sb.WriteString(w.String())
+	}
+	return sb.String()
+}
+
+// bareURLPattern 单个http(s)链接
+var bareURLPattern = regexp.MustCompile(`^https?://\S+$`)
+
+// isBareURL 判断文本是否为单个http(s)链接
+func isBareURL(text string) bool {
+	return bareURLPattern.MatchString(text)
+}
+
+// bareURLParagraph 判断段落文本是否只包含一个URL，返回该URL。
+// 文本节点可以带指向同一URL的链接标记，但不能有加粗、高亮等其他标记或其他文字。
+func bareURLParagraph(texts []TextNode) (string, bool) {
+	var sb strings.Builder
+	for _, text := range texts {
+		if text.Bold || text.Italic || text.Strikethrough || text.InlineCode || text.Highlight || text.Color != "" {
+			return "", false
+		}
+		sb.WriteString(text.Text)
+	}
 
-	return doc
+	url := strings.TrimSpace(sb.String())
+	if !isBareURL(url) {
+		return "", false
+	}
+	for _, text := range texts {
+		if text.Link != "" && text.Link != url {
+			return "", false
+		}
+	}
+	return url, true
+}
+
+// MaxHeadingLevel 标题段落支持的最大级别
+const MaxHeadingLevel = 3
+
+// headingLevel 返回有效的标题级别，0或超出1-MaxHeadingLevel范围时为1
+func headingLevel(level int) int {
+	if level < 1 || level > MaxHeadingLevel {
+		return 1
+	}
+	return level
 }
 
-// convertTextsToContent 将文本节点列表转换为内容
-func convertTextsToContent(texts []TextNode) []NoteAtom {
+// atomHeadingLevel 读取标题节点的级别，属性缺失或无效时为1
+func atomHeadingLevel(block NoteAtom) int {
+	level, err := strconv.Atoi(block.Attrs["level"])
+	if err != nil {
+		return 1
+	}
+	return headingLevel(level)
+}
+
+// linkCardAtom 构建链接卡片节点
+func linkCardAtom(url string) NoteAtom {
+	return NoteAtom{
+		Type: "link_card",
+		Attrs: map[string]string{
+			"url": url,
+		},
+	}
+}
+
+// validateID 校验文件UUID或内链笔记ID是否符合配置的格式
+func (opts ConvertOptions) validateID(id string) error {
+	if id == "" {
+		return fmt.Errorf("id is empty")
+	}
+	if opts.IDPattern != nil && !opts.IDPattern.MatchString(id) {
+		return fmt.Errorf("%q does not look like a valid ID (expected pattern %s)", id, opts.IDPattern.String())
+	}
+	return nil
+}
+
+// maxTimestampSkew 校验时间戳时允许的时钟偏差
+const maxTimestampSkew = 5 * time.Minute
+
+// ValidateNoteTimestamps 校验导入笔记时指定的创建和更新时间，0表示未指定。
+// 时间戳须为Unix秒级时间戳且不晚于当前时间，更新时间不能早于创建时间。
+func ValidateNoteTimestamps(createdAt, updatedAt int64, now time.Time) error {
+	check := func(name string, ts int64) error {
+		if ts == 0 {
+			return nil
+		}
+		if ts < 0 {
+			return fmt.Errorf("invalid %s %d: must be a positive Unix timestamp in seconds", name, ts)
+		}
+		if ts > 1e11 {
+			return fmt.Errorf("invalid %s %d: looks like milliseconds, expected a Unix timestamp in seconds", name, ts)
+		}
+		if time.Unix(ts, 0).After(now.Add(maxTimestampSkew)) {
+			return fmt.Errorf("invalid %s %d: must not be in the future", name, ts)
+		}
+		return nil
+	}
+
+	if err := check("created_at", createdAt); err != nil {
+		return err
+	}
+	if err := check("updated_at", updatedAt); err != nil {
+		return err
+	}
+	if createdAt != 0 && updatedAt != 0 && updatedAt < createdAt {
+		return fmt.Errorf("invalid updated_at %d: must not be earlier than created_at %d", updatedAt, createdAt)
+	}
+	return nil
+}
+
+// 隐私规则中公开截止时间的单位
+const (
+	ExpireAtUnitSeconds      = "seconds"
+	ExpireAtUnitMilliseconds = "milliseconds"
+)
+
+// maxExpireAtHorizon 公开截止时间距今的最大跨度，超出视为不合理的时间戳
+const maxExpireAtHorizon = 100 * 365 * 24 * time.Hour
+
+// FormatExpireAt 将Unix秒级时间戳转换为墨问API期望单位的字符串，0表示永不过期。
+// 时间戳需晚于当前时间，且不能像是误传的毫秒值。
+func FormatExpireAt(expireAt int64, unit string, now time.Time) (string, error) {
+	if expireAt == 0 {
+		return "0", nil
+	}
+	if expireAt < 0 {
+		return "", fmt.Errorf("invalid expire_at %d: must be a positive Unix timestamp in seconds, or 0 for never", expireAt)
+	}
+	if expireAt > 1e11 {
+		return "", fmt.Errorf("invalid expire_at %d: looks like milliseconds, expected a Unix timestamp in seconds", expireAt)
+	}
+
+	expires := time.Unix(expireAt, 0)
+	if !expires.After(now) {
+		return "", fmt.Errorf("invalid expire_at %d: %s is not in the future", expireAt, expires.UTC().Format(time.RFC3339))
+	}
+	if expires.After(now.Add(maxExpireAtHorizon)) {
+		return "", fmt.Errorf("invalid expire_at %d: %s is implausibly far in the future", expireAt, expires.UTC().Format(time.RFC3339))
+	}
+
+	switch unit {
+	case ExpireAtUnitMilliseconds:
+		return strconv.FormatInt(expireAt*1000, 10), nil
+	case "", ExpireAtUnitSeconds:
+		return strconv.FormatInt(expireAt, 10), nil
+	default:
+		return "", fmt.Errorf("unknown expire_at unit %q", unit)
+	}
+}
+
+// ParseExpireIn 解析公开时长，支持Go时长格式（如"36h"、"90m"）和整天数（如"7d"）。
+// 时长必须大于0，且不能超过公开截止时间的最大跨度。
+func ParseExpireIn(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	var d time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid expire_in %q: expected a duration like 36h or a number of days like 7d", value)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid expire_in %q: expected a duration like 36h or a number of days like 7d", value)
+		}
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("invalid expire_in %q: must be positive", value)
+	}
+	if d > maxExpireAtHorizon {
+		return 0, fmt.Errorf("invalid expire_in %q: implausibly long", value)
+	}
+	return d, nil
+}
+
+// ReorderBlocks 按顺序执行段落移动，返回调整后的段落列表，不修改原列表。
+// 任一序号超出范围时返回错误。
+func ReorderBlocks(blocks []NoteAtom, moves []ParagraphMove) ([]NoteAtom, error) {
+	result := make([]NoteAtom, len(blocks))
+	copy(result, blocks)
+
+	for i, move := range moves {
+		if move.From < 1 || move.From > len(result) {
+			return nil, fmt.Errorf("move %d: from index %d out of range [1, %d]", i+1, move.From, len(result))
+		}
+		if move.To < 1 || move.To > len(result) {
+			return nil, fmt.Errorf("move %d: to index %d out of range [1, %d]", i+1, move.To, len(result))
+		}
+
+		block := result[move.From-1]
+		result = append(result[:move.From-1], result[move.From:]...)
+		result = append(result[:move.To-1], append([]NoteAtom{block}, result[move.To-1:]...)...)
+	}
+
+	return result, nil
+}
+
+// DefaultNoteIDPaths 从响应中读取笔记ID的默认路径，按优先级排列。不同接口和版本分别使用note_id、noteId或id。
+var DefaultNoteIDPaths = []string{"data.note_id", "data.noteId", "data.id", "note_id", "noteId"}
+
+// lookupResponsePath 按点分隔的路径读取响应中的值，字符串和整数值以字符串返回
+func lookupResponsePath(result map[string]interface{}, path string) (string, bool) {
+	var current interface{} = result
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := current.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if current, ok = obj[key]; !ok {
+			return "", false
+		}
+	}
+
+	switch v := current.(type) {
+	case string:
+		return v, v != ""
+	case float64:
+		if v == float64(int64(v)) {
+			return strconv.FormatInt(int64(v), 10), true
+		}
+	case json.Number:
+		return v.String(), true
+	}
+	return "", false
+}
+
+// ExtractNoteID 依次尝试paths中的路径，返回第一个找到的笔记ID
+func ExtractNoteID(result map[string]interface{}, paths []string) (string, bool) {
+	for _, path := range paths {
+		if id, ok := lookupResponsePath(result, path); ok {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// CreateNoteResult 创建或编辑笔记响应中的笔记信息，响应中缺少的字段为空字符串
+type CreateNoteResult struct {
+	NoteID string // 笔记ID
+	URL    string // 笔记链接
+}
+
+// ParseCreateNoteResult 从创建或编辑笔记的响应中解析笔记信息，笔记ID按idPaths依次查找。
+// 响应中没有链接但有笔记ID时，使用墨问笔记详情页地址作为链接。
+func ParseCreateNoteResult(result map[string]interface{}, idPaths []string) CreateNoteResult {
+	noteID, _ := ExtractNoteID(result, idPaths)
+	url := extractDataString(result, "url")
+	if url == "" && noteID != "" {
+		url = fmt.Sprintf(MowenNoteURLFormat, noteID)
+	}
+	return CreateNoteResult{NoteID: noteID, URL: url}
+}
+
+// UploadFileResult 文件上传响应中的文件信息，响应中缺少的字段为空字符串
+type UploadFileResult struct {
+	UUID string // 文件UUID，在文件段落中引用
+	URL  string // 文件链接
+}
+
+// ParseUploadFileResult 从文件上传响应的data字段中解析文件UUID和链接，UUID也可能以fileId返回
+func ParseUploadFileResult(result map[string]interface{}) UploadFileResult {
+	uuid := extractDataString(result, "uuid")
+	if uuid == "" {
+		uuid = extractDataString(result, "fileId")
+	}
+	return UploadFileResult{UUID: uuid, URL: extractDataString(result, "url")}
+}
+
+// extractDataString 从响应的data字段中读取字符串值，不存在时返回空字符串
+func extractDataString(result map[string]interface{}, key string) string {
+	data, ok := result["data"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	value, _ := data[key].(string)
+	return value
+}
+
+// isFileAtomType 判断节点类型是否为文件节点
+func isFileAtomType(atomType string) bool {
+	switch atomType {
+	case "image", "audio", "pdf":
+		return true
+	}
+	return false
+}
+
+// convertTextsToContent 将文本节点列表转换为内容。
+// keepEmpty为false时跳过内容为空字符串的节点，避免产生空的文本片段。
+// 文字颜色需要先经过validateTextColors校验。
+func convertTextsToContent(texts []TextNode, keepEmpty bool) []NoteAtom {
 	content := make([]NoteAtom, 0, len(texts))
 
 	for _, text := range texts {
+		if text.Text == "" && !keepEmpty {
+			continue
+		}
+
 		textAtom := NoteAtom{
 			Type: "text",
 			Text: text.Text,
@@ -190,12 +1222,30 @@ func convertTextsToContent(texts []TextNode) []NoteAtom {
 
 		// 添加标记
 		var marks []NoteAtom
+		// 标记顺序固定为 bold→italic→strikethrough→code→highlight→textColor→link
 		if text.Bold {
 			marks = append(marks, NoteAtom{Type: "bold"})
 		}
+		if text.Italic {
+			marks = append(marks, NoteAtom{Type: "italic"})
+		}
+		if text.Strikethrough {
+			marks = append(marks, NoteAtom{Type: "strikethrough"})
+		}
+		if text.InlineCode {
+			marks = append(marks, NoteAtom{Type: "code"})
+		}
 		if text.Highlight {
 			marks = append(marks, NoteAtom{Type: "highlight"})
 		}
+		if text.Color != "" {
+			marks = append(marks, NoteAtom{
+				Type: "textColor",
+				Attrs: map[string]string{
+					"color": canonicalTextColor(text.Color),
+				},
+			})
+		}
 		if text.Link != "" {
 			marks = append(marks, NoteAtom{
 				Type: "link",
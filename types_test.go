@@ -2,7 +2,11 @@ package main
 
 import (
 	"encoding/json"
+	"regexp"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -36,7 +40,8 @@ func (suite *TypesTestSuite) TestConvertParagraphsToNoteAtom() {
 		},
 	}
 
-	result := ConvertParagraphsToNoteAtom(paragraphs)
+	result, err := ConvertParagraphsToNoteAtom(paragraphs)
+	require.NoError(suite.T(), err)
 
 	// 验证结果结构
 	assert.Equal(suite.T(), "doc", result.Type)
@@ -56,7 +61,8 @@ func (suite *TypesTestSuite) TestConvertQuoteParagraph() {
 		},
 	}
 
-	result := ConvertParagraphsToNoteAtom(paragraphs)
+	result, err := ConvertParagraphsToNoteAtom(paragraphs)
+	require.NoError(suite.T(), err)
 
 	// 验证引用段落结构
 	assert.Equal(suite.T(), "doc", result.Type)
@@ -74,7 +80,8 @@ func (suite *TypesTestSuite) TestConvertNoteParagraph() {
 		},
 	}
 
-	result := ConvertParagraphsToNoteAtom(paragraphs)
+	result, err := ConvertParagraphsToNoteAtom(paragraphs)
+	require.NoError(suite.T(), err)
 
 	// 验证内链笔记结构
 	assert.Equal(suite.T(), "doc", result.Type)
@@ -100,7 +107,8 @@ func (suite *TypesTestSuite) TestConvertFileParagraph() {
 		},
 	}
 
-	result := ConvertParagraphsToNoteAtom(paragraphs)
+	result, err := ConvertParagraphsToNoteAtom(paragraphs)
+	require.NoError(suite.T(), err)
 
 	// 验证文件段落结构
 	assert.Equal(suite.T(), "doc", result.Type)
@@ -112,6 +120,33 @@ func (suite *TypesTestSuite) TestConvertFileParagraph() {
 	assert.Equal(suite.T(), "center", result.Content[0].Attrs["align"])
 }
 
+// TestConvertValidatesIDs 测试内链笔记ID和文件UUID的格式校验
+func (suite *TypesTestSuite) TestConvertValidatesIDs() {
+	// 合法的UUID
+	_, err := ConvertParagraphsToNoteAtom([]Paragraph{
+		{Type: "note", NoteID: "VPrWsE_-P0qwrFUOygxxx"},
+		{Type: "file", File: &FileNode{FileType: "image", SourceType: "upload", SourcePath: "3f2b8c1e-9a4d-4e6f-8b7a-1c2d3e4f5a6b"}},
+	})
+	assert.NoError(suite.T(), err)
+
+	// 明显的占位符
+	_, err = ConvertParagraphsToNoteAtom([]Paragraph{
+		{Texts: []TextNode{{Text: "正文"}}},
+		{Type: "file", File: &FileNode{FileType: "image", SourceType: "upload", SourcePath: "TODO"}},
+	})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "paragraph 2")
+	assert.Contains(suite.T(), err.Error(), `"TODO" does not look like a valid ID`)
+
+	_, err = ConvertParagraphsToNoteAtom([]Paragraph{{Type: "note", NoteID: ""}})
+	assert.Error(suite.T(), err)
+
+	// 自定义格式
+	opts := ConvertOptions{IDPattern: regexp.MustCompile(`^TODO$`)}
+	_, err = ConvertParagraphsToNoteAtomWithOptions([]Paragraph{{Type: "note", NoteID: "TODO"}}, opts)
+	assert.NoError(suite.T(), err)
+}
+
 // TestConvertTextsToContent 测试文本转换为内容
 func (suite *TypesTestSuite) TestConvertTextsToContent() {
 	texts := []TextNode{
@@ -122,7 +157,7 @@ func (suite *TypesTestSuite) TestConvertTextsToContent() {
 		{Text: "组合格式", Bold: true, Highlight: true, Link: "https://test.com"},
 	}
 
-	result := convertTextsToContent(texts)
+	result := convertTextsToContent(texts, false)
 
 	// 验证转换结果
 	assert.Len(suite.T(), result, 5)
@@ -157,6 +192,215 @@ func (suite *TypesTestSuite) TestConvertTextsToContent() {
 	assert.Len(suite.T(), result[4].Marks, 3) // bold + highlight + link
 }
 
+// TestConvertTextsToContentMoreMarks 测试斜体、删除线和行内代码的转换
+func (suite *TypesTestSuite) TestConvertTextsToContentMoreMarks() {
+	texts := []TextNode{
+		{Text: "斜体文本", Italic: true},
+		{Text: "删除线文本", Strikethrough: true},
+		{Text: "代码文本", InlineCode: true},
+		{Text: "组合格式", Highlight: true, InlineCode: true, Italic: true, Bold: true, Strikethrough: true, Link: "https://test.com"},
+	}
+
+	result := convertTextsToContent(texts, false)
+
+	// 验证转换结果
+	require.Len(suite.T(), result, 4)
+
+	// 验证斜体文本
+	assert.Equal(suite.T(), "text", result[0].Type)
+	assert.Equal(suite.T(), "斜体文本", result[0].Text)
+	assert.Len(suite.T(), result[0].Marks, 1)
+	assert.Equal(suite.T(), "italic", result[0].Marks[0].Type)
+
+	// 验证删除线文本
+	assert.Equal(suite.T(), "text", result[1].Type)
+	assert.Equal(suite.T(), "删除线文本", result[1].Text)
+	assert.Len(suite.T(), result[1].Marks, 1)
+	assert.Equal(suite.T(), "strikethrough", result[1].Marks[0].Type)
+
+	// 验证代码文本
+	assert.Equal(suite.T(), "text", result[2].Type)
+	assert.Equal(suite.T(), "代码文本", result[2].Text)
+	assert.Len(suite.T(), result[2].Marks, 1)
+	assert.Equal(suite.T(), "code", result[2].Marks[0].Type)
+
+	// 验证组合格式仍为单个文本节点，标记按固定顺序排列
+	assert.Equal(suite.T(), "text", result[3].Type)
+	assert.Equal(suite.T(), "组合格式", result[3].Text)
+	var marks []string
+	for _, mark := range result[3].Marks {
+		marks = append(marks, mark.Type)
+	}
+	assert.Equal(suite.T(), []string{"bold", "italic", "strikethrough", "code", "highlight", "link"}, marks)
+	assert.Equal(suite.T(), "https://test.com", result[3].Marks[5].Attrs["href"])
+
+	// 新标记的文本不会被当作裸URL
+	_, ok := bareURLParagraph([]TextNode{{Text: "https://example.com", InlineCode: true}})
+	assert.False(suite.T(), ok)
+}
+
+// TestConvertLinkCard 测试链接卡片段落和只含URL段落的自动转换
+func (suite *TypesTestSuite) TestConvertLinkCard() {
+	paragraphs := []Paragraph{
+		{Texts: []TextNode{{Text: " https://example.com/post "}}},
+		{Texts: []TextNode{{Text: "https://example.com/a", Link: "https://example.com/a"}}},
+		{Texts: []TextNode{{Text: "详见 "}, {Text: "https://example.com/b", Link: "https://example.com/b"}}},
+		{Texts: []TextNode{{Text: "https://example.com/c", Bold: true}}},
+		{Type: "link_card", URL: "https://example.com/d"},
+	}
+
+	// 默认不自动转换，显式的link_card段落始终生效
+	doc := mustConvert(suite.T(), paragraphs)
+	assert.Equal(suite.T(), "paragraph", doc.Content[0].Type)
+	assert.Equal(suite.T(), "link_card", doc.Content[4].Type)
+	assert.Equal(suite.T(), "https://example.com/d", doc.Content[4].Attrs["url"])
+
+	opts := DefaultConvertOptions()
+	opts.AutoLinkCard = true
+	doc, err := ConvertParagraphsToNoteAtomWithOptions(paragraphs, opts)
+	require.NoError(suite.T(), err)
+
+	assert.Equal(suite.T(), linkCardAtom("https://example.com/post"), doc.Content[0])
+	assert.Equal(suite.T(), linkCardAtom("https://example.com/a"), doc.Content[1])
+	// 混合文字和带格式的段落保持不变
+	assert.Equal(suite.T(), "paragraph", doc.Content[2].Type)
+	assert.Len(suite.T(), doc.Content[2].Content, 2)
+	assert.Equal(suite.T(), "paragraph", doc.Content[3].Type)
+
+	_, err = ConvertParagraphsToNoteAtom([]Paragraph{{Type: "link_card", URL: "not a url"}})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "invalid link_card url")
+}
+
+// TestConvertHeading 测试标题段落的级别和校验
+func (suite *TypesTestSuite) TestConvertHeading() {
+	doc, warnings, err := ConvertParagraphsWithWarnings([]Paragraph{
+		{Type: "heading", Level: 2, Texts: []TextNode{{Text: "二级标题", Bold: true}}},
+		{Type: "heading", Texts: []TextNode{{Text: "默认级别"}}},
+		{Type: "heading", Level: 4, Texts: []TextNode{{Text: "超出范围"}}},
+	}, DefaultConvertOptions())
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), doc.Content, 3)
+
+	assert.Equal(suite.T(), "heading", doc.Content[0].Type)
+	assert.Equal(suite.T(), map[string]string{"level": "2"}, doc.Content[0].Attrs)
+	require.Len(suite.T(), doc.Content[0].Content, 1)
+	assert.Equal(suite.T(), "二级标题", doc.Content[0].Content[0].Text)
+	assert.Equal(suite.T(), "bold", doc.Content[0].Content[0].Marks[0].Type)
+
+	// 级别为0或超出范围时按1级标题处理
+	assert.Equal(suite.T(), "1", doc.Content[1].Attrs["level"])
+	assert.Equal(suite.T(), "1", doc.Content[2].Attrs["level"])
+	require.Len(suite.T(), warnings, 1)
+	assert.Equal(suite.T(), 3, warnings[0].Paragraph)
+	assert.Contains(suite.T(), warnings[0].Message, "标题级别 4")
+
+	// 标题必须包含文本节点
+	_, err = ConvertParagraphsToNoteAtom([]Paragraph{{Type: "heading", Level: 1}})
+	require.Error(suite.T(), err)
+	assert.Contains(suite.T(), err.Error(), "paragraph 1: heading must have at least one text node")
+}
+
+// TestConvertLists 测试列表段落的每一项转换为包含段落的listItem，空列表项被跳过
+func (suite *TypesTestSuite) TestConvertLists() {
+	doc, warnings, err := ConvertParagraphsWithWarnings([]Paragraph{
+		{Type: "bullet_list", Items: [][]TextNode{
+			{{Text: "第一项"}},
+			{{Text: ""}},
+			{{Text: "第二项 "}, {Text: ""}, {Text: "加粗", Bold: true}},
+		}},
+		{Type: "ordered_list", Items: [][]TextNode{{{Text: "步骤一"}}, {{Text: "步骤二"}}}},
+	}, DefaultConvertOptions())
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []NoteAtom{
+		{Type: "bulletList", Content: []NoteAtom{
+			{Type: "listItem", Content: []NoteAtom{{Type: "paragraph", Content: []NoteAtom{{Type: "text", Text: "第一项"}}}}},
+			{Type: "listItem", Content: []NoteAtom{{Type: "paragraph", Content: []NoteAtom{
+				{Type: "text", Text: "第二项 "},
+				{Type: "text", Text: "加粗", Marks: []NoteAtom{{Type: "bold"}}},
+			}}}},
+		}},
+		{Type: "orderedList", Content: []NoteAtom{
+			{Type: "listItem", Content: []NoteAtom{{Type: "paragraph", Content: []NoteAtom{{Type: "text", Text: "步骤一"}}}}},
+			{Type: "listItem", Content: []NoteAtom{{Type: "paragraph", Content: []NoteAtom{{Type: "text", Text: "步骤二"}}}}},
+		}},
+	}, doc.Content)
+	assert.Equal(suite.T(), []ConversionWarning{
+		{Paragraph: 1, Message: "已跳过 1 个空文本节点"},
+		{Paragraph: 1, Message: "已跳过 1 个空列表项"},
+	}, warnings)
+	assert.Equal(suite.T(), []string{"- 第一项\n- 第二项 **加粗**", "1. 步骤一\n2. 步骤二"}, summarizeBlocks(doc.Content))
+
+	// 列表至少要有一个非空的列表项
+	for _, para := range []Paragraph{
+		{Type: "bullet_list"},
+		{Type: "ordered_list", Items: [][]TextNode{{}, {{Text: ""}}}},
+	} {
+		_, err = ConvertParagraphsToNoteAtom([]Paragraph{{Texts: []TextNode{{Text: "正文"}}}, para})
+		require.Error(suite.T(), err)
+		assert.Contains(suite.T(), err.Error(), "paragraph 2: "+para.Type+" must have at least one non-empty item")
+	}
+}
+
+// TestConvertTextColor 测试文字颜色转换为textColor标记，无效颜色返回错误而不是忽略
+func (suite *TypesTestSuite) TestConvertTextColor() {
+	doc, _, err := ConvertParagraphsWithWarnings([]Paragraph{{Texts: []TextNode{
+		{Text: "红色", Color: " Red "},
+		{Text: "自定义", Color: "#1A2B3C", Bold: true},
+	}}}, DefaultConvertOptions())
+	require.NoError(suite.T(), err)
+	content := doc.Content[0].Content
+	require.Len(suite.T(), content, 2)
+	assert.Equal(suite.T(), []NoteAtom{{Type: "textColor", Attrs: map[string]string{"color": "red"}}}, content[0].Marks)
+	assert.Equal(suite.T(), []NoteAtom{{Type: "bold"}, {Type: "textColor", Attrs: map[string]string{"color": "#1a2b3c"}}}, content[1].Marks)
+
+	// 转换回段落时保留颜色
+	paragraphs, err := NoteAtomToParagraphs(doc)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "#1a2b3c", paragraphs[0].Texts[1].Color)
+
+	for _, invalid := range []string{"pink", "#12345", "#GGGGGG", "rgb(1,2,3)"} {
+		_, _, err = ConvertParagraphsWithWarnings([]Paragraph{
+			{Texts: []TextNode{{Text: "正常"}}},
+			{Type: "bullet_list", Items: [][]TextNode{{{Text: "彩色", Color: invalid}}}},
+		}, DefaultConvertOptions())
+		assert.ErrorContains(suite.T(), err, "paragraph 2: invalid color", invalid)
+	}
+}
+
+// TestConvertTextsToContentEmptyText 测试空文本节点的处理
+func (suite *TypesTestSuite) TestConvertTextsToContentEmptyText() {
+	texts := []TextNode{
+		{Text: ""},
+		{Text: "  "},
+		{Text: "正常文本"},
+		{Text: "", Bold: true},
+	}
+
+	// 默认跳过空字符串，保留只含空白的文本
+	result := convertTextsToContent(texts, false)
+	require.Len(suite.T(), result, 2)
+	assert.Equal(suite.T(), "  ", result[0].Text)
+	assert.Equal(suite.T(), "正常文本", result[1].Text)
+
+	// 显式保留空字符串
+	result = convertTextsToContent(texts, true)
+	require.Len(suite.T(), result, 4)
+	assert.Equal(suite.T(), "", result[0].Text)
+	assert.Equal(suite.T(), "bold", result[3].Marks[0].Type)
+
+	// 通过转换选项控制
+	paragraphs := []Paragraph{{Texts: texts}}
+	doc := mustConvert(suite.T(), paragraphs)
+	assert.Len(suite.T(), doc.Content[0].Content, 2)
+
+	opts := DefaultConvertOptions()
+	opts.KeepEmptyText = true
+	doc, err := ConvertParagraphsToNoteAtomWithOptions(paragraphs, opts)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), doc.Content[0].Content, 4)
+}
+
 // TestNoteCreateRequestSerialization 测试笔记创建请求序列化
 func (suite *TypesTestSuite) TestNoteCreateRequestSerialization() {
 	req := NoteCreateRequest{
@@ -245,7 +489,422 @@ func (suite *TypesTestSuite) TestUploadFileViaURLArgsSerialization() {
 	assert.Equal(suite.T(), args.FileName, decoded.FileName)
 }
 
+// TestValidateNoteTimestamps 测试导入笔记的时间戳校验
+func (suite *TypesTestSuite) TestValidateNoteTimestamps() {
+	now := time.Unix(1700000000, 0)
+
+	assert.NoError(suite.T(), ValidateNoteTimestamps(0, 0, now))
+	assert.NoError(suite.T(), ValidateNoteTimestamps(1600000000, 1650000000, now))
+	assert.NoError(suite.T(), ValidateNoteTimestamps(1600000000, 0, now))
+
+	cases := map[string][2]int64{
+		"must be a positive Unix timestamp":   {-1, 0},
+		"looks like milliseconds":             {1600000000000, 0},
+		"must not be in the future":           {0, 1800000000},
+		"must not be earlier than created_at": {1650000000, 1600000000},
+	}
+	for message, ts := range cases {
+		err := ValidateNoteTimestamps(ts[0], ts[1], now)
+		if assert.Error(suite.T(), err, message) {
+			assert.Contains(suite.T(), err.Error(), message)
+		}
+	}
+}
+
+// TestFormatExpireAt 测试公开截止时间按单位转换和校验
+func (suite *TypesTestSuite) TestFormatExpireAt() {
+	now := time.Unix(1700000000, 0)
+
+	value, err := FormatExpireAt(1800000000, ExpireAtUnitSeconds, now)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "1800000000", value)
+
+	value, err = FormatExpireAt(1800000000, ExpireAtUnitMilliseconds, now)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "1800000000000", value)
+
+	// 0表示永不过期，与单位无关
+	value, err = FormatExpireAt(0, ExpireAtUnitMilliseconds, now)
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), "0", value)
+
+	cases := map[int64]string{
+		-5:            "must be a positive Unix timestamp",
+		1600000000:    "is not in the future",
+		1800000000000: "looks like milliseconds",
+		9000000000:    "implausibly far in the future",
+	}
+	for expireAt, message := range cases {
+		_, err := FormatExpireAt(expireAt, ExpireAtUnitSeconds, now)
+		if assert.Error(suite.T(), err, expireAt) {
+			assert.Contains(suite.T(), err.Error(), message)
+		}
+	}
+}
+
+// TestSupportedTypes 测试支持类型列表与转换实现保持一致
+func (suite *TypesTestSuite) TestSupportedTypes() {
+	names := func(infos []TypeInfo) []string {
+		var result []string
+		for _, info := range infos {
+			assert.NotEmpty(suite.T(), info.Description, info.Name)
+			result = append(result, info.Name)
+		}
+		return result
+	}
+	assert.Equal(suite.T(), []string{"paragraph", "quote", "note", "file", "link_card", "heading", "bullet_list", "ordered_list"}, names(SupportedParagraphTypes))
+	assert.Equal(suite.T(), []string{"bold", "italic", "strikethrough", "code", "highlight", "textColor", "link"}, names(SupportedMarkTypes))
+
+	// 每种段落类型都有对应的转换结果
+	doc := mustConvert(suite.T(), []Paragraph{
+		{Texts: []TextNode{{Text: "普通"}}},
+		{Type: "quote", Texts: []TextNode{{Text: "引用"}}},
+		{Type: "note", NoteID: "linked-note-id"},
+		{Type: "file", File: &FileNode{FileType: "image", SourcePath: "image-uuid-1"}},
+		{Type: "link_card", URL: "https://example.com"},
+		{Type: "heading", Texts: []TextNode{{Text: "标题"}}},
+		{Type: "bullet_list", Items: [][]TextNode{{{Text: "无序"}}}},
+		{Type: "ordered_list", Items: [][]TextNode{{{Text: "有序"}}}},
+	})
+	require.Len(suite.T(), doc.Content, len(SupportedParagraphTypes))
+	assert.Equal(suite.T(), "true", doc.Content[1].Attrs["blockquote"])
+	assert.Equal(suite.T(), "note", doc.Content[2].Type)
+	assert.Equal(suite.T(), "image", doc.Content[3].Type)
+	assert.Equal(suite.T(), "link_card", doc.Content[4].Type)
+	assert.Equal(suite.T(), "heading", doc.Content[5].Type)
+	assert.Equal(suite.T(), "bulletList", doc.Content[6].Type)
+	assert.Equal(suite.T(), "orderedList", doc.Content[7].Type)
+
+	// 每种标记都会被转换
+	content := convertTextsToContent([]TextNode{{Text: "全部", Bold: true, Italic: true, Strikethrough: true, InlineCode: true, Highlight: true, Color: "red", Link: "https://example.com"}}, false)
+	var marks []string
+	for _, mark := range content[0].Marks {
+		marks = append(marks, mark.Type)
+	}
+	assert.Equal(suite.T(), names(SupportedMarkTypes), marks)
+}
+
+// TestConvertParagraphsWithWarnings 测试收集不影响转换结果的问题
+func (suite *TypesTestSuite) TestConvertParagraphsWithWarnings() {
+	paragraphs := []Paragraph{
+		{Texts: []TextNode{{Text: "正常"}}},
+		{Type: "headline", Texts: []TextNode{{Text: "未知类型"}}},
+		{Texts: []TextNode{{Text: ""}, {Text: "有空节点"}}},
+		{Texts: []TextNode{}},
+		{Type: "file"},
+		{Type: "file", File: &FileNode{FileType: "image", SourcePath: "image-uuid-1", Metadata: map[string]string{"alt": "图", "colour": "red"}}},
+	}
+
+	doc, warnings, err := ConvertParagraphsWithWarnings(paragraphs, DefaultConvertOptions())
+	require.NoError(suite.T(), err)
+	// 缺少file的文件段落被跳过，其他段落照常转换
+	require.Len(suite.T(), doc.Content, 5)
+	assert.Equal(suite.T(), "未知类型", doc.Content[1].Content[0].Text)
+	assert.Equal(suite.T(), "red", doc.Content[4].Attrs["colour"])
+
+	var rendered []string
+	for _, w := range warnings {
+		rendered = append(rendered, w.String())
+	}
+	assert.Equal(suite.T(), []string{
+		`第 2 段：未知的段落类型 "headline"，已按普通段落处理`,
+		"第 3 段：已跳过 1 个空文本节点",
+		"第 4 段：段落没有文本，将显示为空行",
+		"第 5 段：文件段落缺少file，已跳过",
+		`第 6 段：未知的文件元数据键 "colour"，可能不会生效`,
+	}, rendered)
+
+	// 没有问题时不产生警告
+	_, warnings, err = ConvertParagraphsWithWarnings(paragraphs[:1], DefaultConvertOptions())
+	require.NoError(suite.T(), err)
+	assert.Empty(suite.T(), warnings)
+	assert.Empty(suite.T(), RenderConversionWarnings(warnings))
+}
+
+// TestSplitLongParagraphs 测试超长段落在句子或空白处拆分并保留标记，短段落保持不变
+func (suite *TypesTestSuite) TestSplitLongParagraphs() {
+	opts := DefaultConvertOptions()
+	opts.MaxParagraphLength = 10
+
+	paragraphs := []Paragraph{
+		{Texts: []TextNode{
+			{Text: "这是第一句话。第二句"},
+			{Text: "加粗的话。第三句", Bold: true},
+		}},
+		{Type: "quote", Texts: []TextNode{{Text: "short one"}}},
+		{Texts: []TextNode{{Text: "alpha beta gamma", Link: "https://example.com"}}},
+	}
+	doc, warnings, err := ConvertParagraphsWithWarnings(paragraphs, opts)
+	require.NoError(suite.T(), err)
+
+	type piece struct {
+		text  string
+		marks int
+	}
+	pieces := func(atom NoteAtom) []piece {
+		var result []piece
+		for _, text := range atom.Content {
+			result = append(result, piece{text.Text, len(text.Marks)})
+		}
+		return result
+	}
+	require.Len(suite.T(), doc.Content, 6)
+	// 在句末标点处拆分，跨段的加粗文本两侧都保留加粗
+	assert.Equal(suite.T(), []piece{{"这是第一句话。", 0}}, pieces(doc.Content[0]))
+	assert.Equal(suite.T(), []piece{{"第二句", 0}, {"加粗的话。", 1}}, pieces(doc.Content[1]))
+	assert.Equal(suite.T(), []piece{{"第三句", 1}}, pieces(doc.Content[2]))
+	// 未超过长度的引用段落保持不变
+	assert.Equal(suite.T(), "true", doc.Content[3].Attrs["blockquote"])
+	assert.Equal(suite.T(), []piece{{"short one", 0}}, pieces(doc.Content[3]))
+	// 没有标点时在空白处拆分，链接保留在每一段
+	assert.Equal(suite.T(), []piece{{"alpha ", 1}}, pieces(doc.Content[4]))
+	assert.Equal(suite.T(), []piece{{"beta gamma", 1}}, pieces(doc.Content[5]))
+
+	var rendered []string
+	for _, w := range warnings {
+		rendered = append(rendered, w.String())
+	}
+	assert.Equal(suite.T(), []string{
+		"第 1 段：段落超过 10 个字符，已拆分为 3 段",
+		"第 3 段：段落超过 10 个字符，已拆分为 2 段",
+	}, rendered)
+
+	// 没有边界时在最大长度处截断；默认选项不拆分
+	assert.Len(suite.T(), splitLongTexts([]TextNode{{Text: strings.Repeat("字", 25)}}, 10), 3)
+	assert.Len(suite.T(), mustConvert(suite.T(), paragraphs).Content, 3)
+}
+
+// TestTrimTrailingEmptyParagraphs 测试默认移除末尾的空段落，保留中间的空段落
+func (suite *TypesTestSuite) TestTrimTrailingEmptyParagraphs() {
+	paragraphs := []Paragraph{
+		{Texts: []TextNode{{Text: "开头"}}},
+		{},
+		{Texts: []TextNode{{Text: ""}}},
+		{Texts: []TextNode{{Text: "中间"}}},
+		{},
+		{Type: "quote", Texts: []TextNode{{Text: ""}}},
+		{Texts: nil},
+	}
+
+	doc, warnings, err := ConvertParagraphsWithWarnings(paragraphs, DefaultConvertOptions())
+	require.NoError(suite.T(), err)
+	require.Len(suite.T(), doc.Content, 4)
+	assert.Empty(suite.T(), doc.Content[1].Content)
+	assert.Empty(suite.T(), doc.Content[2].Content)
+	assert.Equal(suite.T(), "中间", doc.Content[3].Content[0].Text)
+	assert.Contains(suite.T(), RenderConversionWarnings(warnings), "第 5 段：已移除末尾的 3 个空段落")
+
+	// 末尾是内链笔记等非文本段落或只含空白的文本时不移除
+	kept := append(append([]Paragraph{}, paragraphs[:2]...), Paragraph{Texts: []TextNode{{Text: " "}}})
+	assert.Len(suite.T(), mustConvert(suite.T(), kept).Content, 3)
+	kept = append(kept, Paragraph{Type: "note", NoteID: "note-abcdef12"})
+	assert.Len(suite.T(), mustConvert(suite.T(), kept).Content, 4)
+
+	// 关闭后保留所有段落
+	opts := DefaultConvertOptions()
+	opts.TrimTrailingEmpty = false
+	doc, err = ConvertParagraphsToNoteAtomWithOptions(paragraphs, opts)
+	require.NoError(suite.T(), err)
+	assert.Len(suite.T(), doc.Content, len(paragraphs))
+}
+
+// TestExtractNoteID 测试按优先级从不同结构的响应中提取笔记ID
+func (suite *TypesTestSuite) TestExtractNoteID() {
+	cases := []struct {
+		response string
+		id       string
+		found    bool
+	}{
+		{`{"data":{"note_id":"snake-id"}}`, "snake-id", true},
+		{`{"data":{"noteId":"camel-id"}}`, "camel-id", true},
+		{`{"data":{"id":12345}}`, "12345", true},
+		{`{"noteId":"top-level-id"}`, "top-level-id", true},
+		// 优先使用排在前面的路径，空字符串视为未找到
+		{`{"data":{"note_id":"","noteId":"camel-id","id":"other"}}`, "camel-id", true},
+		{`{"data":{"url":"https://mowen.cn/note/x"}}`, "", false},
+		{`{"data":"not-an-object"}`, "", false},
+	}
+	for _, c := range cases {
+		var result map[string]interface{}
+		require.NoError(suite.T(), json.Unmarshal([]byte(c.response), &result))
+		id, found := ExtractNoteID(result, DefaultNoteIDPaths)
+		assert.Equal(suite.T(), c.id, id, c.response)
+		assert.Equal(suite.T(), c.found, found, c.response)
+	}
+
+	var nested map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(`{"result":{"note":{"id":"nested-id"}}}`), &nested))
+	id, found := ExtractNoteID(nested, []string{"result.note.id"})
+	assert.True(suite.T(), found)
+	assert.Equal(suite.T(), "nested-id", id)
+}
+
+// TestParseCreateNoteResult 测试从响应中解析笔记ID和链接，缺少链接时使用笔记详情页地址
+func (suite *TypesTestSuite) TestParseCreateNoteResult() {
+	cases := []struct {
+		response string
+		want     CreateNoteResult
+	}{
+		{`{"data":{"note_id":"note-1","url":"https://mowen.cn/note/note-1"}}`, CreateNoteResult{NoteID: "note-1", URL: "https://mowen.cn/note/note-1"}},
+		{`{"data":{"noteId":"note-2"}}`, CreateNoteResult{NoteID: "note-2", URL: "https://note.mowen.cn/detail/note-2"}},
+		{`{"data":{}}`, CreateNoteResult{}},
+		{`{}`, CreateNoteResult{}},
+	}
+	for _, c := range cases {
+		var result map[string]interface{}
+		require.NoError(suite.T(), json.Unmarshal([]byte(c.response), &result))
+		assert.Equal(suite.T(), c.want, ParseCreateNoteResult(result, DefaultNoteIDPaths), c.response)
+	}
+
+	var upload map[string]interface{}
+	require.NoError(suite.T(), json.Unmarshal([]byte(`{"data":{"fileId":"file-1","url":"https://cdn.example.com/a.png"}}`), &upload))
+	assert.Equal(suite.T(), UploadFileResult{UUID: "file-1", URL: "https://cdn.example.com/a.png"}, ParseUploadFileResult(upload))
+	assert.Equal(suite.T(), UploadFileResult{}, ParseUploadFileResult(map[string]interface{}{"data": "not-an-object"}))
+}
+
+// TestLoadNoteIDPaths 测试自定义笔记ID路径优先于默认路径
+func (suite *TypesTestSuite) TestLoadNoteIDPaths() {
+	suite.T().Setenv("MOWEN_NOTE_ID_PATHS", " result.note.id , data.noteId")
+	paths, err := loadNoteIDPaths()
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"result.note.id", "data.noteId", "data.note_id", "data.id", "note_id", "noteId"}, paths)
+
+	suite.T().Setenv("MOWEN_NOTE_ID_PATHS", "data..id")
+	_, err = loadNoteIDPaths()
+	assert.Error(suite.T(), err)
+}
+
+// TestReorderBlocks 测试段落移动
+func (suite *TypesTestSuite) TestReorderBlocks() {
+	blocks := []NoteAtom{{Type: "a"}, {Type: "b"}, {Type: "c"}, {Type: "d"}}
+	types := func(atoms []NoteAtom) []string {
+		var result []string
+		for _, atom := range atoms {
+			result = append(result, atom.Type)
+		}
+		return result
+	}
+
+	// 向前移动和向后移动
+	result, err := ReorderBlocks(blocks, []ParagraphMove{{From: 3, To: 1}})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"c", "a", "b", "d"}, types(result))
+
+	result, err = ReorderBlocks(blocks, []ParagraphMove{{From: 1, To: 4}, {From: 1, To: 2}})
+	require.NoError(suite.T(), err)
+	assert.Equal(suite.T(), []string{"c", "b", "d", "a"}, types(result))
+
+	// 原列表不被修改
+	assert.Equal(suite.T(), []string{"a", "b", "c", "d"}, types(blocks))
+
+	// 序号超出范围
+	_, err = ReorderBlocks(blocks, []ParagraphMove{{From: 5, To: 1}})
+	assert.ErrorContains(suite.T(), err, "from index 5 out of range [1, 4]")
+	_, err = ReorderBlocks(blocks, []ParagraphMove{{From: 1, To: 0}})
+	assert.ErrorContains(suite.T(), err, "to index 0 out of range")
+}
+
+// mustConvert 转换段落列表，转换失败时终止测试
+func mustConvert(t require.TestingT, paragraphs []Paragraph) NoteAtom {
+	atom, err := ConvertParagraphsToNoteAtom(paragraphs)
+	require.NoError(t, err)
+	return atom
+}
+
 // TestTypesTestSuite 运行数据类型测试套件
 func TestTypesTestSuite(t *testing.T) {
 	suite.Run(t, new(TypesTestSuite))
-}
\ No newline at end of file
+}
+// wireKeys 返回请求序列化后的全部字段路径（按字典序），不展开笔记内容body
+func wireKeys(t *testing.T, req interface{}) []string {
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	var keys []string
+	var walk func(prefix string, m map[string]interface{})
+	walk = func(prefix string, m map[string]interface{}) {
+		for k, v := range m {
+			keys = append(keys, prefix+k)
+			if nested, ok := v.(map[string]interface{}); ok && k != "body" {
+				walk(prefix+k+".", nested)
+			}
+		}
+	}
+	walk("", decoded)
+	sort.Strings(keys)
+	return keys
+}
+
+// TestRequestWireFormat 测试各请求发送到墨问API的字段名：笔记接口为驼峰命名，上传接口为下划线命名
+func TestRequestWireFormat(t *testing.T) {
+	pinned := true
+	body := NoteAtom{Type: "doc"}
+	cases := []struct {
+		name     string
+		req      interface{}
+		expected []string
+	}{
+		{"create", NoteCreateRequest{
+			Body:           body,
+			Settings:       NoteCreateRequestSettings{AutoPublish: true, Tags: []string{"t"}, CreatedAt: 1, UpdatedAt: 2},
+			IdempotencyKey: "不应出现在请求体中",
+			SchemaVersion:  "1",
+		}, []string{"body", "schemaVersion", "settings", "settings.autoPublish", "settings.createdAt", "settings.tags", "settings.updatedAt"}},
+		{"edit", NoteEditRequest{NoteID: "n", Body: body, SchemaVersion: "1"}, []string{"body", "noteId", "schemaVersion"}},
+		{"edit without schema version", NoteEditRequest{NoteID: "n", Body: body}, []string{"body", "noteId"}},
+		{"set privacy", NoteSetRequest{NoteID: "n", Section: 1, Settings: &NoteSettings{
+			Privacy: &NotePrivacySet{Type: "rule", Rule: &NotePrivacySetRule{NoShare: true, ExpireAt: "1"}},
+		}}, []string{"noteId", "section", "settings", "settings.privacy", "settings.privacy.rule", "settings.privacy.rule.expireAt", "settings.privacy.rule.noShare", "settings.privacy.type"}},
+		{"set tags", NoteSetRequest{NoteID: "n", Section: 2, Settings: &NoteSettings{Tags: []string{"t"}}},
+			[]string{"noteId", "section", "settings", "settings.tags"}},
+		{"set pinned", NoteSetRequest{NoteID: "n", Section: 3, Settings: &NoteSettings{Pinned: &pinned}},
+			[]string{"noteId", "section", "settings", "settings.pinned"}},
+		{"detail", NoteDetailRequest{NoteID: "n", Cursor: "c"}, []string{"cursor", "noteId"}},
+		{"delete", NoteDeleteRequest{NoteID: "n"}, []string{"noteId"}},
+		{"list", NoteListRequest{Query: "q", Tag: "t", Page: 1, PageSize: 10, Cursor: "c"},
+			[]string{"cursor", "page", "pageSize", "query", "tag"}},
+		{"upload status", UploadStatusRequest{UUID: "u"}, []string{"uuid"}},
+		{"upload prepare", UploadPrepareRequest{FileType: 1, FileName: "a.png"}, []string{"file_name", "file_type"}},
+		{"upload url", UploadURLRequest{URL: "https://example.com/a.png", FileType: 1, FileName: "a.png"},
+			[]string{"file_name", "file_type", "url"}},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, wireKeys(t, c.req), c.name)
+	}
+}
+
+// TestParseExpireIn 测试解析公开时长
+func TestParseExpireIn(t *testing.T) {
+	d, err := ParseExpireIn("36h")
+	require.NoError(t, err)
+	assert.Equal(t, 36*time.Hour, d)
+
+	d, err = ParseExpireIn(" 7d ")
+	require.NoError(t, err)
+	assert.Equal(t, 7*24*time.Hour, d)
+
+	for _, value := range []string{"", "7", "soon", "xd", "0h", "-1d", "40000d"} {
+		_, err := ParseExpireIn(value)
+		assert.Error(t, err, value)
+	}
+}
+
+// TestLoadDefaultExpireIn 测试读取MOWEN_DEFAULT_EXPIRE_IN
+func TestLoadDefaultExpireIn(t *testing.T) {
+	t.Setenv("MOWEN_DEFAULT_EXPIRE_IN", "")
+	d, err := loadDefaultExpireIn()
+	require.NoError(t, err)
+	assert.Zero(t, d)
+
+	t.Setenv("MOWEN_DEFAULT_EXPIRE_IN", "30d")
+	d, err = loadDefaultExpireIn()
+	require.NoError(t, err)
+	assert.Equal(t, 30*24*time.Hour, d)
+
+	t.Setenv("MOWEN_DEFAULT_EXPIRE_IN", "one week")
+	_, err = loadDefaultExpireIn()
+	assert.ErrorContains(t, err, "MOWEN_DEFAULT_EXPIRE_IN")
+}